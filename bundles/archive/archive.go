@@ -0,0 +1,286 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	ArchiveComponentName = "archive"
+)
+
+type ArchiveConfig struct {
+	// The format of the archive to create. One of tar, zip, json_array or lines.
+	Format Format `json:"format" yaml:"format" mapstructure:"format"`
+
+	// The path of each message within the archive. Defaults to the archive_path metadata of the message, its key or
+	// its index within the batch. Only used by the tar and zip formats.
+	Path spec.Expression `json:"path,omitempty" yaml:"path,omitempty" mapstructure:"path,omitempty"`
+
+	// The compression algorithm to apply to the archive, e.g. gzip to create tar.gz archives. Defaults to none.
+	Compression compress.Algorithm `json:"compression,omitempty" yaml:"compression,omitempty" mapstructure:"compression,omitempty"`
+}
+
+// NewArchiveProcessor creates a new archive processor
+func NewArchiveProcessor(config ArchiveConfig) (*ArchiveProcessor, error) {
+	format, err := ParseFormat(string(config.Format))
+	if err != nil {
+		return nil, err
+	}
+	if format == Auto {
+		return nil, fmt.Errorf("format must be one of tar, zip, json_array or lines")
+	}
+	config.Format = format
+
+	if config.Compression, err = compress.ParseAlgorithm(string(config.Compression)); err != nil {
+		return nil, err
+	}
+
+	return &ArchiveProcessor{config: config}, nil
+}
+
+// NewArchiveProcessorFromConfig creates an archive processor from a spec.Config interface
+func NewArchiveProcessorFromConfig(config spec.Config) (*ArchiveProcessor, error) {
+	var cfg ArchiveConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode archive processor config: %w", err)
+	}
+	return NewArchiveProcessor(cfg)
+}
+
+// ArchiveProcessor packs all messages of a batch into a single archive message.
+//
+// The payloads of streaming messages are copied into the archive as a stream, so they are not buffered on top of
+// the archive itself. The archive message carries the metadata of the first message in the batch, except for the
+// entry specific archive_path and archive_size fields, along with the compression marker if the archive is
+// compressed.
+type ArchiveProcessor struct {
+	config ArchiveConfig
+}
+
+func (p *ArchiveProcessor) Init(ctx spec.ComponentContext) error {
+	return nil
+}
+
+func (p *ArchiveProcessor) Close(ctx spec.ComponentContext) error {
+	return nil
+}
+
+func (p *ArchiveProcessor) Process(ctx spec.ComponentContext, batch spec.Batch) (spec.Batch, spec.ProcessedCallback, error) {
+	var buf bytes.Buffer
+	cw, err := compress.NewWriter(p.config.Compression, &buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w := p.newWriter(cw)
+	out := ctx.NewMessage()
+
+	count := 0
+	for idx, msg := range batch.Messages() {
+		if idx == 0 {
+			for k, v := range msg.Metadata() {
+				switch k {
+				case MetadataArchivePath, MetadataArchiveSize, spec.MetadataSize, compress.MetadataCompression:
+					continue
+				}
+				out.SetMetadata(k, v)
+			}
+		}
+
+		if err := w.add(p.entryPath(idx, msg), msg); err != nil {
+			return nil, nil, fmt.Errorf("batch #%d: %w", idx, err)
+		}
+		count++
+	}
+
+	if err := w.close(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := cw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", p.config.Compression, err)
+	}
+
+	if count == 0 {
+		return ctx.NewBatch(), spec.NoopCallback, nil
+	}
+
+	out.SetRaw(buf.Bytes())
+	out.SetMetadata(spec.MetadataSize, int64(buf.Len()))
+	if p.config.Compression != compress.None {
+		out.SetMetadata(compress.MetadataCompression, string(p.config.Compression))
+	}
+
+	return ctx.NewBatch(out), spec.NoopCallback, nil
+}
+
+func (p *ArchiveProcessor) entryPath(idx int, msg spec.Message) string {
+	if p.config.Path != nil {
+		if path, err := p.config.Path.Eval(spec.MessageExpressionContext(msg)); err == nil && path != "" {
+			return path
+		}
+	}
+
+	if key := messageKey(msg); key != "" {
+		return key
+	}
+
+	return strconv.Itoa(idx)
+}
+
+// archiveWriter adds messages to an archive.
+type archiveWriter interface {
+	add(path string, msg spec.Message) error
+	close() error
+}
+
+func (p *ArchiveProcessor) newWriter(w io.Writer) archiveWriter {
+	switch p.config.Format {
+	case Tar:
+		return &tarWriter{tw: tar.NewWriter(w)}
+	case Zip:
+		return &zipWriter{zw: zip.NewWriter(w)}
+	case JSONArray:
+		return &jsonArrayWriter{w: w}
+	default:
+		return &linesWriter{w: w}
+	}
+}
+
+type tarWriter struct {
+	tw *tar.Writer
+}
+
+func (t *tarWriter) add(path string, msg spec.Message) error {
+	// -- tar headers need the size of the entry upfront, so the payload has to be materialized
+	data, err := msg.Raw()
+	if err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     path,
+		Size:     int64(len(data)),
+		Mode:     0o644,
+		ModTime:  time.Now(),
+	}
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("tar: %w", err)
+	}
+
+	if _, err := t.tw.Write(data); err != nil {
+		return fmt.Errorf("tar: %w", err)
+	}
+	return nil
+}
+
+func (t *tarWriter) close() error {
+	if err := t.tw.Close(); err != nil {
+		return fmt.Errorf("tar: %w", err)
+	}
+	return nil
+}
+
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func (z *zipWriter) add(path string, msg spec.Message) error {
+	fw, err := z.zw.CreateHeader(&zip.FileHeader{
+		Name:     path,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+
+	return copyPayload(fw, msg)
+}
+
+func (z *zipWriter) close() error {
+	if err := z.zw.Close(); err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+	return nil
+}
+
+type jsonArrayWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonArrayWriter) add(path string, msg spec.Message) error {
+	data, err := msg.Raw()
+	if err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+
+	if !json.Valid(data) {
+		return fmt.Errorf("payload is not valid json")
+	}
+
+	sep := []byte(",")
+	if j.count == 0 {
+		sep = []byte("[")
+	}
+	j.count++
+
+	if _, err := j.w.Write(sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonArrayWriter) close() error {
+	if j.count == 0 {
+		_, err := j.w.Write([]byte("[]"))
+		return err
+	}
+
+	_, err := j.w.Write([]byte("]"))
+	return err
+}
+
+type linesWriter struct {
+	w io.Writer
+}
+
+func (l *linesWriter) add(path string, msg spec.Message) error {
+	if err := copyPayload(l.w, msg); err != nil {
+		return err
+	}
+
+	_, err := l.w.Write([]byte("\n"))
+	return err
+}
+
+func (l *linesWriter) close() error {
+	return nil
+}
+
+// copyPayload streams the payload of the message into the writer.
+func copyPayload(w io.Writer, msg spec.Message) error {
+	r, err := spec.MessageReader(msg)
+	if err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package archive_test
+
+import (
+	"maps"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+func TestArchive(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Archive Suite")
+}
+
+// entries collects the payloads of the batch keyed by their archive path.
+func entries(batch spec.Batch) map[string]string {
+	result := map[string]string{}
+	for _, msg := range batch.Messages() {
+		meta := maps.Collect(msg.Metadata())
+		raw, err := msg.Raw()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(meta).To(HaveKeyWithValue("archive_size", int64(len(raw))))
+		result[meta["archive_path"].(string)] = string(raw)
+	}
+	return result
+}
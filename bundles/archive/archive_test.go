@@ -0,0 +1,91 @@
+package archive_test
+
+import (
+	"maps"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/archive"
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("ArchiveProcessor", func() {
+	var ctx spec.ComponentContext
+	var batch spec.Batch
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+
+		first := spec.NewBytesMessage([]byte(`{"a": 1}`))
+		first.SetMetadata(spec.MetadataKey, "in/a.json")
+		first.SetMetadata("bucket", "drops")
+		second := spec.NewBytesMessage([]byte(`{"b": 2}`))
+		second.SetMetadata(spec.MetadataKey, "in/b.json")
+
+		batch = ctx.NewBatch(first, second)
+	})
+
+	pack := func(config archive.ArchiveConfig) spec.Message {
+		proc, err := archive.NewArchiveProcessor(config)
+		Expect(err).ToNot(HaveOccurred())
+
+		result, _, err := proc.Process(ctx, batch)
+		Expect(err).ToNot(HaveOccurred())
+
+		msgs := maps.Collect(result.Messages())
+		Expect(msgs).To(HaveLen(1))
+		return msgs[0]
+	}
+
+	roundtrip := func(config archive.ArchiveConfig) map[string]string {
+		packed := pack(config)
+
+		proc, err := archive.NewUnarchiveProcessor(archive.UnarchiveConfig{Format: config.Format, Compression: config.Compression})
+		Expect(err).ToNot(HaveOccurred())
+
+		result, _, err := proc.Process(ctx, ctx.NewBatch(packed))
+		Expect(err).ToNot(HaveOccurred())
+		return entries(result)
+	}
+
+	It("should pack a batch into a tar.gz archive", func() {
+		packed := pack(archive.ArchiveConfig{Format: archive.Tar, Compression: compress.Gzip})
+		meta := maps.Collect(packed.Metadata())
+		Expect(meta).To(HaveKeyWithValue(compress.MetadataCompression, "gzip"))
+		Expect(meta).To(HaveKeyWithValue("bucket", "drops"))
+
+		Expect(roundtrip(archive.ArchiveConfig{Format: archive.Tar, Compression: compress.Gzip})).To(Equal(map[string]string{
+			"in/a.json": `{"a": 1}`,
+			"in/b.json": `{"b": 2}`,
+		}))
+	})
+
+	It("should pack a batch into a zip archive using the path expression", func() {
+		path, err := spec.NewExprLangExpression(`${! "out/" + string(json.a ?? json.b) + ".json" }`)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(roundtrip(archive.ArchiveConfig{Format: archive.Zip, Path: path})).To(Equal(map[string]string{
+			"out/1.json": `{"a": 1}`,
+			"out/2.json": `{"b": 2}`,
+		}))
+	})
+
+	It("should pack a batch into a json array", func() {
+		raw, err := pack(archive.ArchiveConfig{Format: archive.JSONArray}).Raw()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(raw).To(MatchJSON(`[{"a": 1}, {"b": 2}]`))
+	})
+
+	It("should pack a batch into lines", func() {
+		raw, err := pack(archive.ArchiveConfig{Format: archive.Lines}).Raw()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(raw)).To(Equal("{\"a\": 1}\n{\"b\": 2}\n"))
+	})
+
+	It("should require a format", func() {
+		_, err := archive.NewArchiveProcessor(archive.ArchiveConfig{})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,99 @@
+// Package archive provides processors to expand archives into one message per entry and to pack a batch of
+// messages into a single archive. Tar and zip archives are supported, as well as JSON arrays and newline delimited
+// payloads.
+package archive
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	// MetadataArchivePath is the metadata key holding the path of an entry within its archive.
+	MetadataArchivePath = "archive_path"
+
+	// MetadataArchiveSize is the metadata key holding the uncompressed size of an entry within its archive.
+	MetadataArchiveSize = "archive_size"
+)
+
+// Format identifies an archive format.
+type Format string
+
+const (
+	Tar       Format = "tar"
+	Zip       Format = "zip"
+	JSONArray Format = "json_array"
+	Lines     Format = "lines"
+
+	// Auto detects the format from the key or path of the message when unarchiving, falling back to the first
+	// bytes of the payload.
+	Auto Format = "auto"
+)
+
+// ParseFormat converts the given name into a Format.
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case Tar:
+		return Tar, nil
+	case Zip:
+		return Zip, nil
+	case JSONArray:
+		return JSONArray, nil
+	case Lines:
+		return Lines, nil
+	case Auto, "":
+		return Auto, nil
+	default:
+		return "", fmt.Errorf("unsupported archive format %q", name)
+	}
+}
+
+// DetectFormat determines the archive format from the suffix of the given key. Compression suffixes are ignored,
+// so archive.tar.gz is detected as a tar archive. Auto is returned if the suffix doesn't indicate a format.
+func DetectFormat(key string) Format {
+	key = strings.ToLower(key)
+	if compress.Detect(key, "") != compress.None {
+		if strings.HasSuffix(key, ".tgz") {
+			return Tar
+		}
+		key = strings.TrimSuffix(key, path.Ext(key))
+	}
+
+	switch path.Ext(key) {
+	case ".tar":
+		return Tar
+	case ".zip", ".jar":
+		return Zip
+	case ".jsonl", ".ndjson", ".txt", ".log", ".csv":
+		return Lines
+	case ".json":
+		return JSONArray
+	default:
+		return Auto
+	}
+}
+
+// messageKey returns the key or path of the message, if it has one.
+func messageKey(msg spec.Message) string {
+	var key string
+	for k, v := range msg.Metadata() {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		switch k {
+		case MetadataArchivePath:
+			if key == "" {
+				key = s
+			}
+		case spec.MetadataKey, "path":
+			key = s
+		}
+	}
+	return key
+}
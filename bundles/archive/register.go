@@ -0,0 +1,49 @@
+package archive
+
+import (
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const archiveSchema = `{
+	"type": "object",
+	"properties": {
+		"format": {"type": "string", "enum": ["tar", "zip", "json_array", "lines"]},
+		"path": {"type": "string", "description": "The expression producing the path of each message within tar and zip archives.", "examples": ["${! key }"]},
+		"compression": {"type": "string", "enum": ["none", "gzip", "zstd", "snappy", "lz4"], "default": "none"}
+	},
+	"required": ["format"],
+	"additionalProperties": false
+}`
+
+const unarchiveSchema = `{
+	"type": "object",
+	"properties": {
+		"format": {"type": "string", "enum": ["tar", "zip", "json_array", "lines", "auto"], "default": "auto"},
+		"compression": {"type": "string", "enum": ["none", "gzip", "zstd", "snappy", "lz4", "auto"], "default": "auto"},
+		"max_line_size": {"type": "integer", "minimum": 1, "default": 1048576, "description": "The maximum size of a line when unarchiving lines."}
+	},
+	"additionalProperties": false
+}`
+
+// Register adds the archive and unarchive processors to the registry.
+func Register(r *registry.Registry) error {
+	err := r.RegisterProcessor(registry.Spec{
+		Name:    ArchiveComponentName,
+		Summary: "Packs the messages of a batch into a single archive.",
+		Schema:  archiveSchema,
+	}, func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Processor, error) {
+		return NewArchiveProcessorFromConfig(cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterProcessor(registry.Spec{
+		Name:    UnarchiveComponentName,
+		Summary: "Expands archives into a message per entry.",
+		Schema:  unarchiveSchema,
+	}, func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Processor, error) {
+		return NewUnarchiveProcessorFromConfig(cfg)
+	})
+}
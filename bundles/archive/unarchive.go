@@ -0,0 +1,285 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	UnarchiveComponentName = "unarchive"
+)
+
+type UnarchiveConfig struct {
+	// The format of the archives. One of tar, zip, json_array, lines or auto. Auto detects the format from the key
+	// of the message or its first bytes. Defaults to auto.
+	Format Format `json:"format,omitempty" yaml:"format,omitempty" mapstructure:"format,omitempty"`
+
+	// The compression algorithm of the archives, e.g. gzip for tar.gz archives. Defaults to detecting the
+	// algorithm from the message metadata and key, falling back to the first bytes of the payload.
+	Compression compress.Algorithm `json:"compression,omitempty" yaml:"compression,omitempty" mapstructure:"compression,omitempty"`
+
+	// The maximum size of a line when unarchiving lines. Defaults to 1MiB.
+	MaxLineSize int `json:"max_line_size,omitempty" yaml:"max_line_size,omitempty" mapstructure:"max_line_size,omitempty"`
+}
+
+// NewUnarchiveProcessor creates a new unarchive processor
+func NewUnarchiveProcessor(config UnarchiveConfig) (*UnarchiveProcessor, error) {
+	format, err := ParseFormat(string(config.Format))
+	if err != nil {
+		return nil, err
+	}
+	config.Format = format
+
+	if config.Compression == "" {
+		config.Compression = compress.Auto
+	} else if config.Compression != compress.Auto {
+		if config.Compression, err = compress.ParseAlgorithm(string(config.Compression)); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.MaxLineSize <= 0 {
+		config.MaxLineSize = 1024 * 1024
+	}
+
+	return &UnarchiveProcessor{config: config}, nil
+}
+
+// NewUnarchiveProcessorFromConfig creates an unarchive processor from a spec.Config interface
+func NewUnarchiveProcessorFromConfig(config spec.Config) (*UnarchiveProcessor, error) {
+	var cfg UnarchiveConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode unarchive processor config: %w", err)
+	}
+	return NewUnarchiveProcessor(cfg)
+}
+
+// UnarchiveProcessor expands each archive message into one message per entry.
+//
+// Each entry message carries the metadata of the archive message, along with the path and size of the entry in the
+// archive_path and archive_size metadata fields. Directories are skipped.
+//
+// Archives are read as a stream, so streaming messages like S3 objects are never held in memory as a whole. Tar
+// archives and lines are read sequentially, while zip archives, which need random access, are spooled to a
+// temporary file first. Only the entries themselves are materialized.
+type UnarchiveProcessor struct {
+	config UnarchiveConfig
+}
+
+func (p *UnarchiveProcessor) Init(ctx spec.ComponentContext) error {
+	return nil
+}
+
+func (p *UnarchiveProcessor) Close(ctx spec.ComponentContext) error {
+	return nil
+}
+
+func (p *UnarchiveProcessor) Process(ctx spec.ComponentContext, batch spec.Batch) (spec.Batch, spec.ProcessedCallback, error) {
+	result := ctx.NewBatch()
+
+	for idx, msg := range batch.Messages() {
+		if err := p.unarchive(ctx, msg, result); err != nil {
+			return nil, nil, fmt.Errorf("batch #%d: %w", idx, err)
+		}
+	}
+
+	return result, spec.NoopCallback, nil
+}
+
+func (p *UnarchiveProcessor) unarchive(ctx spec.ComponentContext, msg spec.Message, result spec.Batch) error {
+	algo := p.config.Compression
+	if algo == compress.Auto {
+		algo = compress.DetectMessage(msg)
+	}
+
+	format := p.config.Format
+	if format == Auto {
+		format = DetectFormat(messageKey(msg))
+	}
+
+	r, err := spec.MessageReader(msg)
+	if err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	// -- the metadata doesn't always tell, so look at the payload before assuming it isn't compressed
+	cr := bufio.NewReader(r)
+	if algo == compress.None && p.config.Compression == compress.Auto {
+		algo = compress.DetectReader(cr)
+	}
+
+	dr, err := compress.NewReader(algo, cr)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dr.Close() }()
+
+	br := bufio.NewReader(dr)
+	if format == Auto {
+		format = sniffFormat(br)
+	}
+
+	emit := func(path string, data []byte) {
+		entry := ctx.NewMessage()
+		for k, v := range msg.Metadata() {
+			if k == compress.MetadataCompression || k == spec.MetadataSize {
+				continue
+			}
+			entry.SetMetadata(k, v)
+		}
+		entry.SetMetadata(MetadataArchivePath, path)
+		entry.SetMetadata(MetadataArchiveSize, int64(len(data)))
+		entry.SetRaw(data)
+		result.Append(entry)
+	}
+
+	switch format {
+	case Tar:
+		return readTar(br, emit)
+	case Zip:
+		return readZip(br, emit)
+	case JSONArray:
+		return readJSONArray(br, emit)
+	case Lines:
+		return readLines(br, p.config.MaxLineSize, emit)
+	default:
+		return fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+// sniffFormat determines the archive format from the first bytes of the payload.
+func sniffFormat(br *bufio.Reader) Format {
+	if b, _ := br.Peek(4); bytes.Equal(b, []byte("PK\x03\x04")) {
+		return Zip
+	}
+
+	if b, _ := br.Peek(262); len(b) == 262 && string(b[257:262]) == "ustar" {
+		return Tar
+	}
+
+	b, _ := br.Peek(512)
+	if trimmed := bytes.TrimLeft(b, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '[' {
+		return JSONArray
+	}
+
+	return Lines
+}
+
+func readTar(r io.Reader, emit func(path string, data []byte)) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("tar: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("tar: %s: %w", hdr.Name, err)
+		}
+		emit(hdr.Name, data)
+	}
+}
+
+func readZip(r io.Reader, emit func(path string, data []byte)) error {
+	// -- zip archives need random access, so spool them to disk instead of holding them in memory
+	f, err := os.CreateTemp("", "ww-unarchive-*.zip")
+	if err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("zip: %s: %w", zf.Name, err)
+		}
+
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return fmt.Errorf("zip: %s: %w", zf.Name, err)
+		}
+		emit(zf.Name, data)
+	}
+
+	return nil
+}
+
+func readJSONArray(r io.Reader, emit func(path string, data []byte)) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("json: expected an array")
+	}
+
+	for idx := 0; dec.More(); idx++ {
+		var elem json.RawMessage
+		if err := dec.Decode(&elem); err != nil {
+			return fmt.Errorf("json: element %d: %w", idx, err)
+		}
+		emit(strconv.Itoa(idx), elem)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+func readLines(r io.Reader, maxLineSize int, emit func(path string, data []byte)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		emit(strconv.Itoa(line), bytes.Clone(scanner.Bytes()))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("lines: %w", err)
+	}
+	return nil
+}
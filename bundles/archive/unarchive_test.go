@@ -0,0 +1,144 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"maps"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/archive"
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+func tarArchive(files map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	Expect(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "dir/", Mode: 0o755})).To(Succeed())
+	for name, content := range files {
+		Expect(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(content)), Mode: 0o644})).To(Succeed())
+		_, err := tw.Write([]byte(content))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(tw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+func zipArchive(files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	_, err := zw.Create("dir/")
+	Expect(err).ToNot(HaveOccurred())
+	for name, content := range files {
+		fw, err := zw.Create(name)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fw.Write([]byte(content))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(zw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+var _ = Describe("UnarchiveProcessor", func() {
+	var ctx spec.ComponentContext
+	var files map[string]string
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		files = map[string]string{
+			"dir/a.json": `{"a": 1}`,
+			"dir/b.json": `{"b": 2}`,
+		}
+	})
+
+	unarchive := func(config archive.UnarchiveConfig, msg spec.Message) spec.Batch {
+		proc, err := archive.NewUnarchiveProcessor(config)
+		Expect(err).ToNot(HaveOccurred())
+
+		batch, _, err := proc.Process(ctx, ctx.NewBatch(msg))
+		Expect(err).ToNot(HaveOccurred())
+		return batch
+	}
+
+	It("should expand a tar archive into one message per file", func() {
+		msg := spec.NewBytesMessage(tarArchive(files))
+		msg.SetMetadata("source", "drop")
+
+		batch := unarchive(archive.UnarchiveConfig{Format: archive.Tar}, msg)
+		Expect(entries(batch)).To(Equal(files))
+
+		for _, entry := range batch.Messages() {
+			Expect(maps.Collect(entry.Metadata())).To(HaveKeyWithValue("source", "drop"))
+		}
+	})
+
+	It("should stream a tar.gz archive detected from its key", func() {
+		compressed, err := compress.Compress(compress.Gzip, tarArchive(files))
+		Expect(err).ToNot(HaveOccurred())
+
+		body := &trackingReader{Reader: bytes.NewReader(compressed)}
+		msg := spec.NewReaderMessage(body)
+		msg.SetMetadata(spec.MetadataKey, "drops/2024-01-01.tar.gz")
+
+		batch := unarchive(archive.UnarchiveConfig{}, msg)
+		Expect(entries(batch)).To(Equal(files))
+		Expect(body.closed).To(BeTrue())
+	})
+
+	It("should expand compressed archives detected from their content", func() {
+		for _, algo := range []compress.Algorithm{compress.Gzip, compress.Zstd} {
+			compressed, err := compress.Compress(algo, tarArchive(files))
+			Expect(err).ToNot(HaveOccurred())
+
+			batch := unarchive(archive.UnarchiveConfig{}, spec.NewBytesMessage(compressed))
+			Expect(entries(batch)).To(Equal(files), "compressed with %s", algo)
+		}
+	})
+
+	It("should expand a zip archive detected from its content", func() {
+		msg := spec.NewReaderMessage(io.NopCloser(bytes.NewReader(zipArchive(files))))
+
+		batch := unarchive(archive.UnarchiveConfig{}, msg)
+		Expect(entries(batch)).To(Equal(files))
+	})
+
+	It("should split json arrays into their elements", func() {
+		batch := unarchive(archive.UnarchiveConfig{Format: archive.JSONArray}, spec.NewBytesMessage([]byte(`[{"a": 1}, 2, "three"]`)))
+		Expect(entries(batch)).To(Equal(map[string]string{"0": `{"a": 1}`, "1": "2", "2": `"three"`}))
+	})
+
+	It("should split lines and skip empty ones", func() {
+		msg := spec.NewBytesMessage([]byte("first\n\nsecond\nthird"))
+		msg.SetMetadata(spec.MetadataKey, "events.jsonl")
+
+		batch := unarchive(archive.UnarchiveConfig{}, msg)
+		Expect(entries(batch)).To(Equal(map[string]string{"1": "first", "3": "second", "4": "third"}))
+	})
+
+	It("should fail on corrupt archives", func() {
+		proc, err := archive.NewUnarchiveProcessor(archive.UnarchiveConfig{Format: archive.Zip})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, _, err = proc.Process(ctx, ctx.NewBatch(spec.NewBytesMessage([]byte("not a zip"))))
+		Expect(err).To(MatchError(ContainSubstring("batch #0: zip")))
+	})
+
+	It("should reject unknown formats", func() {
+		_, err := archive.NewUnarchiveProcessor(archive.UnarchiveConfig{Format: "rar"})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+type trackingReader struct {
+	io.Reader
+	closed bool
+}
+
+func (t *trackingReader) Close() error {
+	t.closed = true
+	return nil
+}
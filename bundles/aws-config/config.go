@@ -0,0 +1,76 @@
+// Package awsconfig loads the AWS SDK configuration of the components in the AWS bundles from their map
+// configuration, so they can be configured by name through a registry like the other bundles. Without any of the
+// fields, the default credential chain of the SDK is used: environment variables, shared config files and instance
+// roles.
+package awsconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// Properties are the JSON schema properties of Config, to be included in the schemas of the components.
+const Properties = `
+		"region": {"type": "string", "description": "The AWS region. Defaults to the region of the environment.", "examples": ["eu-west-1"]},
+		"profile": {"type": "string", "description": "The profile of the shared config files to use."},
+		"credentials": {
+			"type": "object",
+			"properties": {
+				"access_key_id": {"type": "string"},
+				"secret_access_key": {"type": "string"},
+				"session_token": {"type": "string"}
+			},
+			"required": ["access_key_id", "secret_access_key"],
+			"additionalProperties": false,
+			"description": "Static credentials, instead of the default credential chain."
+		}`
+
+// Config selects the region and credentials of the AWS SDK.
+type Config struct {
+	Region      string       `json:"region,omitempty" yaml:"region,omitempty" mapstructure:"region,omitempty"`
+	Profile     string       `json:"profile,omitempty" yaml:"profile,omitempty" mapstructure:"profile,omitempty"`
+	Credentials *Credentials `json:"credentials,omitempty" yaml:"credentials,omitempty" mapstructure:"credentials,omitempty"`
+}
+
+// Credentials are static AWS credentials.
+type Credentials struct {
+	AccessKeyID     string `json:"access_key_id" yaml:"access_key_id" mapstructure:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key" yaml:"secret_access_key" mapstructure:"secret_access_key"`
+	SessionToken    string `json:"session_token,omitempty" yaml:"session_token,omitempty" mapstructure:"session_token,omitempty"`
+}
+
+// Load creates the AWS SDK configuration. It doesn't contact AWS, credentials are only resolved once they are used.
+func (c Config) Load(ctx context.Context) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+	if c.Region != "" {
+		opts = append(opts, config.WithRegion(c.Region))
+	}
+	if c.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(c.Profile))
+	}
+	if c.Credentials != nil {
+		opts = append(opts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			c.Credentials.AccessKeyID, c.Credentials.SecretAccessKey, c.Credentials.SessionToken)))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load aws config: %w", err)
+	}
+	return cfg, nil
+}
+
+// FromConfig decodes the Config fields of a component configuration and loads the AWS SDK configuration. The other
+// fields of the component configuration are ignored.
+func FromConfig(config spec.Config) (aws.Config, error) {
+	var c Config
+	if err := config.Decode(&c); err != nil {
+		return aws.Config{}, fmt.Errorf("failed to decode aws config: %w", err)
+	}
+	return c.Load(context.Background())
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/wombatwisdom/components/framework/spec"
 )
 
 // IntegrationMode defines how EventBridge events are consumed
@@ -21,40 +22,48 @@ const (
 // TriggerInputConfig defines the configuration for EventBridge trigger input
 type TriggerInputConfig struct {
 	// AWS Configuration
-	aws.Config
+	aws.Config `mapstructure:"-"`
 
 	// Integration Mode
-	Mode IntegrationMode `json:"mode" yaml:"mode"` // sqs, pipes, or simulation
+	Mode IntegrationMode `json:"mode" yaml:"mode" mapstructure:"mode"` // sqs, pipes, or simulation
 
 	// EventBridge Configuration
-	EventBusName string `json:"event_bus_name" yaml:"event_bus_name"`
-	RuleName     string `json:"rule_name" yaml:"rule_name"`
+	EventBusName string `json:"event_bus_name" yaml:"event_bus_name" mapstructure:"event_bus_name"`
+	RuleName     string `json:"rule_name" yaml:"rule_name" mapstructure:"rule_name"`
 
 	// Event Filtering
-	EventSource  string            `json:"event_source" yaml:"event_source"`   // e.g., "aws.s3"
-	DetailType   string            `json:"detail_type" yaml:"detail_type"`     // e.g., "Object Created"
-	EventFilters map[string]string `json:"event_filters" yaml:"event_filters"` // Additional event filters
+	EventSource  string            `json:"event_source" yaml:"event_source" mapstructure:"event_source"`    // e.g., "aws.s3"
+	DetailType   string            `json:"detail_type" yaml:"detail_type" mapstructure:"detail_type"`       // e.g., "Object Created"
+	EventFilters map[string]string `json:"event_filters" yaml:"event_filters" mapstructure:"event_filters"` // Additional event filters
 
 	// Processing Configuration
-	MaxBatchSize     int  `json:"max_batch_size" yaml:"max_batch_size"`         // Max triggers per batch
-	EnableDeadLetter bool `json:"enable_dead_letter" yaml:"enable_dead_letter"` // DLQ for failed events
+	MaxBatchSize     int  `json:"max_batch_size" yaml:"max_batch_size" mapstructure:"max_batch_size"`             // Max triggers per batch
+	EnableDeadLetter bool `json:"enable_dead_letter" yaml:"enable_dead_letter" mapstructure:"enable_dead_letter"` // DLQ for failed events
 
 	// SQS Mode Configuration
-	SQSQueueURL          string `json:"sqs_queue_url" yaml:"sqs_queue_url"`
-	SQSMaxMessages       int32  `json:"sqs_max_messages" yaml:"sqs_max_messages"`
-	SQSWaitTimeSeconds   int32  `json:"sqs_wait_time_seconds" yaml:"sqs_wait_time_seconds"`
-	SQSVisibilityTimeout int32  `json:"sqs_visibility_timeout" yaml:"sqs_visibility_timeout"`
+	SQSQueueURL          string `json:"sqs_queue_url" yaml:"sqs_queue_url" mapstructure:"sqs_queue_url"`
+	SQSMaxMessages       int32  `json:"sqs_max_messages" yaml:"sqs_max_messages" mapstructure:"sqs_max_messages"`
+	SQSWaitTimeSeconds   int32  `json:"sqs_wait_time_seconds" yaml:"sqs_wait_time_seconds" mapstructure:"sqs_wait_time_seconds"`
+	SQSVisibilityTimeout int32  `json:"sqs_visibility_timeout" yaml:"sqs_visibility_timeout" mapstructure:"sqs_visibility_timeout"`
+
+	// Backoff schedule for redelivering events which failed to process, based on the number of times they were
+	// received. Without it, failed events become visible again after the visibility timeout.
+	SQSRedelivery *spec.Backoff `json:"sqs_redelivery,omitempty" yaml:"sqs_redelivery,omitempty" mapstructure:"sqs_redelivery,omitempty"`
+
+	// Delete messages which aren't EventBridge events. By default they are left on the queue, so its redrive policy
+	// moves them to a dead letter queue.
+	SQSDropInvalid bool `json:"sqs_drop_invalid,omitempty" yaml:"sqs_drop_invalid,omitempty" mapstructure:"sqs_drop_invalid,omitempty"`
 
 	// Pipes Mode Configuration
-	PipeName      string `json:"pipe_name" yaml:"pipe_name"`
-	PipeSourceARN string `json:"pipe_source_arn" yaml:"pipe_source_arn"`
-	PipeTargetARN string `json:"pipe_target_arn" yaml:"pipe_target_arn"`
-	PipeBatchSize int32  `json:"pipe_batch_size" yaml:"pipe_batch_size"`
+	PipeName      string `json:"pipe_name" yaml:"pipe_name" mapstructure:"pipe_name"`
+	PipeSourceARN string `json:"pipe_source_arn" yaml:"pipe_source_arn" mapstructure:"pipe_source_arn"`
+	PipeTargetARN string `json:"pipe_target_arn" yaml:"pipe_target_arn" mapstructure:"pipe_target_arn"`
+	PipeBatchSize int32  `json:"pipe_batch_size" yaml:"pipe_batch_size" mapstructure:"pipe_batch_size"`
 
 	// AWS SDK Options
-	Region             string  `json:"region" yaml:"region"`
-	EndpointURL        *string `json:"endpoint_url" yaml:"endpoint_url"`
-	ForcePathStyleURLs bool    `json:"force_path_style_urls" yaml:"force_path_style_urls"`
+	Region             string  `json:"region" yaml:"region" mapstructure:"region"`
+	EndpointURL        *string `json:"endpoint_url" yaml:"endpoint_url" mapstructure:"endpoint_url"`
+	ForcePathStyleURLs bool    `json:"force_path_style_urls" yaml:"force_path_style_urls" mapstructure:"force_path_style_urls"`
 }
 
 // DefaultTriggerInputConfig returns configuration with sensible defaults
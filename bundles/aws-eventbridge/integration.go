@@ -15,8 +15,10 @@ type EventIntegration interface {
 	// Init initializes the integration
 	Init(ctx context.Context, logger spec.Logger) error
 
-	// ReadEvents reads events from the integration source
-	ReadEvents(ctx context.Context, maxEvents int, timeout time.Duration) ([]EventBridgeEvent, error)
+	// ReadEvents reads events from the integration source. The callback is called once the events have been
+	// processed, allowing integrations to only remove the events from their source which were processed
+	// successfully. A spec.BatchError passed to the callback refers to the events by their index.
+	ReadEvents(ctx context.Context, maxEvents int, timeout time.Duration) ([]EventBridgeEvent, spec.ProcessedCallback, error)
 
 	// Close shuts down the integration
 	Close(ctx context.Context) error
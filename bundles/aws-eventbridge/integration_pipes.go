@@ -65,7 +65,7 @@ func (p *PipesIntegration) Init(ctx context.Context, logger spec.Logger) error {
 // ReadEvents reads events from EventBridge Pipes
 // Note: This is a simplified implementation. In reality, EventBridge Pipes
 // pushes events to targets (SQS, Kinesis, Lambda, etc.) rather than being polled.
-func (p *PipesIntegration) ReadEvents(ctx context.Context, maxEvents int, timeout time.Duration) ([]EventBridgeEvent, error) {
+func (p *PipesIntegration) ReadEvents(ctx context.Context, maxEvents int, timeout time.Duration) ([]EventBridgeEvent, spec.ProcessedCallback, error) {
 	events := make([]EventBridgeEvent, 0, maxEvents)
 
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
@@ -83,10 +83,10 @@ func (p *PipesIntegration) ReadEvents(ctx context.Context, maxEvents int, timeou
 	case <-timeoutCtx.Done():
 		// Timeout reached, return what we have
 		p.logger.Debugf("EventBridge Pipes timeout reached, returning %d events", len(events))
-		return events, nil
+		return events, spec.NoopCallback, nil
 	case <-p.stopChan:
 		// Component shutting down
-		return events, nil
+		return events, spec.NoopCallback, nil
 	}
 }
 
@@ -29,7 +29,7 @@ func (s *SimulationIntegration) Init(ctx context.Context, logger spec.Logger) er
 }
 
 // ReadEvents generates simulated events for testing
-func (s *SimulationIntegration) ReadEvents(ctx context.Context, maxEvents int, timeout time.Duration) ([]EventBridgeEvent, error) {
+func (s *SimulationIntegration) ReadEvents(ctx context.Context, maxEvents int, timeout time.Duration) ([]EventBridgeEvent, spec.ProcessedCallback, error) {
 	events := make([]EventBridgeEvent, 0, maxEvents)
 
 	// Only generate events occasionally to avoid flooding tests
@@ -43,10 +43,10 @@ func (s *SimulationIntegration) ReadEvents(ctx context.Context, maxEvents int, t
 	select {
 	case <-time.After(timeout):
 		// Timeout reached, return what we have
-		return events, nil
+		return events, spec.NoopCallback, nil
 	case <-ctx.Done():
 		// Context cancelled
-		return events, ctx.Err()
+		return events, spec.NoopCallback, ctx.Err()
 	}
 }
 
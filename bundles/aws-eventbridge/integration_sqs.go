@@ -3,7 +3,10 @@ package aws_eventbridge
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,6 +15,9 @@ import (
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
+// maxVisibilityTimeout is the longest SQS allows a message to stay invisible
+const maxVisibilityTimeout = 12 * time.Hour
+
 // SQSIntegration implements EventIntegration for SQS-based event consumption
 type SQSIntegration struct {
 	config    TriggerInputConfig
@@ -48,8 +54,11 @@ func (s *SQSIntegration) Init(ctx context.Context, logger spec.Logger) error {
 	return nil
 }
 
-// ReadEvents reads events from SQS queue
-func (s *SQSIntegration) ReadEvents(ctx context.Context, maxEvents int, timeout time.Duration) ([]EventBridgeEvent, error) {
+// ReadEvents reads events from SQS queue. The messages are only deleted from the queue once the callback reports them
+// as processed, or as failed permanently. Messages which failed otherwise become visible again after the delay
+// requested by the error or the redelivery schedule, or else after the visibility timeout, and are redelivered.
+// Messages which aren't EventBridge events are left on the queue for its redrive policy, unless SQSDropInvalid is set.
+func (s *SQSIntegration) ReadEvents(ctx context.Context, maxEvents int, timeout time.Duration) ([]EventBridgeEvent, spec.ProcessedCallback, error) {
 	// Adjust maxEvents to SQS limits
 	maxMessages := int32(maxEvents)
 	if maxMessages > s.config.SQSMaxMessages {
@@ -66,6 +75,9 @@ func (s *SQSIntegration) ReadEvents(ctx context.Context, maxEvents int, timeout
 		WaitTimeSeconds:       s.config.SQSWaitTimeSeconds,
 		VisibilityTimeout:     s.config.SQSVisibilityTimeout,
 		MessageAttributeNames: []string{"All"},
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+		},
 	}
 
 	// Apply timeout context
@@ -75,44 +87,127 @@ func (s *SQSIntegration) ReadEvents(ctx context.Context, maxEvents int, timeout
 	// Receive messages from SQS
 	result, err := s.sqsClient.ReceiveMessage(timeoutCtx, input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to receive messages from SQS: %w", err)
+		return nil, spec.NoopCallback, fmt.Errorf("failed to receive messages from SQS: %w", err)
 	}
 
-	// Convert SQS messages to EventBridge events
+	// Convert SQS messages to EventBridge events, keeping the message of every event
 	events := make([]EventBridgeEvent, 0, len(result.Messages))
-	messagesToDelete := make([]types.DeleteMessageBatchRequestEntry, 0, len(result.Messages))
+	received := make([]types.Message, 0, len(result.Messages))
+	var dropped []*string
 
-	for i, message := range result.Messages {
+	for _, message := range result.Messages {
 		event, err := s.parseEventBridgeMessage(message)
-		if err != nil {
-			s.logger.Warnf("Failed to parse SQS message: %v", err)
+		switch {
+		case err != nil && s.config.SQSDropInvalid:
+			s.logger.Warnf("Dropping SQS message %s which isn't an EventBridge event: %v", aws.ToString(message.MessageId), err)
+			dropped = append(dropped, message.ReceiptHandle)
+			continue
+		case err != nil:
+			// -- the message becomes visible again after the visibility timeout, until the redrive policy moves it
+			s.logger.Warnf("Leaving SQS message %s which isn't an EventBridge event on the queue: %v", aws.ToString(message.MessageId), err)
 			continue
 		}
 
 		events = append(events, event)
+		received = append(received, message)
+	}
 
-		// Prepare for batch deletion
-		messagesToDelete = append(messagesToDelete, types.DeleteMessageBatchRequestEntry{
-			Id:            aws.String(fmt.Sprintf("msg_%d", i)),
-			ReceiptHandle: message.ReceiptHandle,
-		})
+	if err := s.deleteMessages(ctx, dropped); err != nil {
+		s.logger.Warnf("Failed to delete dropped messages: %v", err)
+	}
+
+	s.logger.Debugf("Read %d events from SQS queue", len(events))
+
+	callback := func(ctx context.Context, err error) error {
+		toDelete := make([]*string, 0, len(received))
+		var toDelay []types.ChangeMessageVisibilityBatchRequestEntry
+
+		for idx, message := range received {
+			msgErr := spec.MessageError(err, idx)
+			if msgErr == nil || errors.Is(msgErr, spec.ErrPermanent) {
+				toDelete = append(toDelete, message.ReceiptHandle)
+				continue
+			}
+
+			delay := spec.RedeliveryDelay(msgErr, s.config.SQSRedelivery, receiveCount(message))
+			if delay > 0 {
+				toDelay = append(toDelay, types.ChangeMessageVisibilityBatchRequestEntry{
+					Id:                aws.String(fmt.Sprintf("msg_%d", idx)),
+					ReceiptHandle:     message.ReceiptHandle,
+					VisibilityTimeout: int32(math.Ceil(min(delay, maxVisibilityTimeout).Seconds())),
+				})
+			}
+		}
+
+		if skipped := len(received) - len(toDelete); skipped > 0 {
+			s.logger.Debugf("Leaving %d failed events on the SQS queue for redelivery", skipped)
+		}
+
+		return errors.Join(s.deleteMessages(ctx, toDelete), s.delayMessages(ctx, toDelay))
 	}
 
-	// Delete successfully processed messages
-	if len(messagesToDelete) > 0 {
-		deleteInput := &sqs.DeleteMessageBatchInput{
+	return events, callback, nil
+}
+
+// deleteMessages removes the messages with the given receipt handles from the queue, in batches of at most 10.
+func (s *SQSIntegration) deleteMessages(ctx context.Context, receipts []*string) error {
+	var errs []error
+	for start := 0; start < len(receipts); start += 10 {
+		chunk := receipts[start:min(start+10, len(receipts))]
+
+		entries := make([]types.DeleteMessageBatchRequestEntry, len(chunk))
+		for i, receipt := range chunk {
+			entries[i] = types.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(fmt.Sprintf("msg_%d", start+i)),
+				ReceiptHandle: receipt,
+			}
+		}
+
+		result, err := s.sqsClient.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
 			QueueUrl: aws.String(s.config.SQSQueueURL),
-			Entries:  messagesToDelete,
+			Entries:  entries,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete messages from SQS: %w", err))
+			continue
 		}
 
-		_, err := s.sqsClient.DeleteMessageBatch(ctx, deleteInput)
+		for _, failed := range result.Failed {
+			errs = append(errs, fmt.Errorf("failed to delete message %s from SQS: %s", aws.ToString(failed.Id), aws.ToString(failed.Message)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// delayMessages changes the visibility timeout of failed messages, so they are redelivered after the requested delay.
+func (s *SQSIntegration) delayMessages(ctx context.Context, entries []types.ChangeMessageVisibilityBatchRequestEntry) error {
+	var errs []error
+	for start := 0; start < len(entries); start += 10 {
+		result, err := s.sqsClient.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+			QueueUrl: aws.String(s.config.SQSQueueURL),
+			Entries:  entries[start:min(start+10, len(entries))],
+		})
 		if err != nil {
-			s.logger.Warnf("Failed to delete processed messages: %v", err)
+			errs = append(errs, fmt.Errorf("failed to delay redelivery of messages: %w", err))
+			continue
+		}
+
+		for _, failed := range result.Failed {
+			errs = append(errs, fmt.Errorf("failed to delay redelivery of message %s: %s", aws.ToString(failed.Id), aws.ToString(failed.Message)))
 		}
 	}
 
-	s.logger.Debugf("Read %d events from SQS queue", len(events))
-	return events, nil
+	return errors.Join(errs...)
+}
+
+// receiveCount returns how often the message has been received, including this time.
+func receiveCount(message types.Message) int {
+	count, err := strconv.Atoi(message.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+	if err != nil {
+		return 1
+	}
+	return count
 }
 
 // parseEventBridgeMessage converts an SQS message to an EventBridge event
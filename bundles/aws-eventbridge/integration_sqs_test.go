@@ -0,0 +1,247 @@
+package aws_eventbridge_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	eventbridge "github.com/wombatwisdom/components/bundles/aws-eventbridge"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("SQSIntegration", func() {
+	var (
+		queue       *fakeQueue
+		client      *sqs.Client
+		config      eventbridge.TriggerInputConfig
+		integration *eventbridge.SQSIntegration
+	)
+
+	BeforeEach(func() {
+		queue = &fakeQueue{}
+		srv := httptest.NewServer(queue)
+		DeferCleanup(srv.Close)
+
+		client = sqs.New(sqs.Options{
+			Region:                           "us-east-1",
+			BaseEndpoint:                     aws.String(srv.URL),
+			Credentials:                      credentials.NewStaticCredentialsProvider("key", "secret", ""),
+			DisableMessageChecksumValidation: true,
+		})
+
+		config = eventbridge.DefaultTriggerInputConfig()
+		config.SQSQueueURL = srv.URL + "/queue"
+		config.SQSWaitTimeSeconds = 0
+	})
+
+	JustBeforeEach(func() {
+		integration = eventbridge.NewSQSIntegration(config, client)
+		Expect(integration.Init(context.Background(), test.NewMockComponentContext())).To(Succeed())
+	})
+
+	It("should only delete the messages once they are processed", func() {
+		queue.add(s3Event("one"), s3Event("two"))
+
+		events, callback, err := integration.ReadEvents(context.Background(), 10, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(2))
+		Expect(queue.deleted()).To(BeEmpty())
+
+		Expect(callback(context.Background(), nil)).To(Succeed())
+		Expect(queue.deleted()).To(ConsistOf("receipt-0", "receipt-1"))
+	})
+
+	It("should leave the failed messages of a batch on the queue", func() {
+		queue.add(s3Event("one"), s3Event("two"), s3Event("three"))
+
+		events, callback, err := integration.ReadEvents(context.Background(), 10, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(3))
+
+		batchErr := spec.NewBatchError(errors.New("write failed")).
+			Failed(0, fmt.Errorf("unsupported: %w", spec.ErrPermanent)).
+			Failed(1, errors.New("temporarily unavailable"))
+
+		Expect(callback(context.Background(), batchErr)).To(Succeed())
+		Expect(queue.deleted()).To(ConsistOf("receipt-0", "receipt-2"))
+	})
+
+	It("should keep all messages when the whole batch failed", func() {
+		queue.add(s3Event("one"), s3Event("two"))
+
+		_, callback, err := integration.ReadEvents(context.Background(), 10, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(callback(context.Background(), errors.New("write failed"))).To(Succeed())
+		Expect(queue.deleted()).To(BeEmpty())
+	})
+
+	It("should leave messages which can't be parsed on the queue", func() {
+		queue.add("not an event", s3Event("one"))
+
+		events, _, err := integration.ReadEvents(context.Background(), 10, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+		Expect(queue.deleted()).To(BeEmpty())
+	})
+
+	When("invalid messages are dropped", func() {
+		BeforeEach(func() {
+			config.SQSDropInvalid = true
+		})
+
+		It("should delete messages which can't be parsed", func() {
+			queue.add("not an event", s3Event("one"))
+
+			events, _, err := integration.ReadEvents(context.Background(), 10, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(queue.deleted()).To(ConsistOf("receipt-0"))
+		})
+	})
+
+	It("should delay the redelivery when the error requests it", func() {
+		queue.add(s3Event("one"), s3Event("two"))
+
+		_, callback, err := integration.ReadEvents(context.Background(), 10, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		batchErr := spec.NewBatchError(nil).
+			Failed(0, spec.RedeliverAfter(errors.New("rate limited"), 90*time.Second)).
+			Failed(1, errors.New("temporarily unavailable"))
+
+		Expect(callback(context.Background(), batchErr)).To(Succeed())
+		Expect(queue.visibility()).To(Equal(map[string]int{"receipt-0": 90}))
+		Expect(queue.deleted()).To(BeEmpty())
+	})
+
+	When("a redelivery schedule is configured", func() {
+		BeforeEach(func() {
+			config.SQSRedelivery = &spec.Backoff{Initial: 10 * time.Second, Max: time.Minute, Multiplier: 2}
+		})
+
+		It("should delay the redelivery based on the receive count", func() {
+			queue.add(s3Event("one"))
+			queue.receiveCount = 3
+
+			_, callback, err := integration.ReadEvents(context.Background(), 10, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(callback(context.Background(), errors.New("temporarily unavailable"))).To(Succeed())
+			Expect(queue.visibility()).To(Equal(map[string]int{"receipt-0": 40}))
+		})
+	})
+})
+
+func s3Event(key string) string {
+	return fmt.Sprintf(`{"source":"aws.s3","detail-type":"Object Created","detail":{"bucket":{"name":"test"},"object":{"key":%q}}}`, key)
+}
+
+// fakeQueue is a minimal SQS endpoint speaking the AWS JSON protocol. It hands out all pending messages on receive
+// and records the receipt handles which get deleted or have their visibility changed.
+type fakeQueue struct {
+	lock         sync.Mutex
+	pending      []string
+	receipts     int
+	receiveCount int
+	removed      []string
+	timeouts     map[string]int
+}
+
+func (q *fakeQueue) add(bodies ...string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.pending = append(q.pending, bodies...)
+}
+
+func (q *fakeQueue) deleted() []string {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return append([]string(nil), q.removed...)
+}
+
+func (q *fakeQueue) visibility() map[string]int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return maps.Clone(q.timeouts)
+}
+
+func (q *fakeQueue) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var req struct {
+		Entries []struct {
+			Id                string
+			ReceiptHandle     string
+			VisibilityTimeout int
+		}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp any
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AmazonSQS.") {
+	case "GetQueueAttributes":
+		resp = map[string]any{"Attributes": map[string]string{"ApproximateNumberOfMessages": "0"}}
+
+	case "ReceiveMessage":
+		messages := make([]map[string]any, 0, len(q.pending))
+		for _, body := range q.pending {
+			messages = append(messages, map[string]any{
+				"MessageId":     fmt.Sprintf("id-%d", q.receipts),
+				"ReceiptHandle": fmt.Sprintf("receipt-%d", q.receipts),
+				"Body":          body,
+				"Attributes":    map[string]string{"ApproximateReceiveCount": strconv.Itoa(max(q.receiveCount, 1))},
+			})
+			q.receipts++
+		}
+		q.pending = nil
+		resp = map[string]any{"Messages": messages}
+
+	case "DeleteMessageBatch":
+		successful := make([]map[string]string, 0, len(req.Entries))
+		for _, entry := range req.Entries {
+			q.removed = append(q.removed, entry.ReceiptHandle)
+			successful = append(successful, map[string]string{"Id": entry.Id})
+		}
+		resp = map[string]any{"Successful": successful, "Failed": []any{}}
+
+	case "ChangeMessageVisibilityBatch":
+		if q.timeouts == nil {
+			q.timeouts = make(map[string]int)
+		}
+		successful := make([]map[string]string, 0, len(req.Entries))
+		for _, entry := range req.Entries {
+			q.timeouts[entry.ReceiptHandle] = entry.VisibilityTimeout
+			successful = append(successful, map[string]string{"Id": entry.Id})
+		}
+		resp = map[string]any{"Successful": successful, "Failed": []any{}}
+
+	default:
+		http.Error(w, "unsupported operation", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	_ = json.NewEncoder(w).Encode(resp)
+}
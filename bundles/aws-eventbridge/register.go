@@ -0,0 +1,56 @@
+package aws_eventbridge
+
+import (
+	awsconfig "github.com/wombatwisdom/components/bundles/aws-config"
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const triggerInputSchema = `{
+	"type": "object",
+	"properties": {` + awsconfig.Properties + `,
+		"endpoint_url": {"type": "string", "description": "The endpoint of an AWS compatible service, like LocalStack.", "examples": ["http://localhost:4566"]},
+		"force_path_style_urls": {"type": "boolean", "default": false},
+		"mode": {"type": "string", "enum": ["sqs", "pipes", "simulation"], "default": "sqs", "description": "How the events are consumed: from the SQS queue a rule routes them to, through a pipe, or simulated for testing."},
+		"event_bus_name": {"type": "string", "default": "default"},
+		"rule_name": {"type": "string", "description": "The rule routing the events to the queue. Required for the sqs mode."},
+		"event_source": {"type": "string", "description": "The source of the events.", "examples": ["aws.s3"]},
+		"detail_type": {"type": "string", "description": "The detail type of the events.", "examples": ["Object Created"]},
+		"event_filters": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Additional filters on the fields of the events."},
+		"max_batch_size": {"type": "integer", "minimum": 1, "default": 10, "description": "The maximum number of triggers per batch."},
+		"enable_dead_letter": {"type": "boolean", "default": false},
+		"sqs_queue_url": {"type": "string", "description": "The URL of the queue the events are routed to. Required for the sqs mode."},
+		"sqs_max_messages": {"type": "integer", "minimum": 1, "maximum": 10, "default": 10},
+		"sqs_wait_time_seconds": {"type": "integer", "minimum": 0, "maximum": 20, "default": 20},
+		"sqs_visibility_timeout": {"type": "integer", "minimum": 1, "default": 30},
+		"sqs_redelivery": {
+			"type": "object",
+			"properties": {
+				"initial": {"type": "string", "default": "500ms"},
+				"max": {"type": "string", "default": "30s"},
+				"multiplier": {"type": "number", "default": 2},
+				"jitter": {"type": "number", "minimum": 0, "maximum": 1}
+			},
+			"additionalProperties": false,
+			"description": "The backoff schedule for events which failed to process. Without it, failed events become visible again after the visibility timeout."
+		},
+		"sqs_drop_invalid": {"type": "boolean", "default": false, "description": "Delete messages which aren't EventBridge events instead of leaving them for the redrive policy of the queue."},
+		"pipe_name": {"type": "string", "description": "Required for the pipes mode."},
+		"pipe_source_arn": {"type": "string", "description": "Required for the pipes mode."},
+		"pipe_target_arn": {"type": "string", "description": "Required for the pipes mode."},
+		"pipe_batch_size": {"type": "integer", "minimum": 1, "maximum": 10000, "default": 10}
+	},
+	"required": ["event_source"],
+	"additionalProperties": false
+}`
+
+// Register adds the EventBridge trigger input to the registry.
+func Register(r *registry.Registry) error {
+	return r.RegisterTriggerInput(registry.Spec{
+		Name:    TriggerInputComponentName,
+		Summary: "Emits triggers for EventBridge events, like the object notifications of S3 buckets.",
+		Schema:  triggerInputSchema,
+	}, nil, func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.TriggerInput, error) {
+		return NewTriggerInputFromConfig(cfg)
+	})
+}
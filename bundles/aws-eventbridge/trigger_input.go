@@ -5,9 +5,31 @@ import (
 	"strings"
 	"time"
 
+	awsconfig "github.com/wombatwisdom/components/bundles/aws-config"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
+const (
+	TriggerInputComponentName = "aws_eventbridge"
+)
+
+// NewTriggerInputFromConfig creates an EventBridge trigger input from a spec.Config interface, starting from
+// DefaultTriggerInputConfig. The AWS SDK is configured by the fields of awsconfig.Config.
+func NewTriggerInputFromConfig(config spec.Config) (*TriggerInput, error) {
+	cfg := DefaultTriggerInputConfig()
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode eventbridge trigger input config: %w", err)
+	}
+
+	var err error
+	if cfg.Config, err = awsconfig.FromConfig(config); err != nil {
+		return nil, err
+	}
+
+	// -- the component context is set when the input is initialized
+	return NewTriggerInput(nil, cfg)
+}
+
 // NewTriggerInput creates a new EventBridge trigger input component.
 //
 // This component implements the trigger-retrieval pattern by listening for
@@ -105,7 +127,7 @@ func (t *TriggerInput) ReadTriggers(ctx spec.ComponentContext) (spec.TriggerBatc
 
 	// Read events from the integration
 	timeout := 100 * time.Millisecond
-	events, err := t.integration.ReadEvents(ctx.Context(), t.config.MaxBatchSize, timeout)
+	events, callback, err := t.integration.ReadEvents(ctx.Context(), t.config.MaxBatchSize, timeout)
 	if err != nil {
 		return batch, spec.NoopCallback, fmt.Errorf("failed to read events: %w", err)
 	}
@@ -116,7 +138,7 @@ func (t *TriggerInput) ReadTriggers(ctx spec.ComponentContext) (spec.TriggerBatc
 		batch.Append(trigger)
 	}
 
-	return batch, spec.NoopCallback, nil
+	return batch, callback, nil
 }
 
 // convertEventToTrigger converts an EventBridge event to a trigger event
@@ -0,0 +1,121 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// newStateStore returns the state store an input keeps its position in. A store set in code takes precedence over
+// a directory set in the map configuration, and without either the position is kept in memory.
+func newStateStore(store spec.StateStore, dir string) (spec.StateStore, error) {
+	switch {
+	case store != nil:
+		return store, nil
+	case dir != "":
+		return spec.NewFileStateStore(dir)
+	default:
+		return spec.NewMemoryStateStore(), nil
+	}
+}
+
+// checkpoints commits the positions reached by the batches of an input to a state store, in the order the batches
+// were read, so a batch that is processed early never moves the stored position past a batch that is still in
+// flight. It isn't safe for concurrent use, the input guards it with its own lock.
+type checkpoints[T any] struct {
+	state spec.StateStore
+	key   string
+
+	// committed is the position stored in the state store
+	committed T
+	// pending holds the batches that are in flight, in the order they were read
+	pending []*checkpoint[T]
+	// epoch is incremented when the input rewinds or is reset, invalidating the batches in flight
+	epoch uint64
+}
+
+// checkpoint is the position reached by a batch which has not been processed yet.
+type checkpoint[T any] struct {
+	position T
+	epoch    uint64
+	done     bool
+}
+
+// load reads the committed position from the state store and forgets the batches in flight. It reports false if no
+// position was stored yet, in which case the committed position is the zero value.
+func (c *checkpoints[T]) load(ctx context.Context) (bool, error) {
+	var committed T
+	c.committed = committed
+	c.reset()
+
+	b, err := c.state.Get(ctx, c.key)
+	switch {
+	case errors.Is(err, spec.ErrStateNotFound):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("failed to load position: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &committed); err != nil {
+		return false, fmt.Errorf("failed to decode position: %w", err)
+	}
+	c.committed = committed
+	return true, nil
+}
+
+// track registers the position reached by a batch which was just read.
+func (c *checkpoints[T]) track(position T) *checkpoint[T] {
+	cp := &checkpoint[T]{position: position, epoch: c.epoch}
+	c.pending = append(c.pending, cp)
+	return cp
+}
+
+// reset forgets the batches in flight. Their callbacks may still be called, so the epoch is incremented to keep
+// them from committing a position or rewinding the input.
+func (c *checkpoints[T]) reset() {
+	c.pending = nil
+	c.epoch++
+}
+
+// processed records the outcome of a batch. Successful batches commit their position once all batches read before
+// them have been processed as well. A failed batch invalidates all batches in flight and reports that the input has
+// to rewind to the committed position.
+func (c *checkpoints[T]) processed(ctx context.Context, cp *checkpoint[T], res error) (bool, error) {
+	if cp.epoch != c.epoch {
+		// -- the input rewound after this batch was read, so it will be redelivered
+		return false, nil
+	}
+
+	if res != nil {
+		c.pending = nil
+		c.epoch++
+		return true, nil
+	}
+
+	cp.done = true
+
+	advanced := false
+	for len(c.pending) > 0 && c.pending[0].done {
+		c.committed = c.pending[0].position
+		c.pending = c.pending[1:]
+		advanced = true
+	}
+
+	if !advanced {
+		return false, nil
+	}
+
+	b, err := json.Marshal(c.committed)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode position: %w", err)
+	}
+
+	if err := c.state.Set(ctx, c.key, b); err != nil {
+		return false, fmt.Errorf("failed to store position: %w", err)
+	}
+
+	return false, nil
+}
@@ -3,44 +3,128 @@ package s3
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	awsconfig "github.com/wombatwisdom/components/bundles/aws-config"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
+const (
+	InputComponentName = "aws_s3"
+)
+
 type InputConfig struct {
-	aws.Config
+	aws.Config `mapstructure:"-"`
+
+	Bucket  string `mapstructure:"bucket"`
+	Prefix  string `mapstructure:"prefix"`
+	MaxKeys int32  `mapstructure:"max_keys"`
+
+	ForcePathStyleURLs bool    `mapstructure:"force_path_style_urls"`
+	EndpointURL        *string `mapstructure:"endpoint_url"`
+
+	// Decompress objects while they are being read. The compression algorithm is detected from the
+	// Content-Encoding of the object or the suffix of its key (e.g. .gz, .zst). Decompressed messages carry
+	// the compression=none marker, so a compress processor doesn't decompress them again.
+	Decompress bool `mapstructure:"decompress"`
+
+	// State stores the position of the input within the bucket once a batch has been processed, so a restarted
+	// input resumes after the last processed object instead of listing the whole prefix again.
+	// Defaults to a file store in StateDir if it is set, and to an in-memory store, which doesn't survive restarts,
+	// otherwise.
+	State spec.StateStore `mapstructure:"-"`
+
+	// StateDir is the directory of the file store the position is stored in when no State is set.
+	StateDir string `mapstructure:"state_dir"`
+
+	// StateKey is the key the position is stored under. Defaults to s3/<bucket>/<prefix>.
+	StateKey string `mapstructure:"state_key"`
+}
+
+// Position is the resumable position of the input within the bucket.
+type Position struct {
+	// The continuation token of the next page, if the listing was interrupted halfway.
+	ContinuationToken *string `json:"continuation_token,omitempty"`
+
+	// The last key that was listed. Keys are listed in lexicographical order, so listing resumes after this key.
+	LastKey string `json:"last_key,omitempty"`
+}
 
-	Bucket  string
-	Prefix  string
-	MaxKeys int32
+// NewInputFromConfig creates an S3 input from a spec.Config interface. The AWS SDK is configured by the fields of
+// awsconfig.Config.
+func NewInputFromConfig(env spec.Environment, config spec.Config) (*Input, error) {
+	var cfg InputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode s3 input config: %w", err)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("a bucket is required")
+	}
 
-	ForcePathStyleURLs bool
-	EndpointURL        *string
+	var err error
+	if cfg.Config, err = awsconfig.FromConfig(config); err != nil {
+		return nil, err
+	}
+	return NewInput(env, cfg)
 }
 
 func NewInput(env spec.Environment, config InputConfig) (*Input, error) {
+	var err error
+	if config.State, err = newStateStore(config.State, config.StateDir); err != nil {
+		return nil, err
+	}
+
+	if config.StateKey == "" {
+		config.StateKey = fmt.Sprintf("s3/%s/%s", config.Bucket, config.Prefix)
+	}
+
 	return &Input{
-		config: config,
-		log:    env,
+		config:      config,
+		checkpoints: checkpoints[Position]{state: config.State, key: config.StateKey},
+		log:         env,
 	}, nil
 }
 
+// Input reads all objects below a prefix of an S3 bucket, one page of keys per batch.
+//
+// The position of the input is stored in the configured state store once the callback of a batch has been called
+// without an error. Positions are committed in the order the batches were read, so a batch that is processed
+// early never moves the stored position past a batch that is still in flight. If a batch fails, the input rewinds
+// to the last committed position and redelivers the objects from there. Once the listing is exhausted, Read
+// returns spec.ErrNoData until new objects are added after the last key. Objects which are overwritten below the
+// last key are not read again; use the PollingTriggerInput to pick up modified objects.
+//
+// Input implements spec.Input: it is started with Init and stopped with Close, which replace the Connect and
+// Disconnect methods of earlier versions, and Read returns the batch together with its callback instead of writing
+// it to a collector.
 type Input struct {
 	config InputConfig
 
-	s3        *s3.Client
-	pageToken *string
+	s3 *s3.Client
+
+	// position is the position the next listing starts from
+	position    Position
+	checkpoints checkpoints[Position]
+	lock        sync.Mutex
 
 	log spec.Logger
 }
 
-func (i *Input) Connect(ctx context.Context) error {
+func (i *Input) Init(ctx spec.ComponentContext) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
 	if i.s3 != nil {
-		return nil
+		return spec.ErrAlreadyConnected
 	}
 
+	if _, err := i.checkpoints.load(ctx.Context()); err != nil {
+		return err
+	}
+	i.position = i.checkpoints.committed
+
 	i.s3 = s3.NewFromConfig(i.config.Config, func(o *s3.Options) {
 		o.UsePathStyle = i.config.ForcePathStyleURLs
 		if i.config.EndpointURL != nil {
@@ -51,50 +135,110 @@ func (i *Input) Connect(ctx context.Context) error {
 	return nil
 }
 
-func (i *Input) Disconnect(ctx context.Context) error {
+func (i *Input) Close(ctx spec.ComponentContext) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.s3 = nil
+	i.checkpoints.reset()
 	return nil
 }
 
-func (i *Input) Read(ctx context.Context, collector spec.Collector) error {
+func (i *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
+	i.lock.Lock()
+	client, from := i.s3, i.position
+	i.lock.Unlock()
+
+	if client == nil {
+		return nil, nil, spec.ErrNotConnected
+	}
+
 	// -- list the objects and get the keys
-	resp, err := i.s3.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+	req := &s3.ListObjectsV2Input{
 		Bucket:            &i.config.Bucket,
 		Prefix:            &i.config.Prefix,
-		MaxKeys:           &i.config.MaxKeys,
-		ContinuationToken: i.pageToken,
-	})
+		ContinuationToken: from.ContinuationToken,
+	}
+	if i.config.MaxKeys > 0 {
+		req.MaxKeys = &i.config.MaxKeys
+	}
+	if from.ContinuationToken == nil && from.LastKey != "" {
+		req.StartAfter = aws.String(from.LastKey)
+	}
+
+	resp, err := client.ListObjectsV2(ctx.Context(), req)
 	if err != nil {
-		return fmt.Errorf("failed to list objects: %w", err)
+		return nil, nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	next := Position{LastKey: from.LastKey}
+	if aws.ToBool(resp.IsTruncated) {
+		next.ContinuationToken = resp.NextContinuationToken
 	}
 
+	batch := ctx.NewBatch()
+	var messages []*ObjectResponseMessage
 	for _, obj := range resp.Contents {
 		// -- get the object
-		objResp, err := i.s3.GetObject(ctx, &s3.GetObjectInput{
+		objResp, err := client.GetObject(ctx.Context(), &s3.GetObjectInput{
 			Bucket: &i.config.Bucket,
 			Key:    obj.Key,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to get object: %w", err)
+			closeBodies(messages)
+			return nil, nil, fmt.Errorf("failed to get object: %w", err)
 		}
 
 		// -- create the message
-		msg := NewObjectResponseMessage(objResp)
-
-		// -- write the message
-		if err := collector.Write(msg); err != nil {
-			// Note: Ack/Nack are legacy methods, now handled by message implementation
-			return fmt.Errorf("failed to write message: %w", err)
+		msg, err := newObjectMessage(objResp, *obj.Key, i.config.Decompress)
+		if err != nil {
+			closeBodies(messages)
+			return nil, nil, err
 		}
+		msg.SetMetadata(spec.MetadataBucket, i.config.Bucket)
+		msg.SetMetadata(spec.MetadataKey, aws.ToString(obj.Key))
+		batch.Append(msg)
+		messages = append(messages, msg)
+
+		next.LastKey = aws.ToString(obj.Key)
 	}
 
-	if resp.IsTruncated != nil && !*resp.IsTruncated {
-		if err := collector.Disconnect(); err != nil {
-			return fmt.Errorf("failed to disconnect collector: %w", err)
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if len(messages) == 0 {
+		// -- nothing was listed, so there is nothing to acknowledge before moving on
+		if next.ContinuationToken != nil {
+			i.position = next
 		}
-	} else {
-		// update the pointer to the next page
-		i.pageToken = resp.NextContinuationToken
+		return nil, nil, spec.ErrNoData
 	}
 
-	return nil
+	i.position = next
+	pending := i.checkpoints.track(next)
+
+	return batch, func(ackCtx context.Context, res error) error {
+		closeBodies(messages)
+		return i.processed(ackCtx, pending, res)
+	}, nil
+}
+
+// processed records the outcome of a batch, rewinding the input to the last committed position if it failed.
+func (i *Input) processed(ctx context.Context, pending *checkpoint[Position], res error) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	rewind, err := i.checkpoints.processed(ctx, pending, res)
+	if rewind {
+		i.log.Warnf("Failed to process objects up to %s, rewinding to the last committed position: %v", pending.position.LastKey, res)
+		i.position = i.checkpoints.committed
+	}
+	return err
+}
+
+// closeBodies releases the object bodies which were not consumed.
+func closeBodies(messages []*ObjectResponseMessage) {
+	for _, msg := range messages {
+		_ = msg.Ack()
+	}
 }
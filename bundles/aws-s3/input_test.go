@@ -1,7 +1,9 @@
 package s3_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -11,36 +13,48 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	s3 "github.com/wombatwisdom/components/bundles/aws-s3"
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/framework/spec"
 	"github.com/wombatwisdom/components/framework/test"
 )
 
 var _ = Describe("Input", func() {
 	var input *s3.Input
+	var ctx spec.ComponentContext
 
 	BeforeEach(func() {
 		// Input will be created in the When block with the correct bucket
+		ctx = test.NewMockComponentContext()
 	})
 
 	AfterEach(func() {
 		if input != nil {
-			_ = input.Disconnect(context.Background())
+			_ = input.Close(ctx)
 		}
 	})
 
+	readKeys := func(batch spec.Batch) []string {
+		var keys []string
+		for _, msg := range batch.Messages() {
+			for k, v := range msg.Metadata() {
+				if k == spec.MetadataKey {
+					keys = append(keys, v.(string))
+				}
+			}
+		}
+		return keys
+	}
+
 	When("Reading a file from S3", func() {
 		var bucket string
 		var key string
 
 		BeforeEach(func() {
-			bucket = "testbucket"
-			_, err := s3Client.CreateBucket(context.Background(), &as3.CreateBucketInput{
-				Bucket: aws.String(bucket),
-			})
-			Expect(err).ToNot(HaveOccurred())
+			bucket = createBucket("test")
 
 			// create the file in S3
 			key = fmt.Sprintf("test/files/%s", uuid.New().String())
-			_, err = s3Client.PutObject(context.Background(), &as3.PutObjectInput{
+			_, err := s3Client.PutObject(context.Background(), &as3.PutObjectInput{
 				Bucket: aws.String(bucket),
 				Key:    aws.String(key),
 				Body:   strings.NewReader("hello, world"),
@@ -58,7 +72,7 @@ var _ = Describe("Input", func() {
 			})
 			Expect(err).ToNot(HaveOccurred())
 
-			err = input.Connect(context.Background())
+			err = input.Init(ctx)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -71,13 +85,272 @@ var _ = Describe("Input", func() {
 		})
 
 		It("should receive a single message in the source", func() {
-			collector := test.NewListCollector()
-			defer func() { _ = collector.Disconnect() }()
+			batch, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readKeys(batch)).To(Equal([]string{key}))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+
+			_, _, err = input.Read(ctx)
+			Expect(err).To(MatchError(spec.ErrNoData))
+		})
+
+		It("should fail to read once closed", func() {
+			Expect(input.Close(ctx)).To(Succeed())
+
+			_, _, err := input.Read(ctx)
+			Expect(err).To(MatchError(spec.ErrNotConnected))
+		})
+	})
+
+	When("Reading a compressed file from S3", func() {
+		var bucket string
+		var key string
+
+		BeforeEach(func() {
+			bucket = createBucket("test")
+
+			body, err := compress.Compress(compress.Gzip, []byte("hello, compressed world"))
+			Expect(err).ToNot(HaveOccurred())
+
+			key = fmt.Sprintf("test/compressed/%s.txt.gz", uuid.New().String())
+			_, err = s3Client.PutObject(context.Background(), &as3.PutObjectInput{
+				Bucket: aws.String(bucket),
+				Key:    aws.String(key),
+				Body:   bytes.NewReader(body),
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			input, err = s3.NewInput(env, s3.InputConfig{
+				Config:             awsCfg.Copy(),
+				Bucket:             bucket,
+				Prefix:             "test/compressed/",
+				ForcePathStyleURLs: true,
+				EndpointURL:        aws.String(server.URL),
+				Decompress:         true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = input.Init(ctx)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			_, _ = s3Client.DeleteObject(context.Background(), &as3.DeleteObjectInput{
+				Bucket: aws.String(bucket),
+				Key:    aws.String(key),
+			})
+		})
+
+		It("should decompress the object based on its key suffix", func() {
+			batch, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			defer func() { _ = callback(context.Background(), nil) }()
+
+			var payloads []string
+			for _, msg := range batch.Messages() {
+				raw, err := msg.Raw()
+				Expect(err).ToNot(HaveOccurred())
+				payloads = append(payloads, string(raw))
+
+				// -- the key still ends in .gz, the marker keeps it from being decompressed again
+				Expect(compress.DetectMessage(msg)).To(Equal(compress.None))
+			}
+			Expect(payloads).To(Equal([]string{"hello, compressed world"}))
+		})
+	})
+
+	When("Reading a compressed file without decompressing it", func() {
+		It("should keep the content encoding of the object", func() {
+			bucket := createBucket("test")
+
+			body, err := compress.Compress(compress.Gzip, []byte("hello, encoded world"))
+			Expect(err).ToNot(HaveOccurred())
+
+			prefix := fmt.Sprintf("test/encoded/%s/", uuid.New().String())
+			_, err = s3Client.PutObject(context.Background(), &as3.PutObjectInput{
+				Bucket:          aws.String(bucket),
+				Key:             aws.String(prefix + "data"),
+				Body:            bytes.NewReader(body),
+				ContentEncoding: aws.String("gzip"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			input, err = s3.NewInput(env, s3.InputConfig{
+				Config:             awsCfg.Copy(),
+				Bucket:             bucket,
+				Prefix:             prefix,
+				ForcePathStyleURLs: true,
+				EndpointURL:        aws.String(server.URL),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(input.Init(ctx)).To(Succeed())
+
+			batch, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			defer func() { _ = callback(context.Background(), nil) }()
 
-			err := input.Read(context.Background(), collector)
+			Expect(batch.Messages()).ToNot(BeEmpty())
+			for _, msg := range batch.Messages() {
+				Expect(compress.DetectMessage(msg)).To(Equal(compress.Gzip))
+			}
+		})
+	})
+
+	When("Resuming from a stored position", func() {
+		var bucket string
+		var prefix string
+		var keys []string
+		var state spec.StateStore
+
+		newInput := func() *s3.Input {
+			in, err := s3.NewInput(env, s3.InputConfig{
+				Config:             awsCfg.Copy(),
+				Bucket:             bucket,
+				Prefix:             prefix,
+				MaxKeys:            2,
+				ForcePathStyleURLs: true,
+				EndpointURL:        aws.String(server.URL),
+				State:              state,
+			})
 			Expect(err).ToNot(HaveOccurred())
+			Expect(in.Init(ctx)).To(Succeed())
+			return in
+		}
+
+		BeforeEach(func() {
+			bucket = createBucket("resume")
+
+			prefix = fmt.Sprintf("resume/%s/", uuid.New().String())
+			keys = nil
+			for idx := 0; idx < 5; idx++ {
+				key := fmt.Sprintf("%sobject-%d", prefix, idx)
+				_, err := s3Client.PutObject(context.Background(), &as3.PutObjectInput{
+					Bucket: aws.String(bucket),
+					Key:    aws.String(key),
+					Body:   strings.NewReader(key),
+				})
+				Expect(err).ToNot(HaveOccurred())
+				keys = append(keys, key)
+			}
+
+			var err error
+			state, err = spec.NewFileStateStore(GinkgoT().TempDir())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should continue after the last processed object when restarted", func() {
+			input = newInput()
+
+			batch, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readKeys(batch)).To(Equal(keys[:2]))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+
+			// -- the second page is read but never processed
+			batch, _, err = input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readKeys(batch)).To(Equal(keys[2:4]))
+			Expect(input.Close(ctx)).To(Succeed())
+
+			input = newInput()
+
+			batch, callback, err = input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readKeys(batch)).To(Equal(keys[2:4]))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+
+			batch, callback, err = input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readKeys(batch)).To(Equal(keys[4:]))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+
+			_, _, err = input.Read(ctx)
+			Expect(err).To(MatchError(spec.ErrNoData))
+		})
+
+		It("should only commit positions in the order the batches were read", func() {
+			input = newInput()
+
+			_, first, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			_, second, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(second(context.Background(), nil)).To(Succeed())
+			_, err = state.Get(context.Background(), fmt.Sprintf("s3/%s/%s", bucket, prefix))
+			Expect(err).To(MatchError(spec.ErrStateNotFound))
 
-			Expect(collector.Messages()).To(HaveLen(1))
+			Expect(first(context.Background(), nil)).To(Succeed())
+			stored, err := state.Get(context.Background(), fmt.Sprintf("s3/%s/%s", bucket, prefix))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(stored)).To(ContainSubstring(keys[3]))
+		})
+
+		It("should redeliver the objects of a failed batch", func() {
+			input = newInput()
+
+			_, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(callback(context.Background(), errors.New("processing failed"))).To(Succeed())
+
+			batch, _, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readKeys(batch)).To(Equal(keys[:2]))
+		})
+
+		It("should ignore the callbacks of batches read before it was closed", func() {
+			input = newInput()
+
+			_, stale, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(input.Close(ctx)).To(Succeed())
+			Expect(input.Init(ctx)).To(Succeed())
+
+			batch, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readKeys(batch)).To(Equal(keys[:2]))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+
+			batch, callback, err = input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readKeys(batch)).To(Equal(keys[2:4]))
+
+			// -- the batch was redelivered after the restart already, so its failure doesn't rewind the input
+			Expect(stale(context.Background(), errors.New("processing failed"))).To(Succeed())
+
+			Expect(callback(context.Background(), nil)).To(Succeed())
+			stored, err := state.Get(context.Background(), fmt.Sprintf("s3/%s/%s", bucket, prefix))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(stored)).To(ContainSubstring(keys[3]))
+
+			batch, _, err = input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readKeys(batch)).To(Equal(keys[4:]))
+		})
+
+		It("should pick up objects added after the listing was exhausted", func() {
+			input = newInput()
+
+			for range 3 {
+				_, callback, err := input.Read(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(callback(context.Background(), nil)).To(Succeed())
+			}
+
+			_, _, err := input.Read(ctx)
+			Expect(err).To(MatchError(spec.ErrNoData))
+
+			key := prefix + "object-5"
+			_, err = s3Client.PutObject(context.Background(), &as3.PutObjectInput{
+				Bucket: aws.String(bucket),
+				Key:    aws.String(key),
+				Body:   strings.NewReader(key),
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			batch, _, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readKeys(batch)).To(Equal([]string{key}))
 		})
 	})
 })
@@ -1,11 +1,16 @@
 package s3
 
 import (
+	"bytes"
+	"fmt"
 	"io"
 	"iter"
 	"maps"
+	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/wombatwisdom/components/bundles/compress"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
@@ -17,29 +22,123 @@ func NewObjectResponseMessage(resp *s3.GetObjectOutput) spec.Message {
 	}
 }
 
+// decompressObject wraps the body of the object response with a streaming decompressor if the Content-Encoding
+// or the key suffix indicates the object is compressed. The detected algorithm is returned.
+func decompressObject(resp *s3.GetObjectOutput, key string) (compress.Algorithm, error) {
+	algo := compress.Detect(key, aws.ToString(resp.ContentEncoding))
+	if algo == compress.None || resp.Body == nil {
+		return compress.None, nil
+	}
+
+	body, err := compress.NewReader(algo, resp.Body)
+	if err != nil {
+		_ = resp.Body.Close()
+		return algo, fmt.Errorf("failed to decompress object %s: %w", key, err)
+	}
+
+	resp.Body = body
+	return algo, nil
+}
+
+// MetadataContentEncoding is the metadata key holding the Content-Encoding of an object which wasn't decompressed.
+const MetadataContentEncoding = "content_encoding"
+
+// newObjectMessage creates the message for an object response, decompressing the body when requested. A message
+// which was decompressed is marked as uncompressed, so processors further down the pipeline don't decompress it
+// again because of the suffix of its key. Otherwise the Content-Encoding of the object is kept for them to detect.
+// The size of a decompressed message is only known once it was read, so it doesn't carry the size of the object.
+func newObjectMessage(resp *s3.GetObjectOutput, key string, decompress bool) (*ObjectResponseMessage, error) {
+	algo := compress.None
+	if decompress {
+		var err error
+		if algo, err = decompressObject(resp, key); err != nil {
+			return nil, err
+		}
+	}
+
+	msg := NewObjectResponseMessage(resp).(*ObjectResponseMessage)
+	switch {
+	case algo != compress.None:
+		msg.SetMetadata(compress.MetadataCompression, string(compress.None))
+		msg.decompressed = true
+	case aws.ToString(resp.ContentEncoding) != "":
+		msg.SetMetadata(MetadataContentEncoding, aws.ToString(resp.ContentEncoding))
+	}
+	return msg, nil
+}
+
 type ObjectResponseMessage struct {
 	resp     *s3.GetObjectOutput
 	meta     *ObjectResponseMetadata
 	metadata map[string]any
+
+	// decompressed is set when the body is decompressed while it is read
+	decompressed bool
+
+	bodyLock sync.Mutex
+	body     []byte
+	loaded   bool
+	streamed bool
 }
 
 func (o *ObjectResponseMessage) SetMetadata(key string, value any) {
 	o.metadata[key] = value
 }
 
+// SetRaw replaces the payload of the message. The S3 object itself is immutable, so the remaining object body
+// is released and the new payload is kept in memory instead.
 func (o *ObjectResponseMessage) SetRaw(b []byte) {
-	// S3 objects are immutable, so this is a no-op
-	// In a real implementation, you might store this separately
+	o.bodyLock.Lock()
+	defer o.bodyLock.Unlock()
+
+	if !o.loaded && !o.streamed && o.resp.Body != nil {
+		_ = o.resp.Body.Close()
+	}
+
+	o.body = b
+	o.loaded = true
 }
 
 func (o *ObjectResponseMessage) Raw() ([]byte, error) {
-	if o.resp.Body == nil {
-		return nil, nil
+	o.bodyLock.Lock()
+	defer o.bodyLock.Unlock()
+
+	if o.loaded || o.resp.Body == nil {
+		return o.body, nil
+	}
+
+	if o.streamed {
+		return nil, spec.ErrStreamConsumed
+	}
+
+	defer func() { _ = o.resp.Body.Close() }()
+
+	b, err := io.ReadAll(o.resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	o.body = b
+	o.loaded = true
+	return o.body, nil
+}
+
+// Reader streams the object body without buffering it in memory. The body can only be streamed once, unless it
+// was materialized through Raw first.
+func (o *ObjectResponseMessage) Reader() (io.ReadCloser, error) {
+	o.bodyLock.Lock()
+	defer o.bodyLock.Unlock()
+
+	if o.loaded || o.resp.Body == nil {
+		return io.NopCloser(bytes.NewReader(o.body)), nil
+	}
+
+	if o.streamed {
+		return nil, spec.ErrStreamConsumed
 	}
 
-	// This is a simplified implementation
-	// In practice, you'd want to cache the body or use a different approach
-	return io.ReadAll(o.resp.Body)
+	o.streamed = true
+	return o.resp.Body, nil
 }
 
 func (o *ObjectResponseMessage) Metadata() iter.Seq2[string, any] {
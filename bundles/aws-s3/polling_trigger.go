@@ -0,0 +1,338 @@
+package s3
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	awsconfig "github.com/wombatwisdom/components/bundles/aws-config"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	PollingTriggerInputComponentName = "aws_s3_polling_trigger"
+
+	defaultPollInterval = time.Minute
+	defaultMaxTriggers  = 100
+)
+
+type PollingTriggerConfig struct {
+	aws.Config `mapstructure:"-"`
+
+	Bucket string `mapstructure:"bucket"`
+	Prefix string `mapstructure:"prefix"`
+
+	ForcePathStyleURLs bool    `mapstructure:"force_path_style_urls"`
+	EndpointURL        *string `mapstructure:"endpoint_url"`
+
+	// PollInterval is the time between two listings of the prefix. Defaults to 1m.
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+
+	// MaxBatchSize is the maximum number of triggers per batch. Defaults to 100.
+	MaxBatchSize int `mapstructure:"max_batch_size"`
+
+	// SkipExisting only triggers on objects modified after the input first started, instead of on all objects below
+	// the prefix. It has no effect once a watermark has been stored.
+	SkipExisting bool `mapstructure:"skip_existing"`
+
+	// State stores the watermark of the input once a batch of triggers has been processed, so a restarted input only
+	// triggers on objects which were added or changed in the meantime.
+	// Defaults to a file store in StateDir if it is set, and to an in-memory store, which doesn't survive restarts,
+	// otherwise.
+	State spec.StateStore `mapstructure:"-"`
+
+	// StateDir is the directory of the file store the watermark is stored in when no State is set.
+	StateDir string `mapstructure:"state_dir"`
+
+	// StateKey is the key the watermark is stored under. Defaults to s3-polling/<bucket>/<prefix>.
+	StateKey string `mapstructure:"state_key"`
+}
+
+// Watermark tells which objects the polling trigger input has already triggered on.
+type Watermark struct {
+	// The most recent modification time of the objects triggered on.
+	LastModified time.Time `json:"last_modified,omitempty"`
+
+	// The ETags of the objects modified at LastModified, keyed by object key. S3 reports modification times with a
+	// precision of a second, so objects sharing the watermark's timestamp are told apart by key and ETag.
+	ETags map[string]string `json:"etags,omitempty"`
+}
+
+// includes reports whether the object was triggered on.
+func (w Watermark) includes(obj types.Object) bool {
+	modified := aws.ToTime(obj.LastModified)
+	switch {
+	case modified.Before(w.LastModified):
+		return true
+	case modified.After(w.LastModified):
+		return false
+	default:
+		etag, ok := w.ETags[aws.ToString(obj.Key)]
+		return ok && etag == aws.ToString(obj.ETag)
+	}
+}
+
+// advance returns the watermark after triggering on the object. Objects have to be triggered on in the order of
+// their modification time.
+func (w Watermark) advance(obj types.Object) Watermark {
+	modified := aws.ToTime(obj.LastModified)
+	if modified.Before(w.LastModified) {
+		return w
+	}
+
+	next := Watermark{LastModified: modified, ETags: map[string]string{}}
+	if modified.Equal(w.LastModified) {
+		next.ETags = maps.Clone(w.ETags)
+	}
+	next.ETags[aws.ToString(obj.Key)] = aws.ToString(obj.ETag)
+	return next
+}
+
+// NewPollingTriggerInputFromConfig creates a polling trigger input from a spec.Config interface. The AWS SDK is
+// configured by the fields of awsconfig.Config.
+func NewPollingTriggerInputFromConfig(env spec.Environment, config spec.Config) (*PollingTriggerInput, error) {
+	var cfg PollingTriggerConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode s3 polling trigger config: %w", err)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("a bucket is required")
+	}
+
+	var err error
+	if cfg.Config, err = awsconfig.FromConfig(config); err != nil {
+		return nil, err
+	}
+	return NewPollingTriggerInput(env, cfg)
+}
+
+func NewPollingTriggerInput(env spec.Environment, config PollingTriggerConfig) (*PollingTriggerInput, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = defaultMaxTriggers
+	}
+
+	var err error
+	if config.State, err = newStateStore(config.State, config.StateDir); err != nil {
+		return nil, err
+	}
+
+	if config.StateKey == "" {
+		config.StateKey = fmt.Sprintf("s3-polling/%s/%s", config.Bucket, config.Prefix)
+	}
+
+	return &PollingTriggerInput{
+		config:      config,
+		checkpoints: checkpoints[Watermark]{state: config.State, key: config.StateKey},
+		log:         env,
+	}, nil
+}
+
+// PollingTriggerInput triggers on objects added or changed below a prefix of an S3 bucket, for buckets which don't
+// publish their events to EventBridge. It lists the prefix periodically and emits a trigger for every object
+// modified after its watermark, referencing the object as bucket/key for the RetrievalProcessor.
+//
+// Like the Input, the watermark is committed to the state store in the order the batches were read, once their
+// callback has been called without an error. If a batch fails, the input rewinds to the last committed watermark
+// and triggers on the objects again with the next listing. ReadTriggers returns spec.ErrNoData when a listing
+// found nothing new.
+//
+// Objects are detected by their modification time, which S3 sets when an upload starts. An object whose upload
+// takes longer than the poll interval may end up older than the watermark by the time it is listed, and be missed.
+type PollingTriggerInput struct {
+	config PollingTriggerConfig
+
+	s3      *s3.Client
+	stopped chan struct{}
+
+	// position is the watermark of the triggers emitted so far
+	position    Watermark
+	checkpoints checkpoints[Watermark]
+	// queue holds the listed objects which were not emitted yet, oldest first
+	queue    []types.Object
+	lastPoll time.Time
+	lock     sync.Mutex
+
+	log spec.Logger
+}
+
+func (p *PollingTriggerInput) Init(ctx spec.ComponentContext) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.s3 != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	found, err := p.checkpoints.load(ctx.Context())
+	if err != nil {
+		return err
+	}
+	if !found && p.config.SkipExisting {
+		p.checkpoints.committed.LastModified = time.Now()
+	}
+	p.position = p.checkpoints.committed
+	p.queue = nil
+	p.lastPoll = time.Time{}
+
+	p.s3 = s3.NewFromConfig(p.config.Config, func(o *s3.Options) {
+		o.UsePathStyle = p.config.ForcePathStyleURLs
+		if p.config.EndpointURL != nil {
+			o.BaseEndpoint = p.config.EndpointURL
+		}
+	})
+	p.stopped = make(chan struct{})
+
+	return nil
+}
+
+func (p *PollingTriggerInput) Close(ctx spec.ComponentContext) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.stopped != nil {
+		close(p.stopped)
+		p.stopped = nil
+	}
+	p.s3 = nil
+	p.checkpoints.reset()
+	p.queue = nil
+	return nil
+}
+
+func (p *PollingTriggerInput) ReadTriggers(ctx spec.ComponentContext) (spec.TriggerBatch, spec.ProcessedCallback, error) {
+	p.lock.Lock()
+	client, stopped := p.s3, p.stopped
+	if client == nil {
+		p.lock.Unlock()
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	if len(p.queue) > 0 {
+		defer p.lock.Unlock()
+		return p.emit()
+	}
+
+	wait := time.Until(p.lastPoll.Add(p.config.PollInterval))
+	p.lock.Unlock()
+
+	// -- wait for the next listing to be due
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-stopped:
+			return nil, nil, spec.ErrNotConnected
+		case <-ctx.Context().Done():
+			return nil, nil, ctx.Context().Err()
+		}
+	}
+
+	objects, err := p.list(ctx.Context(), client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.s3 != client {
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	p.lastPoll = time.Now()
+	p.queue = nil
+	for _, obj := range objects {
+		if !p.position.includes(obj) {
+			p.queue = append(p.queue, obj)
+		}
+	}
+
+	if len(p.queue) == 0 {
+		return nil, nil, spec.ErrNoData
+	}
+	return p.emit()
+}
+
+// list returns all objects below the prefix, ordered by their modification time and key.
+func (p *PollingTriggerInput) list(ctx context.Context, client *s3.Client) ([]types.Object, error) {
+	var objects []types.Object
+
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: &p.config.Bucket,
+		Prefix: &p.config.Prefix,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		objects = append(objects, page.Contents...)
+	}
+
+	slices.SortFunc(objects, func(a, b types.Object) int {
+		return cmp.Or(
+			aws.ToTime(a.LastModified).Compare(aws.ToTime(b.LastModified)),
+			strings.Compare(aws.ToString(a.Key), aws.ToString(b.Key)),
+		)
+	})
+	return objects, nil
+}
+
+// emit turns the oldest queued objects into a batch of triggers. The caller must hold the lock.
+func (p *PollingTriggerInput) emit() (spec.TriggerBatch, spec.ProcessedCallback, error) {
+	n := min(len(p.queue), p.config.MaxBatchSize)
+	objects := p.queue[:n]
+	p.queue = p.queue[n:]
+
+	batch := spec.NewTriggerBatch()
+	for _, obj := range objects {
+		key := aws.ToString(obj.Key)
+		batch.Append(spec.NewTriggerEvent(spec.TriggerSourceS3Polling, p.config.Bucket+"/"+key, map[string]any{
+			spec.MetadataBucket:    p.config.Bucket,
+			spec.MetadataKey:       key,
+			spec.MetadataSize:      aws.ToInt64(obj.Size),
+			spec.MetadataETag:      strings.Trim(aws.ToString(obj.ETag), "\""),
+			spec.MetadataTimestamp: aws.ToTime(obj.LastModified).Unix(),
+		}))
+
+		p.position = p.position.advance(obj)
+	}
+
+	pending := p.checkpoints.track(p.position)
+
+	return batch, func(ackCtx context.Context, res error) error {
+		return p.processed(ackCtx, pending, res)
+	}, nil
+}
+
+// processed records the outcome of a batch, rewinding the input to the last committed watermark if it failed.
+func (p *PollingTriggerInput) processed(ctx context.Context, pending *checkpoint[Watermark], res error) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	rewind, err := p.checkpoints.processed(ctx, pending, res)
+	if rewind {
+		p.log.Warnf("Failed to process objects modified up to %s, rewinding to the last committed watermark: %v", pending.position.LastModified, res)
+		p.position = p.checkpoints.committed
+		p.queue = nil
+	}
+	return err
+}
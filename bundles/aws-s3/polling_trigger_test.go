@@ -0,0 +1,336 @@
+package s3_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	as3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/archive"
+	s3 "github.com/wombatwisdom/components/bundles/aws-s3"
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("PollingTriggerInput", func() {
+	var (
+		ctx    spec.ComponentContext
+		bucket string
+		state  spec.StateStore
+	)
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		state = spec.NewMemoryStateStore()
+
+		bucket = createBucket("polling")
+	})
+
+	put := func(key, content string) {
+		_, err := s3Client.PutObject(context.Background(), &as3.PutObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+			Body:   strings.NewReader(content),
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	newInput := func(modify ...func(cfg *s3.PollingTriggerConfig)) *s3.PollingTriggerInput {
+		cfg := s3.PollingTriggerConfig{
+			Config:             awsCfg,
+			Bucket:             bucket,
+			Prefix:             "incoming/",
+			ForcePathStyleURLs: true,
+			EndpointURL:        aws.String(server.URL),
+			PollInterval:       10 * time.Millisecond,
+			State:              state,
+		}
+		for _, fn := range modify {
+			fn(&cfg)
+		}
+
+		input, err := s3.NewPollingTriggerInput(env, cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+		DeferCleanup(func() {
+			_ = input.Close(ctx)
+		})
+		return input
+	}
+
+	// read returns the keys of the next batch of triggers, or nil if the listing found nothing new.
+	read := func(input *s3.PollingTriggerInput) ([]string, spec.ProcessedCallback) {
+		batch, callback, err := input.ReadTriggers(ctx)
+		if errors.Is(err, spec.ErrNoData) {
+			return nil, nil
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		var keys []string
+		for _, trigger := range batch.Triggers() {
+			keys = append(keys, trigger.Metadata()[spec.MetadataKey].(string))
+		}
+		return keys, callback
+	}
+
+	It("should require a bucket", func() {
+		_, err := s3.NewPollingTriggerInput(env, s3.PollingTriggerConfig{})
+		Expect(err).To(MatchError(ContainSubstring("bucket is required")))
+	})
+
+	It("should trigger on the objects below the prefix", func() {
+		put("incoming/a.json", "a")
+		put("incoming/b.json", "b")
+		put("other/c.json", "c")
+
+		batch, _, err := newInput().ReadTriggers(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		triggers := batch.Triggers()
+		Expect(triggers).To(HaveLen(2))
+		Expect(triggers[0].Source()).To(Equal(spec.TriggerSourceS3Polling))
+		Expect(triggers[0].Reference()).To(Equal(bucket + "/incoming/a.json"))
+		Expect(triggers[0].Metadata()).To(HaveKeyWithValue(spec.MetadataBucket, bucket))
+		Expect(triggers[0].Metadata()).To(HaveKeyWithValue(spec.MetadataKey, "incoming/a.json"))
+		Expect(triggers[0].Metadata()).To(HaveKeyWithValue(spec.MetadataSize, int64(1)))
+		Expect(triggers[0].Metadata()).To(HaveKey(spec.MetadataETag))
+	})
+
+	It("should only trigger on new and changed objects with the next listing", func() {
+		put("incoming/a.json", "a")
+		input := newInput()
+
+		keys, callback := read(input)
+		Expect(keys).To(Equal([]string{"incoming/a.json"}))
+		Expect(callback(context.Background(), nil)).To(Succeed())
+
+		keys, _ = read(input)
+		Expect(keys).To(BeEmpty())
+
+		put("incoming/b.json", "b")
+		put("incoming/a.json", "changed")
+
+		keys, _ = read(input)
+		Expect(keys).To(ConsistOf("incoming/a.json", "incoming/b.json"))
+	})
+
+	It("should limit the number of triggers per batch", func() {
+		put("incoming/a.json", "a")
+		put("incoming/b.json", "b")
+		put("incoming/c.json", "c")
+
+		input := newInput(func(cfg *s3.PollingTriggerConfig) { cfg.MaxBatchSize = 2 })
+
+		keys, _ := read(input)
+		Expect(keys).To(HaveLen(2))
+		keys, _ = read(input)
+		Expect(keys).To(HaveLen(1))
+	})
+
+	It("should resume from the stored watermark after a restart", func() {
+		put("incoming/a.json", "a")
+
+		input := newInput()
+		keys, callback := read(input)
+		Expect(keys).To(Equal([]string{"incoming/a.json"}))
+		Expect(callback(context.Background(), nil)).To(Succeed())
+		Expect(input.Close(ctx)).To(Succeed())
+
+		put("incoming/b.json", "b")
+
+		keys, _ = read(newInput())
+		Expect(keys).To(Equal([]string{"incoming/b.json"}))
+	})
+
+	It("should trigger on the objects of a failed batch again", func() {
+		put("incoming/a.json", "a")
+		input := newInput()
+
+		keys, callback := read(input)
+		Expect(keys).To(Equal([]string{"incoming/a.json"}))
+		Expect(callback(context.Background(), errors.New("processing failed"))).To(Succeed())
+
+		keys, _ = read(input)
+		Expect(keys).To(Equal([]string{"incoming/a.json"}))
+	})
+
+	It("should only commit the watermark once earlier batches were processed", func() {
+		put("incoming/a.json", "a")
+		put("incoming/b.json", "b")
+
+		input := newInput(func(cfg *s3.PollingTriggerConfig) { cfg.MaxBatchSize = 1 })
+
+		_, first := read(input)
+		_, second := read(input)
+
+		Expect(second(context.Background(), nil)).To(Succeed())
+		_, err := state.Get(context.Background(), "s3-polling/"+bucket+"/incoming/")
+		Expect(err).To(MatchError(spec.ErrStateNotFound))
+
+		Expect(first(context.Background(), nil)).To(Succeed())
+		_, err = state.Get(context.Background(), "s3-polling/"+bucket+"/incoming/")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should store its watermark in the state directory of its map configuration", func() {
+		put("incoming/a.json", "a")
+		dir := GinkgoT().TempDir()
+
+		input, err := s3.NewPollingTriggerInputFromConfig(env, spec.NewMapConfig(map[string]any{
+			"bucket":                bucket,
+			"prefix":                "incoming/",
+			"region":                "us-east-1",
+			"credentials":           map[string]any{"access_key_id": "KEY", "secret_access_key": "SECRET"},
+			"endpoint_url":          server.URL,
+			"force_path_style_urls": true,
+			"poll_interval":         "10ms",
+			"state_dir":             dir,
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+		DeferCleanup(func() {
+			_ = input.Close(ctx)
+		})
+
+		keys, callback := read(input)
+		Expect(keys).To(Equal([]string{"incoming/a.json"}))
+		Expect(callback(context.Background(), nil)).To(Succeed())
+
+		stored, err := spec.NewFileStateStore(dir)
+		Expect(err).ToNot(HaveOccurred())
+		watermark, err := stored.Get(context.Background(), "s3-polling/"+bucket+"/incoming/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(watermark)).To(ContainSubstring("incoming/a.json"))
+	})
+
+	It("should skip the existing objects when asked to", func() {
+		put("incoming/a.json", "a")
+
+		input := newInput(func(cfg *s3.PollingTriggerConfig) { cfg.SkipExisting = true })
+
+		keys, _ := read(input)
+		Expect(keys).To(BeEmpty())
+	})
+
+	It("should feed the retrieval processor", func() {
+		put("incoming/a.json", `{"hello": "world"}`)
+
+		triggers, _, err := newInput().ReadTriggers(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		processor := s3.NewRetrievalProcessor(s3.RetrievalConfig{
+			Config:             awsCfg,
+			ForcePathStyleURLs: true,
+			EndpointURL:        aws.String(server.URL),
+		})
+		Expect(processor.Init(ctx)).To(Succeed())
+		defer func() {
+			_ = processor.Close(ctx)
+		}()
+
+		batch, _, err := processor.Retrieve(ctx, triggers)
+		Expect(err).ToNot(HaveOccurred())
+
+		var payloads []string
+		for _, msg := range batch.Messages() {
+			raw, err := msg.Raw()
+			Expect(err).ToNot(HaveOccurred())
+			payloads = append(payloads, string(raw))
+		}
+		Expect(payloads).To(Equal([]string{`{"hello": "world"}`}))
+	})
+
+	It("should leave the object size out of the metadata of decompressed objects", func() {
+		body, err := compress.Compress(compress.Gzip, []byte(`{"hello": "world"}`))
+		Expect(err).ToNot(HaveOccurred())
+		put("incoming/a.json.gz", string(body))
+
+		triggers, _, err := newInput().ReadTriggers(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, decompress := range []bool{false, true} {
+			processor := s3.NewRetrievalProcessor(s3.RetrievalConfig{
+				Config:             awsCfg,
+				ForcePathStyleURLs: true,
+				EndpointURL:        aws.String(server.URL),
+				Decompress:         decompress,
+			})
+			Expect(processor.Init(ctx)).To(Succeed())
+			DeferCleanup(func() {
+				_ = processor.Close(ctx)
+			})
+
+			batch, _, err := processor.Retrieve(ctx, triggers)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, msg := range batch.Messages() {
+				metadata := maps.Collect(msg.Metadata())
+				if decompress {
+					Expect(metadata).ToNot(HaveKey("trigger_" + spec.MetadataSize))
+				} else {
+					Expect(metadata).To(HaveKeyWithValue("trigger_"+spec.MetadataSize, int64(len(body))))
+				}
+			}
+		}
+	})
+
+	It("should feed retrieved archives to the unarchive processor", func() {
+		var tarball bytes.Buffer
+		tw := tar.NewWriter(&tarball)
+		for _, name := range []string{"a.json", "b.json"} {
+			Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: 2})).To(Succeed())
+			_, err := tw.Write([]byte("{}"))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+
+		body, err := compress.Compress(compress.Gzip, tarball.Bytes())
+		Expect(err).ToNot(HaveOccurred())
+		put("incoming/export.tar.gz", string(body))
+
+		triggers, _, err := newInput().ReadTriggers(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		processor := s3.NewRetrievalProcessor(s3.RetrievalConfig{
+			Config:             awsCfg,
+			ForcePathStyleURLs: true,
+			EndpointURL:        aws.String(server.URL),
+		})
+		Expect(processor.Init(ctx)).To(Succeed())
+		defer func() {
+			_ = processor.Close(ctx)
+		}()
+
+		batch, _, err := processor.Retrieve(ctx, triggers)
+		Expect(err).ToNot(HaveOccurred())
+
+		unarchive, err := archive.NewUnarchiveProcessor(archive.UnarchiveConfig{})
+		Expect(err).ToNot(HaveOccurred())
+
+		entries, _, err := unarchive.Process(ctx, batch)
+		Expect(err).ToNot(HaveOccurred())
+
+		var paths []any
+		for _, msg := range entries.Messages() {
+			paths = append(paths, maps.Collect(msg.Metadata())[archive.MetadataArchivePath])
+		}
+		Expect(paths).To(Equal([]any{"a.json", "b.json"}))
+	})
+
+	It("should report being closed", func() {
+		input := newInput()
+		Expect(input.Init(ctx)).To(MatchError(spec.ErrAlreadyConnected))
+		Expect(input.Close(ctx)).To(Succeed())
+
+		_, _, err := input.ReadTriggers(ctx)
+		Expect(err).To(MatchError(spec.ErrNotConnected))
+	})
+})
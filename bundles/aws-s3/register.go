@@ -0,0 +1,85 @@
+package s3
+
+import (
+	awsconfig "github.com/wombatwisdom/components/bundles/aws-config"
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// clientProperties are the properties of the S3 client configuration shared by the components.
+const clientProperties = awsconfig.Properties + `,
+		"endpoint_url": {"type": "string", "description": "The endpoint of an S3 compatible service, like MinIO.", "examples": ["http://localhost:9000"]},
+		"force_path_style_urls": {"type": "boolean", "default": false, "description": "Address buckets by path instead of by host name."}`
+
+const inputSchema = `{
+	"type": "object",
+	"properties": {` + clientProperties + `,
+		"bucket": {"type": "string", "description": "The bucket to read the objects of."},
+		"prefix": {"type": "string", "description": "Only read the objects with keys starting with this prefix."},
+		"max_keys": {"type": "integer", "minimum": 1, "maximum": 1000, "description": "The maximum number of objects per batch. Defaults to 1000."},
+		"decompress": {"type": "boolean", "default": false, "description": "Decompress objects based on their Content-Encoding or the suffix of their key while they are read."},
+		"state_dir": {"type": "string", "description": "The directory the position of the input is stored in, so a restarted input resumes where it left off. Without it, the position is kept in memory."},
+		"state_key": {"type": "string", "description": "The key the position of the input is stored under. Defaults to s3/<bucket>/<prefix>."}
+	},
+	"required": ["bucket"],
+	"additionalProperties": false
+}`
+
+const pollingTriggerSchema = `{
+	"type": "object",
+	"properties": {` + clientProperties + `,
+		"bucket": {"type": "string", "description": "The bucket to poll for added or changed objects."},
+		"prefix": {"type": "string", "description": "Only trigger on the objects with keys starting with this prefix."},
+		"poll_interval": {"type": "string", "default": "1m", "description": "The time between two listings of the prefix."},
+		"max_batch_size": {"type": "integer", "minimum": 1, "default": 100, "description": "The maximum number of triggers per batch."},
+		"skip_existing": {"type": "boolean", "default": false, "description": "Only trigger on objects modified after the input first started, instead of on all objects below the prefix."},
+		"state_dir": {"type": "string", "description": "The directory the watermark of the input is stored in, so a restarted input only triggers on objects added or changed in the meantime. Without it, the watermark is kept in memory."},
+		"state_key": {"type": "string", "description": "The key the watermark of the input is stored under. Defaults to s3-polling/<bucket>/<prefix>."}
+	},
+	"required": ["bucket"],
+	"additionalProperties": false
+}`
+
+const retrievalSchema = `{
+	"type": "object",
+	"properties": {` + clientProperties + `,
+		"max_concurrent_retrievals": {"type": "integer", "minimum": 1, "default": 10, "description": "The maximum number of objects retrieved at the same time."},
+		"filter_prefix": {"type": "string", "description": "Only retrieve the objects with keys starting with this prefix."},
+		"filter_suffix": {"type": "string", "description": "Only retrieve the objects with keys ending with this suffix.", "examples": [".json"]},
+		"decompress": {"type": "boolean", "default": false, "description": "Decompress objects based on their Content-Encoding or the suffix of their key while they are read."}
+	},
+	"additionalProperties": false
+}`
+
+// Register adds the S3 input, polling trigger input and retrieval processor to the registry.
+func Register(r *registry.Registry) error {
+	err := r.RegisterInput(registry.Spec{
+		Name:    InputComponentName,
+		Summary: "Reads the objects below a prefix of an S3 bucket.",
+		Schema:  inputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.Input, error) {
+		return NewInputFromConfig(env, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.RegisterTriggerInput(registry.Spec{
+		Name:    PollingTriggerInputComponentName,
+		Summary: "Emits triggers for the objects added or changed below a prefix of an S3 bucket, by listing it periodically.",
+		Schema:  pollingTriggerSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.TriggerInput, error) {
+		return NewPollingTriggerInputFromConfig(env, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterRetrieval(registry.Spec{
+		Name:    RetrievalProcessorComponentName,
+		Summary: "Reads the S3 objects referenced by triggers.",
+		Schema:  retrievalSchema,
+	}, nil, func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.RetrievalProcessor, error) {
+		return NewRetrievalProcessorFromConfig(cfg)
+	})
+}
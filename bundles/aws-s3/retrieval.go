@@ -7,21 +7,47 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	awsconfig "github.com/wombatwisdom/components/bundles/aws-config"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
+const (
+	RetrievalProcessorComponentName = "aws_s3"
+)
+
 // RetrievalConfig defines configuration for S3 retrieval processor
 type RetrievalConfig struct {
-	aws.Config
+	aws.Config `mapstructure:"-"`
 
 	// S3 client configuration
-	ForcePathStyleURLs bool
-	EndpointURL        *string
+	ForcePathStyleURLs bool    `mapstructure:"force_path_style_urls"`
+	EndpointURL        *string `mapstructure:"endpoint_url"`
 
 	// Retrieval options
-	MaxConcurrentRetrivals int    // Maximum concurrent S3 retrievals
-	FilterPrefix           string // Only retrieve objects with this prefix
-	FilterSuffix           string // Only retrieve objects with this suffix
+	MaxConcurrentRetrivals int    `mapstructure:"max_concurrent_retrievals"` // Maximum concurrent S3 retrievals
+	FilterPrefix           string `mapstructure:"filter_prefix"`             // Only retrieve objects with this prefix
+	FilterSuffix           string `mapstructure:"filter_suffix"`             // Only retrieve objects with this suffix
+
+	// Decompress objects while they are being read. The compression algorithm is detected from the
+	// Content-Encoding of the object or the suffix of its key (e.g. .gz, .zst). Decompressed messages carry
+	// the compression=none marker, so a compress processor doesn't decompress them again, and leave out the
+	// trigger_size of the compressed object.
+	Decompress bool `mapstructure:"decompress"`
+}
+
+// NewRetrievalProcessorFromConfig creates an S3 retrieval processor from a spec.Config interface. The AWS SDK is
+// configured by the fields of awsconfig.Config.
+func NewRetrievalProcessorFromConfig(config spec.Config) (*RetrievalProcessor, error) {
+	var cfg RetrievalConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode s3 retrieval config: %w", err)
+	}
+
+	var err error
+	if cfg.Config, err = awsconfig.FromConfig(config); err != nil {
+		return nil, err
+	}
+	return NewRetrievalProcessor(cfg), nil
 }
 
 // NewRetrievalProcessor creates a new S3 retrieval processor
@@ -149,12 +175,26 @@ func (r *RetrievalProcessor) retrieveSingleObject(ctx context.Context, trigger s
 	}
 
 	// Create message from S3 response
-	message := NewObjectResponseMessage(resp)
+	message, err := newObjectMessage(resp, s3Info.Key, r.config.Decompress)
+	if err != nil {
+		return retrievalResult{
+			reference: trigger.Reference(),
+			err:       err,
+		}
+	}
+
+	// Add the location of the object, which processors like unarchive detect formats from
+	message.SetMetadata(spec.MetadataBucket, s3Info.Bucket)
+	message.SetMetadata(spec.MetadataKey, s3Info.Key)
 
 	// Add trigger metadata to message
 	message.SetMetadata("trigger_source", trigger.Source())
 	message.SetMetadata("trigger_timestamp", trigger.Timestamp())
 	for key, value := range trigger.Metadata() {
+		if key == spec.MetadataSize && message.decompressed {
+			// -- the size of the object isn't the size of its decompressed body
+			continue
+		}
 		message.SetMetadata("trigger_"+key, value)
 	}
 
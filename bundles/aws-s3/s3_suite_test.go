@@ -4,12 +4,14 @@ import (
 	"context"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
 	"github.com/johannesboyne/gofakes3"
 	"github.com/johannesboyne/gofakes3/backend/s3mem"
 	. "github.com/onsi/ginkgo/v2"
@@ -23,6 +25,14 @@ var server *httptest.Server
 var awsCfg aws.Config
 var s3Client *s3.Client
 
+// createBucket creates a bucket with a unique name, so the specs don't share their objects.
+func createBucket(prefix string) string {
+	bucket := prefix + "-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:16]
+	_, err := s3Client.CreateBucket(context.Background(), &s3.CreateBucketInput{Bucket: aws.String(bucket)})
+	Expect(err).ToNot(HaveOccurred())
+	return bucket
+}
+
 func TestMqtt(t *testing.T) {
 	RegisterFailHandler(Fail)
 
@@ -0,0 +1,125 @@
+package sqs
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	// maxEntries is the maximum number of messages SQS receives, sends or settles in a single request
+	maxEntries = 10
+
+	// maxBatchBytes is the maximum size of the messages sent in a single request, and of a single message
+	maxBatchBytes = 256 * 1024
+
+	// maxAttributes is the maximum number of message attributes SQS accepts per message
+	maxAttributes = 10
+)
+
+// Metadata keys set by the Input. Metadata keys with the sqs_ prefix are never sent as message attributes by the
+// Output.
+const (
+	MetadataMessageID      = "sqs_message_id"
+	MetadataReceiveCount   = "sqs_receive_count"
+	MetadataMessageGroupID = "sqs_message_group_id"
+
+	reservedPrefix = "sqs_"
+)
+
+// ReceiveConfig controls how messages are received from a queue. Use DefaultReceiveConfig for sensible defaults.
+type ReceiveConfig struct {
+	// MaxMessages is the maximum number of messages per receive, between 1 and 10.
+	MaxMessages int32 `mapstructure:"max_messages"`
+
+	// WaitTimeSeconds is how long a receive waits for messages to arrive, at most 20 seconds. Zero doesn't wait.
+	WaitTimeSeconds int32 `mapstructure:"wait_time_seconds"`
+
+	// VisibilityTimeout is how many seconds received messages stay invisible to other consumers. The visibility is
+	// extended for as long as the messages are being processed. Zero uses the visibility timeout of the queue.
+	VisibilityTimeout int32 `mapstructure:"visibility_timeout"`
+
+	// Redelivery is the backoff schedule for messages which failed to process, based on the number of times they
+	// were received. Without it, failed messages become visible again after the visibility timeout.
+	Redelivery *spec.Backoff `mapstructure:"redelivery"`
+}
+
+// DefaultReceiveConfig returns a receive configuration which long polls for up to 10 messages.
+func DefaultReceiveConfig() ReceiveConfig {
+	return ReceiveConfig{
+		MaxMessages:     maxEntries,
+		WaitTimeSeconds: 20,
+	}
+}
+
+func (c ReceiveConfig) validate() error {
+	if c.MaxMessages < 1 || c.MaxMessages > maxEntries {
+		return fmt.Errorf("max messages must be between 1 and %d", maxEntries)
+	}
+
+	if c.WaitTimeSeconds < 0 || c.WaitTimeSeconds > 20 {
+		return fmt.Errorf("wait time must be between 0 and 20 seconds")
+	}
+
+	if c.VisibilityTimeout < 0 {
+		return fmt.Errorf("visibility timeout must not be negative")
+	}
+
+	return nil
+}
+
+type InputConfig struct {
+	aws.Config    `mapstructure:"-"`
+	ReceiveConfig `mapstructure:",squash"`
+
+	QueueURL    string  `mapstructure:"queue_url"`
+	EndpointURL *string `mapstructure:"endpoint_url"`
+}
+
+type TriggerInputConfig struct {
+	aws.Config    `mapstructure:"-"`
+	ReceiveConfig `mapstructure:",squash"`
+
+	QueueURL    string  `mapstructure:"queue_url"`
+	EndpointURL *string `mapstructure:"endpoint_url"`
+
+	// DropInvalid deletes messages which aren't S3 event notifications. By default they are left on the queue, so
+	// its redrive policy moves them to a dead letter queue.
+	DropInvalid bool `mapstructure:"drop_invalid"`
+
+	// EventNames are the S3 event types which produce triggers, like ObjectCreated:Put. A trailing * matches all
+	// types with the prefix, and the s3: prefix of the bucket notification configuration may be included. Defaults
+	// to ObjectCreated:*, since removed objects can't be retrieved anymore.
+	EventNames []string `mapstructure:"event_names"`
+}
+
+type OutputConfig struct {
+	aws.Config `mapstructure:"-"`
+
+	QueueURL    string  `mapstructure:"queue_url"`
+	EndpointURL *string `mapstructure:"endpoint_url"`
+
+	// MessageGroupID is evaluated for every message to get its message group, required for FIFO queues.
+	MessageGroupID spec.Expression `mapstructure:"message_group_id"`
+
+	// DeduplicationID is evaluated for every message to get its deduplication id, for FIFO queues without content
+	// based deduplication.
+	DeduplicationID spec.Expression `mapstructure:"deduplication_id"`
+
+	// DelaySeconds delays the delivery of the messages, at most 900 seconds.
+	DelaySeconds int32 `mapstructure:"delay_seconds"`
+
+	// MetadataFilter selects the metadata sent as message attributes. Without it, all metadata is sent. SQS accepts
+	// at most 10 attributes per message, the remaining ones are dropped.
+	MetadataFilter spec.MetadataFilter `mapstructure:"-"`
+}
+
+func newClient(config aws.Config, endpoint *string) *sqs.Client {
+	return sqs.NewFromConfig(config, func(o *sqs.Options) {
+		if endpoint != nil {
+			o.BaseEndpoint = endpoint
+		}
+	})
+}
@@ -0,0 +1,325 @@
+package sqs_test
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+)
+
+// newFakeSQS starts a fake SQS endpoint, stopped when the spec or suite it was started in is done.
+func newFakeSQS() *fakeSQS {
+	f := &fakeSQS{
+		queues:            map[string]*fakeQueue{},
+		changed:           make(chan struct{}),
+		visibilityTimeout: 30,
+	}
+
+	srv := httptest.NewServer(f)
+	DeferCleanup(srv.Close)
+	f.url = srv.URL
+	return f
+}
+
+// fakeSQS is a minimal SQS endpoint speaking the AWS JSON protocol. It keeps a queue for every queue url it is
+// asked about. Received messages stay invisible until they are deleted or their visibility timeout is set to zero,
+// time doesn't make them visible again.
+type fakeSQS struct {
+	url string
+
+	lock              sync.Mutex
+	queues            map[string]*fakeQueue
+	changed           chan struct{}
+	receipts          int
+	visibilityTimeout int
+}
+
+type fakeQueue struct {
+	messages []*fakeMessage
+	sends    []int
+	deleted  []string
+	timeouts map[string][]int
+}
+
+type fakeMessage struct {
+	id         string
+	body       string
+	attributes map[string]fakeAttribute
+	groupID    string
+	receipt    string
+	invisible  bool
+	receives   int
+}
+
+type fakeAttribute struct {
+	DataType    string
+	StringValue string `json:",omitempty"`
+	BinaryValue []byte `json:",omitempty"`
+}
+
+// queueURL returns the url of the queue with the given name.
+func (f *fakeSQS) queueURL(name string) string {
+	return f.url + "/000000000000/" + name
+}
+
+func (f *fakeSQS) queue(url string) *fakeQueue {
+	q, ok := f.queues[url]
+	if !ok {
+		q = &fakeQueue{timeouts: map[string][]int{}}
+		f.queues[url] = q
+	}
+	return q
+}
+
+// add puts messages with the given bodies on the queue.
+func (f *fakeSQS) add(url string, bodies ...string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	q := f.queue(url)
+	for _, body := range bodies {
+		q.messages = append(q.messages, &fakeMessage{id: fmt.Sprintf("id-%d", len(q.messages)), body: body})
+	}
+	f.notify()
+}
+
+// messages returns the bodies of the messages on the queue, visible or not.
+func (f *fakeSQS) messages(url string) []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var bodies []string
+	for _, m := range f.queue(url).messages {
+		bodies = append(bodies, m.body)
+	}
+	return bodies
+}
+
+// attributes returns the message attributes of the message with the given body.
+func (f *fakeSQS) attributes(url, body string) map[string]fakeAttribute {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, m := range f.queue(url).messages {
+		if m.body == body {
+			return maps.Clone(m.attributes)
+		}
+	}
+	return nil
+}
+
+// deleted returns the bodies of the messages deleted from the queue.
+func (f *fakeSQS) deleted(url string) []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return slices.Clone(f.queue(url).deleted)
+}
+
+// timeouts returns the visibility timeouts set for the message with the given body, in order.
+func (f *fakeSQS) timeouts(url, body string) []int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return slices.Clone(f.queue(url).timeouts[body])
+}
+
+// sends returns the number of entries of every SendMessageBatch request.
+func (f *fakeSQS) sends(url string) []int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return slices.Clone(f.queue(url).sends)
+}
+
+// notify wakes up the receives waiting for messages. The caller must hold the lock.
+func (f *fakeSQS) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeSQS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		QueueUrl            string
+		MaxNumberOfMessages int
+		WaitTimeSeconds     int
+		Entries             []struct {
+			Id                string
+			MessageBody       string
+			MessageAttributes map[string]fakeAttribute
+			MessageGroupId    string
+			ReceiptHandle     string
+			VisibilityTimeout int
+		}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp any
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AmazonSQS.") {
+	case "GetQueueAttributes":
+		f.lock.Lock()
+		resp = map[string]any{"Attributes": map[string]string{"VisibilityTimeout": strconv.Itoa(f.visibilityTimeout)}}
+		f.lock.Unlock()
+
+	case "ReceiveMessage":
+		resp = map[string]any{"Messages": f.receive(r, req.QueueUrl, max(req.MaxNumberOfMessages, 1), req.WaitTimeSeconds)}
+
+	case "SendMessageBatch":
+		f.lock.Lock()
+		q := f.queue(req.QueueUrl)
+		q.sends = append(q.sends, len(req.Entries))
+
+		successful, failed := []any{}, []any{}
+		for _, entry := range req.Entries {
+			if strings.Contains(entry.MessageBody, "reject") {
+				failed = append(failed, map[string]any{"Id": entry.Id, "Code": "InvalidMessageContents", "Message": "rejected", "SenderFault": true})
+				continue
+			}
+
+			m := &fakeMessage{
+				id:         fmt.Sprintf("id-%d", len(q.messages)),
+				body:       entry.MessageBody,
+				attributes: entry.MessageAttributes,
+				groupID:    entry.MessageGroupId,
+			}
+			q.messages = append(q.messages, m)
+
+			result := map[string]any{"Id": entry.Id, "MessageId": m.id, "MD5OfMessageBody": md5Hex([]byte(m.body))}
+			if len(m.attributes) > 0 {
+				result["MD5OfMessageAttributes"] = attributesMD5(m.attributes)
+			}
+			successful = append(successful, result)
+		}
+		f.notify()
+		f.lock.Unlock()
+
+		resp = map[string]any{"Successful": successful, "Failed": failed}
+
+	case "DeleteMessageBatch", "ChangeMessageVisibilityBatch":
+		deleting := strings.HasPrefix(r.Header.Get("X-Amz-Target"), "AmazonSQS.Delete")
+
+		f.lock.Lock()
+		q := f.queue(req.QueueUrl)
+		successful := []any{}
+		for _, entry := range req.Entries {
+			idx := slices.IndexFunc(q.messages, func(m *fakeMessage) bool { return m.receipt == entry.ReceiptHandle })
+			if idx < 0 {
+				continue
+			}
+
+			m := q.messages[idx]
+			if deleting {
+				q.messages = slices.Delete(q.messages, idx, idx+1)
+				q.deleted = append(q.deleted, m.body)
+			} else {
+				q.timeouts[m.body] = append(q.timeouts[m.body], entry.VisibilityTimeout)
+				m.invisible = entry.VisibilityTimeout > 0
+			}
+			successful = append(successful, map[string]any{"Id": entry.Id})
+		}
+		f.notify()
+		f.lock.Unlock()
+
+		resp = map[string]any{"Successful": successful, "Failed": []any{}}
+
+	default:
+		http.Error(w, "unsupported operation", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// receive hands out the visible messages of the queue, waiting for them up to the wait time.
+func (f *fakeSQS) receive(r *http.Request, url string, maxMessages, waitSeconds int) []map[string]any {
+	deadline := time.After(time.Duration(waitSeconds) * time.Second)
+
+	for {
+		f.lock.Lock()
+		var messages []map[string]any
+		for _, m := range f.queue(url).messages {
+			if m.invisible || len(messages) == maxMessages {
+				continue
+			}
+
+			f.receipts++
+			m.receipt = fmt.Sprintf("receipt-%d", f.receipts)
+			m.invisible = true
+			m.receives++
+
+			message := map[string]any{
+				"MessageId":     m.id,
+				"ReceiptHandle": m.receipt,
+				"Body":          m.body,
+				"MD5OfBody":     md5Hex([]byte(m.body)),
+				"Attributes":    map[string]string{"ApproximateReceiveCount": strconv.Itoa(m.receives)},
+			}
+			if m.groupID != "" {
+				message["Attributes"].(map[string]string)["MessageGroupId"] = m.groupID
+			}
+			if len(m.attributes) > 0 {
+				message["MessageAttributes"] = m.attributes
+				message["MD5OfMessageAttributes"] = attributesMD5(m.attributes)
+			}
+			messages = append(messages, message)
+		}
+		changed := f.changed
+		f.lock.Unlock()
+
+		if len(messages) > 0 || waitSeconds == 0 {
+			return messages
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return nil
+		case <-r.Context().Done():
+			return nil
+		}
+	}
+}
+
+func md5Hex(b []byte) string {
+	sum := md5.Sum(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// attributesMD5 calculates the digest SQS returns for message attributes.
+func attributesMD5(attributes map[string]fakeAttribute) string {
+	var buf []byte
+	appendField := func(b []byte) {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(b)))
+		buf = append(buf, b...)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(attributes)) {
+		attr := attributes[name]
+		appendField([]byte(name))
+		appendField([]byte(attr.DataType))
+		if strings.HasPrefix(attr.DataType, "Binary") {
+			buf = append(buf, 2)
+			appendField(attr.BinaryValue)
+		} else {
+			buf = append(buf, 1)
+			appendField([]byte(attr.StringValue))
+		}
+	}
+
+	return md5Hex(buf)
+}
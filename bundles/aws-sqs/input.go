@@ -0,0 +1,150 @@
+package sqs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	awsconfig "github.com/wombatwisdom/components/bundles/aws-config"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	InputComponentName = "aws_sqs"
+)
+
+// NewInputFromConfig creates an SQS input from a spec.Config interface. The AWS SDK is configured by the fields of
+// awsconfig.Config.
+func NewInputFromConfig(env spec.Environment, config spec.Config) (*Input, error) {
+	cfg := InputConfig{ReceiveConfig: DefaultReceiveConfig()}
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode sqs input config: %w", err)
+	}
+
+	var err error
+	if cfg.Config, err = awsconfig.FromConfig(config); err != nil {
+		return nil, err
+	}
+	return NewInput(env, cfg)
+}
+
+func NewInput(env spec.Environment, config InputConfig) (*Input, error) {
+	if config.QueueURL == "" {
+		return nil, fmt.Errorf("queue url is required")
+	}
+
+	if err := config.ReceiveConfig.validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Input{
+		config: config,
+		log:    env,
+	}, nil
+}
+
+// Input reads messages from an SQS queue, long polling for them as configured. The message attributes are set as
+// metadata on the messages, together with the message id, receive count and message group.
+//
+// Messages stay invisible to other consumers while they are processed, and are deleted from the queue once the
+// callback reports them as processed, or as failed permanently. A spec.BatchError settles every message on its
+// own. Read returns spec.ErrNoData when no messages arrived within the wait time.
+type Input struct {
+	config InputConfig
+
+	lock     sync.Mutex
+	receiver *receiver
+
+	log spec.Logger
+}
+
+func (i *Input) Init(ctx spec.ComponentContext) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.receiver != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	client := newClient(i.config.Config, i.config.EndpointURL)
+	r, err := newReceiver(ctx.Context(), client, i.config.QueueURL, i.config.ReceiveConfig, i.log)
+	if err != nil {
+		return err
+	}
+
+	i.receiver = r
+	return nil
+}
+
+func (i *Input) Close(ctx spec.ComponentContext) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.receiver != nil {
+		i.receiver.close()
+		i.receiver = nil
+	}
+	return nil
+}
+
+func (i *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
+	i.lock.Lock()
+	r := i.receiver
+	i.lock.Unlock()
+
+	if r == nil {
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	messages, err := r.receive(ctx.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(messages) == 0 {
+		return nil, nil, spec.ErrNoData
+	}
+
+	batch := ctx.NewBatch()
+	for _, message := range messages {
+		batch.Append(newMessage(ctx, message))
+	}
+
+	release := r.hold(messages)
+
+	var once sync.Once
+	return batch, func(ackCtx context.Context, res error) error {
+		var err error
+		once.Do(func() {
+			release()
+			err = r.settle(ackCtx, messages, func(idx int) error {
+				return spec.MessageError(res, idx)
+			})
+		})
+		return err
+	}, nil
+}
+
+// newMessage converts an SQS message, setting its attributes as metadata.
+func newMessage(factory spec.MessageFactory, message types.Message) spec.Message {
+	msg := factory.NewMessage()
+	msg.SetRaw([]byte(aws.ToString(message.Body)))
+
+	for name, attr := range message.MessageAttributes {
+		if attr.BinaryValue != nil {
+			msg.SetMetadata(name, attr.BinaryValue)
+		} else {
+			msg.SetMetadata(name, aws.ToString(attr.StringValue))
+		}
+	}
+
+	msg.SetMetadata(MetadataMessageID, aws.ToString(message.MessageId))
+	msg.SetMetadata(MetadataReceiveCount, receiveCount(message))
+	if group, ok := message.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]; ok {
+		msg.SetMetadata(MetadataMessageGroupID, group)
+	}
+
+	return msg
+}
@@ -0,0 +1,174 @@
+package sqs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sqs "github.com/wombatwisdom/components/bundles/aws-sqs"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("Input", func() {
+	var ctx spec.ComponentContext
+	var queue string
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		queue = newQueue()
+	})
+
+	newInput := func(config sqs.InputConfig) *sqs.Input {
+		input, err := sqs.NewInput(env, config)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+
+		DeferCleanup(func() {
+			_ = input.Close(ctx)
+		})
+		return input
+	}
+
+	It("should reject an invalid configuration", func() {
+		_, err := sqs.NewInput(env, sqs.InputConfig{ReceiveConfig: receiveConfig()})
+		Expect(err).To(MatchError(ContainSubstring("queue url is required")))
+
+		config := inputConfig(queue)
+		config.MaxMessages = 11
+		_, err = sqs.NewInput(env, config)
+		Expect(err).To(MatchError(ContainSubstring("max messages")))
+	})
+
+	It("should return ErrNoData when no messages arrive", func() {
+		config := inputConfig(queue)
+		config.WaitTimeSeconds = 0
+		input := newInput(config)
+
+		_, _, err := input.Read(ctx)
+		Expect(err).To(MatchError(spec.ErrNoData))
+	})
+
+	It("should set the message attributes and receive count as metadata", func() {
+		output, err := sqs.NewOutput(env, outputConfig(queue))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Init(ctx)).To(Succeed())
+
+		msg := ctx.NewMessage()
+		msg.SetRaw([]byte("hello"))
+		msg.SetMetadata("color", "blue")
+		msg.SetMetadata("count", 3)
+		msg.SetMetadata("blob", []byte{1, 2, 3})
+		Expect(output.Write(ctx, ctx.NewBatch(msg))).To(Succeed())
+
+		input := newInput(inputConfig(queue))
+		batch, callback, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payloads(batch)).To(Equal([]string{"hello"}))
+
+		metadata := map[string]any{}
+		for _, msg := range batch.Messages() {
+			for key, value := range msg.Metadata() {
+				metadata[key] = value
+			}
+		}
+		Expect(metadata).To(HaveKeyWithValue("color", "blue"))
+		Expect(metadata).To(HaveKeyWithValue("count", "3"))
+		Expect(metadata).To(HaveKeyWithValue("blob", []byte{1, 2, 3}))
+		Expect(metadata).To(HaveKeyWithValue(sqs.MetadataMessageID, "id-0"))
+		Expect(metadata).To(HaveKeyWithValue(sqs.MetadataReceiveCount, 1))
+
+		Expect(callback(context.Background(), nil)).To(Succeed())
+	})
+
+	It("should delete the messages once they are processed", func() {
+		fake.add(queue, "one", "two", "three")
+		input := newInput(inputConfig(queue))
+
+		batch, callback, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payloads(batch)).To(Equal([]string{"one", "two", "three"}))
+
+		Expect(callback(context.Background(), nil)).To(Succeed())
+		Expect(fake.deleted(queue)).To(Equal([]string{"one", "two", "three"}))
+		Expect(fake.messages(queue)).To(BeEmpty())
+	})
+
+	It("should only leave the messages which failed on the queue", func() {
+		fake.add(queue, "one", "two", "three")
+		input := newInput(inputConfig(queue))
+
+		_, callback, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		batchErr := spec.NewBatchError(nil).
+			Failed(1, errors.New("try again")).
+			Failed(2, fmt.Errorf("%w: broken", spec.ErrPermanent))
+		Expect(callback(context.Background(), batchErr)).To(Succeed())
+
+		Expect(fake.deleted(queue)).To(Equal([]string{"one", "three"}))
+		Expect(fake.messages(queue)).To(Equal([]string{"two"}))
+	})
+
+	It("should delay the redelivery of failed messages", func() {
+		fake.add(queue, "one")
+
+		config := inputConfig(queue)
+		config.Redelivery = &spec.Backoff{Initial: 5 * time.Second}
+		input := newInput(config)
+
+		_, callback, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(callback(context.Background(), errors.New("try again"))).To(Succeed())
+		Expect(fake.timeouts(queue, "one")).To(Equal([]int{5}))
+
+		// -- a delay requested by the error takes precedence
+		fake.add(queue, "two")
+		batch, callback, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payloads(batch)).To(Equal([]string{"two"}))
+		Expect(callback(context.Background(), spec.RedeliverAfter(nil, 90*time.Second))).To(Succeed())
+		Expect(fake.timeouts(queue, "two")).To(Equal([]int{90}))
+	})
+
+	It("should extend the visibility of messages in flight", func() {
+		fake.add(queue, "slow")
+
+		config := inputConfig(queue)
+		config.VisibilityTimeout = 1
+		input := newInput(config)
+
+		_, callback, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() []int { return fake.timeouts(queue, "slow") }, 3*time.Second).Should(ContainElement(1))
+
+		Expect(callback(context.Background(), nil)).To(Succeed())
+		Expect(fake.deleted(queue)).To(Equal([]string{"slow"}))
+
+		// -- no more extensions once the message was settled
+		extensions := len(fake.timeouts(queue, "slow"))
+		Consistently(func() []int { return fake.timeouts(queue, "slow") }, time.Second).Should(HaveLen(extensions))
+	})
+
+	It("should return ErrNotConnected before it is initialized", func() {
+		input, err := sqs.NewInput(env, inputConfig(queue))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, _, err = input.Read(ctx)
+		Expect(err).To(MatchError(spec.ErrNotConnected))
+	})
+})
+
+var _ = test.DescribeConformance("SQS", test.Conformance{
+	NewInput: func(_ spec.System, target string) (spec.Input, error) {
+		return sqs.NewInput(env, inputConfig(fake.queueURL(target)))
+	},
+	NewOutput: func(_ spec.System, target string) (spec.Output, error) {
+		return sqs.NewOutput(env, outputConfig(fake.queueURL(target)))
+	},
+	Metadata: true,
+})
@@ -0,0 +1,260 @@
+package sqs
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	awsconfig "github.com/wombatwisdom/components/bundles/aws-config"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	OutputComponentName = "aws_sqs"
+)
+
+// attributeName matches the names SQS accepts for message attributes
+var attributeName = regexp.MustCompile(`^[A-Za-z0-9_\-.]{1,256}$`)
+
+// NewOutputFromConfig creates an SQS output from a spec.Config interface. The AWS SDK is configured by the fields of
+// awsconfig.Config.
+func NewOutputFromConfig(env spec.Environment, config spec.Config) (*Output, error) {
+	var cfg OutputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode sqs output config: %w", err)
+	}
+
+	var err error
+	if cfg.Config, err = awsconfig.FromConfig(config); err != nil {
+		return nil, err
+	}
+	return NewOutput(env, cfg)
+}
+
+func NewOutput(env spec.Environment, config OutputConfig) (*Output, error) {
+	if config.QueueURL == "" {
+		return nil, fmt.Errorf("queue url is required")
+	}
+
+	if config.DelaySeconds < 0 || config.DelaySeconds > 900 {
+		return nil, fmt.Errorf("delay must be between 0 and 900 seconds")
+	}
+
+	return &Output{
+		config: config,
+		log:    env,
+	}, nil
+}
+
+// Output sends messages to an SQS queue, with their metadata as message attributes. Messages are sent with
+// SendMessageBatch, at most 10 messages and 256KB per request.
+//
+// Write returns a spec.BatchError if only some of the messages could be sent, so the input only redelivers the
+// messages which failed. Messages SQS rejects, like a message larger than 256KB, fail with spec.ErrPermanent.
+type Output struct {
+	config OutputConfig
+
+	lock   sync.Mutex
+	client *sqs.Client
+
+	log spec.Logger
+}
+
+func (o *Output) Init(ctx spec.ComponentContext) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.client != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	o.client = newClient(o.config.Config, o.config.EndpointURL)
+	return nil
+}
+
+func (o *Output) Close(ctx spec.ComponentContext) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.client = nil
+	return nil
+}
+
+func (o *Output) Write(ctx spec.ComponentContext, batch spec.Batch) error {
+	o.lock.Lock()
+	client := o.client
+	o.lock.Unlock()
+
+	if client == nil {
+		return spec.ErrNotConnected
+	}
+
+	batchErr := spec.NewBatchError(nil)
+
+	var entries []types.SendMessageBatchRequestEntry
+	var size int
+	flush := func() {
+		if len(entries) > 0 {
+			o.send(ctx.Context(), client, entries, batchErr)
+		}
+		entries, size = nil, 0
+	}
+
+	for idx, message := range batch.Messages() {
+		entry, entrySize, err := o.entry(idx, message)
+		if err != nil {
+			batchErr.Failed(idx, err)
+			continue
+		}
+
+		if entrySize > maxBatchBytes {
+			batchErr.Failed(idx, fmt.Errorf("%w: message of %d bytes exceeds the limit of %d bytes", spec.ErrPermanent, entrySize, maxBatchBytes))
+			continue
+		}
+
+		if len(entries) == maxEntries || size+entrySize > maxBatchBytes {
+			flush()
+		}
+
+		entries = append(entries, entry)
+		size += entrySize
+	}
+	flush()
+
+	if batchErr.Len() > 0 {
+		return batchErr
+	}
+	return nil
+}
+
+// send sends the entries in a single request, marking the ones which failed in batchErr. Entries are identified by
+// the index of their message.
+func (o *Output) send(ctx context.Context, client *sqs.Client, entries []types.SendMessageBatchRequestEntry, batchErr *spec.BatchError) {
+	resp, err := client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(o.config.QueueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		for _, entry := range entries {
+			batchErr.Failed(entryIndex(entry.Id), fmt.Errorf("failed to send messages to SQS: %w", err))
+		}
+		return
+	}
+
+	for _, failed := range resp.Failed {
+		err := fmt.Errorf("SQS rejected the message: %s: %s", aws.ToString(failed.Code), aws.ToString(failed.Message))
+		if failed.SenderFault {
+			err = fmt.Errorf("%w: %w", spec.ErrPermanent, err)
+		}
+		batchErr.Failed(entryIndex(failed.Id), err)
+	}
+}
+
+// entry creates the request entry for a message and returns its size as SQS counts it.
+func (o *Output) entry(idx int, message spec.Message) (types.SendMessageBatchRequestEntry, int, error) {
+	raw, err := message.Raw()
+	if err != nil {
+		return types.SendMessageBatchRequestEntry{}, 0, fmt.Errorf("payload: %w", err)
+	}
+
+	entry := types.SendMessageBatchRequestEntry{
+		Id:          aws.String(strconv.Itoa(idx)),
+		MessageBody: aws.String(string(raw)),
+	}
+	if o.config.DelaySeconds > 0 {
+		entry.DelaySeconds = o.config.DelaySeconds
+	}
+
+	if o.config.MessageGroupID != nil {
+		group, err := o.config.MessageGroupID.Eval(spec.MessageExpressionContext(message))
+		if err != nil {
+			return entry, 0, fmt.Errorf("message group id: %w", err)
+		}
+		entry.MessageGroupId = aws.String(group)
+	}
+
+	if o.config.DeduplicationID != nil {
+		id, err := o.config.DeduplicationID.Eval(spec.MessageExpressionContext(message))
+		if err != nil {
+			return entry, 0, fmt.Errorf("deduplication id: %w", err)
+		}
+		entry.MessageDeduplicationId = aws.String(id)
+	}
+
+	size := len(raw)
+	entry.MessageAttributes, size = o.attributes(message, size)
+	return entry, size, nil
+}
+
+// attributes converts the metadata of the message to message attributes, adding their size to the given one.
+func (o *Output) attributes(message spec.Message, size int) (map[string]types.MessageAttributeValue, int) {
+	values := make(map[string]any)
+	for key, value := range message.Metadata() {
+		if o.config.MetadataFilter != nil && !o.config.MetadataFilter.Include(key) {
+			continue
+		}
+		if strings.HasPrefix(key, reservedPrefix) || !validAttributeName(key) {
+			continue
+		}
+		values[key] = value
+	}
+
+	if len(values) == 0 {
+		return nil, size
+	}
+
+	names := slices.Sorted(maps.Keys(values))
+	if len(names) > maxAttributes {
+		o.log.Warnf("Dropping %d metadata keys, SQS accepts at most %d message attributes", len(names)-maxAttributes, maxAttributes)
+		names = names[:maxAttributes]
+	}
+
+	attributes := make(map[string]types.MessageAttributeValue, len(names))
+	for _, name := range names {
+		attr := attributeValue(values[name])
+		attributes[name] = attr
+
+		size += len(name) + len(aws.ToString(attr.DataType)) + len(aws.ToString(attr.StringValue)) + len(attr.BinaryValue)
+	}
+
+	return attributes, size
+}
+
+func attributeValue(value any) types.MessageAttributeValue {
+	switch v := value.(type) {
+	case []byte:
+		return types.MessageAttributeValue{DataType: aws.String("Binary"), BinaryValue: v}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return types.MessageAttributeValue{DataType: aws.String("Number"), StringValue: aws.String(fmt.Sprint(v))}
+	default:
+		return types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(fmt.Sprint(v))}
+	}
+}
+
+// validAttributeName reports whether SQS accepts the name for a message attribute.
+func validAttributeName(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, "aws.") || strings.HasPrefix(lower, "amazon.") {
+		return false
+	}
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") || strings.Contains(name, "..") {
+		return false
+	}
+	return attributeName.MatchString(name)
+}
+
+func entryIndex(id *string) int {
+	idx, err := strconv.Atoi(aws.ToString(id))
+	if err != nil {
+		return -1
+	}
+	return idx
+}
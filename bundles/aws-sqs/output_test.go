@@ -0,0 +1,135 @@
+package sqs_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sqs "github.com/wombatwisdom/components/bundles/aws-sqs"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("Output", func() {
+	var ctx spec.ComponentContext
+	var queue string
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		queue = newQueue()
+	})
+
+	newOutput := func(config sqs.OutputConfig) *sqs.Output {
+		output, err := sqs.NewOutput(env, config)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Init(ctx)).To(Succeed())
+
+		DeferCleanup(func() {
+			_ = output.Close(ctx)
+		})
+		return output
+	}
+
+	batchOf := func(bodies ...string) spec.Batch {
+		batch := ctx.NewBatch()
+		for _, body := range bodies {
+			msg := ctx.NewMessage()
+			msg.SetRaw([]byte(body))
+			batch.Append(msg)
+		}
+		return batch
+	}
+
+	It("should reject an invalid delay", func() {
+		config := outputConfig(queue)
+		config.DelaySeconds = 901
+
+		_, err := sqs.NewOutput(env, config)
+		Expect(err).To(MatchError(ContainSubstring("delay")))
+	})
+
+	It("should send at most 10 messages per request", func() {
+		output := newOutput(outputConfig(queue))
+
+		var bodies []string
+		for i := range 25 {
+			bodies = append(bodies, fmt.Sprintf("message %d", i))
+		}
+
+		Expect(output.Write(ctx, batchOf(bodies...))).To(Succeed())
+		Expect(fake.sends(queue)).To(Equal([]int{10, 10, 5}))
+		Expect(fake.messages(queue)).To(Equal(bodies))
+	})
+
+	It("should keep requests within 256KB", func() {
+		output := newOutput(outputConfig(queue))
+
+		large := strings.Repeat("x", 100*1024)
+		Expect(output.Write(ctx, batchOf(large, large, large, "small"))).To(Succeed())
+		Expect(fake.sends(queue)).To(Equal([]int{2, 2}))
+	})
+
+	It("should fail messages larger than 256KB permanently", func() {
+		output := newOutput(outputConfig(queue))
+
+		err := output.Write(ctx, batchOf("small", strings.Repeat("x", 257*1024)))
+
+		var batchErr *spec.BatchError
+		Expect(errors.As(err, &batchErr)).To(BeTrue())
+		Expect(batchErr.Len()).To(Equal(1))
+		Expect(batchErr.IndexErr(1)).To(MatchError(spec.ErrPermanent))
+		Expect(fake.messages(queue)).To(Equal([]string{"small"}))
+	})
+
+	It("should only fail the messages SQS rejected", func() {
+		output := newOutput(outputConfig(queue))
+
+		err := output.Write(ctx, batchOf("one", "reject me", "three"))
+
+		var batchErr *spec.BatchError
+		Expect(errors.As(err, &batchErr)).To(BeTrue())
+		Expect(batchErr.Len()).To(Equal(1))
+		Expect(batchErr.IndexErr(0)).ToNot(HaveOccurred())
+		Expect(batchErr.IndexErr(1)).To(MatchError(spec.ErrPermanent))
+		Expect(batchErr.IndexErr(1)).To(MatchError(ContainSubstring("InvalidMessageContents")))
+		Expect(fake.messages(queue)).To(Equal([]string{"one", "three"}))
+	})
+
+	It("should send metadata as message attributes", func() {
+		output := newOutput(outputConfig(queue))
+
+		msg := ctx.NewMessage()
+		msg.SetRaw([]byte("hello"))
+		msg.SetMetadata("color", "blue")
+		msg.SetMetadata("size", 42)
+		msg.SetMetadata("aws.reserved", "dropped")
+		msg.SetMetadata("invalid name", "dropped")
+		msg.SetMetadata(sqs.MetadataMessageID, "dropped")
+		Expect(output.Write(ctx, ctx.NewBatch(msg))).To(Succeed())
+
+		attributes := fake.attributes(queue, "hello")
+		Expect(attributes).To(HaveLen(2))
+		Expect(attributes).To(HaveKeyWithValue("color", fakeAttribute{DataType: "String", StringValue: "blue"}))
+		Expect(attributes).To(HaveKeyWithValue("size", fakeAttribute{DataType: "Number", StringValue: "42"}))
+	})
+
+	It("should only send the metadata selected by the filter", func() {
+		filter, err := ctx.BuildMetadataFilter([]string{"^keep$"}, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		config := outputConfig(queue)
+		config.MetadataFilter = filter
+		output := newOutput(config)
+
+		msg := ctx.NewMessage()
+		msg.SetRaw([]byte("hello"))
+		msg.SetMetadata("keep", "yes")
+		msg.SetMetadata("skip", "no")
+		Expect(output.Write(ctx, ctx.NewBatch(msg))).To(Succeed())
+
+		Expect(fake.attributes(queue, "hello")).To(HaveKey("keep"))
+		Expect(fake.attributes(queue, "hello")).ToNot(HaveKey("skip"))
+	})
+})
@@ -0,0 +1,233 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// maxVisibilityTimeout is the longest SQS allows a message to stay invisible
+const maxVisibilityTimeout = 12 * time.Hour
+
+// receiver receives messages from a queue, keeps them invisible while they are processed and settles them
+// afterwards. It is shared by the Input and the TriggerInput.
+type receiver struct {
+	client   *sqs.Client
+	queueURL string
+	config   ReceiveConfig
+	log      spec.Logger
+
+	// visibility is the visibility timeout of received messages, in seconds
+	visibility int32
+
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// newReceiver checks the queue can be accessed and looks up its visibility timeout if none is configured.
+func newReceiver(ctx context.Context, client *sqs.Client, queueURL string, config ReceiveConfig, log spec.Logger) (*receiver, error) {
+	resp, err := client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameVisibilityTimeout},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to access SQS queue %s: %w", queueURL, err)
+	}
+
+	visibility := config.VisibilityTimeout
+	if visibility == 0 {
+		if v, err := strconv.Atoi(resp.Attributes[string(types.QueueAttributeNameVisibilityTimeout)]); err == nil {
+			visibility = int32(v)
+		}
+	}
+
+	return &receiver{
+		client:     client,
+		queueURL:   queueURL,
+		config:     config,
+		log:        log,
+		visibility: visibility,
+		stopped:    make(chan struct{}),
+	}, nil
+}
+
+// close stops extending the visibility of the messages in flight. They become visible again once their visibility
+// timeout has passed.
+func (r *receiver) close() {
+	r.closeOnce.Do(func() {
+		close(r.stopped)
+	})
+}
+
+// receive returns the next messages of the queue, waiting for them as configured.
+func (r *receiver) receive(ctx context.Context) ([]types.Message, error) {
+	resp, err := r.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(r.queueURL),
+		MaxNumberOfMessages:   r.config.MaxMessages,
+		WaitTimeSeconds:       r.config.WaitTimeSeconds,
+		VisibilityTimeout:     r.config.VisibilityTimeout,
+		MessageAttributeNames: []string{"All"},
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+			types.MessageSystemAttributeNameMessageGroupId,
+		},
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to receive messages from SQS: %w", err)
+	}
+
+	return resp.Messages, nil
+}
+
+// hold keeps the messages invisible to other consumers until the returned function is called, by extending their
+// visibility timeout before it runs out.
+func (r *receiver) hold(messages []types.Message) (release func()) {
+	if r.visibility <= 0 || len(messages) == 0 {
+		return func() {}
+	}
+
+	done, finished := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(finished)
+
+		ticker := time.NewTicker(time.Duration(r.visibility) * time.Second / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-r.stopped:
+				return
+			case <-ticker.C:
+				if err := r.changeVisibility(context.Background(), messages, r.visibility); err != nil {
+					r.log.Warnf("Failed to extend the visibility of messages in flight: %v", err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		// -- wait for an extension in progress, so it doesn't override the settled visibility
+		once.Do(func() { close(done) })
+		<-finished
+	}
+}
+
+// settle deletes the messages which were processed, or failed permanently. The others stay on the queue and are
+// redelivered after the delay requested by their error or the redelivery schedule, or else after the visibility
+// timeout.
+func (r *receiver) settle(ctx context.Context, messages []types.Message, errOf func(idx int) error) error {
+	var toDelete []*string
+	var toDelay []types.ChangeMessageVisibilityBatchRequestEntry
+
+	for idx, message := range messages {
+		msgErr := errOf(idx)
+		if msgErr == nil || errors.Is(msgErr, spec.ErrPermanent) {
+			toDelete = append(toDelete, message.ReceiptHandle)
+			continue
+		}
+
+		delay := spec.RedeliveryDelay(msgErr, r.config.Redelivery, receiveCount(message))
+		if delay > 0 {
+			toDelay = append(toDelay, types.ChangeMessageVisibilityBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(idx)),
+				ReceiptHandle:     message.ReceiptHandle,
+				VisibilityTimeout: int32(math.Ceil(min(delay, maxVisibilityTimeout).Seconds())),
+			})
+		}
+	}
+
+	if skipped := len(messages) - len(toDelete); skipped > 0 {
+		r.log.Debugf("Leaving %d failed messages on the SQS queue for redelivery", skipped)
+	}
+
+	return errors.Join(r.delete(ctx, toDelete), r.delay(ctx, toDelay))
+}
+
+// delete removes the messages with the given receipt handles from the queue.
+func (r *receiver) delete(ctx context.Context, receipts []*string) error {
+	var errs []error
+	for start := 0; start < len(receipts); start += maxEntries {
+		chunk := receipts[start:min(start+maxEntries, len(receipts))]
+
+		entries := make([]types.DeleteMessageBatchRequestEntry, len(chunk))
+		for i, receipt := range chunk {
+			entries[i] = types.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(start + i)),
+				ReceiptHandle: receipt,
+			}
+		}
+
+		result, err := r.client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(r.queueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete messages from SQS: %w", err))
+			continue
+		}
+
+		for _, failed := range result.Failed {
+			errs = append(errs, fmt.Errorf("failed to delete message %s from SQS: %s", aws.ToString(failed.Id), aws.ToString(failed.Message)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// delay changes the visibility timeout of failed messages, so they are redelivered after the requested delay.
+func (r *receiver) delay(ctx context.Context, entries []types.ChangeMessageVisibilityBatchRequestEntry) error {
+	var errs []error
+	for start := 0; start < len(entries); start += maxEntries {
+		result, err := r.client.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+			QueueUrl: aws.String(r.queueURL),
+			Entries:  entries[start:min(start+maxEntries, len(entries))],
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to change the visibility of messages: %w", err))
+			continue
+		}
+
+		for _, failed := range result.Failed {
+			errs = append(errs, fmt.Errorf("failed to change the visibility of message %s: %s", aws.ToString(failed.Id), aws.ToString(failed.Message)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// changeVisibility sets the visibility timeout of all given messages.
+func (r *receiver) changeVisibility(ctx context.Context, messages []types.Message, seconds int32) error {
+	entries := make([]types.ChangeMessageVisibilityBatchRequestEntry, len(messages))
+	for idx, message := range messages {
+		entries[idx] = types.ChangeMessageVisibilityBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(idx)),
+			ReceiptHandle:     message.ReceiptHandle,
+			VisibilityTimeout: seconds,
+		}
+	}
+	return r.delay(ctx, entries)
+}
+
+// receiveCount returns how often the message has been received, including this time.
+func receiveCount(message types.Message) int {
+	count, err := strconv.Atoi(message.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+	if err != nil {
+		return 1
+	}
+	return count
+}
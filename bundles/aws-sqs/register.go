@@ -0,0 +1,91 @@
+package sqs
+
+import (
+	awsconfig "github.com/wombatwisdom/components/bundles/aws-config"
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// queueProperties are the properties of the queue configuration shared by the components.
+const queueProperties = awsconfig.Properties + `,
+		"queue_url": {"type": "string", "description": "The URL of the queue.", "examples": ["https://sqs.eu-west-1.amazonaws.com/123456789012/orders"]},
+		"endpoint_url": {"type": "string", "description": "The endpoint of an SQS compatible service, like LocalStack.", "examples": ["http://localhost:4566"]}`
+
+// receiveProperties are the properties of ReceiveConfig.
+const receiveProperties = `
+		"max_messages": {"type": "integer", "minimum": 1, "maximum": 10, "default": 10, "description": "The maximum number of messages per receive."},
+		"wait_time_seconds": {"type": "integer", "minimum": 0, "maximum": 20, "default": 20, "description": "How long a receive waits for messages to arrive."},
+		"visibility_timeout": {"type": "integer", "minimum": 0, "description": "How many seconds received messages stay invisible to other consumers. Defaults to the visibility timeout of the queue."},
+		"redelivery": {
+			"type": "object",
+			"properties": {
+				"initial": {"type": "string", "default": "500ms"},
+				"max": {"type": "string", "default": "30s"},
+				"multiplier": {"type": "number", "default": 2},
+				"jitter": {"type": "number", "minimum": 0, "maximum": 1}
+			},
+			"additionalProperties": false,
+			"description": "The backoff schedule for messages which failed to process. Without it, failed messages become visible again after the visibility timeout."
+		}`
+
+const inputSchema = `{
+	"type": "object",
+	"properties": {` + queueProperties + `,` + receiveProperties + `
+	},
+	"required": ["queue_url"],
+	"additionalProperties": false
+}`
+
+const triggerInputSchema = `{
+	"type": "object",
+	"properties": {` + queueProperties + `,` + receiveProperties + `,
+		"drop_invalid": {"type": "boolean", "default": false, "description": "Delete messages which aren't S3 event notifications instead of leaving them for the redrive policy of the queue."},
+		"event_names": {"type": "array", "items": {"type": "string"}, "default": ["ObjectCreated:*"], "description": "The S3 event types which produce triggers. A trailing * matches all types with the prefix."}
+	},
+	"required": ["queue_url"],
+	"additionalProperties": false
+}`
+
+const outputSchema = `{
+	"type": "object",
+	"properties": {` + queueProperties + `,
+		"message_group_id": {"type": "string", "description": "The expression producing the message group of each message, required for FIFO queues."},
+		"deduplication_id": {"type": "string", "description": "The expression producing the deduplication id of each message, for FIFO queues without content based deduplication."},
+		"delay_seconds": {"type": "integer", "minimum": 0, "maximum": 900, "description": "Delays the delivery of the messages."}
+	},
+	"required": ["queue_url"],
+	"additionalProperties": false
+}`
+
+// Register adds the SQS input, output and S3 notification trigger input to the registry.
+func Register(r *registry.Registry) error {
+	err := r.RegisterInput(registry.Spec{
+		Name:    InputComponentName,
+		Summary: "Receives messages from an SQS queue.",
+		Schema:  inputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.Input, error) {
+		return NewInputFromConfig(env, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.RegisterTriggerInput(registry.Spec{
+		Name:    TriggerInputComponentName,
+		Summary: "Emits triggers for the S3 event notifications sent to an SQS queue.",
+		Schema:  triggerInputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.TriggerInput, error) {
+		return NewTriggerInputFromConfig(env, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterOutput(registry.Spec{
+		Name:    OutputComponentName,
+		Summary: "Sends messages to an SQS queue.",
+		Schema:  outputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.Output, error) {
+		return NewOutputFromConfig(env, cfg)
+	})
+}
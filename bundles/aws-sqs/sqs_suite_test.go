@@ -0,0 +1,72 @@
+package sqs_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sqs "github.com/wombatwisdom/components/bundles/aws-sqs"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var env spec.Environment
+var awsCfg aws.Config
+var fake *fakeSQS
+
+func TestSQS(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	BeforeSuite(func() {
+		awsCfg = aws.Config{
+			Region:      "us-east-1",
+			Credentials: credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		}
+
+		fake = newFakeSQS()
+		env = test.TestEnvironment()
+	})
+
+	RunSpecs(t, "SQS Suite")
+}
+
+// newQueue returns the url of a fresh queue on the fake endpoint.
+func newQueue() string {
+	return fake.queueURL(uuid.NewString())
+}
+
+func receiveConfig() sqs.ReceiveConfig {
+	cfg := sqs.DefaultReceiveConfig()
+	cfg.WaitTimeSeconds = 1
+	return cfg
+}
+
+func inputConfig(queue string) sqs.InputConfig {
+	return sqs.InputConfig{
+		Config:        awsCfg.Copy(),
+		ReceiveConfig: receiveConfig(),
+		QueueURL:      queue,
+		EndpointURL:   aws.String(fake.url),
+	}
+}
+
+func outputConfig(queue string) sqs.OutputConfig {
+	return sqs.OutputConfig{
+		Config:      awsCfg.Copy(),
+		QueueURL:    queue,
+		EndpointURL: aws.String(fake.url),
+	}
+}
+
+func payloads(batch spec.Batch) []string {
+	var result []string
+	for _, msg := range batch.Messages() {
+		raw, err := msg.Raw()
+		Expect(err).ToNot(HaveOccurred())
+		result = append(result, string(raw))
+	}
+	return result
+}
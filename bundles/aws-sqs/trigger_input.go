@@ -0,0 +1,293 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	awsconfig "github.com/wombatwisdom/components/bundles/aws-config"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	TriggerInputComponentName = "aws_sqs_s3_trigger"
+)
+
+// NewTriggerInputFromConfig creates an SQS trigger input from a spec.Config interface. The AWS SDK is configured by
+// the fields of awsconfig.Config.
+func NewTriggerInputFromConfig(env spec.Environment, config spec.Config) (*TriggerInput, error) {
+	cfg := TriggerInputConfig{ReceiveConfig: DefaultReceiveConfig()}
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode sqs trigger input config: %w", err)
+	}
+
+	var err error
+	if cfg.Config, err = awsconfig.FromConfig(config); err != nil {
+		return nil, err
+	}
+	return NewTriggerInput(env, cfg)
+}
+
+func NewTriggerInput(env spec.Environment, config TriggerInputConfig) (*TriggerInput, error) {
+	if config.QueueURL == "" {
+		return nil, fmt.Errorf("queue url is required")
+	}
+
+	if err := config.ReceiveConfig.validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if len(config.EventNames) == 0 {
+		config.EventNames = []string{"ObjectCreated:*"}
+	}
+
+	return &TriggerInput{
+		config: config,
+		log:    env,
+	}, nil
+}
+
+// TriggerInput emits triggers for the S3 event notifications a bucket sends to an SQS queue, directly or through an
+// SNS topic. Every record of a notification with one of the configured event names becomes a trigger referencing
+// the object as bucket/key, with the bucket and key metadata the S3 RetrievalProcessor uses. Notifications without
+// such records are deleted right away.
+//
+// A notification is deleted from the queue once all of its triggers were processed, or failed permanently. Messages
+// which aren't S3 event notifications are left on the queue for its redrive policy, unless DropInvalid is set. The
+// test event S3 sends when notifications are configured is deleted right away.
+type TriggerInput struct {
+	config TriggerInputConfig
+
+	lock     sync.Mutex
+	receiver *receiver
+
+	log spec.Logger
+}
+
+func (t *TriggerInput) Init(ctx spec.ComponentContext) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.receiver != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	client := newClient(t.config.Config, t.config.EndpointURL)
+	r, err := newReceiver(ctx.Context(), client, t.config.QueueURL, t.config.ReceiveConfig, t.log)
+	if err != nil {
+		return err
+	}
+
+	t.receiver = r
+	return nil
+}
+
+func (t *TriggerInput) Close(ctx spec.ComponentContext) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.receiver != nil {
+		t.receiver.close()
+		t.receiver = nil
+	}
+	return nil
+}
+
+// ReadTriggers receives S3 event notifications and returns their records as triggers. It returns spec.ErrNoData
+// when no notifications arrived within the wait time.
+func (t *TriggerInput) ReadTriggers(ctx spec.ComponentContext) (spec.TriggerBatch, spec.ProcessedCallback, error) {
+	t.lock.Lock()
+	r := t.receiver
+	t.lock.Unlock()
+
+	if r == nil {
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	messages, err := r.receive(ctx.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batch := spec.NewTriggerBatch()
+
+	// -- received holds the notifications with triggers, owners the index of the notification of every trigger
+	var received []types.Message
+	var owners []int
+	var discarded []*string
+
+	for _, message := range messages {
+		records, err := parseNotification(aws.ToString(message.Body))
+		switch {
+		case errors.Is(err, errTestEvent):
+			discarded = append(discarded, message.ReceiptHandle)
+			continue
+		case err != nil && t.config.DropInvalid:
+			t.log.Warnf("Dropping SQS message %s which isn't an S3 event notification: %v", aws.ToString(message.MessageId), err)
+			discarded = append(discarded, message.ReceiptHandle)
+			continue
+		case err != nil:
+			t.log.Warnf("Leaving SQS message %s which isn't an S3 event notification on the queue: %v", aws.ToString(message.MessageId), err)
+			continue
+		}
+
+		records = slices.DeleteFunc(records, func(record s3Record) bool {
+			return !t.matchesEventName(record.EventName)
+		})
+		if len(records) == 0 {
+			discarded = append(discarded, message.ReceiptHandle)
+			continue
+		}
+
+		for _, record := range records {
+			batch.Append(record.trigger())
+			owners = append(owners, len(received))
+		}
+		received = append(received, message)
+	}
+
+	if err := r.delete(ctx.Context(), discarded); err != nil {
+		t.log.Warnf("Failed to delete discarded messages: %v", err)
+	}
+
+	if len(received) == 0 {
+		return nil, nil, spec.ErrNoData
+	}
+
+	release := r.hold(received)
+
+	var once sync.Once
+	return batch, func(ackCtx context.Context, res error) error {
+		var err error
+		once.Do(func() {
+			release()
+
+			// -- a notification failed if any of its records failed
+			failures := make([]error, len(received))
+			for idx, owner := range owners {
+				if failures[owner] == nil {
+					failures[owner] = spec.MessageError(res, idx)
+				}
+			}
+
+			err = r.settle(ackCtx, received, func(idx int) error {
+				return failures[idx]
+			})
+		})
+		return err
+	}, nil
+}
+
+// matchesEventName reports whether records with the given event name produce triggers.
+func (t *TriggerInput) matchesEventName(name string) bool {
+	for _, pattern := range t.config.EventNames {
+		pattern = strings.TrimPrefix(pattern, "s3:")
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(name, prefix) {
+			return true
+		}
+		if pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+// errTestEvent is returned for the test event S3 sends when event notifications are configured
+var errTestEvent = errors.New("s3 test event")
+
+// s3Record is a record of an S3 event notification.
+type s3Record struct {
+	EventSource string    `json:"eventSource"`
+	EventName   string    `json:"eventName"`
+	EventTime   time.Time `json:"eventTime"`
+	AWSRegion   string    `json:"awsRegion"`
+	S3          struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key       string `json:"key"`
+			Size      int64  `json:"size"`
+			ETag      string `json:"eTag"`
+			VersionID string `json:"versionId"`
+			Sequencer string `json:"sequencer"`
+		} `json:"object"`
+	} `json:"s3"`
+}
+
+func (r s3Record) trigger() spec.TriggerEvent {
+	metadata := map[string]any{
+		spec.MetadataBucket:    r.S3.Bucket.Name,
+		spec.MetadataKey:       r.S3.Object.Key,
+		spec.MetadataEventName: r.EventName,
+		spec.MetadataRegion:    r.AWSRegion,
+		spec.MetadataTimestamp: r.EventTime.Unix(),
+		spec.MetadataSize:      r.S3.Object.Size,
+		spec.MetadataETag:      strings.Trim(r.S3.Object.ETag, "\""),
+	}
+	if r.S3.Object.VersionID != "" {
+		metadata["version_id"] = r.S3.Object.VersionID
+	}
+	if r.S3.Object.Sequencer != "" {
+		metadata["sequencer"] = r.S3.Object.Sequencer
+	}
+
+	return spec.NewTriggerEvent(spec.TriggerSourceSQS, r.S3.Bucket.Name+"/"+r.S3.Object.Key, metadata)
+}
+
+// parseNotification returns the records of an S3 event notification, unwrapping it from an SNS notification if the
+// bucket notifies a topic the queue is subscribed to.
+func parseNotification(body string) ([]s3Record, error) {
+	var notification struct {
+		// -- set when the notification was delivered through SNS
+		Type    string `json:"Type"`
+		Message string `json:"Message"`
+
+		// -- set for the test event
+		Event string `json:"Event"`
+
+		Records []s3Record `json:"Records"`
+	}
+
+	if err := json.Unmarshal([]byte(body), &notification); err != nil {
+		return nil, fmt.Errorf("failed to parse notification: %w", err)
+	}
+
+	if notification.Type == "Notification" && notification.Message != "" {
+		return parseNotification(notification.Message)
+	}
+
+	if notification.Event == "s3:TestEvent" {
+		return nil, errTestEvent
+	}
+
+	if len(notification.Records) == 0 {
+		return nil, fmt.Errorf("notification has no records")
+	}
+
+	for idx, record := range notification.Records {
+		if record.EventSource != "aws:s3" {
+			return nil, fmt.Errorf("record #%d: unexpected event source %q", idx, record.EventSource)
+		}
+		if record.S3.Bucket.Name == "" || record.S3.Object.Key == "" {
+			return nil, fmt.Errorf("record #%d: missing bucket or key", idx)
+		}
+
+		// -- keys are URL encoded in notifications, with spaces as plus signs
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			return nil, fmt.Errorf("record #%d: invalid key %q: %w", idx, record.S3.Object.Key, err)
+		}
+		notification.Records[idx].S3.Object.Key = key
+	}
+
+	return notification.Records, nil
+}
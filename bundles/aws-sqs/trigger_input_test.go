@@ -0,0 +1,166 @@
+package sqs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sqs "github.com/wombatwisdom/components/bundles/aws-sqs"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+// notification returns an S3 event notification for the given keys of the bucket.
+func notification(bucket string, keys ...string) string {
+	var records []map[string]any
+	for _, key := range keys {
+		records = append(records, map[string]any{
+			"eventSource": "aws:s3",
+			"eventName":   "ObjectCreated:Put",
+			"eventTime":   "2024-05-01T12:00:00.000Z",
+			"awsRegion":   "eu-west-1",
+			"s3": map[string]any{
+				"bucket": map[string]any{"name": bucket},
+				"object": map[string]any{"key": key, "size": 1024, "eTag": "abc123", "sequencer": "0055AED6DCD90281E5"},
+			},
+		})
+	}
+
+	b, err := json.Marshal(map[string]any{"Records": records})
+	Expect(err).ToNot(HaveOccurred())
+	return string(b)
+}
+
+var _ = Describe("TriggerInput", func() {
+	var ctx spec.ComponentContext
+	var queue string
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		queue = newQueue()
+	})
+
+	newTriggerInput := func(dropInvalid bool) *sqs.TriggerInput {
+		input, err := sqs.NewTriggerInput(env, sqs.TriggerInputConfig{
+			Config:        awsCfg.Copy(),
+			ReceiveConfig: receiveConfig(),
+			QueueURL:      queue,
+			EndpointURL:   aws.String(fake.url),
+			DropInvalid:   dropInvalid,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+
+		DeferCleanup(func() {
+			_ = input.Close(ctx)
+		})
+		return input
+	}
+
+	references := func(batch spec.TriggerBatch) []string {
+		var result []string
+		for _, trigger := range batch.Triggers() {
+			result = append(result, trigger.Reference())
+		}
+		return result
+	}
+
+	It("should emit a trigger for every record", func() {
+		fake.add(queue, notification("bucket", "a.json", "b.json"))
+		input := newTriggerInput(false)
+
+		batch, callback, err := input.ReadTriggers(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(references(batch)).To(Equal([]string{"bucket/a.json", "bucket/b.json"}))
+
+		trigger := batch.Triggers()[0]
+		Expect(trigger.Source()).To(Equal(spec.TriggerSourceSQS))
+		Expect(trigger.Metadata()).To(HaveKeyWithValue(spec.MetadataBucket, "bucket"))
+		Expect(trigger.Metadata()).To(HaveKeyWithValue(spec.MetadataKey, "a.json"))
+		Expect(trigger.Metadata()).To(HaveKeyWithValue(spec.MetadataEventName, "ObjectCreated:Put"))
+		Expect(trigger.Metadata()).To(HaveKeyWithValue(spec.MetadataRegion, "eu-west-1"))
+		Expect(trigger.Metadata()).To(HaveKeyWithValue(spec.MetadataSize, int64(1024)))
+		Expect(trigger.Metadata()).To(HaveKeyWithValue(spec.MetadataETag, "abc123"))
+		Expect(trigger.Metadata()).To(HaveKeyWithValue("sequencer", "0055AED6DCD90281E5"))
+
+		Expect(callback(context.Background(), nil)).To(Succeed())
+		Expect(fake.messages(queue)).To(BeEmpty())
+	})
+
+	It("should only emit triggers for the configured event names", func() {
+		removed := strings.Replace(notification("bucket", "gone.json"), "ObjectCreated:Put", "ObjectRemoved:Delete", 1)
+		fake.add(queue, removed, notification("bucket", "a.json"))
+		input := newTriggerInput(false)
+
+		batch, callback, err := input.ReadTriggers(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(references(batch)).To(Equal([]string{"bucket/a.json"}))
+
+		Expect(callback(context.Background(), nil)).To(Succeed())
+		Expect(fake.messages(queue)).To(BeEmpty())
+	})
+
+	It("should unwrap notifications delivered through SNS", func() {
+		b, err := json.Marshal(map[string]any{
+			"Type":    "Notification",
+			"Message": notification("bucket", "folder/my+file%21.txt"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		fake.add(queue, string(b))
+
+		input := newTriggerInput(false)
+		batch, _, err := input.ReadTriggers(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(references(batch)).To(Equal([]string{"bucket/folder/my file!.txt"}))
+	})
+
+	It("should delete the test event", func() {
+		fake.add(queue, `{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"bucket"}`)
+		input := newTriggerInput(false)
+
+		_, _, err := input.ReadTriggers(ctx)
+		Expect(err).To(MatchError(spec.ErrNoData))
+		Expect(fake.messages(queue)).To(BeEmpty())
+	})
+
+	It("should leave invalid messages on the queue", func() {
+		fake.add(queue, "not a notification", `{"Records":[{"eventSource":"aws:sqs"}]}`)
+		input := newTriggerInput(false)
+
+		_, _, err := input.ReadTriggers(ctx)
+		Expect(err).To(MatchError(spec.ErrNoData))
+		Expect(fake.deleted(queue)).To(BeEmpty())
+		Expect(fake.messages(queue)).To(HaveLen(2))
+	})
+
+	It("should drop invalid messages when asked to", func() {
+		fake.add(queue, "not a notification", notification("bucket", "a.json"))
+		input := newTriggerInput(true)
+
+		batch, callback, err := input.ReadTriggers(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(references(batch)).To(Equal([]string{"bucket/a.json"}))
+		Expect(fake.deleted(queue)).To(Equal([]string{"not a notification"}))
+
+		Expect(callback(context.Background(), nil)).To(Succeed())
+		Expect(fake.messages(queue)).To(BeEmpty())
+	})
+
+	It("should keep a notification until all of its records were processed", func() {
+		first, second := notification("bucket", "a.json", "b.json"), notification("bucket", "c.json")
+		fake.add(queue, first, second)
+		input := newTriggerInput(false)
+
+		batch, callback, err := input.ReadTriggers(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(references(batch)).To(Equal([]string{"bucket/a.json", "bucket/b.json", "bucket/c.json"}))
+
+		Expect(callback(context.Background(), spec.NewBatchError(nil).Failed(1, errors.New("try again")))).To(Succeed())
+		Expect(fake.deleted(queue)).To(Equal([]string{second}))
+		Expect(fake.messages(queue)).To(Equal([]string{first}))
+	})
+})
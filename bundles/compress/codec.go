@@ -0,0 +1,213 @@
+// Package compress provides streaming compression codecs and a processor to compress or decompress message
+// payloads. The codecs are shared with other bundles, so inputs can transparently decompress objects and outputs
+// can compress bodies before publishing them.
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// MetadataCompression is the metadata key used to mark the compression algorithm applied to a message payload.
+const MetadataCompression = "compression"
+
+// Algorithm identifies a compression algorithm.
+type Algorithm string
+
+const (
+	None   Algorithm = "none"
+	Gzip   Algorithm = "gzip"
+	Zstd   Algorithm = "zstd"
+	Snappy Algorithm = "snappy"
+	LZ4    Algorithm = "lz4"
+)
+
+// ParseAlgorithm converts the given name into an Algorithm. An empty name is treated as None.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch Algorithm(strings.ToLower(strings.TrimSpace(name))) {
+	case "", None:
+		return None, nil
+	case Gzip, "gz":
+		return Gzip, nil
+	case Zstd, "zst":
+		return Zstd, nil
+	case Snappy:
+		return Snappy, nil
+	case LZ4:
+		return LZ4, nil
+	default:
+		return None, fmt.Errorf("unsupported compression algorithm %q", name)
+	}
+}
+
+// suffixes maps file extensions to the algorithm they indicate.
+var suffixes = map[string]Algorithm{
+	".gz":     Gzip,
+	".gzip":   Gzip,
+	".tgz":    Gzip,
+	".zst":    Zstd,
+	".zstd":   Zstd,
+	".sz":     Snappy,
+	".snappy": Snappy,
+	".lz4":    LZ4,
+}
+
+// Detect determines the compression algorithm from a content encoding or, if that doesn't indicate a known
+// algorithm, from the suffix of a key or file name. None is returned if neither does.
+func Detect(key string, contentEncoding string) Algorithm {
+	for _, enc := range strings.Split(contentEncoding, ",") {
+		if algo, err := ParseAlgorithm(enc); err == nil && algo != None {
+			return algo
+		}
+	}
+
+	if algo, ok := suffixes[strings.ToLower(path.Ext(key))]; ok {
+		return algo
+	}
+
+	return None
+}
+
+// magics maps the leading bytes of compressed payloads to their algorithm. Raw snappy blocks have no magic, only
+// the framed snappy format is recognized.
+var magics = []struct {
+	magic []byte
+	algo  Algorithm
+}{
+	{[]byte{0x1f, 0x8b}, Gzip},
+	{[]byte{0x28, 0xb5, 0x2f, 0xfd}, Zstd},
+	{[]byte{0x04, 0x22, 0x4d, 0x18}, LZ4},
+	{[]byte("\xff\x06\x00\x00sNaPpY"), Snappy},
+}
+
+// DetectReader determines the compression algorithm from the first bytes of the payload, without consuming them.
+// None is returned if they don't match the magic of a known algorithm.
+func DetectReader(br *bufio.Reader) Algorithm {
+	for _, m := range magics {
+		if b, _ := br.Peek(len(m.magic)); bytes.Equal(b, m.magic) {
+			return m.algo
+		}
+	}
+	return None
+}
+
+// NewReader wraps the given reader with a decompressing reader for the algorithm. Closing the returned reader
+// also closes r if it implements io.Closer.
+func NewReader(algo Algorithm, r io.Reader) (io.ReadCloser, error) {
+	var dr io.Reader
+	var closeFn func()
+
+	switch algo {
+	case None, "":
+		dr = r
+	case Gzip:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		dr, closeFn = gr, func() { _ = gr.Close() }
+	case Zstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("zstd: %w", err)
+		}
+		dr, closeFn = zr, zr.Close
+	case Snappy:
+		dr = snappy.NewReader(r)
+	case LZ4:
+		dr = lz4.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm %q", algo)
+	}
+
+	return &readCloser{Reader: dr, closeFn: closeFn, underlying: r}, nil
+}
+
+// NewWriter wraps the given writer with a compressing writer for the algorithm. Closing the returned writer
+// flushes any pending data but does not close w.
+func NewWriter(algo Algorithm, w io.Writer) (io.WriteCloser, error) {
+	switch algo {
+	case None, "":
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("zstd: %w", err)
+		}
+		return zw, nil
+	case Snappy:
+		return snappy.NewBufferedWriter(w), nil
+	case LZ4:
+		return lz4.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm %q", algo)
+	}
+}
+
+// Compress compresses the given data using the algorithm.
+func Compress(algo Algorithm, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := NewWriter(algo, &buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", algo, err)
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("%s: %w", algo, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses the given data using the algorithm.
+func Decompress(algo Algorithm, data []byte) ([]byte, error) {
+	r, err := NewReader(algo, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", algo, err)
+	}
+	return b, nil
+}
+
+type readCloser struct {
+	io.Reader
+	closeFn    func()
+	underlying io.Reader
+}
+
+func (r *readCloser) Close() error {
+	if r.closeFn != nil {
+		r.closeFn()
+	}
+
+	if c, ok := r.underlying.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
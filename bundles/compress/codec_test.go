@@ -0,0 +1,73 @@
+package compress_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/compress"
+)
+
+var _ = Describe("Codecs", func() {
+	DescribeTable("should round-trip data",
+		func(algo compress.Algorithm) {
+			data := []byte("hello, world - hello, world - hello, world")
+
+			compressed, err := compress.Compress(algo, data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(compressed).ToNot(Equal(data))
+
+			decompressed, err := compress.Decompress(algo, compressed)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decompressed).To(Equal(data))
+		},
+		Entry("gzip", compress.Gzip),
+		Entry("zstd", compress.Zstd),
+		Entry("snappy", compress.Snappy),
+		Entry("lz4", compress.LZ4),
+	)
+
+	DescribeTable("should detect the algorithm",
+		func(key string, encoding string, expected compress.Algorithm) {
+			Expect(compress.Detect(key, encoding)).To(Equal(expected))
+		},
+		Entry("gzip suffix", "logs/2024/01/01.json.gz", "", compress.Gzip),
+		Entry("zstd suffix", "logs/2024/01/01.json.zst", "", compress.Zstd),
+		Entry("uppercase suffix", "logs/FILE.GZ", "", compress.Gzip),
+		Entry("content encoding", "logs/file.json", "gzip", compress.Gzip),
+		Entry("content encoding wins", "logs/file.json.gz", "zstd", compress.Zstd),
+		Entry("uncompressed", "logs/file.json", "", compress.None),
+		Entry("unknown encoding", "logs/file.json", "identity", compress.None),
+	)
+
+	DescribeTable("should detect the algorithm from the payload",
+		func(algo compress.Algorithm) {
+			compressed, err := compress.Compress(algo, []byte("hello, world"))
+			Expect(err).ToNot(HaveOccurred())
+
+			br := bufio.NewReader(bytes.NewReader(compressed))
+			Expect(compress.DetectReader(br)).To(Equal(algo))
+
+			// -- detecting doesn't consume the payload
+			rest, err := io.ReadAll(br)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rest).To(Equal(compressed))
+		},
+		Entry("gzip", compress.Gzip),
+		Entry("zstd", compress.Zstd),
+		Entry("snappy", compress.Snappy),
+		Entry("lz4", compress.LZ4),
+	)
+
+	It("should not detect an algorithm for uncompressed payloads", func() {
+		Expect(compress.DetectReader(bufio.NewReader(strings.NewReader("hello, world")))).To(Equal(compress.None))
+	})
+
+	It("should reject unknown algorithms", func() {
+		_, err := compress.ParseAlgorithm("brotli")
+		Expect(err).To(HaveOccurred())
+	})
+})
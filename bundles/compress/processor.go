@@ -0,0 +1,191 @@
+package compress
+
+import (
+	"fmt"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	ProcessorComponentName = "compress"
+)
+
+// Auto can be used as the processor algorithm when decompressing to detect the algorithm from the message
+// metadata, using the compression marker, a content encoding or the key of the object.
+const Auto Algorithm = "auto"
+
+// Operation defines whether the processor compresses or decompresses payloads.
+type Operation string
+
+const (
+	OperationCompress   Operation = "compress"
+	OperationDecompress Operation = "decompress"
+)
+
+type ProcessorConfig struct {
+	// The compression algorithm to use. One of gzip, zstd, snappy or lz4. When decompressing, auto can be used to
+	// detect the algorithm from the message metadata.
+	Algorithm Algorithm `json:"algorithm" yaml:"algorithm" mapstructure:"algorithm"`
+
+	// Whether to compress or decompress the message payloads. Defaults to compress.
+	Operation Operation `json:"operation,omitempty" yaml:"operation,omitempty" mapstructure:"operation,omitempty"`
+}
+
+// NewProcessor creates a new compression processor
+func NewProcessor(config ProcessorConfig) (*Processor, error) {
+	if config.Operation == "" {
+		config.Operation = OperationCompress
+	}
+
+	if config.Operation != OperationCompress && config.Operation != OperationDecompress {
+		return nil, fmt.Errorf("invalid operation %q (must be compress or decompress)", config.Operation)
+	}
+
+	if config.Algorithm != Auto {
+		algo, err := ParseAlgorithm(string(config.Algorithm))
+		if err != nil {
+			return nil, err
+		}
+		config.Algorithm = algo
+	} else if config.Operation == OperationCompress {
+		return nil, fmt.Errorf("algorithm auto is only supported when decompressing")
+	}
+
+	return &Processor{config: config}, nil
+}
+
+// NewProcessorFromConfig creates a compression processor from a spec.Config interface
+func NewProcessorFromConfig(config spec.Config) (*Processor, error) {
+	var cfg ProcessorConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode compress processor config: %w", err)
+	}
+	return NewProcessor(cfg)
+}
+
+// Processor compresses or decompresses message payloads.
+//
+// When compressing, the algorithm is recorded in the compression metadata field so downstream components know
+// how to decode the payload. When decompressing a streaming message, like an S3 object or a file, the payload is
+// decompressed while it is being read instead of being buffered in memory first.
+type Processor struct {
+	config ProcessorConfig
+}
+
+func (p *Processor) Init(ctx spec.ComponentContext) error {
+	return nil
+}
+
+func (p *Processor) Close(ctx spec.ComponentContext) error {
+	return nil
+}
+
+func (p *Processor) Process(ctx spec.ComponentContext, batch spec.Batch) (spec.Batch, spec.ProcessedCallback, error) {
+	result := ctx.NewBatch()
+
+	for idx, msg := range batch.Messages() {
+		var out spec.Message
+		var err error
+
+		if p.config.Operation == OperationDecompress {
+			out, err = p.decompress(msg)
+		} else {
+			out, err = p.compress(msg)
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("batch #%d: %w", idx, err)
+		}
+
+		result.Append(out)
+	}
+
+	return result, spec.NoopCallback, nil
+}
+
+func (p *Processor) compress(msg spec.Message) (spec.Message, error) {
+	raw, err := msg.Raw()
+	if err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+
+	data, err := Compress(p.config.Algorithm, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	msg.SetRaw(data)
+	msg.SetMetadata(MetadataCompression, string(p.config.Algorithm))
+	return msg, nil
+}
+
+func (p *Processor) decompress(msg spec.Message) (spec.Message, error) {
+	algo := p.config.Algorithm
+	if algo == Auto {
+		algo = DetectMessage(msg)
+	}
+
+	if algo == None {
+		return msg, nil
+	}
+
+	// -- streaming messages are decompressed while they are being read
+	if sm, ok := msg.(spec.StreamingMessage); ok {
+		r, err := sm.Reader()
+		if err != nil {
+			return nil, fmt.Errorf("payload: %w", err)
+		}
+
+		dr, err := NewReader(algo, r)
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+
+		out := spec.NewReaderMessage(dr)
+		for k, v := range msg.Metadata() {
+			out.SetMetadata(k, v)
+		}
+		out.SetMetadata(MetadataCompression, string(None))
+		return out, nil
+	}
+
+	raw, err := msg.Raw()
+	if err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+
+	data, err := Decompress(algo, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	msg.SetRaw(data)
+	msg.SetMetadata(MetadataCompression, string(None))
+	return msg, nil
+}
+
+// DetectMessage determines the compression algorithm of a message payload from its metadata. The compression
+// marker takes precedence, followed by a content encoding and the key or path of the object.
+func DetectMessage(msg spec.Message) Algorithm {
+	var encoding, key string
+	for k, v := range msg.Metadata() {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		switch k {
+		case MetadataCompression:
+			if algo, err := ParseAlgorithm(s); err == nil {
+				return algo
+			}
+		case "content_encoding", "Content-Encoding":
+			encoding = s
+		case spec.MetadataKey, "path":
+			key = s
+		}
+	}
+
+	return Detect(key, encoding)
+}
@@ -0,0 +1,96 @@
+package compress_test
+
+import (
+	"bytes"
+	"io"
+	"maps"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("Processor", func() {
+	var ctx spec.ComponentContext
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+	})
+
+	It("should compress and mark the payload", func() {
+		proc, err := compress.NewProcessor(compress.ProcessorConfig{Algorithm: compress.Zstd})
+		Expect(err).ToNot(HaveOccurred())
+
+		batch, _, err := proc.Process(ctx, ctx.NewBatch(spec.NewBytesMessage([]byte("hello, world"))))
+		Expect(err).ToNot(HaveOccurred())
+
+		msgs := maps.Collect(batch.Messages())
+		Expect(msgs).To(HaveLen(1))
+
+		meta := maps.Collect(msgs[0].Metadata())
+		Expect(meta).To(HaveKeyWithValue(compress.MetadataCompression, "zstd"))
+
+		raw, err := msgs[0].Raw()
+		Expect(err).ToNot(HaveOccurred())
+		data, err := compress.Decompress(compress.Zstd, raw)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("hello, world"))
+	})
+
+	It("should detect the algorithm from the metadata when decompressing", func() {
+		proc, err := compress.NewProcessor(compress.ProcessorConfig{
+			Algorithm: compress.Auto,
+			Operation: compress.OperationDecompress,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		compressed, err := compress.Compress(compress.Gzip, []byte("hello, world"))
+		Expect(err).ToNot(HaveOccurred())
+
+		msg := spec.NewBytesMessage(compressed)
+		msg.SetMetadata(spec.MetadataKey, "data/file.txt.gz")
+
+		batch, _, err := proc.Process(ctx, ctx.NewBatch(msg))
+		Expect(err).ToNot(HaveOccurred())
+
+		msgs := maps.Collect(batch.Messages())
+		raw, err := msgs[0].Raw()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(raw)).To(Equal("hello, world"))
+	})
+
+	It("should decompress streaming messages without materializing them", func() {
+		proc, err := compress.NewProcessor(compress.ProcessorConfig{
+			Algorithm: compress.LZ4,
+			Operation: compress.OperationDecompress,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		compressed, err := compress.Compress(compress.LZ4, []byte("hello, world"))
+		Expect(err).ToNot(HaveOccurred())
+
+		msg := spec.NewReaderMessage(io.NopCloser(bytes.NewReader(compressed)))
+		msg.SetMetadata("source", "test")
+
+		batch, _, err := proc.Process(ctx, ctx.NewBatch(msg))
+		Expect(err).ToNot(HaveOccurred())
+
+		msgs := maps.Collect(batch.Messages())
+		sm, ok := msgs[0].(spec.StreamingMessage)
+		Expect(ok).To(BeTrue())
+		Expect(maps.Collect(sm.Metadata())).To(HaveKeyWithValue("source", "test"))
+
+		r, err := sm.Reader()
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("hello, world"))
+	})
+
+	It("should reject auto detection when compressing", func() {
+		_, err := compress.NewProcessor(compress.ProcessorConfig{Algorithm: compress.Auto})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,27 @@
+package compress
+
+import (
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const processorSchema = `{
+	"type": "object",
+	"properties": {
+		"algorithm": {"type": "string", "enum": ["gzip", "zstd", "snappy", "lz4", "auto"], "description": "The compression algorithm. Auto detects the algorithm from the metadata when decompressing."},
+		"operation": {"type": "string", "enum": ["compress", "decompress"], "default": "compress"}
+	},
+	"required": ["algorithm"],
+	"additionalProperties": false
+}`
+
+// Register adds the compress processor to the registry.
+func Register(r *registry.Registry) error {
+	return r.RegisterProcessor(registry.Spec{
+		Name:    ProcessorComponentName,
+		Summary: "Compresses or decompresses message payloads.",
+		Schema:  processorSchema,
+	}, func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Processor, error) {
+		return NewProcessorFromConfig(cfg)
+	})
+}
@@ -0,0 +1,131 @@
+package file
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// Action is what happens to a file once the messages retrieved from it were processed.
+type Action string
+
+const (
+	// ActionNone leaves the file in place.
+	ActionNone Action = "none"
+	// ActionDelete removes the file.
+	ActionDelete Action = "delete"
+	// ActionMove moves the file to another directory, keeping its path relative to the watched directory.
+	ActionMove Action = "move"
+)
+
+// AfterProcessing configures the action taken on a file after it was processed.
+type AfterProcessing struct {
+	// The action to take. Defaults to none.
+	Action Action `json:"action,omitempty" yaml:"action,omitempty" mapstructure:"action,omitempty"`
+
+	// The directory to move the file to. Relative directories are resolved against the watched directory, and are
+	// never watched themselves.
+	Directory string `json:"directory,omitempty" yaml:"directory,omitempty" mapstructure:"directory,omitempty"`
+}
+
+func (a AfterProcessing) validate() error {
+	switch a.Action {
+	case "", ActionNone, ActionDelete:
+		return nil
+	case ActionMove:
+		if a.Directory == "" {
+			return fmt.Errorf("a directory is required to move files")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q", a.Action)
+	}
+}
+
+// TriggerInputConfig defines the configuration of the file trigger input.
+type TriggerInputConfig struct {
+	// The directory to watch.
+	Path string `json:"path" yaml:"path" mapstructure:"path"`
+
+	// The glob files have to match to be triggered on. Without a slash the pattern is matched against the name of the
+	// file, with a slash against its path relative to the watched directory (e.g. "incoming/*.csv"). Defaults to "*".
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty" mapstructure:"pattern,omitempty"`
+
+	// Watch the subdirectories of the directory as well.
+	Recursive bool `json:"recursive,omitempty" yaml:"recursive,omitempty" mapstructure:"recursive,omitempty"`
+
+	// Poll the directory at this interval instead of watching it, for filesystems which don't report changes, like
+	// network shares. Watching falls back to polling every second if the platform doesn't support it.
+	PollInterval string `json:"poll_interval,omitempty" yaml:"poll_interval,omitempty" mapstructure:"poll_interval,omitempty"`
+
+	// How long a file has to remain unchanged before it is triggered on, so files which are still being written
+	// aren't picked up halfway. Defaults to 500ms.
+	SettleTime string `json:"settle_time,omitempty" yaml:"settle_time,omitempty" mapstructure:"settle_time,omitempty"`
+
+	// Only trigger on files created or modified after the input started, instead of also on the files which are
+	// already in the directory.
+	SkipExisting bool `json:"skip_existing,omitempty" yaml:"skip_existing,omitempty" mapstructure:"skip_existing,omitempty"`
+
+	// The maximum number of triggers per batch. Defaults to 10.
+	MaxBatchSize int `json:"max_batch_size,omitempty" yaml:"max_batch_size,omitempty" mapstructure:"max_batch_size,omitempty"`
+
+	// What to do with files whose messages were processed successfully.
+	OnSuccess AfterProcessing `json:"on_success,omitempty" yaml:"on_success,omitempty" mapstructure:"on_success,omitempty"`
+
+	// What to do with files whose messages failed to process. Failed files which are left in place are triggered on
+	// again once they change or the input restarts.
+	OnError AfterProcessing `json:"on_error,omitempty" yaml:"on_error,omitempty" mapstructure:"on_error,omitempty"`
+}
+
+const (
+	defaultPattern      = "*"
+	defaultSettleTime   = 500 * time.Millisecond
+	defaultPollInterval = time.Second
+	defaultMaxBatchSize = 10
+)
+
+// Validate checks the configuration.
+func (c TriggerInputConfig) Validate() error {
+	if c.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	if c.Pattern != "" {
+		if _, err := path.Match(c.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+
+	if _, err := parseDuration(c.PollInterval, 0); err != nil {
+		return fmt.Errorf("invalid poll interval: %w", err)
+	}
+
+	if _, err := parseDuration(c.SettleTime, defaultSettleTime); err != nil {
+		return fmt.Errorf("invalid settle time: %w", err)
+	}
+
+	if err := c.OnSuccess.validate(); err != nil {
+		return fmt.Errorf("on success: %w", err)
+	}
+
+	if err := c.OnError.validate(); err != nil {
+		return fmt.Errorf("on error: %w", err)
+	}
+
+	return nil
+}
+
+func parseDuration(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return d, nil
+}
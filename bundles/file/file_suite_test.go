@@ -0,0 +1,23 @@
+package file_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "File Suite")
+}
+
+// writeFile writes a file below dir, creating the directories in between.
+func writeFile(dir, name, content string) string {
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	Expect(os.MkdirAll(filepath.Dir(p), 0o755)).To(Succeed())
+	Expect(os.WriteFile(p, []byte(content), 0o644)).To(Succeed())
+	return p
+}
@@ -0,0 +1,69 @@
+package file
+
+import (
+	"fmt"
+
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const afterProcessingSchema = `{
+	"type": "object",
+	"properties": {
+		"action": {"type": "string", "enum": ["none", "delete", "move"], "default": "none"},
+		"directory": {"type": "string", "description": "The directory to move the file to, relative to the watched directory."}
+	},
+	"additionalProperties": false
+}`
+
+const triggerInputSchema = `{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string", "description": "The directory to watch."},
+		"pattern": {"type": "string", "default": "*", "description": "The glob files have to match, against their name or, with a slash, their relative path.", "examples": ["*.csv", "incoming/*.json"]},
+		"recursive": {"type": "boolean", "default": false, "description": "Watch the subdirectories as well."},
+		"poll_interval": {"type": "string", "description": "Poll the directory at this interval instead of watching it.", "examples": ["10s"]},
+		"settle_time": {"type": "string", "default": "500ms", "description": "How long a file has to remain unchanged before it is triggered on."},
+		"skip_existing": {"type": "boolean", "default": false, "description": "Only trigger on files created or modified after the input started."},
+		"max_batch_size": {"type": "integer", "minimum": 1, "default": 10, "description": "The maximum number of triggers per batch."},
+		"on_success": ` + afterProcessingSchema + `,
+		"on_error": ` + afterProcessingSchema + `
+	},
+	"required": ["path"],
+	"additionalProperties": false
+}`
+
+const retrievalSchema = `{
+	"type": "object",
+	"properties": {
+		"root": {"type": "string", "description": "Only retrieve files below this directory. Relative references are resolved against it."},
+		"decompress": {"type": "boolean", "default": false, "description": "Decompress files based on the suffix of their name while they are read."}
+	},
+	"additionalProperties": false
+}`
+
+// Register adds the file trigger input and retrieval processor to the registry.
+func Register(r *registry.Registry) error {
+	err := r.RegisterTriggerInput(registry.Spec{
+		Name:    TriggerInputComponentName,
+		Summary: "Triggers on files created or modified in a directory, like a drop folder.",
+		Schema:  triggerInputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.TriggerInput, error) {
+		return NewTriggerInputFromConfig(env, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterRetrieval(registry.Spec{
+		Name:    RetrievalProcessorComponentName,
+		Summary: "Reads the files referenced by triggers.",
+		Schema:  retrievalSchema,
+	}, nil, func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.RetrievalProcessor, error) {
+		var c RetrievalConfig
+		if err := cfg.Decode(&c); err != nil {
+			return nil, fmt.Errorf("failed to decode file retrieval config: %w", err)
+		}
+		return NewRetrievalProcessor(c), nil
+	})
+}
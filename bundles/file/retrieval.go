@@ -0,0 +1,184 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	RetrievalProcessorComponentName = "file_retrieval"
+)
+
+// RetrievalConfig defines configuration for the file retrieval processor
+type RetrievalConfig struct {
+	// Only retrieve files below this directory. Relative references are resolved against it. Without a root,
+	// references have to be absolute paths.
+	Root string `json:"root,omitempty" yaml:"root,omitempty" mapstructure:"root,omitempty"`
+
+	// Decompress files while they are being read. The compression algorithm is detected from the suffix of the file
+	// name (e.g. .gz, .zst).
+	Decompress bool `json:"decompress,omitempty" yaml:"decompress,omitempty" mapstructure:"decompress,omitempty"`
+}
+
+// NewRetrievalProcessor creates a new file retrieval processor
+func NewRetrievalProcessor(config RetrievalConfig) *RetrievalProcessor {
+	return &RetrievalProcessor{
+		config: config,
+	}
+}
+
+// RetrievalProcessor implements spec.RetrievalProcessor for local files. It reads the file referenced by the path
+// metadata of a trigger, or by its reference, into a message whose payload is streamed from the file.
+//
+// Every trigger results in a message, in the order of the triggers, so a spec.BatchError for the messages applies
+// to the triggers of the same index. Triggers referencing files which don't exist fail with spec.ErrPermanent.
+type RetrievalProcessor struct {
+	config RetrievalConfig
+	root   string
+	logger spec.Logger
+}
+
+// Init initializes the file retrieval processor
+func (r *RetrievalProcessor) Init(ctx spec.ComponentContext) error {
+	r.logger = ctx
+
+	r.root = ""
+	if r.config.Root != "" {
+		root, err := filepath.Abs(r.config.Root)
+		if err != nil {
+			return fmt.Errorf("failed to resolve root %s: %w", r.config.Root, err)
+		}
+		r.root = root
+	}
+
+	return nil
+}
+
+// Close cleans up the file retrieval processor
+func (r *RetrievalProcessor) Close(ctx spec.ComponentContext) error {
+	return nil
+}
+
+// Retrieve reads the files referenced by the trigger events
+func (r *RetrievalProcessor) Retrieve(ctx spec.ComponentContext, triggers spec.TriggerBatch) (spec.Batch, spec.ProcessedCallback, error) {
+	batch := ctx.NewBatch()
+
+	for idx, trigger := range triggers.Triggers() {
+		p, err := r.resolve(trigger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("trigger #%d: %w", idx, err)
+		}
+
+		info, err := os.Stat(p)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil, fmt.Errorf("trigger #%d: %w: %w", idx, spec.ErrPermanent, err)
+			}
+			return nil, nil, fmt.Errorf("trigger #%d: %w", idx, err)
+		}
+		if info.IsDir() {
+			return nil, nil, fmt.Errorf("trigger #%d: %w: %s is a directory", idx, spec.ErrPermanent, p)
+		}
+
+		msg := spec.NewReaderMessage(&fileReader{path: p, decompress: r.config.Decompress})
+		msg.SetMetadata(MetadataPath, p)
+		msg.SetMetadata(MetadataName, filepath.Base(p))
+		msg.SetMetadata(spec.MetadataSize, info.Size())
+		msg.SetMetadata(spec.MetadataTimestamp, info.ModTime().Unix())
+
+		msg.SetMetadata("trigger_source", trigger.Source())
+		msg.SetMetadata("trigger_timestamp", trigger.Timestamp())
+		for key, value := range trigger.Metadata() {
+			msg.SetMetadata("trigger_"+key, value)
+		}
+
+		batch.Append(msg)
+	}
+
+	return batch, spec.NoopCallback, nil
+}
+
+// resolve returns the absolute path of the file referenced by the trigger.
+func (r *RetrievalProcessor) resolve(trigger spec.TriggerEvent) (string, error) {
+	p, ok := trigger.Metadata()[MetadataPath].(string)
+	if !ok || p == "" {
+		p = trigger.Reference()
+	}
+
+	if !filepath.IsAbs(p) {
+		if r.root == "" {
+			return "", fmt.Errorf("%w: %q is not an absolute path", spec.ErrPermanent, p)
+		}
+		p = filepath.Join(r.root, p)
+	}
+	p = filepath.Clean(p)
+
+	if r.root != "" {
+		rel, err := filepath.Rel(r.root, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("%w: %s is outside of %s", spec.ErrPermanent, p, r.root)
+		}
+	}
+
+	return p, nil
+}
+
+// fileReader opens the file on the first read, so the files of a batch don't hold a descriptor before their payload
+// is needed.
+type fileReader struct {
+	path       string
+	decompress bool
+	r          io.ReadCloser
+}
+
+func (f *fileReader) Read(p []byte) (int, error) {
+	if f.r == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	return f.r.Read(p)
+}
+
+func (f *fileReader) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.r = file
+	if !f.decompress {
+		return nil
+	}
+
+	algo := compress.Detect(f.path, "")
+	if algo == compress.None {
+		return nil
+	}
+
+	dr, err := compress.NewReader(algo, file)
+	if err != nil {
+		_ = file.Close()
+		f.r = nil
+		return fmt.Errorf("failed to decompress %s: %w", f.path, err)
+	}
+	f.r = dr
+	return nil
+}
+
+func (f *fileReader) Close() error {
+	if f.r == nil {
+		return nil
+	}
+	err := f.r.Close()
+	f.r = nil
+	return err
+}
@@ -0,0 +1,122 @@
+package file_test
+
+import (
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/bundles/file"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("RetrievalProcessor", func() {
+	var (
+		ctx spec.ComponentContext
+		dir string
+	)
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		dir = GinkgoT().TempDir()
+	})
+
+	newProcessor := func(cfg file.RetrievalConfig) *file.RetrievalProcessor {
+		processor := file.NewRetrievalProcessor(cfg)
+		Expect(processor.Init(ctx)).To(Succeed())
+		DeferCleanup(func() {
+			_ = processor.Close(ctx)
+		})
+		return processor
+	}
+
+	triggers := func(references ...string) spec.TriggerBatch {
+		batch := spec.NewTriggerBatch()
+		for _, reference := range references {
+			batch.Append(spec.NewTriggerEvent(spec.TriggerSourceFile, reference, nil))
+		}
+		return batch
+	}
+
+	It("should read the referenced files in order", func() {
+		a := writeFile(dir, "a.txt", "first")
+		b := writeFile(dir, "b.txt", "second")
+
+		batch, _, err := newProcessor(file.RetrievalConfig{}).Retrieve(ctx, triggers(b, a))
+		Expect(err).ToNot(HaveOccurred())
+
+		var payloads []string
+		for _, msg := range batch.Messages() {
+			raw, err := msg.Raw()
+			Expect(err).ToNot(HaveOccurred())
+			payloads = append(payloads, string(raw))
+		}
+		Expect(payloads).To(Equal([]string{"second", "first"}))
+	})
+
+	It("should set the file and trigger metadata", func() {
+		p := writeFile(dir, "a.txt", "hello")
+
+		batch := spec.NewTriggerBatch()
+		batch.Append(spec.NewTriggerEvent(spec.TriggerSourceFile, "ignored", map[string]any{file.MetadataPath: p}))
+
+		result, _, err := newProcessor(file.RetrievalConfig{}).Retrieve(ctx, batch)
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, msg := range result.Messages() {
+			metadata := maps.Collect(msg.Metadata())
+			Expect(metadata).To(HaveKeyWithValue(file.MetadataPath, p))
+			Expect(metadata).To(HaveKeyWithValue(file.MetadataName, "a.txt"))
+			Expect(metadata).To(HaveKeyWithValue(spec.MetadataSize, int64(5)))
+			Expect(metadata).To(HaveKeyWithValue("trigger_source", spec.TriggerSourceFile))
+		}
+	})
+
+	It("should resolve relative references against the root", func() {
+		writeFile(dir, "nested/a.txt", "hello")
+
+		result, _, err := newProcessor(file.RetrievalConfig{Root: dir}).Retrieve(ctx, triggers("nested/a.txt"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Messages()).ToNot(BeEmpty())
+	})
+
+	It("should refuse references outside of the root", func() {
+		outside := writeFile(GinkgoT().TempDir(), "secret.txt", "secret")
+
+		processor := newProcessor(file.RetrievalConfig{Root: dir})
+		for _, reference := range []string{outside, "../" + filepath.Base(outside)} {
+			_, _, err := processor.Retrieve(ctx, triggers(reference))
+			Expect(err).To(MatchError(spec.ErrPermanent))
+		}
+	})
+
+	It("should fail permanently for files which don't exist", func() {
+		_, _, err := newProcessor(file.RetrievalConfig{}).Retrieve(ctx, triggers(filepath.Join(dir, "missing.txt")))
+		Expect(err).To(MatchError(spec.ErrPermanent))
+		Expect(err).To(MatchError(os.ErrNotExist))
+	})
+
+	It("should decompress files while reading them", func() {
+		compressed, err := compress.Compress(compress.Gzip, []byte("hello, compressed"))
+		Expect(err).ToNot(HaveOccurred())
+
+		p := filepath.Join(dir, "a.txt.gz")
+		Expect(os.WriteFile(p, compressed, 0o644)).To(Succeed())
+
+		result, _, err := newProcessor(file.RetrievalConfig{Decompress: true}).Retrieve(ctx, triggers(p))
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, msg := range result.Messages() {
+			r, err := spec.MessageReader(msg)
+			Expect(err).ToNot(HaveOccurred())
+			raw, err := io.ReadAll(r)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.Close()).To(Succeed())
+			Expect(string(raw)).To(Equal("hello, compressed"))
+		}
+	})
+})
@@ -0,0 +1,529 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	TriggerInputComponentName = "file_trigger"
+)
+
+// Metadata keys set on the triggers and on the retrieved messages.
+const (
+	// MetadataPath is the absolute path of the file.
+	MetadataPath = "path"
+	// MetadataName is the path of the file relative to the watched directory, or its name for retrieved files.
+	MetadataName = "name"
+)
+
+// NewTriggerInput creates a new file trigger input
+func NewTriggerInput(env spec.Environment, config TriggerInputConfig) (*TriggerInput, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// -- the durations were checked by Validate
+	pollInterval, _ := parseDuration(config.PollInterval, 0)
+	settle, _ := parseDuration(config.SettleTime, defaultSettleTime)
+
+	if config.Pattern == "" {
+		config.Pattern = defaultPattern
+	}
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = defaultMaxBatchSize
+	}
+
+	return &TriggerInput{
+		config:       config,
+		pollInterval: pollInterval,
+		settle:       settle,
+		log:          env,
+	}, nil
+}
+
+// NewTriggerInputFromConfig creates a file trigger input from a spec.Config interface
+func NewTriggerInputFromConfig(env spec.Environment, config spec.Config) (*TriggerInput, error) {
+	var cfg TriggerInputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode file trigger input config: %w", err)
+	}
+	return NewTriggerInput(env, cfg)
+}
+
+// TriggerInput emits triggers for files created or modified in a directory, like an on-prem drop folder. Changes
+// are picked up through the notifications of the operating system, or by polling the directory if those aren't
+// available. A file is triggered on once it stopped changing for the configured settle time.
+//
+// The triggers reference the absolute path of the file, which the file RetrievalProcessor reads. Once the batch
+// was processed, the file is left in place, deleted or moved depending on whether processing succeeded. A
+// spec.BatchError applies the error action to the failed files only.
+type TriggerInput struct {
+	config       TriggerInputConfig
+	pollInterval time.Duration
+	settle       time.Duration
+
+	// dir is the absolute path of the watched directory, excluded holds the directories files are moved to
+	dir      string
+	excluded []string
+
+	lock     sync.Mutex
+	stopped  chan struct{}
+	changed  chan struct{}
+	pending  map[string]time.Time
+	inflight map[string]bool
+	seen     map[string]fileState
+	watcher  *fsnotify.Watcher
+	wg       sync.WaitGroup
+
+	log spec.Logger
+}
+
+// fileState is what polling compares to detect a modified file.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+func (t *TriggerInput) Init(ctx spec.ComponentContext) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.stopped != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	dir, err := filepath.Abs(t.config.Path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path %s: %w", t.config.Path, err)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to access directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	t.dir = dir
+	t.excluded = nil
+	for _, action := range []AfterProcessing{t.config.OnSuccess, t.config.OnError} {
+		if action.Action == ActionMove {
+			t.excluded = append(t.excluded, t.resolve(action.Directory))
+		}
+	}
+
+	t.stopped = make(chan struct{})
+	t.changed = make(chan struct{})
+	t.pending = make(map[string]time.Time)
+	t.inflight = make(map[string]bool)
+	t.seen = make(map[string]fileState)
+	t.watcher = nil
+
+	// -- watch before scanning, so files created in between aren't missed
+	pollInterval := t.pollInterval
+	if pollInterval == 0 {
+		if t.watcher, err = t.newWatcher(); err != nil {
+			t.log.Warnf("Failed to watch %s, polling it instead: %v", dir, err)
+			pollInterval = defaultPollInterval
+		}
+	}
+
+	t.walk(dir, func(p string, info fs.FileInfo) {
+		t.seen[p] = fileState{size: info.Size(), modTime: info.ModTime()}
+		if !t.config.SkipExisting {
+			// -- files which were there before are considered settled
+			t.pending[p] = time.Time{}
+		}
+	})
+
+	t.wg.Add(1)
+	if t.watcher != nil {
+		go t.watch(t.watcher, t.stopped)
+	} else {
+		go t.poll(pollInterval, t.stopped)
+	}
+
+	ctx.Infof("File trigger input watching %s", dir)
+	return nil
+}
+
+func (t *TriggerInput) Close(ctx spec.ComponentContext) error {
+	t.lock.Lock()
+	if t.stopped == nil {
+		t.lock.Unlock()
+		return nil
+	}
+
+	close(t.stopped)
+	t.stopped = nil
+
+	var err error
+	if t.watcher != nil {
+		err = t.watcher.Close()
+		t.watcher = nil
+	}
+	t.lock.Unlock()
+
+	t.wg.Wait()
+	return err
+}
+
+// ReadTriggers blocks until files have settled, returning at most the configured number of triggers.
+func (t *TriggerInput) ReadTriggers(ctx spec.ComponentContext) (spec.TriggerBatch, spec.ProcessedCallback, error) {
+	for {
+		t.lock.Lock()
+		stopped, changed := t.stopped, t.changed
+		if stopped == nil {
+			t.lock.Unlock()
+			return nil, nil, spec.ErrNotConnected
+		}
+		batch, paths, wait := t.due()
+		t.lock.Unlock()
+
+		if len(paths) > 0 {
+			return batch, t.callback(paths), nil
+		}
+
+		var timeout <-chan time.Time
+		var timer *time.Timer
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+		case <-stopped:
+			return nil, nil, spec.ErrNotConnected
+		case <-ctx.Context().Done():
+			return nil, nil, ctx.Context().Err()
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// due takes the files which have settled from the pending files and returns their triggers. If no file has settled
+// yet, it returns how long to wait for the next one. The caller must hold the lock.
+func (t *TriggerInput) due() (spec.TriggerBatch, []string, time.Duration) {
+	batch := spec.NewTriggerBatch()
+	var paths []string
+	var wait time.Duration
+
+	now := time.Now()
+	for _, p := range slices.Sorted(maps.Keys(t.pending)) {
+		if t.inflight[p] {
+			continue
+		}
+
+		info, err := os.Stat(p)
+		if err != nil || info.IsDir() {
+			// -- the file is gone again
+			delete(t.pending, p)
+			continue
+		}
+
+		changedAt := t.pending[p]
+		if !changedAt.IsZero() && info.ModTime().After(changedAt) && info.ModTime().Before(now) {
+			changedAt = info.ModTime()
+		}
+
+		if remaining := changedAt.Add(t.settle).Sub(now); remaining > 0 {
+			if wait == 0 || remaining < wait {
+				wait = remaining
+			}
+			continue
+		}
+
+		delete(t.pending, p)
+		t.inflight[p] = true
+		paths = append(paths, p)
+		batch.Append(spec.NewTriggerEvent(spec.TriggerSourceFile, p, map[string]any{
+			MetadataPath:           p,
+			MetadataName:           t.relative(p),
+			spec.MetadataSize:      info.Size(),
+			spec.MetadataTimestamp: info.ModTime().Unix(),
+		}))
+
+		if len(paths) >= t.config.MaxBatchSize {
+			break
+		}
+	}
+
+	return batch, paths, wait
+}
+
+// callback applies the after processing actions to the files of a batch.
+func (t *TriggerInput) callback(paths []string) spec.ProcessedCallback {
+	var once sync.Once
+	return func(ctx context.Context, err error) error {
+		var errs []error
+		once.Do(func() {
+			for idx, p := range paths {
+				action := t.config.OnSuccess
+				if msgErr := spec.MessageError(err, idx); msgErr != nil {
+					t.log.Warnf("Failed to process %s: %v", p, msgErr)
+					action = t.config.OnError
+				}
+
+				if err := t.apply(action, p); err != nil {
+					errs = append(errs, err)
+				}
+			}
+
+			t.lock.Lock()
+			for _, p := range paths {
+				delete(t.inflight, p)
+			}
+			t.notify()
+			t.lock.Unlock()
+		})
+		return errors.Join(errs...)
+	}
+}
+
+func (t *TriggerInput) apply(action AfterProcessing, p string) error {
+	switch action.Action {
+	case ActionDelete:
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete %s: %w", p, err)
+		}
+	case ActionMove:
+		target := filepath.Join(t.resolve(action.Directory), filepath.FromSlash(t.relative(p)))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", target, err)
+		}
+		if err := os.Rename(p, target); err != nil {
+			return fmt.Errorf("failed to move %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func (t *TriggerInput) newWatcher() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.addWatches(watcher, t.dir); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// addWatches watches dir and, for recursive inputs, the directories below it.
+func (t *TriggerInput) addWatches(watcher *fsnotify.Watcher, dir string) error {
+	if err := watcher.Add(dir); err != nil {
+		return err
+	}
+
+	if !t.config.Recursive {
+		return nil
+	}
+
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || p == dir {
+			return nil
+		}
+		if t.isExcluded(p) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(p)
+	})
+}
+
+func (t *TriggerInput) watch(watcher *fsnotify.Watcher, stopped <-chan struct{}) {
+	defer t.wg.Done()
+
+	for {
+		select {
+		case <-stopped:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				t.changedPath(watcher, event.Name)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			t.log.Warnf("Error watching %s: %v", t.dir, err)
+		}
+	}
+}
+
+// changedPath records a file reported by the watcher. Directories created below a recursive input are watched,
+// and the files they already contain are recorded as well.
+func (t *TriggerInput) changedPath(watcher *fsnotify.Watcher, p string) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return
+	}
+
+	if !info.IsDir() {
+		if t.matches(p) {
+			t.touch(p)
+		}
+		return
+	}
+
+	if !t.config.Recursive || t.isExcluded(p) {
+		return
+	}
+
+	if err := t.addWatches(watcher, p); err != nil {
+		t.log.Warnf("Failed to watch %s: %v", p, err)
+	}
+	t.walk(p, func(p string, _ fs.FileInfo) {
+		t.touch(p)
+	})
+}
+
+func (t *TriggerInput) poll(interval time.Duration, stopped <-chan struct{}) {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopped:
+			return
+		case <-ticker.C:
+			t.scan()
+		}
+	}
+}
+
+// scan records the files which were created or modified since the last scan.
+func (t *TriggerInput) scan() {
+	current := make(map[string]fileState)
+	t.walk(t.dir, func(p string, info fs.FileInfo) {
+		current[p] = fileState{size: info.Size(), modTime: info.ModTime()}
+	})
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for p, state := range current {
+		if previous, ok := t.seen[p]; !ok || previous != state {
+			t.pending[p] = time.Now()
+			t.notify()
+		}
+	}
+	t.seen = current
+}
+
+// walk calls fn for every file below dir which matches the pattern.
+func (t *TriggerInput) walk(dir string, fn func(p string, info fs.FileInfo)) {
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if d.IsDir() {
+			if p != dir && (!t.config.Recursive || t.isExcluded(p)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() || !t.matches(p) {
+			return nil
+		}
+
+		if info, err := d.Info(); err == nil {
+			fn(p, info)
+		}
+		return nil
+	})
+}
+
+func (t *TriggerInput) touch(p string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.stopped == nil {
+		return
+	}
+
+	t.pending[p] = time.Now()
+	t.notify()
+}
+
+// notify wakes up a ReadTriggers waiting for files. The caller must hold the lock.
+func (t *TriggerInput) notify() {
+	if t.changed == nil {
+		return
+	}
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// matches reports whether the file at p is one the input triggers on.
+func (t *TriggerInput) matches(p string) bool {
+	rel := t.relative(p)
+	if strings.HasPrefix(rel, "../") || t.isExcluded(p) {
+		return false
+	}
+	if !t.config.Recursive && strings.Contains(rel, "/") {
+		return false
+	}
+
+	name := rel
+	if !strings.Contains(t.config.Pattern, "/") {
+		name = path.Base(rel)
+	}
+
+	ok, _ := path.Match(t.config.Pattern, name)
+	return ok
+}
+
+func (t *TriggerInput) isExcluded(p string) bool {
+	for _, dir := range t.excluded {
+		if p == dir || strings.HasPrefix(p, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// relative returns the slash separated path of p relative to the watched directory.
+func (t *TriggerInput) relative(p string) string {
+	rel, err := filepath.Rel(t.dir, p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// resolve returns the absolute path of a directory given relative to the watched directory.
+func (t *TriggerInput) resolve(dir string) string {
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir)
+	}
+	return filepath.Join(t.dir, dir)
+}
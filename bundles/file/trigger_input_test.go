@@ -0,0 +1,226 @@
+package file_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/file"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("TriggerInput", func() {
+	var (
+		ctx spec.ComponentContext
+		dir string
+	)
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		dir = GinkgoT().TempDir()
+	})
+
+	newInput := func(cfg file.TriggerInputConfig) *file.TriggerInput {
+		cfg.Path = dir
+		if cfg.SettleTime == "" {
+			cfg.SettleTime = "50ms"
+		}
+
+		input, err := file.NewTriggerInput(test.TestEnvironment(), cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+		DeferCleanup(func() {
+			_ = input.Close(ctx)
+		})
+		return input
+	}
+
+	read := func(input *file.TriggerInput) ([]spec.TriggerEvent, spec.ProcessedCallback) {
+		readCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		batch, callback, err := input.ReadTriggers(test.NewMockComponentContextWithContext(readCtx))
+		Expect(err).ToNot(HaveOccurred())
+		return batch.Triggers(), callback
+	}
+
+	references := func(triggers []spec.TriggerEvent) []string {
+		var result []string
+		for _, trigger := range triggers {
+			result = append(result, trigger.Reference())
+		}
+		return result
+	}
+
+	Describe("configuration", func() {
+		It("should require a path", func() {
+			_, err := file.NewTriggerInput(test.TestEnvironment(), file.TriggerInputConfig{})
+			Expect(err).To(MatchError(ContainSubstring("path is required")))
+		})
+
+		It("should require a directory to move files to", func() {
+			_, err := file.NewTriggerInput(test.TestEnvironment(), file.TriggerInputConfig{
+				Path:      dir,
+				OnSuccess: file.AfterProcessing{Action: file.ActionMove},
+			})
+			Expect(err).To(MatchError(ContainSubstring("a directory is required")))
+		})
+
+		It("should refuse to watch a file", func() {
+			input, err := file.NewTriggerInput(test.TestEnvironment(), file.TriggerInputConfig{
+				Path: writeFile(dir, "file.txt", "hello"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(input.Init(ctx)).To(MatchError(ContainSubstring("is not a directory")))
+		})
+	})
+
+	for _, mode := range []struct {
+		name         string
+		pollInterval string
+	}{
+		{name: "watching", pollInterval: ""},
+		{name: "polling", pollInterval: "20ms"},
+	} {
+		When(mode.name+" the directory", func() {
+			It("should trigger on the files already in the directory", func() {
+				a := writeFile(dir, "a.csv", "a")
+				b := writeFile(dir, "b.csv", "b")
+
+				input := newInput(file.TriggerInputConfig{PollInterval: mode.pollInterval})
+
+				triggers, _ := read(input)
+				Expect(references(triggers)).To(Equal([]string{a, b}))
+				Expect(triggers[0].Source()).To(Equal(spec.TriggerSourceFile))
+				Expect(triggers[0].Metadata()).To(HaveKeyWithValue(file.MetadataPath, a))
+				Expect(triggers[0].Metadata()).To(HaveKeyWithValue(file.MetadataName, "a.csv"))
+				Expect(triggers[0].Metadata()).To(HaveKeyWithValue(spec.MetadataSize, int64(1)))
+			})
+
+			It("should trigger on created files matching the pattern once they settled", func() {
+				writeFile(dir, "existing.csv", "skipped")
+
+				input := newInput(file.TriggerInputConfig{
+					PollInterval: mode.pollInterval,
+					Pattern:      "*.csv",
+					SkipExisting: true,
+				})
+
+				writeFile(dir, "ignored.txt", "ignored")
+				created := time.Now()
+				p := writeFile(dir, "new.csv", "new")
+
+				triggers, _ := read(input)
+				Expect(references(triggers)).To(Equal([]string{p}))
+				Expect(time.Since(created)).To(BeNumerically(">=", 50*time.Millisecond))
+			})
+
+			It("should trigger on files in subdirectories of a recursive input", func() {
+				input := newInput(file.TriggerInputConfig{
+					PollInterval: mode.pollInterval,
+					Pattern:      "incoming/*.csv",
+					Recursive:    true,
+				})
+
+				writeFile(dir, "other/skipped.csv", "skipped")
+				p := writeFile(dir, "incoming/data.csv", "data")
+
+				triggers, _ := read(input)
+				Expect(references(triggers)).To(Equal([]string{p}))
+				Expect(triggers[0].Metadata()).To(HaveKeyWithValue(file.MetadataName, "incoming/data.csv"))
+			})
+		})
+	}
+
+	It("should move processed files to the done and error directories", func() {
+		writeFile(dir, "a.csv", "a")
+		writeFile(dir, "b.csv", "b")
+
+		input := newInput(file.TriggerInputConfig{
+			OnSuccess: file.AfterProcessing{Action: file.ActionMove, Directory: "done"},
+			OnError:   file.AfterProcessing{Action: file.ActionMove, Directory: "error"},
+		})
+
+		triggers, callback := read(input)
+		Expect(triggers).To(HaveLen(2))
+
+		batchErr := spec.NewBatchError(nil).Failed(1, spec.ErrPermanent)
+		Expect(callback(context.Background(), batchErr)).To(Succeed())
+
+		Expect(filepath.Join(dir, "done", "a.csv")).To(BeARegularFile())
+		Expect(filepath.Join(dir, "error", "b.csv")).To(BeARegularFile())
+		Expect(filepath.Join(dir, "a.csv")).ToNot(BeAnExistingFile())
+		Expect(filepath.Join(dir, "b.csv")).ToNot(BeAnExistingFile())
+
+		// -- the moved files don't trigger again
+		readCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, _, err := input.ReadTriggers(test.NewMockComponentContextWithContext(readCtx))
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("should delete processed files", func() {
+		p := writeFile(dir, "a.csv", "a")
+
+		input := newInput(file.TriggerInputConfig{
+			OnSuccess: file.AfterProcessing{Action: file.ActionDelete},
+		})
+
+		_, callback := read(input)
+		Expect(callback(context.Background(), nil)).To(Succeed())
+		Expect(p).ToNot(BeAnExistingFile())
+	})
+
+	It("should leave failed files in place by default", func() {
+		p := writeFile(dir, "a.csv", "a")
+
+		input := newInput(file.TriggerInputConfig{
+			OnSuccess: file.AfterProcessing{Action: file.ActionDelete},
+		})
+
+		_, callback := read(input)
+		Expect(callback(context.Background(), os.ErrInvalid)).To(Succeed())
+		Expect(p).To(BeARegularFile())
+	})
+
+	It("should trigger on a file again once it changed after processing", func() {
+		p := writeFile(dir, "a.csv", "a")
+
+		input := newInput(file.TriggerInputConfig{})
+
+		_, callback := read(input)
+		Expect(callback(context.Background(), nil)).To(Succeed())
+
+		writeFile(dir, "a.csv", "changed")
+		triggers, _ := read(input)
+		Expect(references(triggers)).To(Equal([]string{p}))
+	})
+
+	It("should limit the number of triggers per batch", func() {
+		for _, name := range []string{"a", "b", "c"} {
+			writeFile(dir, name, name)
+		}
+
+		input := newInput(file.TriggerInputConfig{MaxBatchSize: 2})
+
+		triggers, _ := read(input)
+		Expect(triggers).To(HaveLen(2))
+		triggers, _ = read(input)
+		Expect(triggers).To(HaveLen(1))
+	})
+
+	It("should refuse to initialize twice and report being closed", func() {
+		input := newInput(file.TriggerInputConfig{})
+		Expect(input.Init(ctx)).To(MatchError(spec.ErrAlreadyConnected))
+
+		Expect(input.Close(ctx)).To(Succeed())
+		Expect(input.Close(ctx)).To(Succeed())
+
+		_, _, err := input.ReadTriggers(ctx)
+		Expect(err).To(MatchError(spec.ErrNotConnected))
+	})
+})
@@ -0,0 +1,30 @@
+package generate_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+func TestGenerate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Generate Suite")
+}
+
+func expression(s string) spec.Expression {
+	expr, err := spec.NewExprLangExpression(s)
+	Expect(err).ToNot(HaveOccurred())
+	return expr
+}
+
+func payloads(batch spec.Batch) []string {
+	var result []string
+	for _, msg := range batch.Messages() {
+		raw, err := msg.Raw()
+		Expect(err).ToNot(HaveOccurred())
+		result = append(result, string(raw))
+	}
+	return result
+}
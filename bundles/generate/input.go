@@ -0,0 +1,137 @@
+package generate
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	InputComponentName = "generate"
+)
+
+type InputConfig struct {
+	ScheduleConfig `mapstructure:",squash"`
+
+	// The expression producing the payload of each message. Besides the usual functions, the expression can use
+	// counter, the number of the message starting at 1, and timestamp, the time the batch was emitted.
+	Mapping spec.Expression `json:"mapping" yaml:"mapping" mapstructure:"mapping"`
+
+	// Expressions producing the metadata of each message, evaluated like the mapping.
+	Metadata map[string]spec.Expression `json:"metadata,omitempty" yaml:"metadata,omitempty" mapstructure:"metadata,omitempty"`
+
+	// The number of messages in each batch. Defaults to 1.
+	BatchSize int `json:"batch_size,omitempty" yaml:"batch_size,omitempty" mapstructure:"batch_size,omitempty"`
+}
+
+// NewInput creates a new generate input
+func NewInput(env spec.Environment, config InputConfig) (*Input, error) {
+	if config.Mapping == nil {
+		return nil, fmt.Errorf("mapping is required")
+	}
+
+	schedule, err := newScheduler(config.ScheduleConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Input{
+		config:   config,
+		schedule: schedule,
+		log:      env,
+	}, nil
+}
+
+// NewInputFromConfig creates a generate input from a spec.Config interface
+func NewInputFromConfig(env spec.Environment, config spec.Config) (*Input, error) {
+	var cfg InputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode generate input config: %w", err)
+	}
+	return NewInput(env, cfg)
+}
+
+// Input emits messages produced by an expression, on an interval, a cron schedule or as fast as they are read, and
+// optionally only a fixed number of times. It serves load tests, heartbeats and periodic jobs.
+//
+// Read blocks until the next batch is due. Once the configured number of batches has been emitted, Read returns
+// spec.ErrEndOfInput. There is nothing to acknowledge, so failed batches are not generated again.
+type Input struct {
+	config   InputConfig
+	schedule *scheduler
+
+	lock    sync.Mutex
+	stopped chan struct{}
+	counter int
+
+	log spec.Logger
+}
+
+func (i *Input) Init(ctx spec.ComponentContext) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.stopped != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	i.stopped = make(chan struct{})
+	i.counter = 0
+	i.schedule.start()
+	return nil
+}
+
+func (i *Input) Close(ctx spec.ComponentContext) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.stopped != nil {
+		close(i.stopped)
+		i.stopped = nil
+	}
+	return nil
+}
+
+func (i *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
+	i.lock.Lock()
+	stopped := i.stopped
+	i.lock.Unlock()
+
+	if stopped == nil {
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	emitted, err := i.schedule.wait(ctx.Context(), stopped)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batch := ctx.NewBatch()
+	for idx := range max(i.config.BatchSize, 1) {
+		i.lock.Lock()
+		i.counter++
+		exprCtx := spec.ExpressionContext{"counter": i.counter, "timestamp": emitted}
+		i.lock.Unlock()
+
+		payload, err := i.config.Mapping.Eval(exprCtx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("batch #%d: mapping: %w", idx, err)
+		}
+
+		msg := ctx.NewMessage()
+		msg.SetRaw([]byte(payload))
+
+		for key, expr := range i.config.Metadata {
+			value, err := expr.Eval(exprCtx)
+			if err != nil {
+				return nil, nil, fmt.Errorf("batch #%d: metadata %s: %w", idx, key, err)
+			}
+			msg.SetMetadata(key, value)
+		}
+
+		batch.Append(msg)
+	}
+
+	return batch, spec.NoopCallback, nil
+}
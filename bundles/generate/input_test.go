@@ -0,0 +1,192 @@
+package generate_test
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/generate"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("Input", func() {
+	var ctx spec.ComponentContext
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+	})
+
+	newInput := func(cfg generate.InputConfig) *generate.Input {
+		input, err := generate.NewInput(test.TestEnvironment(), cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+		DeferCleanup(func() {
+			_ = input.Close(ctx)
+		})
+		return input
+	}
+
+	When("creating an input", func() {
+		It("should require a mapping", func() {
+			_, err := generate.NewInput(test.TestEnvironment(), generate.InputConfig{})
+			Expect(err).To(MatchError(ContainSubstring("mapping is required")))
+		})
+
+		It("should refuse both an interval and a cron expression", func() {
+			_, err := generate.NewInput(test.TestEnvironment(), generate.InputConfig{
+				ScheduleConfig: generate.ScheduleConfig{Interval: "1s", Cron: "* * * * *"},
+				Mapping:        expression("hello"),
+			})
+			Expect(err).To(MatchError(ContainSubstring("can't be combined")))
+		})
+
+		It("should refuse an invalid cron expression", func() {
+			_, err := generate.NewInput(test.TestEnvironment(), generate.InputConfig{
+				ScheduleConfig: generate.ScheduleConfig{Cron: "every tuesday"},
+				Mapping:        expression("hello"),
+			})
+			Expect(err).To(MatchError(ContainSubstring("failed to parse cron expression")))
+		})
+
+		It("should decode the schedule from a config", func() {
+			input, err := generate.NewInputFromConfig(test.TestEnvironment(), spec.NewMapConfig(map[string]any{
+				"interval": "10ms",
+				"count":    1,
+				"mapping":  expression("hello"),
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(input.Init(ctx)).To(Succeed())
+
+			batch, _, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(payloads(batch)).To(Equal([]string{"hello"}))
+
+			_, _, err = input.Read(ctx)
+			Expect(err).To(MatchError(spec.ErrEndOfInput))
+		})
+	})
+
+	When("a count is configured", func() {
+		It("should end the input after the configured number of batches", func() {
+			input := newInput(generate.InputConfig{
+				ScheduleConfig: generate.ScheduleConfig{Count: 2},
+				Mapping:        expression(`msg-${! counter }`),
+				BatchSize:      2,
+			})
+
+			batch, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(payloads(batch)).To(Equal([]string{"msg-1", "msg-2"}))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+
+			batch, _, err = input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(payloads(batch)).To(Equal([]string{"msg-3", "msg-4"}))
+
+			_, _, err = input.Read(ctx)
+			Expect(err).To(MatchError(spec.ErrEndOfInput))
+		})
+	})
+
+	When("an interval is configured", func() {
+		It("should emit the first batch right away and wait for the next", func() {
+			input := newInput(generate.InputConfig{
+				ScheduleConfig: generate.ScheduleConfig{Interval: "200ms"},
+				Mapping:        expression("tick"),
+			})
+
+			start := time.Now()
+			_, _, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically("<", 100*time.Millisecond))
+
+			_, _, err = input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+		})
+
+		It("should unblock a read when its context is cancelled", func() {
+			input := newInput(generate.InputConfig{
+				ScheduleConfig: generate.ScheduleConfig{Interval: "1h"},
+				Mapping:        expression("tick"),
+			})
+
+			_, _, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			readCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			_, _, err = input.Read(test.NewMockComponentContextWithContext(readCtx))
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+
+		It("should unblock a read when the input is closed", func() {
+			input := newInput(generate.InputConfig{
+				ScheduleConfig: generate.ScheduleConfig{Interval: "1h"},
+				Mapping:        expression("tick"),
+			})
+
+			_, _, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			time.AfterFunc(50*time.Millisecond, func() {
+				_ = input.Close(ctx)
+			})
+
+			_, _, err = input.Read(ctx)
+			Expect(err).To(MatchError(spec.ErrNotConnected))
+		})
+	})
+
+	When("a cron expression is configured", func() {
+		It("should emit batches at the matching times", func() {
+			input := newInput(generate.InputConfig{
+				ScheduleConfig: generate.ScheduleConfig{Cron: "* * * * * *", Count: 2},
+				Mapping:        expression("tick"),
+			})
+
+			var emitted []time.Time
+			for range 2 {
+				_, _, err := input.Read(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				emitted = append(emitted, time.Now())
+			}
+
+			Expect(emitted[1].Truncate(time.Second)).To(BeTemporally(">", emitted[0].Truncate(time.Second)))
+		})
+	})
+
+	It("should evaluate the metadata of each message", func() {
+		input := newInput(generate.InputConfig{
+			Mapping: expression("hello"),
+			Metadata: map[string]spec.Expression{
+				"index": expression(`${! counter * 10 }`),
+				"kind":  expression("synthetic"),
+			},
+		})
+
+		batch, _, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		for _, msg := range batch.Messages() {
+			Expect(maps.Collect(msg.Metadata())).To(Equal(map[string]any{"index": "10", "kind": "synthetic"}))
+		}
+	})
+
+	It("should refuse to initialize twice", func() {
+		input := newInput(generate.InputConfig{Mapping: expression("hello")})
+		Expect(input.Init(ctx)).To(MatchError(spec.ErrAlreadyConnected))
+	})
+
+	It("should report the input as disconnected after closing it", func() {
+		input := newInput(generate.InputConfig{Mapping: expression("hello")})
+		Expect(input.Close(ctx)).To(Succeed())
+		Expect(input.Close(ctx)).To(Succeed())
+
+		_, _, err := input.Read(ctx)
+		Expect(err).To(MatchError(spec.ErrNotConnected))
+	})
+})
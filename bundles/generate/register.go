@@ -0,0 +1,56 @@
+package generate
+
+import (
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const inputSchema = `{
+	"type": "object",
+	"properties": {
+		"interval": {"type": "string", "description": "The time between two batches, in Go duration format.", "examples": ["500ms", "1m"]},
+		"cron": {"type": "string", "description": "A cron expression for when to emit batches, with an optional leading seconds field, or a descriptor like @hourly.", "examples": ["*/5 * * * *", "@every 10s"]},
+		"count": {"type": "integer", "minimum": 0, "description": "The number of batches to emit before the input ends. Zero keeps emitting batches."},
+		"mapping": {"type": "string", "description": "The expression producing the payload of each message. It can use counter and timestamp.", "examples": ["${! counter }"]},
+		"metadata": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Expressions producing the metadata of each message."},
+		"batch_size": {"type": "integer", "minimum": 1, "default": 1, "description": "The number of messages in each batch."}
+	},
+	"required": ["mapping"],
+	"additionalProperties": false
+}`
+
+const triggerInputSchema = `{
+	"type": "object",
+	"properties": {
+		"interval": {"type": "string", "description": "The time between two batches, in Go duration format.", "examples": ["500ms", "1m"]},
+		"cron": {"type": "string", "description": "A cron expression for when to emit batches, with an optional leading seconds field, or a descriptor like @hourly.", "examples": ["*/5 * * * *", "@every 10s"]},
+		"count": {"type": "integer", "minimum": 0, "description": "The number of batches to emit before the input ends. Zero keeps emitting batches."},
+		"reference": {"type": "string", "description": "The expression producing the reference of each trigger. It can use counter and timestamp.", "examples": ["reports/${! counter }.json"]},
+		"metadata": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Expressions producing the metadata of each trigger."},
+		"batch_size": {"type": "integer", "minimum": 1, "default": 1, "description": "The number of triggers in each batch."}
+	},
+	"required": ["reference"],
+	"additionalProperties": false
+}`
+
+// Register adds the generate input and trigger input to the registry.
+func Register(r *registry.Registry) error {
+	err := r.RegisterInput(registry.Spec{
+		Name:    InputComponentName,
+		Summary: "Emits messages produced by an expression on an interval or a cron schedule.",
+		Schema:  inputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.Input, error) {
+		return NewInputFromConfig(env, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterTriggerInput(registry.Spec{
+		Name:    TriggerInputComponentName,
+		Summary: "Emits triggers with references produced by an expression on an interval or a cron schedule.",
+		Schema:  triggerInputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.TriggerInput, error) {
+		return NewTriggerInputFromConfig(env, cfg)
+	})
+}
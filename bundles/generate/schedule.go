@@ -0,0 +1,120 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// ScheduleConfig controls when the generate components emit a batch. Without an interval or cron expression batches
+// are emitted as fast as they are read.
+type ScheduleConfig struct {
+	// The time between two batches, in Go duration format (e.g. "500ms", "1m"). The first batch is emitted right away.
+	Interval string `json:"interval,omitempty" yaml:"interval,omitempty" mapstructure:"interval,omitempty"`
+
+	// A cron expression for when to emit batches, with an optional leading seconds field (e.g. "*/5 * * * *",
+	// "0 0 * * * *") or a descriptor like "@hourly" or "@every 10s". Can't be combined with an interval.
+	Cron string `json:"cron,omitempty" yaml:"cron,omitempty" mapstructure:"cron,omitempty"`
+
+	// The number of batches to emit before the input ends. Zero keeps emitting batches.
+	Count int `json:"count,omitempty" yaml:"count,omitempty" mapstructure:"count,omitempty"`
+}
+
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// scheduler decides when the next batch is due and counts the batches emitted.
+type scheduler struct {
+	interval time.Duration
+	cron     cron.Schedule
+	count    int
+
+	lock    sync.Mutex
+	next    time.Time
+	emitted int
+}
+
+func newScheduler(cfg ScheduleConfig) (*scheduler, error) {
+	if cfg.Interval != "" && cfg.Cron != "" {
+		return nil, fmt.Errorf("interval and cron can't be combined")
+	}
+
+	if cfg.Count < 0 {
+		return nil, fmt.Errorf("count must not be negative")
+	}
+
+	s := &scheduler{count: cfg.Count}
+
+	if cfg.Interval != "" {
+		interval, err := time.ParseDuration(cfg.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse interval: %w", err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("interval must be positive")
+		}
+		s.interval = interval
+	}
+
+	if cfg.Cron != "" {
+		schedule, err := cronParser.Parse(cfg.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cron expression: %w", err)
+		}
+		s.cron = schedule
+	}
+
+	return s, nil
+}
+
+// start resets the schedule, making the first batch due at the first matching time from now.
+func (s *scheduler) start() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.emitted = 0
+	s.next = time.Now()
+	if s.cron != nil {
+		s.next = s.cron.Next(s.next)
+	}
+}
+
+// wait blocks until the next batch is due and returns the time it was emitted at. It returns spec.ErrEndOfInput once
+// all batches have been emitted, and spec.ErrNotConnected when stopped is closed.
+func (s *scheduler) wait(ctx context.Context, stopped <-chan struct{}) (time.Time, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.count > 0 && s.emitted >= s.count {
+		return time.Time{}, spec.ErrEndOfInput
+	}
+
+	if delay := time.Until(s.next); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-stopped:
+			return time.Time{}, spec.ErrNotConnected
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		}
+	}
+
+	now := time.Now()
+	switch {
+	case s.cron != nil:
+		s.next = s.cron.Next(now)
+	case s.interval > 0:
+		s.next = now.Add(s.interval)
+	}
+
+	s.emitted++
+	return now, nil
+}
@@ -0,0 +1,137 @@
+package generate
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	TriggerInputComponentName = "generate_trigger"
+)
+
+type TriggerInputConfig struct {
+	ScheduleConfig `mapstructure:",squash"`
+
+	// The expression producing the reference of each trigger, like bucket/key for the S3 retrieval processor. The
+	// expression can use counter, the number of the trigger starting at 1, and timestamp, the time the batch was
+	// emitted.
+	Reference spec.Expression `json:"reference" yaml:"reference" mapstructure:"reference"`
+
+	// Expressions producing the metadata of each trigger, evaluated like the reference. Use the common metadata keys,
+	// e.g. bucket and key, to reference data for the retrieval processors.
+	Metadata map[string]spec.Expression `json:"metadata,omitempty" yaml:"metadata,omitempty" mapstructure:"metadata,omitempty"`
+
+	// The number of triggers in each batch. Defaults to 1.
+	BatchSize int `json:"batch_size,omitempty" yaml:"batch_size,omitempty" mapstructure:"batch_size,omitempty"`
+}
+
+// NewTriggerInput creates a new generate trigger input
+func NewTriggerInput(env spec.Environment, config TriggerInputConfig) (*TriggerInput, error) {
+	if config.Reference == nil {
+		return nil, fmt.Errorf("reference is required")
+	}
+
+	schedule, err := newScheduler(config.ScheduleConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TriggerInput{
+		config:   config,
+		schedule: schedule,
+		log:      env,
+	}, nil
+}
+
+// NewTriggerInputFromConfig creates a generate trigger input from a spec.Config interface
+func NewTriggerInputFromConfig(env spec.Environment, config spec.Config) (*TriggerInput, error) {
+	var cfg TriggerInputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode generate trigger input config: %w", err)
+	}
+	return NewTriggerInput(env, cfg)
+}
+
+// TriggerInput emits synthetic trigger events on a schedule, e.g. to have a retrieval processor poll the same object
+// periodically. The triggers use spec.TriggerSourceGenerate as their source.
+//
+// ReadTriggers blocks until the next batch is due, and returns spec.ErrEndOfInput once the configured number of
+// batches has been emitted.
+type TriggerInput struct {
+	config   TriggerInputConfig
+	schedule *scheduler
+
+	lock    sync.Mutex
+	stopped chan struct{}
+	counter int
+
+	log spec.Logger
+}
+
+func (t *TriggerInput) Init(ctx spec.ComponentContext) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.stopped != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	t.stopped = make(chan struct{})
+	t.counter = 0
+	t.schedule.start()
+	return nil
+}
+
+func (t *TriggerInput) Close(ctx spec.ComponentContext) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.stopped != nil {
+		close(t.stopped)
+		t.stopped = nil
+	}
+	return nil
+}
+
+func (t *TriggerInput) ReadTriggers(ctx spec.ComponentContext) (spec.TriggerBatch, spec.ProcessedCallback, error) {
+	t.lock.Lock()
+	stopped := t.stopped
+	t.lock.Unlock()
+
+	if stopped == nil {
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	emitted, err := t.schedule.wait(ctx.Context(), stopped)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batch := spec.NewTriggerBatch()
+	for idx := range max(t.config.BatchSize, 1) {
+		t.lock.Lock()
+		t.counter++
+		exprCtx := spec.ExpressionContext{"counter": t.counter, "timestamp": emitted}
+		t.lock.Unlock()
+
+		reference, err := t.config.Reference.Eval(exprCtx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("batch #%d: reference: %w", idx, err)
+		}
+
+		metadata := make(map[string]any, len(t.config.Metadata))
+		for key, expr := range t.config.Metadata {
+			value, err := expr.Eval(exprCtx)
+			if err != nil {
+				return nil, nil, fmt.Errorf("batch #%d: metadata %s: %w", idx, key, err)
+			}
+			metadata[key] = value
+		}
+
+		batch.Append(spec.NewTriggerEvent(spec.TriggerSourceGenerate, reference, metadata))
+	}
+
+	return batch, spec.NoopCallback, nil
+}
@@ -0,0 +1,67 @@
+package generate_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/generate"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("TriggerInput", func() {
+	var ctx spec.ComponentContext
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+	})
+
+	It("should require a reference", func() {
+		_, err := generate.NewTriggerInput(test.TestEnvironment(), generate.TriggerInputConfig{})
+		Expect(err).To(MatchError(ContainSubstring("reference is required")))
+	})
+
+	It("should emit trigger events referencing the generated objects", func() {
+		input, err := generate.NewTriggerInput(test.TestEnvironment(), generate.TriggerInputConfig{
+			ScheduleConfig: generate.ScheduleConfig{Count: 1},
+			Reference:      expression(`reports/daily-${! counter }`),
+			Metadata: map[string]spec.Expression{
+				spec.MetadataBucket: expression("reports"),
+				spec.MetadataKey:    expression(`daily-${! counter }`),
+			},
+			BatchSize: 2,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+		defer func() {
+			_ = input.Close(ctx)
+		}()
+
+		batch, _, err := input.ReadTriggers(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		triggers := batch.Triggers()
+		Expect(triggers).To(HaveLen(2))
+		for idx, reference := range []string{"reports/daily-1", "reports/daily-2"} {
+			Expect(triggers[idx].Source()).To(Equal(spec.TriggerSourceGenerate))
+			Expect(triggers[idx].Reference()).To(Equal(reference))
+			Expect(triggers[idx].Metadata()).To(HaveKeyWithValue(spec.MetadataBucket, "reports"))
+		}
+		Expect(triggers[1].Metadata()).To(HaveKeyWithValue(spec.MetadataKey, "daily-2"))
+
+		_, _, err = input.ReadTriggers(ctx)
+		Expect(err).To(MatchError(spec.ErrEndOfInput))
+	})
+
+	It("should report the input as disconnected after closing it", func() {
+		input, err := generate.NewTriggerInput(test.TestEnvironment(), generate.TriggerInputConfig{
+			Reference: expression("bucket/key"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+		Expect(input.Init(ctx)).To(MatchError(spec.ErrAlreadyConnected))
+		Expect(input.Close(ctx)).To(Succeed())
+
+		_, _, err = input.ReadTriggers(ctx)
+		Expect(err).To(MatchError(spec.ErrNotConnected))
+	})
+})
@@ -0,0 +1,59 @@
+//go:build mqclient
+
+package ibm_mq_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/gomega"
+	ibm_mq "github.com/wombatwisdom/components/bundles/ibm-mq"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+// conformanceQueue defines the local queue for a conformance target. The developer defaults only grant the app user
+// access to the queues below DEV, so the queue is named after the target within that namespace.
+func conformanceQueue(target string) string {
+	queue := "DEV." + target
+
+	code, _, err := ibmMQContainer.Exec(context.Background(), []string{
+		"bash", "-c", fmt.Sprintf(`echo "DEFINE QLOCAL('%s') NOREPLACE" | runmqsc QM1`, queue),
+	})
+	Expect(err).ToNot(HaveOccurred())
+	// -- runmqsc exits with 10 when the queue was already defined for the other component of the spec
+	Expect(code).To(BeElementOf(0, 10))
+
+	return queue
+}
+
+var commonMQConfig = ibm_mq.CommonMQConfig{
+	QueueManagerName: "QM1",
+	ConnectionName:   "",         // fallback to MQSERVER env var
+	UserId:           "app",      // testcontainer default
+	Password:         "passw0rd", // testcontainer default
+}
+
+var _ = test.DescribeConformance("IBM MQ", test.Conformance{
+	NewInput: func(_ spec.System, target string) (spec.Input, error) {
+		return ibm_mq.NewInput(test.TestEnvironment(), ibm_mq.InputConfig{
+			CommonMQConfig: commonMQConfig,
+			QueueName:      conformanceQueue(target),
+		})
+	},
+	NewOutput: func(_ spec.System, target string) (spec.Output, error) {
+		queue, err := spec.NewExprLangExpression(conformanceQueue(target))
+		if err != nil {
+			return nil, err
+		}
+
+		return ibm_mq.NewOutput(test.TestEnvironment(), ibm_mq.OutputConfig{
+			CommonMQConfig: commonMQConfig,
+			QueueExpr:      queue,
+		})
+	},
+	// -- the input only exposes the fields of the message descriptor as metadata, it doesn't read message properties
+	Metadata: false,
+	// -- a failed batch is backed out, so the queue manager hands it out again
+	Redelivery: true,
+})
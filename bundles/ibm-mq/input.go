@@ -17,13 +17,28 @@ import (
 
 const (
 	InputComponentName = "mq"
+
+	defaultMaxRedeliveryHold = 10 * time.Second
 )
 
+// NewInputFromConfig creates an MQ input from a spec.Config interface
+func NewInputFromConfig(env spec.Environment, config spec.Config) (*Input, error) {
+	var cfg InputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode mq input config: %w", err)
+	}
+	if cfg.QueueName == "" {
+		return nil, fmt.Errorf("a queue name is required")
+	}
+	return NewInput(env, cfg)
+}
+
 // NewInput creates a new MQ input component
 func NewInput(env spec.Environment, config InputConfig) (*Input, error) {
 	return &Input{
-		env: env,
-		cfg: config,
+		env:      env,
+		cfg:      config,
+		inflight: spec.NewInFlight(),
 	}, nil
 }
 
@@ -36,6 +51,9 @@ type Input struct {
 	qObject ibmmq.MQObject
 	mqLock  sync.Mutex
 
+	// inflight tracks the batches which haven't been committed or backed out yet
+	inflight *spec.InFlight
+
 	initialized bool
 }
 
@@ -136,19 +154,32 @@ func (i *Input) Init(ctx spec.ComponentContext) error {
 		return fmt.Errorf("failed to open queue %s: %w", i.cfg.QueueName, err)
 	}
 	i.qObject = qObject
+	i.inflight = spec.NewInFlight()
 	i.initialized = true
 
 	return nil
 }
 
+// Drain stops the input from reading new messages and waits until the batches which were already read have been
+// committed or backed out by their callbacks.
+func (i *Input) Drain(ctx context.Context) error {
+	i.inflight.Drain()
+	return i.inflight.Wait(ctx)
+}
+
+// Close disconnects from the queue manager. Batches which are still in flight are backed out so their messages are
+// redelivered, call Drain first to give them the chance to be committed.
 func (i *Input) Close(ctx spec.ComponentContext) error {
 	if !i.initialized {
 		return nil
 	}
 
 	i.mqLock.Lock()
-	if err := i.qmgr.Back(); err != nil {
-		i.env.Errorf("Failed to rollback transaction: %v", err)
+	if pending := i.inflight.Count(); pending > 0 {
+		i.env.Warnf("Backing out %d batches which are still in flight", pending)
+		if err := i.qmgr.Back(); err != nil {
+			i.env.Errorf("Failed to rollback transaction: %v", err)
+		}
 	}
 	i.mqLock.Unlock()
 
@@ -164,11 +195,36 @@ func (i *Input) Close(ctx spec.ComponentContext) error {
 	return nil
 }
 
+// hold delays backing out a failed batch, since MQ would redeliver it right away. The delay is bounded by
+// MaxRedeliveryHold, and cut short when either context is done.
+func (i *Input) hold(ackCtx, readCtx context.Context, delay time.Duration) {
+	limit := defaultMaxRedeliveryHold
+	if i.cfg.MaxRedeliveryHold != "" {
+		if duration, err := time.ParseDuration(i.cfg.MaxRedeliveryHold); err == nil && duration >= 0 {
+			limit = duration
+		}
+	}
+
+	delay = min(delay, limit)
+	if delay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ackCtx.Done():
+	case <-readCtx.Done():
+	}
+}
+
 func (i *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
 	i.mqLock.Lock()
 	defer i.mqLock.Unlock()
 
-	if !i.initialized {
+	if !i.initialized || i.inflight.Draining() {
 		return nil, nil, spec.ErrNotConnected
 	}
 
@@ -189,8 +245,9 @@ func (i *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallb
 		}
 	}
 
-	// Collect messages for the batch
+	// Collect messages for the batch, along with how often each was backed out before
 	var messages []spec.Message
+	var backouts []int32
 	buffer := make([]byte, 32768)
 
 	// Try to get first message WITHOUT waiting (let Benthos handle retry/backoff)
@@ -219,9 +276,11 @@ func (i *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallb
 	msg.SetMetadata("mq_message_id", string(mqmd.MsgId))
 	msg.SetMetadata("mq_correlation_id", string(mqmd.CorrelId))
 	msg.SetMetadata("mq_format", mqmd.Format)
+	msg.SetMetadata("mq_ccsid", fmt.Sprintf("%d", mqmd.CodedCharSetId))
 	msg.SetMetadata("mq_priority", fmt.Sprintf("%d", mqmd.Priority))
 	msg.SetMetadata("mq_persistence", fmt.Sprintf("%d", mqmd.Persistence))
 	messages = append(messages, msg)
+	backouts = append(backouts, mqmd.BackoutCount)
 
 	// Now try to collect more messages within batch_wait_time
 	batchStartTime := time.Now()
@@ -268,10 +327,12 @@ func (i *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallb
 		msg.SetMetadata("mq_message_id", string(mqmd.MsgId))
 		msg.SetMetadata("mq_correlation_id", string(mqmd.CorrelId))
 		msg.SetMetadata("mq_format", mqmd.Format)
+		msg.SetMetadata("mq_ccsid", fmt.Sprintf("%d", mqmd.CodedCharSetId))
 		msg.SetMetadata("mq_priority", fmt.Sprintf("%d", mqmd.Priority))
 		msg.SetMetadata("mq_persistence", fmt.Sprintf("%d", mqmd.Persistence))
 
 		messages = append(messages, msg)
+		backouts = append(backouts, mqmd.BackoutCount)
 	}
 
 	// Create batch with all collected messages
@@ -287,10 +348,19 @@ func (i *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallb
 		}
 
 		if ackErr != nil {
+			// -- the whole batch is backed out, so it is held for the longest delay any of its failed messages needs
+			var delay time.Duration
+			for idx, count := range backouts {
+				if msgErr := spec.MessageError(ackErr, idx); msgErr != nil {
+					delay = max(delay, spec.RedeliveryDelay(msgErr, i.cfg.Redelivery, int(count)+1))
+				}
+			}
+
+			i.hold(ackCtx, ctx.Context(), delay)
 			return i.qmgr.Back()
 		}
 		return i.qmgr.Cmit()
 	}
 
-	return batch, ackFn, nil
+	return batch, i.inflight.Track(ackFn), nil
 }
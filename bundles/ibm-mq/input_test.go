@@ -3,6 +3,8 @@
 package ibm_mq_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -129,6 +131,122 @@ var _ = Describe("Input", func() {
 		})
 	})
 
+	When("draining the input", func() {
+		It("should commit the batches in flight before closing", func() {
+			clearQueue()
+
+			cno := ibmmq.NewMQCNO()
+			csp := ibmmq.NewMQCSP()
+			csp.AuthenticationType = ibmmq.MQCSP_AUTH_USER_ID_AND_PWD
+			csp.UserId = "app"
+			csp.Password = "passw0rd" // #nosec G101 - testcontainer default credential
+			cno.SecurityParms = csp
+
+			qMgr, err := ibmmq.Connx("QM1", cno)
+			Expect(err).ToNot(HaveOccurred())
+			defer qMgr.Disc()
+
+			mqod := ibmmq.NewMQOD()
+			mqod.ObjectType = ibmmq.MQOT_Q
+			mqod.ObjectName = "DEV.QUEUE.1"
+
+			qObj, err := qMgr.Open(mqod, ibmmq.MQOO_OUTPUT)
+			Expect(err).ToNot(HaveOccurred())
+			defer qObj.Close(ibmmq.MQCO_NONE)
+
+			pmo := ibmmq.NewMQPMO()
+			pmo.Options = ibmmq.MQPMO_NO_SYNCPOINT
+			Expect(qObj.Put(ibmmq.NewMQMD(), pmo, []byte("drain test"))).To(Succeed())
+
+			_, ackFn, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			drained := make(chan error, 1)
+			go func() { drained <- input.Drain(context.Background()) }()
+			Consistently(drained, 100*time.Millisecond).ShouldNot(Receive())
+
+			_, _, err = input.Read(ctx)
+			Expect(err).To(MatchError(spec.ErrNotConnected))
+
+			Expect(ackFn(ctx.Context(), nil)).To(Succeed())
+			Eventually(drained).Should(Receive(BeNil()))
+			Expect(input.Close(ctx)).To(Succeed())
+
+			// -- the message was committed, so a new input doesn't receive it again
+			input, _ = ibm_mq.NewInput(test.TestEnvironment(), ibm_mq.InputConfig{
+				CommonMQConfig: ibm_mq.CommonMQConfig{
+					QueueManagerName: "QM1",
+					UserId:           "app",
+					Password:         "passw0rd", // #nosec G101 - testcontainer default credential
+				},
+				QueueName: "DEV.QUEUE.1",
+			})
+			Expect(input.Init(ctx)).To(Succeed())
+
+			_, _, err = input.Read(ctx)
+			Expect(err).To(MatchError(spec.ErrNoData))
+		})
+	})
+
+	When("processing a batch fails", func() {
+		It("should hold the batch before backing it out", func() {
+			clearQueue()
+			Expect(input.Close(ctx)).To(Succeed())
+
+			input, _ = ibm_mq.NewInput(test.TestEnvironment(), ibm_mq.InputConfig{
+				CommonMQConfig: ibm_mq.CommonMQConfig{
+					QueueManagerName: "QM1",
+					UserId:           "app",
+					Password:         "passw0rd", // #nosec G101 - testcontainer default credential
+				},
+				QueueName:         "DEV.QUEUE.1",
+				Redelivery:        &spec.Backoff{Initial: 5 * time.Second},
+				MaxRedeliveryHold: "500ms",
+			})
+			Expect(input.Init(ctx)).To(Succeed())
+
+			cno := ibmmq.NewMQCNO()
+			csp := ibmmq.NewMQCSP()
+			csp.AuthenticationType = ibmmq.MQCSP_AUTH_USER_ID_AND_PWD
+			csp.UserId = "app"
+			csp.Password = "passw0rd" // #nosec G101 - testcontainer default credential
+			cno.SecurityParms = csp
+
+			qMgr, err := ibmmq.Connx("QM1", cno)
+			Expect(err).ToNot(HaveOccurred())
+			defer qMgr.Disc()
+
+			mqod := ibmmq.NewMQOD()
+			mqod.ObjectType = ibmmq.MQOT_Q
+			mqod.ObjectName = "DEV.QUEUE.1"
+
+			qObj, err := qMgr.Open(mqod, ibmmq.MQOO_OUTPUT)
+			Expect(err).ToNot(HaveOccurred())
+			defer qObj.Close(ibmmq.MQCO_NONE)
+
+			pmo := ibmmq.NewMQPMO()
+			pmo.Options = ibmmq.MQPMO_NO_SYNCPOINT
+			Expect(qObj.Put(ibmmq.NewMQMD(), pmo, []byte("hold test"))).To(Succeed())
+
+			_, ackFn, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// -- the schedule asks for 5s, but the hold is bounded by max_redelivery_hold
+			start := time.Now()
+			Expect(ackFn(ctx.Context(), errors.New("processing failed"))).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically("~", 500*time.Millisecond, 250*time.Millisecond))
+
+			batch, ackFn, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			for _, msg := range batch.Messages() {
+				data, err := msg.Raw()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(Equal("hold test"))
+			}
+			Expect(ackFn(ctx.Context(), nil)).To(Succeed())
+		})
+	})
+
 	Context("when using batch processing", func() {
 		It("should read multiple messages as a batch when batch_size > 1", func() {
 			// Create input with batch_size = 3
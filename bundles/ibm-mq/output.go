@@ -10,6 +10,7 @@ import (
 	"sync"
 
 	"github.com/ibm-messaging/mq-golang/v5/ibmmq"
+	"github.com/wombatwisdom/components/bundles/compress"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
@@ -17,6 +18,18 @@ const (
 	OutputComponentName = "mq"
 )
 
+// NewOutputFromConfig creates an MQ output from a spec.Config interface
+func NewOutputFromConfig(env spec.Environment, config spec.Config) (*Output, error) {
+	var cfg OutputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode mq output config: %w", err)
+	}
+	if cfg.QueueExpr == nil {
+		return nil, fmt.Errorf("a queue expression is required")
+	}
+	return NewOutput(env, cfg)
+}
+
 // NewOutput creates a new MQ output component
 func NewOutput(env spec.Environment, cfg OutputConfig) (*Output, error) {
 	return &Output{
@@ -30,7 +43,9 @@ type Output struct {
 	env spec.Environment
 	cfg OutputConfig
 
-	metadataFilter spec.MetadataFilter
+	metadataFilter   spec.MetadataFilter
+	propertiesFilter spec.MetadataFilter
+	compression      compress.Algorithm
 
 	qmgr        ibmmq.MQQueueManager
 	queues      map[string]ibmmq.MQObject
@@ -44,6 +59,12 @@ func (o *Output) Init(ctx spec.ComponentContext) error {
 		return spec.ErrAlreadyConnected
 	}
 
+	compression, err := compress.ParseAlgorithm(o.cfg.Compression)
+	if err != nil {
+		return fmt.Errorf("compression: %w", err)
+	}
+	o.compression = compression
+
 	// Create connection to IBM MQ
 	cno := ibmmq.NewMQCNO()
 	cd := ibmmq.NewMQCD()
@@ -134,6 +155,13 @@ func (o *Output) Init(ctx spec.ComponentContext) error {
 		}
 	}
 
+	// Setup message properties filter if configured
+	if o.cfg.MessageProperties != nil {
+		if o.propertiesFilter, err = ctx.BuildMetadataFilter(o.cfg.MessageProperties.Patterns, o.cfg.MessageProperties.Invert); err != nil {
+			return fmt.Errorf("message_properties: %w", err)
+		}
+	}
+
 	// Initialize queue cache
 	o.queues = make(map[string]ibmmq.MQObject)
 	o.initialized = true
@@ -240,6 +268,31 @@ func (o *Output) WriteMessage(ctx spec.ComponentContext, message spec.Message) e
 	mqmd, hasCorrelId := o.createMQMD(message)
 	pmo := ibmmq.NewMQPMO()
 
+	properties := o.messageProperties(message)
+	if o.compression != compress.None {
+		if data, err = compress.Compress(o.compression, data); err != nil {
+			return fmt.Errorf("failed to compress message data: %w", err)
+		}
+
+		// Record the algorithm so consumers know how to decode the data
+		properties[compress.MetadataCompression] = string(o.compression)
+	}
+
+	if len(properties) > 0 {
+		mh, err := o.qmgr.CrtMH(ibmmq.NewMQCMHO())
+		if err != nil {
+			return fmt.Errorf("failed to create message handle: %w", err)
+		}
+		defer func() { _ = mh.DltMH(ibmmq.NewMQDMHO()) }()
+
+		for name, value := range properties {
+			if err := mh.SetMP(ibmmq.NewMQSMPO(), name, ibmmq.NewMQPD(), value); err != nil {
+				return fmt.Errorf("failed to set message property %s: %w", name, err)
+			}
+		}
+		pmo.OriginalMsgHandle = mh
+	}
+
 	pmoOptions := ibmmq.MQPMO_SYNCPOINT + ibmmq.MQPMO_NEW_MSG_ID
 	if !hasCorrelId {
 		pmoOptions += ibmmq.MQPMO_NEW_CORREL_ID
@@ -260,6 +313,9 @@ func (o *Output) createMQMD(message spec.Message) (*ibmmq.MQMD, bool) {
 
 	if o.cfg.Format != "" {
 		mqmd.Format = o.cfg.Format
+	} else if o.compression != compress.None {
+		// Compressed data is binary, so it must not be converted by the queue manager
+		mqmd.Format = ibmmq.MQFMT_NONE
 	} else {
 		mqmd.Format = "MQSTR"
 	}
@@ -294,6 +350,21 @@ func (o *Output) createMQMD(message spec.Message) (*ibmmq.MQMD, bool) {
 		metadataMap[key] = value
 	}
 
+	// Compressed data is binary, so the format of the original data no longer applies
+	if o.shouldIncludeMetadata("mq_format") && o.compression == compress.None {
+		if format, exists := metadataMap["mq_format"]; exists {
+			mqmd.Format = fmt.Sprintf("%v", format)
+		}
+	}
+
+	if o.shouldIncludeMetadata("mq_ccsid") {
+		if ccsid, exists := metadataMap["mq_ccsid"]; exists {
+			if ccsidInt, err := strconv.Atoi(fmt.Sprintf("%v", ccsid)); err == nil {
+				mqmd.CodedCharSetId = int32(ccsidInt)
+			}
+		}
+	}
+
 	if o.shouldIncludeMetadata("mq_priority") {
 		if priority, exists := metadataMap["mq_priority"]; exists {
 			if priorityInt, err := strconv.Atoi(fmt.Sprintf("%v", priority)); err == nil {
@@ -322,6 +393,21 @@ func (o *Output) createMQMD(message spec.Message) (*ibmmq.MQMD, bool) {
 	return mqmd, hasCorrelId
 }
 
+// messageProperties returns the metadata selected to be set as message properties
+func (o *Output) messageProperties(message spec.Message) map[string]string {
+	properties := make(map[string]string)
+	if o.propertiesFilter == nil {
+		return properties
+	}
+
+	for key, value := range message.Metadata() {
+		if o.propertiesFilter.Include(key) && o.shouldIncludeMetadata(key) {
+			properties[key] = fmt.Sprintf("%v", value)
+		}
+	}
+	return properties
+}
+
 // shouldIncludeMetadata checks if a metadata key should be included based on the filter
 func (o *Output) shouldIncludeMetadata(key string) bool {
 	// If no filter is configured, include all metadata
@@ -0,0 +1,106 @@
+package ibm_mq
+
+import (
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// connectionProperties are the properties of CommonMQConfig.
+const connectionProperties = `
+		"queue_manager_name": {"type": "string", "description": "The name of the queue manager."},
+		"channel_name": {"type": "string", "description": "The channel for client connections.", "examples": ["DEV.APP.SVRCONN"]},
+		"connection_name": {"type": "string", "description": "The host and port of the queue manager. Defaults to the MQSERVER environment variable.", "examples": ["localhost(1414)"]},
+		"user_id": {"type": "string"},
+		"password": {"type": "string"},
+		"application_name": {"type": "string", "description": "The name the connection is identified by in the queue manager."},
+		"tls": {
+			"type": "object",
+			"properties": {
+				"enabled": {"type": "boolean", "default": false},
+				"cipher_spec": {"type": "string", "examples": ["ANY_TLS12_OR_HIGHER"]},
+				"key_repository": {"type": "string", "description": "The path of the key repository, without the file extension."},
+				"key_repository_password": {"type": "string"},
+				"certificate_label": {"type": "string"},
+				"ssl_peer_name": {"type": "string"},
+				"fips_required": {"type": "boolean", "default": false}
+			},
+			"additionalProperties": false
+		}`
+
+const inputSchema = `{
+	"type": "object",
+	"properties": {` + connectionProperties + `,
+		"queue_name": {"type": "string", "description": "The queue to read messages from."},
+		"batch_size": {"type": "integer", "minimum": 1, "default": 1, "description": "The maximum number of messages per batch."},
+		"batch_wait_time": {"type": "string", "default": "100ms", "description": "How long to wait for a batch to fill up before returning it."},
+		"redelivery": {
+			"type": "object",
+			"properties": {
+				"initial": {"type": "string", "default": "500ms"},
+				"max": {"type": "string", "default": "30s"},
+				"multiplier": {"type": "number", "default": 2},
+				"jitter": {"type": "number", "minimum": 0, "maximum": 1}
+			},
+			"additionalProperties": false,
+			"description": "The backoff schedule for batches which failed to process, based on the backout count of their messages."
+		},
+		"max_redelivery_hold": {"type": "string", "default": "10s", "description": "How long a failed batch is held at most before it is backed out."}
+	},
+	"required": ["queue_manager_name", "queue_name"],
+	"additionalProperties": false
+}`
+
+const outputSchema = `{
+	"type": "object",
+	"properties": {` + connectionProperties + `,
+		"queue_expr": {"type": "string", "description": "The expression producing the queue of each message.", "examples": ["DEV.QUEUE.1"]},
+		"metadata": {
+			"type": "object",
+			"properties": {
+				"patterns": {"type": "array", "items": {"type": "string"}},
+				"invert": {"type": "boolean", "default": false}
+			},
+			"additionalProperties": false,
+			"description": "Selects the metadata applied to the message descriptor and set as message properties."
+		},
+		"message_properties": {
+			"type": "object",
+			"properties": {
+				"patterns": {"type": "array", "items": {"type": "string"}},
+				"invert": {"type": "boolean", "default": false}
+			},
+			"additionalProperties": false,
+			"description": "Selects the metadata set as message properties, like the schema_id and schema_name set by the schema processor. Without it, no metadata is set as message properties.",
+			"examples": [{"patterns": ["^schema_"]}]
+		},
+		"format": {"type": "string", "default": "MQSTR"},
+		"ccsid": {"type": "string", "default": "1208"},
+		"encoding": {"type": "string", "default": "546"},
+		"compression": {"type": "string", "enum": ["none", "gzip", "zstd", "snappy", "lz4"], "default": "none"}
+	},
+	"required": ["queue_manager_name", "queue_expr"],
+	"additionalProperties": false
+}`
+
+// Register adds the IBM MQ input and output to the registry. The components only work when built with the mqclient
+// tag, which requires the IBM MQ client libraries.
+func Register(r *registry.Registry) error {
+	err := r.RegisterInput(registry.Spec{
+		Name:    InputComponentName,
+		Summary: "Reads messages from an IBM MQ queue.",
+		Schema:  inputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.Input, error) {
+		return NewInputFromConfig(env, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterOutput(registry.Spec{
+		Name:    OutputComponentName,
+		Summary: "Puts messages on IBM MQ queues.",
+		Schema:  outputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.Output, error) {
+		return NewOutputFromConfig(env, cfg)
+	})
+}
@@ -56,36 +56,29 @@ var _ = Describe("Roundtrip", func() {
 		_ = output.Close(ctx)
 	})
 
-	When("sending a message through output and reading through input", func() {
-		It("should successfully roundtrip the message", func() {
-			testData := []byte("roundtrip test message")
-
-			// Create and send message through output
+	When("sending a message with format and ccsid metadata", func() {
+		It("should apply the metadata to the MQ message descriptor", func() {
 			msg := ctx.NewMessage()
-			msg.SetRaw(testData)
-			batch := ctx.NewBatch(msg)
+			msg.SetRaw([]byte{0x00, 0x00, 0x00, 0x00, 0x2a})
+			msg.SetMetadata("mq_format", "")
+			msg.SetMetadata("mq_ccsid", "819")
+			msg.SetMetadata("schema_id", "42")
 
-			err := output.Write(ctx, batch)
+			err := output.Write(ctx, ctx.NewBatch(msg))
 			Expect(err).ToNot(HaveOccurred())
 
-			// Read message through input
 			receivedBatch, ackFn, err := input.Read(ctx)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(receivedBatch).ToNot(BeNil())
-			Expect(ackFn).ToNot(BeNil())
-
-			// Verify the message content
-			var messageCount int
-			var receivedData []byte
-			for _, msg := range receivedBatch.Messages() {
-				messageCount++
-				receivedData, err = msg.Raw()
-				Expect(err).ToNot(HaveOccurred())
+
+			for _, received := range receivedBatch.Messages() {
+				metadata := make(map[string]any)
+				for key, value := range received.Metadata() {
+					metadata[key] = value
+				}
+				Expect(metadata).To(HaveKeyWithValue("mq_ccsid", "819"))
+				Expect(metadata["mq_format"]).To(BeElementOf("", "        "))
 			}
-			Expect(messageCount).To(Equal(1))
-			Expect(receivedData).To(Equal(testData))
 
-			// Acknowledge the message
 			err = ackFn(ctx.Context(), nil)
 			Expect(err).ToNot(HaveOccurred())
 		})
@@ -9,6 +9,11 @@ import (
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
+const (
+	InputComponentName  = "mq"
+	OutputComponentName = "mq"
+)
+
 // CommonMQConfig stub for non-mqclient builds
 type CommonMQConfig struct {
 	QueueManagerName string
@@ -23,12 +28,14 @@ type CommonMQConfig struct {
 // OutputConfig stub for non-mqclient builds
 type OutputConfig struct {
 	CommonMQConfig
-	QueueName string
-	QueueExpr spec.Expression
-	Metadata  *MetadataConfig
-	Format    string
-	Ccsid     string
-	Encoding  string
+	QueueName         string
+	QueueExpr         spec.Expression
+	Metadata          *MetadataConfig
+	MessageProperties *MetadataConfig
+	Format            string
+	Ccsid             string
+	Encoding          string
+	Compression       string
 }
 
 // MetadataConfig stub for non-mqclient builds
@@ -40,9 +47,11 @@ type MetadataConfig struct {
 // InputConfig stub for non-mqclient builds
 type InputConfig struct {
 	CommonMQConfig
-	QueueName     string
-	BatchSize     int
-	BatchWaitTime string
+	QueueName         string
+	BatchSize         int
+	BatchWaitTime     string
+	Redelivery        *spec.Backoff
+	MaxRedeliveryHold string
 }
 
 // SystemConfig stub for non-mqclient builds
@@ -95,6 +104,10 @@ type Input struct {
 	cfg InputConfig
 }
 
+func NewInputFromConfig(env spec.Environment, config spec.Config) (*Input, error) {
+	return nil, fmt.Errorf("IBM MQ client libraries not available - build with -tags mqclient")
+}
+
 func NewInput(env spec.Environment, config InputConfig) (*Input, error) {
 	return &Input{env: env, cfg: config}, fmt.Errorf("IBM MQ client libraries not available - build with -tags mqclient")
 }
@@ -103,6 +116,10 @@ func (i *Input) Init(ctx spec.ComponentContext) error {
 	return fmt.Errorf("IBM MQ client libraries not available")
 }
 
+func (i *Input) Drain(ctx context.Context) error {
+	return nil
+}
+
 func (i *Input) Close(ctx spec.ComponentContext) error {
 	return nil
 }
@@ -121,7 +138,7 @@ func NewOutput(env spec.Environment, cfg OutputConfig) (*Output, error) {
 	return &Output{env: env, cfg: cfg}, fmt.Errorf("IBM MQ client libraries not available - build with -tags mqclient")
 }
 
-func NewOutputFromConfig(sys spec.System, config spec.Config) (*Output, error) {
+func NewOutputFromConfig(env spec.Environment, config spec.Config) (*Output, error) {
 	return nil, fmt.Errorf("IBM MQ client libraries not available - build with -tags mqclient")
 }
 
@@ -8,20 +8,25 @@ import (
 )
 
 type CommonMQTTConfig struct {
-	ClientId string   `json:"client_id" yaml:"client_id"`
-	Urls     []string `json:"urls" yaml:"urls"`
+	ClientId string   `json:"client_id" yaml:"client_id" mapstructure:"client_id"`
+	Urls     []string `json:"urls" yaml:"urls" mapstructure:"urls"`
 
-	ConnectTimeout       *time.Duration `json:"connect_timeout" yaml:"connect_timeout"`
-	ConnectRetry         bool           `json:"connect_retry" yaml:"connect_retry"`
-	ConnectRetryInterval time.Duration  `json:"connect_retry_interval" yaml:"connect_retry_interval"`
-	KeepAlive            *time.Duration `json:"keepalive" yaml:"keepalive"`
+	ConnectTimeout       *time.Duration `json:"connect_timeout" yaml:"connect_timeout" mapstructure:"connect_timeout"`
+	ConnectRetry         bool           `json:"connect_retry" yaml:"connect_retry" mapstructure:"connect_retry"`
+	ConnectRetryInterval time.Duration  `json:"connect_retry_interval" yaml:"connect_retry_interval" mapstructure:"connect_retry_interval"`
+	KeepAlive            *time.Duration `json:"keepalive" yaml:"keepalive" mapstructure:"keepalive"`
 
-	Username string `json:"username" yaml:"username"`
-	Password string `json:"password" yaml:"password"`
+	// Quiesce is how long the client waits for outstanding work, like in-flight publishes and acknowledgements, to
+	// complete when disconnecting. Defaults to 250ms.
+	Quiesce *time.Duration `json:"quiesce" yaml:"quiesce" mapstructure:"quiesce"`
 
-	TLS *tls.Config `json:"tls" yaml:"tls"`
+	Username string `json:"username" yaml:"username" mapstructure:"username"`
+	Password string `json:"password" yaml:"password" mapstructure:"password"`
 
-	Will *WillConfig `json:"will" yaml:"will"`
+	// TLS can't be configured from a map, since it holds certificates rather than the paths to them.
+	TLS *tls.Config `json:"tls" yaml:"tls" mapstructure:"-"`
+
+	Will *WillConfig `json:"will" yaml:"will" mapstructure:"will"`
 }
 
 func (c *CommonMQTTConfig) apply(opts *mqtt.ClientOptions) *mqtt.ClientOptions {
@@ -62,15 +67,25 @@ func (c *CommonMQTTConfig) apply(opts *mqtt.ClientOptions) *mqtt.ClientOptions {
 	return opts
 }
 
+// quiesceMillis returns the quiesce period in milliseconds, as expected by mqtt.Client.Disconnect.
+func (c *CommonMQTTConfig) quiesceMillis() uint {
+	if c.Quiesce == nil {
+		return defaultQuiesce
+	}
+	return uint(max(c.Quiesce.Milliseconds(), 0))
+}
+
+const defaultQuiesce = 250
+
 func NewClientOptions(config CommonMQTTConfig) *mqtt.ClientOptions {
 	return config.apply(mqtt.NewClientOptions())
 }
 
 type WillConfig struct {
-	QoS      uint8
-	Retained bool
-	Topic    string
-	Payload  string
+	QoS      uint8  `json:"qos" yaml:"qos" mapstructure:"qos"`
+	Retained bool   `json:"retained" yaml:"retained" mapstructure:"retained"`
+	Topic    string `json:"topic" yaml:"topic" mapstructure:"topic"`
+	Payload  string `json:"payload" yaml:"payload" mapstructure:"payload"`
 }
 
 func (w *WillConfig) apply(opts *mqtt.ClientOptions) *mqtt.ClientOptions {
@@ -0,0 +1,43 @@
+package mqtt_test
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wombatwisdom/components/bundles/mqtt"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = test.DescribeConformance("MQTT", test.Conformance{
+	NewInput: func(_ spec.System, target string) (spec.Input, error) {
+		return mqtt.NewInput(env, mqtt.InputConfig{
+			CommonMQTTConfig: mqtt.CommonMQTTConfig{
+				Urls:     []string{url},
+				ClientId: uuid.New().String(),
+			},
+			// -- QoS 1 without auto-ack leaves failed messages unacknowledged, so they are redelivered
+			Filters: map[string]byte{target: 1},
+		})
+	},
+	NewOutput: func(_ spec.System, target string) (spec.Output, error) {
+		topic, err := spec.NewExprLangExpression(`${! "` + target + `" }`)
+		if err != nil {
+			return nil, err
+		}
+
+		return mqtt.NewOutput(env, mqtt.OutputConfig{
+			CommonMQTTConfig: mqtt.CommonMQTTConfig{
+				Urls:     []string{url},
+				ClientId: uuid.New().String(),
+			},
+			QOS:       1,
+			TopicExpr: topic,
+		})
+	},
+	// -- the subscription is made once the client connected, in the background
+	Settle: 100 * time.Millisecond,
+	// -- MQTT 3.1.1 has no user properties, so the metadata of a message isn't carried to the broker
+	Metadata:   false,
+	Redelivery: true,
+})
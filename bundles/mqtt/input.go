@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/wombatwisdom/components/framework/spec"
 	"maps"
@@ -11,20 +12,33 @@ import (
 	"sync"
 )
 
+const (
+	InputComponentName = "mqtt"
+)
+
 type InputConfig struct {
-	CommonMQTTConfig
+	CommonMQTTConfig `mapstructure:",squash"`
 
 	// Filters is a map of topics and QoS levels to subscribe to
-	Filters map[string]byte
+	Filters map[string]byte `mapstructure:"filters"`
 
 	// CleanSession
-	CleanSession bool
+	CleanSession bool `mapstructure:"clean_session"`
 
 	// ClientId is an optional unique identifier for the client
-	ClientId string
+	ClientId string `mapstructure:"-"`
 
 	// EnableAutoAck enables automatic acknowledgment for at-least-once delivery (paho SetAutoAckDisabled)
-	EnableAutoAck bool
+	EnableAutoAck bool `mapstructure:"enable_auto_ack"`
+}
+
+// NewInputFromConfig creates an MQTT input from a spec.Config interface
+func NewInputFromConfig(env spec.Environment, config spec.Config) (*Input, error) {
+	var cfg InputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode mqtt input config: %w", err)
+	}
+	return NewInput(env, cfg)
 }
 
 func NewInput(env spec.Environment, config InputConfig) (*Input, error) {
@@ -42,6 +56,13 @@ type Input struct {
 	msgChan     chan mqtt.Message
 	msgChanLock sync.Mutex
 
+	// inflight tracks the batches which haven't been acknowledged yet, stopped is closed once the input drains
+	inflight *spec.InFlight
+	stopped  chan struct{}
+
+	// retry holds the messages of failed batches, which are handed out again before new messages are received
+	retry []mqtt.Message
+
 	log spec.Logger
 }
 
@@ -64,6 +85,7 @@ func (m *Input) Init(ctx spec.ComponentContext) error {
 
 	var msgMut sync.Mutex
 	msgChan := make(chan mqtt.Message)
+	stopped := make(chan struct{})
 
 	opts := NewClientOptions(m.InputConfig.CommonMQTTConfig).
 		SetCleanSession(m.CleanSession).
@@ -78,8 +100,10 @@ func (m *Input) Init(ctx spec.ComponentContext) error {
 				defer msgMut.Unlock()
 
 				if msgChan != nil {
+					// -- messages arriving while draining are not acknowledged, so the broker redelivers them
 					select {
 					case msgChan <- msg:
+					case <-stopped:
 					case <-ctx.Context().Done():
 					}
 				}
@@ -113,17 +137,57 @@ func (m *Input) Init(ctx spec.ComponentContext) error {
 		}
 	}()
 
+	m.msgChanLock.Lock()
 	m.client = client
 	m.msgChan = msgChan
+	m.inflight = spec.NewInFlight()
+	m.stopped = stopped
+	m.retry = nil
+	m.msgChanLock.Unlock()
 	return nil
 }
 
+// Drain stops the input from returning new batches and waits until the batches which were already read have been
+// acknowledged. Messages which arrive in the meantime are left unacknowledged and will be redelivered by the broker.
+func (m *Input) Drain(ctx context.Context) error {
+	m.msgChanLock.Lock()
+	inflight := m.inflight
+	m.stop()
+	m.msgChanLock.Unlock()
+
+	if inflight == nil {
+		return nil
+	}
+
+	return inflight.Wait(ctx)
+}
+
+// stop makes the input stop handing out messages. The caller must hold msgChanLock.
+func (m *Input) stop() {
+	if m.inflight != nil && !m.inflight.Draining() {
+		m.inflight.Drain()
+		close(m.stopped)
+	}
+}
+
+// Close disconnects from the broker, giving outstanding acknowledgements the configured quiesce period to complete.
+// Call Drain first to wait for the batches in flight to be processed.
 func (m *Input) Close(ctx spec.ComponentContext) error {
 	m.msgChanLock.Lock()
 	defer m.msgChanLock.Unlock()
 
+	// -- reads after closing report the input as disconnected instead of waiting for messages which won't arrive
+	m.stop()
+	m.msgChan = nil
+	// -- failed messages were never acknowledged, so the broker redelivers them when the session resumes
+	m.retry = nil
+
 	if m.client != nil {
-		m.client.Disconnect(0)
+		if m.inflight != nil && m.inflight.Count() > 0 {
+			m.log.Warnf("Closing with %d unacknowledged batches, they will be redelivered", m.inflight.Count())
+		}
+
+		m.client.Disconnect(m.quiesceMillis())
 		m.client = nil
 	}
 
@@ -132,13 +196,21 @@ func (m *Input) Close(ctx spec.ComponentContext) error {
 
 func (m *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
 	m.msgChanLock.Lock()
-	msgChan := m.msgChan
+	msgChan, inflight, stopped := m.msgChan, m.inflight, m.stopped
+	var retry mqtt.Message
+	if msgChan != nil && len(m.retry) > 0 {
+		retry, m.retry = m.retry[0], m.retry[1:]
+	}
 	m.msgChanLock.Unlock()
 
-	if msgChan == nil {
+	if msgChan == nil || inflight.Draining() {
 		return nil, nil, spec.ErrNotConnected
 	}
 
+	if retry != nil {
+		return m.batch(ctx, inflight, retry)
+	}
+
 	select {
 	case msg, open := <-msgChan:
 		if !open {
@@ -146,48 +218,80 @@ func (m *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallb
 			return nil, nil, spec.ErrNotConnected
 		}
 
-		specMsg := ctx.NewMessage()
-		specMsg.SetRaw(msg.Payload())
-
-		specMsg.SetMetadata("mqtt_duplicate", msg.Duplicate())
-		specMsg.SetMetadata("mqtt_qos", int(msg.Qos()))
-		specMsg.SetMetadata("mqtt_retained", msg.Retained())
-		specMsg.SetMetadata("mqtt_topic", msg.Topic())
-		specMsg.SetMetadata("mqtt_message_id", int(msg.MessageID()))
-
-		return ctx.NewBatch(specMsg), func(ackCtx context.Context, res error) error {
-			// check for any errors in the component context
-			if err := ackCtx.Err(); err != nil {
-				if !m.EnableAutoAck {
-					var reason string
-					switch {
-					case errors.Is(err, context.Canceled):
-						reason = "context cancellation"
-					case errors.Is(err, context.DeadlineExceeded):
-						reason = "deadline exceeded"
-					default:
-						reason = "context error: " + err.Error()
-					}
-					m.log.Infof("Skipping ACK for message (topic: %s, id: %d) due to %s - message will be redelivered",
-						msg.Topic(), msg.MessageID(), reason)
+		if inflight.Draining() {
+			// -- the message is left unacknowledged, so it will be redelivered
+			return nil, nil, spec.ErrNotConnected
+		}
+
+		if m.EnableAutoAck {
+			// -- paho acknowledges once the subscription handler returns, which races with a disconnect right after
+			// this read. Acknowledging here queues the ack before the batch is handed out.
+			msg.Ack()
+		}
+
+		return m.batch(ctx, inflight, msg)
+	case <-stopped:
+		return nil, nil, spec.ErrNotConnected
+	case <-ctx.Context().Done():
+		return nil, nil, ctx.Context().Err()
+	}
+}
+
+// batch wraps a received message in a batch. Without auto-ack the message is acknowledged once the batch was
+// processed, and handed out again by the next read if processing failed with anything but spec.ErrPermanent. The
+// broker only redelivers unacknowledged messages when the session resumes, so a failed message would otherwise stall
+// until the client reconnects.
+func (m *Input) batch(ctx spec.ComponentContext, inflight *spec.InFlight, msg mqtt.Message) (spec.Batch, spec.ProcessedCallback, error) {
+	specMsg := ctx.NewMessage()
+	specMsg.SetRaw(msg.Payload())
+
+	specMsg.SetMetadata("mqtt_duplicate", msg.Duplicate())
+	specMsg.SetMetadata("mqtt_qos", int(msg.Qos()))
+	specMsg.SetMetadata("mqtt_retained", msg.Retained())
+	specMsg.SetMetadata("mqtt_topic", msg.Topic())
+	specMsg.SetMetadata("mqtt_message_id", int(msg.MessageID()))
+
+	return ctx.NewBatch(specMsg), inflight.Track(func(ackCtx context.Context, res error) error {
+		// check for any errors in the component context
+		if err := ackCtx.Err(); err != nil {
+			if !m.EnableAutoAck {
+				var reason string
+				switch {
+				case errors.Is(err, context.Canceled):
+					reason = "context cancellation"
+				case errors.Is(err, context.DeadlineExceeded):
+					reason = "deadline exceeded"
+				default:
+					reason = "context error: " + err.Error()
 				}
-				return nil
+				m.log.Infof("Skipping ACK for message (topic: %s, id: %d) due to %s - message will be redelivered",
+					msg.Topic(), msg.MessageID(), reason)
 			}
+			return nil
+		}
 
-			if res == nil {
-				if !m.EnableAutoAck {
-					// Check if client is still connected before ACKing
-					if m.client != nil && m.client.IsConnected() {
-						msg.Ack()
-					} else {
-						m.log.Infof("Skipping ACK for message (topic: %s, id: %d) - client disconnected, message will be redelivered",
-							msg.Topic(), msg.MessageID())
-					}
-				}
+		if m.EnableAutoAck {
+			return nil
+		}
+
+		if res != nil && !errors.Is(res, spec.ErrPermanent) {
+			m.msgChanLock.Lock()
+			defer m.msgChanLock.Unlock()
+
+			// -- a closed input leaves the message to the broker, which redelivers it when the session resumes
+			if m.msgChan != nil && m.inflight == inflight {
+				m.retry = append(m.retry, msg)
 			}
 			return nil
-		}, nil
-	case <-ctx.Context().Done():
-		return nil, nil, ctx.Context().Err()
-	}
+		}
+
+		// Check if client is still connected before ACKing
+		if m.client != nil && m.client.IsConnected() {
+			msg.Ack()
+		} else {
+			m.log.Infof("Skipping ACK for message (topic: %s, id: %d) - client disconnected, message will be redelivered",
+				msg.Topic(), msg.MessageID())
+		}
+		return nil
+	}), nil
 }
@@ -326,6 +326,111 @@ var _ = Describe("Input ACK behavior", func() {
 				// Test passes - the timeout proves auto-ACK worked correctly
 			}
 		})
+
+		It("should not hand out a message again when it failed permanently", func() {
+			var err error
+			input, err = mqtt.NewInput(env, mqtt.InputConfig{
+				CommonMQTTConfig: mqtt.CommonMQTTConfig{
+					Urls:     []string{url},
+					ClientId: "ACK_TEST_SUBSCRIBER_4",
+				},
+				Filters: map[string]byte{
+					"ack-test/permanent": 1,
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(input.Init(ctx)).To(Succeed())
+
+			waitForSubscription(input)
+
+			for _, payload := range []string{"poison", "next"} {
+				pubToken := publisher.Publish("ack-test/permanent", 1, false, []byte(payload))
+				pubToken.Wait()
+				Expect(pubToken.Error()).ToNot(HaveOccurred())
+			}
+
+			_, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(callback(context.Background(), fmt.Errorf("%w: unparseable", spec.ErrPermanent))).To(Succeed())
+
+			batch, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			for _, msg := range batch.Messages() {
+				raw, err := msg.Raw()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(raw)).To(Equal("next"))
+			}
+			Expect(callback(context.Background(), nil)).To(Succeed())
+		})
 	})
 
 })
+
+var _ = Describe("Input drain", func() {
+	var input *mqtt.Input
+	var ctx spec.ComponentContext
+	var publisher mqtt2.Client
+
+	BeforeEach(func() {
+		publisher = mqtt2.NewClient(mqtt2.NewClientOptions().
+			AddBroker(url).
+			SetClientID("DRAIN_TEST_PUBLISHER"))
+		token := publisher.Connect()
+		token.Wait()
+		Expect(token.Error()).ToNot(HaveOccurred())
+
+		ctx = test.NewMockComponentContext()
+
+		var err error
+		input, err = mqtt.NewInput(env, mqtt.InputConfig{
+			CommonMQTTConfig: mqtt.CommonMQTTConfig{
+				Urls:     []string{url},
+				ClientId: "DRAIN_TEST_SUBSCRIBER",
+			},
+			Filters: map[string]byte{
+				"drain-test": 1,
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+
+		waitForSubscription(input)
+	})
+
+	AfterEach(func() {
+		_ = input.Close(ctx)
+		publisher.Disconnect(250)
+	})
+
+	It("should wait for the batches in flight to be acknowledged", func() {
+		pubToken := publisher.Publish("drain-test", 1, false, []byte("in flight"))
+		pubToken.Wait()
+		Expect(pubToken.Error()).ToNot(HaveOccurred())
+
+		_, callback, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		drained := make(chan error, 1)
+		go func() { drained <- input.Drain(context.Background()) }()
+		Consistently(drained, 100*time.Millisecond).ShouldNot(Receive())
+
+		_, _, err = input.Read(ctx)
+		Expect(err).To(MatchError(spec.ErrNotConnected))
+
+		Expect(callback(context.Background(), nil)).To(Succeed())
+		Eventually(drained).Should(Receive(BeNil()))
+	})
+
+	It("should give up waiting once the deadline passes", func() {
+		pubToken := publisher.Publish("drain-test", 1, false, []byte("never acknowledged"))
+		pubToken.Wait()
+		Expect(pubToken.Error()).ToNot(HaveOccurred())
+
+		_, _, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		drainCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		Expect(input.Drain(drainCtx)).To(MatchError(spec.ErrDrainTimeout))
+	})
+})
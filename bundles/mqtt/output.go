@@ -12,15 +12,31 @@ import (
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
+const (
+	OutputComponentName = "mqtt"
+)
+
 type OutputConfig struct {
-	CommonMQTTConfig
-
-	TopicExpr        spec.Expression `json:"topic_expr" yaml:"topic_expr"`
-	WriteTimeout     time.Duration   `json:"write_timeout" yaml:"write_timeout"`
-	Retained         bool            `json:"retained" yaml:"retained"`
-	QOS              byte            `json:"qos" yaml:"qos"`
-	FailBatchOnError bool            `json:"fail_batch_on_error" yaml:"fail_batch_on_error"`
-	CleanSession     bool            `json:"clean_session" yaml:"clean_session"`
+	CommonMQTTConfig `mapstructure:",squash"`
+
+	TopicExpr        spec.Expression `json:"topic_expr" yaml:"topic_expr" mapstructure:"topic_expr"`
+	WriteTimeout     time.Duration   `json:"write_timeout" yaml:"write_timeout" mapstructure:"write_timeout"`
+	Retained         bool            `json:"retained" yaml:"retained" mapstructure:"retained"`
+	QOS              byte            `json:"qos" yaml:"qos" mapstructure:"qos"`
+	FailBatchOnError bool            `json:"fail_batch_on_error" yaml:"fail_batch_on_error" mapstructure:"fail_batch_on_error"`
+	CleanSession     bool            `json:"clean_session" yaml:"clean_session" mapstructure:"clean_session"`
+}
+
+// NewOutputFromConfig creates an MQTT output from a spec.Config interface
+func NewOutputFromConfig(env spec.Environment, config spec.Config) (*Output, error) {
+	var cfg OutputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode mqtt output config: %w", err)
+	}
+	if cfg.TopicExpr == nil {
+		return nil, fmt.Errorf("a topic expression is required")
+	}
+	return NewOutput(env, cfg)
 }
 
 func NewOutput(env spec.Environment, config OutputConfig) (*Output, error) {
@@ -44,7 +60,7 @@ func (m *Output) Init(ctx spec.ComponentContext) error {
 	defer m.connMut.Unlock()
 
 	if m.client != nil {
-		return nil
+		return spec.ErrAlreadyConnected
 	}
 
 	opts := NewClientOptions(m.config.CommonMQTTConfig).
@@ -81,7 +97,7 @@ func (m *Output) Close(ctx spec.ComponentContext) error {
 	defer m.connMut.Unlock()
 
 	if m.client != nil {
-		m.client.Disconnect(0)
+		m.client.Disconnect(m.config.quiesceMillis())
 		m.client = nil
 	}
 	return nil
@@ -96,55 +112,50 @@ func (m *Output) Write(ctx spec.ComponentContext, batch spec.Batch) error {
 		return spec.ErrNotConnected
 	}
 
-	var errs error
-	for _, message := range batch.Messages() {
-		exprCtx := spec.MessageExpressionContext(message)
+	// -- without FailBatchOnError the remaining messages are still sent, and the failed ones are reported through a
+	// spec.BatchError so the input only redelivers those
+	batchErr := spec.NewBatchError(nil)
+	for idx, message := range batch.Messages() {
+		err := m.publish(client, message)
+		if err == nil {
+			continue
+		}
 
-		var err error
+		if m.config.FailBatchOnError {
+			return fmt.Errorf("batch #%d: %w", idx, err)
+		}
+		batchErr.Failed(idx, err)
+	}
 
-		topicStr, err := m.config.TopicExpr.Eval(exprCtx)
-		if err != nil {
-			errs = errors.Join(errs, fmt.Errorf("topic interpolation error: %w", err))
+	if batchErr.Len() > 0 {
+		return batchErr
+	}
+	return nil
+}
 
-			if m.config.FailBatchOnError {
-				break
-			} else {
-				continue
-			}
-		}
+func (m *Output) publish(client mqtt.Client, message spec.Message) error {
+	topicStr, err := m.config.TopicExpr.Eval(spec.MessageExpressionContext(message))
+	if err != nil {
+		return fmt.Errorf("topic interpolation error: %w", err)
+	}
 
-		mb, err := message.Raw()
-		if err != nil {
-			errs = errors.Join(errs, fmt.Errorf("failed to access message data: %w", err))
-			if m.config.FailBatchOnError {
-				break
-			} else {
-				continue
-			}
-		}
+	mb, err := message.Raw()
+	if err != nil {
+		return fmt.Errorf("failed to access message data: %w", err)
+	}
+
+	mtok := client.Publish(topicStr, m.config.QOS, m.config.Retained, mb)
+	mtok.Wait()
 
-		mtok := client.Publish(topicStr, m.config.QOS, m.config.Retained, mb)
-		mtok.Wait()
-
-		sendErr := mtok.Error()
-		if sendErr == nil {
-			m.log.Infof("Message sent to topic %s", topicStr)
-		} else {
-			m.log.Errorf("Failed to send message to topic %s: %v", topicStr, sendErr)
-
-			if errors.Is(sendErr, mqtt.ErrNotConnected) {
-				errs = errors.Join(errs, spec.ErrNotConnected)
-			} else {
-				errs = errors.Join(errs, sendErr)
-			}
-
-			if m.config.FailBatchOnError {
-				break
-			} else {
-				continue
-			}
+	if sendErr := mtok.Error(); sendErr != nil {
+		m.log.Errorf("Failed to send message to topic %s: %v", topicStr, sendErr)
+
+		if errors.Is(sendErr, mqtt.ErrNotConnected) {
+			return spec.ErrNotConnected
 		}
+		return sendErr
 	}
 
-	return errs
+	m.log.Infof("Message sent to topic %s", topicStr)
+	return nil
 }
@@ -1,6 +1,10 @@
 package mqtt_test
 
 import (
+	"errors"
+	"io"
+	"testing/iotest"
+
 	mqtt2 "github.com/eclipse/paho.mqtt.golang"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -66,4 +70,37 @@ var _ = Describe("Output", func() {
 			}
 		})
 	})
+
+	When("some messages of a batch fail", func() {
+		It("should report the failed messages in a batch error", func() {
+			recv := make(chan mqtt2.Message, 10)
+			tc := mqtt2.NewClient(mqtt2.NewClientOptions().AddBroker(url))
+			tok := tc.Connect()
+			tok.Wait()
+			Expect(tok.Error()).ToNot(HaveOccurred())
+			defer tc.Disconnect(250)
+
+			tok = tc.Subscribe("test", 1, func(client mqtt2.Client, msg mqtt2.Message) {
+				recv <- msg
+			})
+			tok.Wait()
+			Expect(tok.Error()).ToNot(HaveOccurred())
+
+			broken := spec.NewReaderMessage(io.NopCloser(iotest.ErrReader(errors.New("broken payload"))))
+			batch := ctx.NewBatch(
+				spec.NewBytesMessage([]byte("first")),
+				broken,
+				spec.NewBytesMessage([]byte("third")),
+			)
+
+			err := output.Write(ctx, batch)
+
+			var batchErr *spec.BatchError
+			Expect(errors.As(err, &batchErr)).To(BeTrue())
+			Expect(batchErr.Indexes()).To(Equal([]int{1}))
+			Expect(batchErr.IndexErr(1)).To(MatchError(ContainSubstring("failed to access message data")))
+
+			Eventually(recv).Should(HaveLen(2))
+		})
+	})
 })
@@ -0,0 +1,77 @@
+package mqtt
+
+import (
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// connectionProperties are the properties of the client configuration shared by the input and output.
+const connectionProperties = `
+		"urls": {"type": "array", "items": {"type": "string"}, "minItems": 1, "description": "The URLs of the brokers to connect to.", "examples": [["tcp://localhost:1883"]]},
+		"client_id": {"type": "string", "description": "The unique identifier of the client."},
+		"connect_timeout": {"type": "string", "description": "How long to wait for a connection to be established.", "examples": ["30s"]},
+		"connect_retry": {"type": "boolean", "default": false, "description": "Keep retrying to connect when the first attempt fails."},
+		"connect_retry_interval": {"type": "string", "description": "The time between two connect attempts.", "examples": ["10s"]},
+		"keepalive": {"type": "string", "description": "The interval of the keepalive pings.", "examples": ["30s"]},
+		"quiesce": {"type": "string", "default": "250ms", "description": "How long to wait for outstanding work to complete when disconnecting."},
+		"username": {"type": "string"},
+		"password": {"type": "string"},
+		"will": {
+			"type": "object",
+			"properties": {
+				"topic": {"type": "string"},
+				"payload": {"type": "string"},
+				"qos": {"type": "integer", "enum": [0, 1, 2], "default": 0},
+				"retained": {"type": "boolean", "default": false}
+			},
+			"required": ["topic"],
+			"additionalProperties": false,
+			"description": "The message the broker publishes when the client disconnects unexpectedly."
+		}`
+
+const inputSchema = `{
+	"type": "object",
+	"properties": {` + connectionProperties + `,
+		"filters": {"type": "object", "additionalProperties": {"type": "integer", "enum": [0, 1, 2]}, "description": "The topic filters to subscribe to, with their QoS level.", "examples": [{"sensors/#": 1}]},
+		"clean_session": {"type": "boolean", "default": false, "description": "Start without the subscriptions and messages of a previous session."},
+		"enable_auto_ack": {"type": "boolean", "default": false, "description": "Acknowledge messages when they are read instead of once they have been processed."}
+	},
+	"required": ["urls", "filters"],
+	"additionalProperties": false
+}`
+
+const outputSchema = `{
+	"type": "object",
+	"properties": {` + connectionProperties + `,
+		"topic_expr": {"type": "string", "description": "The expression producing the topic of each message.", "examples": ["sensors/${! metadata.device }"]},
+		"write_timeout": {"type": "string", "description": "How long to wait for a message to be published.", "examples": ["5s"]},
+		"retained": {"type": "boolean", "default": false, "description": "Publish the messages as retained messages."},
+		"qos": {"type": "integer", "enum": [0, 1, 2], "default": 0},
+		"fail_batch_on_error": {"type": "boolean", "default": false, "description": "Fail the whole batch when a message can't be published, instead of only that message."},
+		"clean_session": {"type": "boolean", "default": false}
+	},
+	"required": ["urls", "topic_expr"],
+	"additionalProperties": false
+}`
+
+// Register adds the MQTT input and output to the registry.
+func Register(r *registry.Registry) error {
+	err := r.RegisterInput(registry.Spec{
+		Name:    InputComponentName,
+		Summary: "Subscribes to topics of an MQTT broker.",
+		Schema:  inputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.Input, error) {
+		return NewInputFromConfig(env, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterOutput(registry.Spec{
+		Name:    OutputComponentName,
+		Summary: "Publishes messages to an MQTT broker.",
+		Schema:  outputSchema,
+	}, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.Output, error) {
+		return NewOutputFromConfig(env, cfg)
+	})
+}
@@ -0,0 +1,52 @@
+package nats_test
+
+import (
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = test.DescribeConformance("NATS JetStream", test.Conformance{
+	NewSystem: func() (spec.System, error) {
+		jwt, seed := acc.Creds()
+		return wwnats.NewJetStreamSystemFromConfig(spec.NewMapConfig(map[string]any{
+			"url": srv.ClientURL(),
+			"auth": map[string]any{
+				"jwt":  jwt,
+				"seed": string(seed),
+			},
+		}))
+	},
+	// -- the input provisions a stream named after the target, which the output publishes to
+	NewInput: func(sys spec.System, target string) (spec.Input, error) {
+		stream, err := spec.NewExprLangExpression(target)
+		if err != nil {
+			return nil, err
+		}
+
+		return wwnats.NewStreamInputFromConfig(sys, spec.NewMapConfig(map[string]any{
+			"Stream": stream,
+			"Provision": map[string]any{
+				"Subjects": []string{target},
+				"Storage":  "memory",
+			},
+			"Consumer": map[string]any{
+				"DeliverPolicy": "all",
+				"AckPolicy":     "explicit",
+			},
+		}))
+	},
+	NewOutput: func(sys spec.System, target string) (spec.Output, error) {
+		subject, err := spec.NewExprLangExpression(target)
+		if err != nil {
+			return nil, err
+		}
+
+		return wwnats.NewStreamOutputFromConfig(sys, spec.NewMapConfig(map[string]any{
+			"Stream":  subject,
+			"Subject": subject,
+		}))
+	},
+	Metadata:   true,
+	Redelivery: true,
+})
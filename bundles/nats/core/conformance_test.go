@@ -0,0 +1,37 @@
+package core_test
+
+import (
+	"github.com/wombatwisdom/components/bundles/nats/core"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = test.DescribeConformance("NATS core", test.Conformance{
+	NewSystem: func() (spec.System, error) {
+		jwt, seed := acc.Creds()
+		return core.NewSystemFromConfig(spec.NewMapConfig(map[string]any{
+			"url": srv.ClientURL(),
+			"auth": map[string]any{
+				"jwt":  jwt,
+				"seed": string(seed),
+			},
+		}))
+	},
+	NewInput: func(sys spec.System, target string) (spec.Input, error) {
+		return core.NewInput(sys, spec.NewMapConfig(map[string]any{
+			"subject": target,
+		}))
+	},
+	NewOutput: func(sys spec.System, target string) (spec.Output, error) {
+		subject, err := spec.NewExprLangExpression(`${! "` + target + `" }`)
+		if err != nil {
+			return nil, err
+		}
+
+		return core.NewOutput(sys, core.OutputConfig{Subject: subject}), nil
+	},
+	Metadata: true,
+	// -- core NATS delivers at most once: a message is gone once it was handed to the subscriber, so there is no
+	// acknowledgement to withhold and nothing the server could redeliver
+	Redelivery: false,
+})
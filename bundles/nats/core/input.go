@@ -1,10 +1,13 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
@@ -19,8 +22,9 @@ func NewInput(sys spec.System, rawConfig spec.Config) (*Input, error) {
 	}
 
 	return &Input{
-		sys: sys,
-		cfg: cfg,
+		sys:      sys,
+		cfg:      cfg,
+		inflight: spec.NewInFlight(),
 	}, nil
 }
 
@@ -38,19 +42,36 @@ func NewInputFromConfig(sys spec.System, config spec.Config) (*Input, error) {
 // ## Queue Groups
 // Each input with the same queue name will be load balancing messages across all members of the group. This is useful
 // when you want to scale the processing of messages across multiple instances.
+//
+// ## Replies
+// When reply is enabled, messages which have a reply subject are responded to once their batch has been processed,
+// which exposes the pipeline as a NATS service. The response carries the payload and metadata of the processed
+// message. When processing failed, the response carries the error in the NATS service error headers instead. If the
+// processors changed the number of messages, the processed messages can't be matched with the requests anymore and
+// the messages as they were read are used instead.
 type Input struct {
 	sys spec.System
 	cfg InputConfig
 
+	nc  *nats.Conn
 	sub *nats.Subscription
+
+	// inflight tracks the batches which haven't been processed yet
+	inflight *spec.InFlight
 }
 
 func (i *Input) Init(ctx spec.ComponentContext) error {
+	if i.sub != nil && i.sub.IsValid() {
+		return spec.ErrAlreadyConnected
+	}
+
 	client, ok := i.sys.Client().(*nats.Conn)
 	if !ok {
 		return fmt.Errorf("nats client is not of type *nats.Conn")
 	}
 
+	i.nc = client
+
 	// create the subscription
 	var err error
 	if i.cfg.Queue == nil {
@@ -58,11 +79,31 @@ func (i *Input) Init(ctx spec.ComponentContext) error {
 	} else {
 		i.sub, err = client.QueueSubscribeSync(i.cfg.Subject, *i.cfg.Queue)
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	// -- a drained input reads again once it is initialized
+	i.inflight.Reset()
+	return nil
+}
+
+// Drain stops the subscription from receiving new messages and waits until the batches which were already read have
+// been processed.
+func (i *Input) Drain(ctx context.Context) error {
+	i.inflight.Drain()
+
+	if i.sub != nil && i.sub.IsValid() {
+		if err := i.sub.Drain(); err != nil {
+			return fmt.Errorf("failed to drain subscription: %w", err)
+		}
+	}
+
+	return i.inflight.Wait(ctx)
 }
 
 func (i *Input) Close(ctx spec.ComponentContext) error {
-	if i.sub != nil {
+	if i.sub != nil && i.sub.IsValid() {
 		if err := i.sub.Unsubscribe(); err != nil {
 			return err
 		}
@@ -72,12 +113,34 @@ func (i *Input) Close(ctx spec.ComponentContext) error {
 }
 
 func (i *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
-	msgs, err := i.sub.Fetch(i.cfg.BatchCount)
+	if i.sub == nil || !i.sub.IsValid() || i.inflight.Draining() {
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	// -- wait for the first message, then add the ones which are already pending up to the batch count
+	msg, err := i.sub.NextMsgWithContext(ctx.Context())
 	if err != nil {
+		if errors.Is(err, nats.ErrBadSubscription) || errors.Is(err, nats.ErrConnectionClosed) {
+			return nil, nil, spec.ErrNotConnected
+		}
 		return nil, nil, err
 	}
 
+	msgs := []*nats.Msg{msg}
+	for len(msgs) < i.cfg.BatchCount {
+		if pending, _, err := i.sub.Pending(); err != nil || pending == 0 {
+			break
+		}
+
+		next, err := i.sub.NextMsg(0)
+		if err != nil {
+			break
+		}
+		msgs = append(msgs, next)
+	}
+
 	batch := ctx.NewBatch()
+	read := make([]spec.Message, 0, len(msgs))
 	for _, msg := range msgs {
 		m := ctx.NewMessage()
 		m.SetRaw(msg.Data)
@@ -95,7 +158,53 @@ func (i *Input) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallb
 		m.SetMetadata("nats_reply", msg.Reply)
 
 		batch.Append(m)
+		read = append(read, m)
+	}
+
+	if !i.cfg.Reply {
+		return batch, i.inflight.Track(spec.NoopCallback), nil
+	}
+	return batch, i.inflight.Track(i.replyCallback(msgs, read)), nil
+}
+
+// replyCallback responds to the messages with a reply subject with the outcome of processing them.
+func (i *Input) replyCallback(msgs []*nats.Msg, read []spec.Message) spec.ProcessedCallback {
+	return func(ctx context.Context, err error) error {
+		results := processedResults(ctx, read)
+
+		var errs []error
+		for idx, msg := range msgs {
+			if msg.Reply == "" {
+				continue
+			}
+
+			reply, rErr := newReply(msg.Reply, results[idx], spec.MessageError(err, idx))
+			if rErr == nil {
+				rErr = i.nc.PublishMsg(reply)
+			}
+			if rErr != nil {
+				errs = append(errs, fmt.Errorf("failed to reply to message #%d: %w", idx, rErr))
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// newReply creates the response to a request, carrying the error in the NATS service error headers if the request
+// failed.
+func newReply(subject string, result spec.Message, err error) (*nats.Msg, error) {
+	reply := nats.NewMsg(subject)
+	if err != nil {
+		reply.Header.Set(micro.ErrorHeader, err.Error())
+		reply.Header.Set(micro.ErrorCodeHeader, errorCode(err))
+		return reply, nil
 	}
 
-	return batch, spec.NoopCallback, nil
+	data, err := result.Raw()
+	if err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+	reply.Data = data
+	reply.Header = replyHeaders(result)
+	return reply, nil
 }
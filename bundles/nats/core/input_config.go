@@ -21,6 +21,12 @@ type InputConfig struct {
 	//
 	Queue *string `json:"queue,omitempty" yaml:"queue,omitempty" mapstructure:"queue,omitempty"`
 
+	// Whether to respond to messages which have a reply subject once they have been
+	// processed. The response carries the processed message, or the error when
+	// processing failed.
+	//
+	Reply bool `json:"reply,omitempty" yaml:"reply,omitempty" mapstructure:"reply,omitempty"`
+
 	// The subject to subscribe to. The subject may contain wildcards, which will be
 	// matched against any subject that matches the pattern.
 	//
@@ -0,0 +1,105 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go/micro"
+	"github.com/wombatwisdom/components/bundles/nats/core"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Input", func() {
+	var ctx spec.ComponentContext
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+	})
+
+	newInput := func(cfg map[string]any) *core.Input {
+		input, err := core.NewInputFromConfig(newSystem(), spec.NewMapConfig(cfg))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+		DeferCleanup(func() { _ = input.Close(ctx) })
+		Expect(nc.Flush()).To(Succeed())
+		return input
+	}
+
+	// serve reads a single request, processes it with fn and settles the batch the way the pipeline does
+	serve := func(input *core.Input, fn func(msg spec.Message) error) {
+		go func() {
+			defer GinkgoRecover()
+
+			batch, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			var procErr error
+			for _, msg := range batch.Messages() {
+				procErr = fn(msg)
+			}
+			Expect(callback(spec.WithProcessedBatch(context.Background(), batch), procErr)).To(Succeed())
+		}()
+	}
+
+	When("reply is enabled", func() {
+		It("should respond with the processed message", func() {
+			input := newInput(map[string]any{"subject": "service.echo", "reply": true})
+			serve(input, func(msg spec.Message) error {
+				raw, _ := msg.Raw()
+				msg.SetRaw(append([]byte("processed "), raw...))
+				msg.SetMetadata("status", "ok")
+				return nil
+			})
+
+			reply, err := nc.Request("service.echo", []byte("hello"), 5*time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(reply.Data)).To(Equal("processed hello"))
+			Expect(reply.Header.Get("status")).To(Equal("ok"))
+			Expect(reply.Header.Get("nats_reply")).To(BeEmpty())
+		})
+
+		It("should respond with the error when processing failed", func() {
+			input := newInput(map[string]any{"subject": "service.fail", "reply": true})
+			serve(input, func(msg spec.Message) error {
+				return errors.New("bad input")
+			})
+
+			reply, err := nc.Request("service.fail", []byte("hello"), 5*time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reply.Header.Get(micro.ErrorHeader)).To(Equal("bad input"))
+			Expect(reply.Header.Get(micro.ErrorCodeHeader)).To(Equal("500"))
+		})
+	})
+
+	It("should not respond unless reply is enabled", func() {
+		input := newInput(map[string]any{"subject": "service.silent"})
+		serve(input, func(msg spec.Message) error { return nil })
+
+		_, err := nc.Request("service.silent", []byte("hello"), 200*time.Millisecond)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should read again when initialized after being drained and closed", func() {
+		input := newInput(map[string]any{"subject": "input.restart"})
+		Expect(spec.DrainAndClose(ctx, input, time.Second)).To(Succeed())
+
+		Expect(input.Init(ctx)).To(Succeed())
+		Expect(nc.Flush()).To(Succeed())
+		Expect(nc.Publish("input.restart", []byte("after restart"))).To(Succeed())
+
+		batch, _, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		var payloads []string
+		for _, msg := range batch.Messages() {
+			raw, err := msg.Raw()
+			Expect(err).ToNot(HaveOccurred())
+			payloads = append(payloads, string(raw))
+		}
+		Expect(payloads).To(Equal([]string{"after restart"}))
+	})
+})
@@ -1,11 +1,14 @@
 package core_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
+	"github.com/wombatwisdom/components/bundles/nats/core"
 	"github.com/wombatwisdom/components/bundles/nats/test"
+	"github.com/wombatwisdom/components/framework/spec"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -31,3 +34,22 @@ func TestNats(t *testing.T) {
 
 	RunSpecs(t, "Nats Suite")
 }
+
+// newSystem creates a connected system for the test account.
+func newSystem() *core.System {
+	jwt, seed := acc.Creds()
+	sys, err := core.NewSystemFromConfig(spec.NewYamlConfig(`
+url: ##url##
+auth:
+  jwt: ##jwt##
+  seed: ##seed##
+`, "##url##", srv.ClientURL(), "##jwt##", jwt, "##seed##", string(seed)))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(sys.Connect(context.Background())).To(Succeed())
+
+	DeferCleanup(func() {
+		_ = sys.Close(context.Background())
+	})
+
+	return sys
+}
@@ -39,8 +39,12 @@ type Output struct {
 }
 
 func (o *Output) Init(ctx spec.ComponentContext) error {
-	var ok bool
-	if o.nc, ok = o.sys.Client().(*nats.Conn); !ok {
+	if o.nc != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	nc, ok := o.sys.Client().(*nats.Conn)
+	if !ok {
 		return fmt.Errorf("nats client is not of type *nats.Conn")
 	}
 
@@ -48,10 +52,12 @@ func (o *Output) Init(ctx spec.ComponentContext) error {
 		return fmt.Errorf("subject must be specified")
 	}
 
+	o.nc = nc
 	return nil
 }
 
 func (o *Output) Close(ctx spec.ComponentContext) error {
+	o.nc = nil
 	return nil
 }
 
@@ -66,6 +72,10 @@ func (o *Output) Write(ctx spec.ComponentContext, batch spec.Batch) error {
 }
 
 func (o *Output) WriteMessage(ctx spec.ComponentContext, message spec.Message) error {
+	if o.nc == nil {
+		return spec.ErrNotConnected
+	}
+
 	subject, err := o.cfg.Subject.Eval(spec.MessageExpressionContext(message))
 	if err != nil {
 		return fmt.Errorf("subject: %w", err)
@@ -82,11 +92,10 @@ func (o *Output) WriteMessage(ctx spec.ComponentContext, message spec.Message) e
 	for key, value := range message.Metadata() {
 		// -- skip the metadata if the filter is set and the key is not included
 		if o.cfg.MetadataFilter != nil && !o.cfg.MetadataFilter.Include(key) {
-			return nil
+			continue
 		}
 
 		msg.Header[key] = append(msg.Header[key], fmt.Sprintf("%v", value))
-		return nil
 	}
 
 	if err := o.nc.PublishMsg(msg); err != nil {
@@ -0,0 +1,65 @@
+package core_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/nats/core"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("Output", func() {
+	var ctx spec.ComponentContext
+	var system *core.System
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+
+		jwt, seed := acc.Creds()
+		var err error
+		system, err = core.NewSystemFromConfig(spec.NewYamlConfig(`
+url: ##url##
+auth:
+  jwt: ##jwt##
+  seed: ##seed##
+`, "##url##", srv.ClientURL(), "##jwt##", jwt, "##seed##", string(seed)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(system.Connect(context.Background())).To(Succeed())
+
+		DeferCleanup(func() {
+			_ = system.Close(context.Background())
+		})
+	})
+
+	It("should publish the message metadata as headers", func() {
+		subject, err := spec.NewExprLangExpression("output.headers")
+		Expect(err).ToNot(HaveOccurred())
+		filter, err := ctx.BuildMetadataFilter([]string{"^schema_"}, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		output := core.NewOutput(system, core.OutputConfig{Subject: subject, MetadataFilter: filter})
+		Expect(output.Init(ctx)).To(Succeed())
+		defer func() { _ = output.Close(ctx) }()
+
+		sub, err := nc.SubscribeSync("output.headers")
+		Expect(err).ToNot(HaveOccurred())
+		defer func() { _ = sub.Unsubscribe() }()
+		Expect(nc.Flush()).To(Succeed())
+
+		msg := spec.NewBytesMessage([]byte("hello"))
+		msg.SetMetadata("schema_id", "42")
+		msg.SetMetadata("schema_name", "orders")
+		msg.SetMetadata("internal", "skip me")
+		Expect(output.Write(ctx, ctx.NewBatch(msg))).To(Succeed())
+
+		received, err := sub.NextMsg(5 * time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(received.Data)).To(Equal("hello"))
+		Expect(received.Header.Get("schema_id")).To(Equal("42"))
+		Expect(received.Header.Get("schema_name")).To(Equal("orders"))
+		Expect(received.Header.Values("internal")).To(BeEmpty())
+	})
+})
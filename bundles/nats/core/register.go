@@ -0,0 +1,171 @@
+package core
+
+import (
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// SystemConfigSchema is the JSON schema of the system configuration, shared with the JetStream components.
+const SystemConfigSchema = `{
+	"type": "object",
+	"properties": {
+		"url": {"type": "string", "default": "nats://localhost:4222", "description": "The url of the NATS server, multiple urls are separated by commas.", "examples": ["nats://demo.nats.io:4222"]},
+		"name": {"type": "string", "description": "A name for the connection to distinguish it from others."},
+		"servers": {"type": "array", "items": {"type": "string"}, "description": "Additional urls of servers in the cluster, used as seeds next to the url."},
+		"auth": {
+			"type": "object",
+			"description": "The credentials to authenticate with. Only one way of authenticating can be configured.",
+			"properties": {
+				"creds_file": {"type": "string", "description": "The file holding the user JWT and seed."},
+				"jwt": {"type": "string", "description": "The user JWT token."},
+				"seed": {"type": "string", "description": "The user seed."},
+				"nkey_seed": {"type": "string", "description": "The seed of an nkey user."},
+				"token": {"type": "string", "description": "The token to authenticate with."},
+				"user": {"type": "string", "description": "The name of the user to authenticate with."},
+				"password": {"type": "string", "description": "The password of the user."}
+			},
+			"dependencies": {"jwt": ["seed"], "seed": ["jwt"], "password": ["user"]},
+			"additionalProperties": false
+		},
+		"tls": {
+			"type": "object",
+			"description": "Secures the connection with TLS.",
+			"properties": {
+				"ca_file": {"type": "string", "description": "The file of the certificate authority to verify the server certificate with."},
+				"cert_file": {"type": "string", "description": "The file of the client certificate, for mutual TLS."},
+				"key_file": {"type": "string", "description": "The file of the private key of the client certificate."},
+				"server_name": {"type": "string", "description": "The name to verify the server certificate against."},
+				"insecure_skip_verify": {"type": "boolean", "default": false, "description": "Skip verifying the server certificate. Only use this for testing."}
+			},
+			"dependencies": {"cert_file": ["key_file"], "key_file": ["cert_file"]},
+			"additionalProperties": false
+		},
+		"reconnect_wait": {"type": "string", "description": "The time to wait between attempts to reconnect to the same server.", "examples": ["2s"]},
+		"reconnect_jitter": {"type": "string", "description": "The upper bound of the random delay added to the reconnect wait.", "examples": ["100ms"]},
+		"max_reconnects": {"type": "integer", "default": -1, "description": "The maximum number of attempts to restore a lost connection, -1 keeps trying indefinitely."},
+		"ping_interval": {"type": "string", "description": "The interval at which the server is pinged to detect a broken connection.", "examples": ["20s"]},
+		"inbox_prefix": {"type": "string", "description": "The prefix of the inboxes replies are received on.", "examples": ["_INBOX.orders"]},
+		"jetstream": {
+			"type": "object",
+			"description": "JetStream settings, only applying to the JetStream components.",
+			"properties": {
+				"domain": {"type": "string", "description": "The JetStream domain to use."},
+				"api_prefix": {"type": "string", "description": "The prefix of the JetStream API subjects."}
+			},
+			"additionalProperties": false
+		}
+	},
+	"additionalProperties": false
+}`
+
+const inputSchema = `{
+	"type": "object",
+	"properties": {
+		"subject": {"type": "string", "description": "The subject to subscribe to, which may contain wildcards.", "examples": ["orders.>"]},
+		"queue": {"type": "string", "description": "A queue group to join, to load balance messages across its members."},
+		"batch_count": {"type": "integer", "minimum": 1, "default": 1, "description": "The maximum number of messages to fetch at a time."},
+		"reply": {"type": "boolean", "default": false, "description": "Respond to messages with a reply subject with the processed message, or the error when processing failed."}
+	},
+	"required": ["subject"],
+	"additionalProperties": false
+}`
+
+const outputSchema = `{
+	"type": "object",
+	"properties": {
+		"subject": {"type": "string", "description": "The expression producing the subject to publish each message to.", "examples": ["orders.${! region }"]}
+	},
+	"required": ["subject"],
+	"additionalProperties": false
+}`
+
+const requestSchema = `{
+	"type": "object",
+	"properties": {
+		"subject": {"type": "string", "description": "The expression producing the subject to send each request to.", "examples": ["prices.${! metadata.region }"]},
+		"timeout": {"type": "string", "default": "5s", "description": "The time to wait for the reply to a request."}
+	},
+	"required": ["subject"],
+	"additionalProperties": false
+}`
+
+const serviceInputSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "pattern": "^[A-Za-z0-9_-]+$", "description": "The name of the service.", "examples": ["pricing"]},
+		"version": {"type": "string", "description": "The semantic version of the service.", "examples": ["1.0.0"]},
+		"description": {"type": "string", "description": "A description of the service, reported by the discovery requests."},
+		"queue_group": {"type": "string", "default": "q", "description": "The queue group the endpoints join to load balance requests."},
+		"metadata": {"type": "object", "additionalProperties": {"type": "string"}},
+		"endpoints": {
+			"type": "array",
+			"minItems": 1,
+			"items": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string", "description": "The name of the endpoint."},
+					"subject": {"type": "string", "description": "The subject the endpoint listens on. Defaults to its name."},
+					"queue_group": {"type": "string", "description": "The queue group of the endpoint, overriding the one of the service."},
+					"metadata": {"type": "object", "additionalProperties": {"type": "string"}}
+				},
+				"required": ["name"],
+				"additionalProperties": false
+			}
+		},
+		"batch_count": {"type": "integer", "minimum": 1, "default": 1, "description": "The maximum number of requests to read at a time. An endpoint hands over one request at a time, so only requests to different endpoints are batched."}
+	},
+	"required": ["name", "version", "endpoints"],
+	"additionalProperties": false
+}`
+
+// Register adds the NATS core inputs, output and request processor to the registry.
+func Register(r *registry.Registry) error {
+	newSystem := func(cfg spec.Config) (spec.System, error) {
+		return NewSystemFromConfig(cfg)
+	}
+
+	err := r.RegisterInput(registry.Spec{
+		Name:         InputComponentName,
+		Summary:      "Receives messages from a NATS subject.",
+		Schema:       inputSchema,
+		SystemSchema: SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Input, error) {
+		return NewInputFromConfig(sys, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.RegisterInput(registry.Spec{
+		Name:         ServiceInputComponentName,
+		Summary:      "Exposes the pipeline as a NATS service, responding to requests with the processed messages.",
+		Schema:       serviceInputSchema,
+		SystemSchema: SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Input, error) {
+		return NewServiceInputFromConfig(sys, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.RegisterOutput(registry.Spec{
+		Name:         OutputComponentName,
+		Summary:      "Publishes messages to a NATS subject.",
+		Schema:       outputSchema,
+		SystemSchema: SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Output, error) {
+		return NewOutputFromConfig(sys, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterProcessorWithSystem(registry.Spec{
+		Name:         RequestComponentName,
+		Summary:      "Sends messages as NATS requests and replaces them by the replies.",
+		Schema:       requestSchema,
+		SystemSchema: SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Processor, error) {
+		return NewRequestProcessorFromConfig(sys, cfg)
+	})
+}
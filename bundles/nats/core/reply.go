@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// ServiceError is a failed request reported through the NATS service error headers. Responses to requests which
+// failed with a ServiceError carry its code, so a pipeline relaying requests to another service keeps the codes of
+// that service.
+type ServiceError struct {
+	Code        string
+	Description string
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("service error %s: %s", e.Code, e.Description)
+}
+
+// processedResults returns the messages to respond to the requests of a batch with. These are the processed messages
+// the callback was given, unless the processors changed the number of messages, in which case they can't be matched
+// with the requests and the messages as they were read are used instead.
+func processedResults(ctx context.Context, read []spec.Message) []spec.Message {
+	processed, ok := spec.ProcessedBatch(ctx)
+	if !ok {
+		return read
+	}
+
+	var results []spec.Message
+	for _, m := range processed.Messages() {
+		results = append(results, m)
+	}
+	if len(results) != len(read) {
+		return read
+	}
+	return results
+}
+
+// replyHeaders returns the metadata of a processed message as the headers of its response, without the metadata
+// describing the request itself.
+func replyHeaders(result spec.Message) nats.Header {
+	headers := nats.Header{}
+	for key, value := range result.Metadata() {
+		switch key {
+		case "nats_subject", "nats_reply", "nats_service", "nats_service_endpoint":
+			continue
+		}
+		headers.Set(key, fmt.Sprintf("%v", value))
+	}
+	return headers
+}
+
+// errorCode returns the NATS service error code for a failed request. Errors carrying a ServiceError keep its code,
+// permanent failures are reported as bad requests and all others as internal errors.
+func errorCode(err error) string {
+	var svcErr *ServiceError
+	switch {
+	case errors.As(err, &svcErr) && svcErr.Code != "":
+		return svcErr.Code
+	case errors.Is(err, spec.ErrPermanent):
+		return "400"
+	default:
+		return "500"
+	}
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	RequestComponentName = "nats_request"
+
+	defaultRequestTimeout = 5 * time.Second
+)
+
+// NewRequestProcessorFromConfig creates a request processor from a spec.Config interface
+func NewRequestProcessorFromConfig(sys spec.System, config spec.Config) (*RequestProcessor, error) {
+	var cfg RequestConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Subject == nil {
+		return nil, fmt.Errorf("subject must be specified")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultRequestTimeout
+	}
+
+	return &RequestProcessor{
+		sys: sys,
+		cfg: cfg,
+	}, nil
+}
+
+// RequestProcessor sends each message as a NATS request and replaces it by the reply.
+//
+// The metadata of a message is sent as the headers of the request. The payload of the message is replaced by the
+// payload of the reply and the headers of the reply are merged into its metadata. Replies carrying a NATS service
+// error fail the batch with a ServiceError, requests nobody responds to and requests which time out fail it as well.
+type RequestProcessor struct {
+	sys spec.System
+	cfg RequestConfig
+
+	nc *nats.Conn
+}
+
+func (p *RequestProcessor) Init(ctx spec.ComponentContext) error {
+	if p.nc != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	nc, ok := p.sys.Client().(*nats.Conn)
+	if !ok {
+		return fmt.Errorf("nats client is not of type *nats.Conn")
+	}
+
+	p.nc = nc
+	return nil
+}
+
+func (p *RequestProcessor) Close(ctx spec.ComponentContext) error {
+	p.nc = nil
+	return nil
+}
+
+func (p *RequestProcessor) Process(ctx spec.ComponentContext, batch spec.Batch) (spec.Batch, spec.ProcessedCallback, error) {
+	if p.nc == nil {
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	for idx, msg := range batch.Messages() {
+		if err := p.request(ctx.Context(), msg); err != nil {
+			return nil, nil, fmt.Errorf("batch #%d: %w", idx, err)
+		}
+	}
+
+	return batch, spec.NoopCallback, nil
+}
+
+func (p *RequestProcessor) request(ctx context.Context, message spec.Message) error {
+	subject, err := p.cfg.Subject.Eval(spec.MessageExpressionContext(message))
+	if err != nil {
+		return fmt.Errorf("subject: %w", err)
+	}
+
+	req := nats.NewMsg(subject)
+	req.Data, err = message.Raw()
+	if err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+
+	for key, value := range message.Metadata() {
+		req.Header[key] = append(req.Header[key], fmt.Sprintf("%v", value))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	reply, err := p.nc.RequestMsgWithContext(ctx, req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("no reply from %s within %s", subject, p.cfg.Timeout)
+		}
+		return fmt.Errorf("request to %s: %w", subject, err)
+	}
+
+	if desc := reply.Header.Get(micro.ErrorHeader); desc != "" {
+		return fmt.Errorf("request to %s failed: %w", subject, &ServiceError{Code: reply.Header.Get(micro.ErrorCodeHeader), Description: desc})
+	}
+
+	message.SetRaw(reply.Data)
+	for key, values := range reply.Header {
+		if len(values) > 0 {
+			message.SetMetadata(key, strings.Join(values, ","))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package core
+
+import (
+	"time"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+type RequestConfig struct {
+	// The subject to send the requests to. The subject may not contain wildcards,
+	// but may contain variables that are extracted from the message being processed.
+	//
+	Subject spec.Expression `json:"subject" yaml:"subject" mapstructure:"subject"`
+
+	// The time to wait for the reply to a request. Defaults to 5 seconds.
+	//
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty" mapstructure:"timeout,omitempty"`
+}
@@ -0,0 +1,82 @@
+package core_test
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	"github.com/wombatwisdom/components/bundles/nats/core"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestProcessor", func() {
+	var ctx spec.ComponentContext
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+
+		// -- a service uppercasing requests, failing the ones asking for it
+		sub, err := nc.Subscribe("upper.*", func(msg *nats.Msg) {
+			reply := nats.NewMsg(msg.Reply)
+			if string(msg.Data) == "fail" {
+				reply.Header.Set(micro.ErrorHeader, "refused")
+				reply.Header.Set(micro.ErrorCodeHeader, "400")
+			} else {
+				reply.Data = []byte(strings.ToUpper(string(msg.Data)))
+				reply.Header.Set("origin", msg.Header.Get("origin"))
+				reply.Header.Set("handled_by", msg.Subject)
+			}
+			_ = msg.RespondMsg(reply)
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nc.Flush()).To(Succeed())
+		DeferCleanup(func() { _ = sub.Unsubscribe() })
+	})
+
+	newProcessor := func(cfg map[string]any) *core.RequestProcessor {
+		processor, err := core.NewRequestProcessorFromConfig(newSystem(), spec.NewMapConfig(cfg))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(processor.Init(ctx)).To(Succeed())
+		DeferCleanup(func() { _ = processor.Close(ctx) })
+		return processor
+	}
+
+	It("should replace the messages by the replies and merge their headers", func() {
+		processor := newProcessor(map[string]any{"subject": "${! \"upper.\" + metadata.region }"})
+
+		msg := spec.NewBytesMessage([]byte("hello"))
+		msg.SetMetadata("origin", "test")
+		msg.SetMetadata("region", "eu")
+
+		out, _, err := processor.Process(ctx, ctx.NewBatch(msg))
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, m := range out.Messages() {
+			Expect(m.Raw()).To(Equal([]byte("HELLO")))
+			Expect(maps.Collect(m.Metadata())).To(And(
+				HaveKeyWithValue("origin", "test"),
+				HaveKeyWithValue("region", "eu"),
+				HaveKeyWithValue("handled_by", "upper.eu"),
+			))
+		}
+	})
+
+	It("should fail the batch when the service reports an error", func() {
+		processor := newProcessor(map[string]any{"subject": "upper.any"})
+
+		_, _, err := processor.Process(ctx, ctx.NewBatch(spec.NewBytesMessage([]byte("fail"))))
+		Expect(err).To(MatchError(ContainSubstring("service error 400: refused")))
+	})
+
+	It("should fail the batch when nobody responds in time", func() {
+		processor := newProcessor(map[string]any{"subject": "nobody.home", "timeout": "100ms"})
+
+		_, _, err := processor.Process(ctx, ctx.NewBatch(spec.NewBytesMessage([]byte("hello"))))
+		Expect(err).To(HaveOccurred())
+	})
+})
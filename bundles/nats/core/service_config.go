@@ -0,0 +1,52 @@
+package core
+
+type ServiceConfig struct {
+	// The name of the service, used by the discovery requests to address it. The name
+	// may only contain letters, digits, dashes and underscores.
+	//
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// The version of the service, in semantic versioning format.
+	//
+	Version string `json:"version" yaml:"version" mapstructure:"version"`
+
+	// A description of the service, reported by the info discovery request.
+	//
+	Description string `json:"description,omitempty" yaml:"description,omitempty" mapstructure:"description,omitempty"`
+
+	// The queue group the endpoints join, so requests are load balanced across the
+	// instances of the service. Defaults to q.
+	//
+	QueueGroup string `json:"queue_group,omitempty" yaml:"queue_group,omitempty" mapstructure:"queue_group,omitempty"`
+
+	// Metadata of the service, reported by the discovery requests.
+	//
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty" mapstructure:"metadata,omitempty"`
+
+	// The endpoints of the service. Requests to all endpoints are read by the input.
+	//
+	Endpoints []ServiceEndpointConfig `json:"endpoints" yaml:"endpoints" mapstructure:"endpoints"`
+
+	// The maximum number of requests to read at a time. An endpoint hands over one
+	// request at a time, so only requests to different endpoints are batched.
+	//
+	BatchCount int `json:"batch_count,omitempty" yaml:"batch_count,omitempty" mapstructure:"batch_count,omitempty"`
+}
+
+type ServiceEndpointConfig struct {
+	// The name of the endpoint.
+	//
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// The subject the endpoint listens on. Defaults to the name of the endpoint.
+	//
+	Subject string `json:"subject,omitempty" yaml:"subject,omitempty" mapstructure:"subject,omitempty"`
+
+	// The queue group of the endpoint, overriding the one of the service.
+	//
+	QueueGroup string `json:"queue_group,omitempty" yaml:"queue_group,omitempty" mapstructure:"queue_group,omitempty"`
+
+	// Metadata of the endpoint, reported by the discovery requests.
+	//
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty" mapstructure:"metadata,omitempty"`
+}
@@ -0,0 +1,278 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	ServiceInputComponentName = "nats_service"
+)
+
+// NewServiceInputFromConfig creates a service input from a spec.Config interface
+func NewServiceInputFromConfig(sys spec.System, config spec.Config) (*ServiceInput, error) {
+	var cfg ServiceConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("name must be specified")
+	}
+	if cfg.Version == "" {
+		return nil, fmt.Errorf("version must be specified")
+	}
+	if len(cfg.Endpoints) == 0 {
+		return nil, fmt.Errorf("at least one endpoint must be specified")
+	}
+	if cfg.BatchCount <= 0 {
+		cfg.BatchCount = 1
+	}
+
+	return &ServiceInput{
+		sys:      sys,
+		cfg:      cfg,
+		inflight: spec.NewInFlight(),
+	}, nil
+}
+
+// ServiceInput exposes a pipeline as a NATS service, built on the NATS services framework.
+//
+// The input registers a service with the configured endpoints and reads the requests to them as messages. Once the
+// batch of a request has been processed, the request is responded to with the payload and metadata of the processed
+// message, or with the error when processing failed. Failed requests carry the NATS service error headers, with code
+// 400 for permanent failures, the code of a ServiceError, or 500 otherwise.
+//
+// ## Discovery
+// The service answers the $SRV.PING, $SRV.INFO and $SRV.STATS discovery requests. A request counts as handled once it
+// was responded to, so the request and error counts and the processing times of the endpoints cover the whole
+// pipeline. The data of the endpoint stats reports the batches in flight and whether the input is draining.
+//
+// ## Queue Groups
+// The endpoints join a queue group, so requests are load balanced across all instances of the service.
+//
+// ## Batching
+// An endpoint handles its requests one at a time, waiting until a request was responded to before taking the next.
+// A batch therefore holds at most one request per endpoint, the batch count only batches requests across endpoints.
+type ServiceInput struct {
+	sys spec.System
+	cfg ServiceConfig
+
+	// lock guards the service and its channels, which are replaced when a closed input is initialized again
+	lock     sync.Mutex
+	svc      micro.Service
+	requests chan *serviceRequest
+	stopped  chan struct{}
+
+	// inflight tracks the batches which haven't been processed yet
+	inflight *spec.InFlight
+}
+
+// serviceRequest is a request waiting to be read by the input. The handler of the endpoint waits until it was
+// responded to, so the service stats include the time spent processing it.
+type serviceRequest struct {
+	endpoint string
+	req      micro.Request
+	done     chan struct{}
+}
+
+func (i *ServiceInput) Init(ctx spec.ComponentContext) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.svc != nil && !i.svc.Stopped() {
+		return spec.ErrAlreadyConnected
+	}
+
+	nc, ok := i.sys.Client().(*nats.Conn)
+	if !ok {
+		return fmt.Errorf("nats client is not of type *nats.Conn")
+	}
+
+	i.requests = make(chan *serviceRequest)
+	i.stopped = make(chan struct{})
+
+	svc, err := micro.AddService(nc, micro.Config{
+		Name:        i.cfg.Name,
+		Version:     i.cfg.Version,
+		Description: i.cfg.Description,
+		QueueGroup:  i.cfg.QueueGroup,
+		Metadata:    i.cfg.Metadata,
+		StatsHandler: func(*micro.Endpoint) any {
+			return map[string]any{
+				"in_flight": i.inflight.Count(),
+				"draining":  i.inflight.Draining(),
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add service %s: %w", i.cfg.Name, err)
+	}
+
+	for _, endpoint := range i.cfg.Endpoints {
+		var opts []micro.EndpointOpt
+		if endpoint.Subject != "" {
+			opts = append(opts, micro.WithEndpointSubject(endpoint.Subject))
+		}
+		if endpoint.QueueGroup != "" {
+			opts = append(opts, micro.WithEndpointQueueGroup(endpoint.QueueGroup))
+		}
+		if len(endpoint.Metadata) > 0 {
+			opts = append(opts, micro.WithEndpointMetadata(endpoint.Metadata))
+		}
+
+		if err := svc.AddEndpoint(endpoint.Name, handler(endpoint.Name, i.requests, i.stopped), opts...); err != nil {
+			_ = svc.Stop()
+			return fmt.Errorf("failed to add endpoint %s: %w", endpoint.Name, err)
+		}
+	}
+
+	i.svc = svc
+
+	// -- a drained input reads again once it is initialized
+	i.inflight.Reset()
+	ctx.Infof("Service %s %s listening on %d endpoints", i.cfg.Name, i.cfg.Version, len(i.cfg.Endpoints))
+	return nil
+}
+
+// handler hands the requests to an endpoint to Read and waits until they have been responded to.
+func handler(endpoint string, requests chan<- *serviceRequest, stopped <-chan struct{}) micro.Handler {
+	return micro.HandlerFunc(func(req micro.Request) {
+		r := &serviceRequest{endpoint: endpoint, req: req, done: make(chan struct{})}
+
+		select {
+		case requests <- r:
+		case <-stopped:
+			_ = req.Error("503", "service is stopping", nil)
+			return
+		}
+
+		select {
+		case <-r.done:
+		case <-stopped:
+			// -- the callback still responds, the stats just don't wait for it
+		}
+	})
+}
+
+// Drain stops the service from receiving new requests and waits until the batches which were already read have
+// been processed.
+func (i *ServiceInput) Drain(ctx context.Context) error {
+	i.inflight.Drain()
+	if err := i.stop(); err != nil {
+		return err
+	}
+	return i.inflight.Wait(ctx)
+}
+
+func (i *ServiceInput) Close(ctx spec.ComponentContext) error {
+	return i.stop()
+}
+
+func (i *ServiceInput) stop() error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.svc == nil || i.svc.Stopped() {
+		return nil
+	}
+
+	close(i.stopped)
+	if err := i.svc.Stop(); err != nil {
+		return fmt.Errorf("failed to stop service %s: %w", i.cfg.Name, err)
+	}
+	return nil
+}
+
+func (i *ServiceInput) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
+	i.lock.Lock()
+	svc, waiting, stopped := i.svc, i.requests, i.stopped
+	i.lock.Unlock()
+
+	if svc == nil || svc.Stopped() || i.inflight.Draining() {
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	// -- wait for the first request, then add the ones which are already waiting up to the batch count
+	var requests []*serviceRequest
+	select {
+	case r := <-waiting:
+		requests = append(requests, r)
+	case <-stopped:
+		return nil, nil, spec.ErrNotConnected
+	case <-ctx.Context().Done():
+		return nil, nil, ctx.Context().Err()
+	}
+
+pending:
+	for len(requests) < i.cfg.BatchCount {
+		select {
+		case r := <-waiting:
+			requests = append(requests, r)
+		default:
+			break pending
+		}
+	}
+
+	batch := ctx.NewBatch()
+	read := make([]spec.Message, 0, len(requests))
+	for _, r := range requests {
+		m := ctx.NewMessage()
+		m.SetRaw(r.req.Data())
+
+		for k, v := range r.req.Headers() {
+			if len(v) == 1 {
+				m.SetMetadata(k, v[0])
+			} else {
+				m.SetMetadata(k, strings.Join(v, ","))
+			}
+		}
+
+		m.SetMetadata("nats_subject", r.req.Subject())
+		m.SetMetadata("nats_service", i.cfg.Name)
+		m.SetMetadata("nats_service_endpoint", r.endpoint)
+
+		batch.Append(m)
+		read = append(read, m)
+	}
+
+	return batch, i.inflight.Track(i.respondCallback(requests, read)), nil
+}
+
+// respondCallback responds to the requests of a batch with the outcome of processing them. A request can only be
+// responded to once, so calling the callback again has no effect.
+func (i *ServiceInput) respondCallback(requests []*serviceRequest, read []spec.Message) spec.ProcessedCallback {
+	var once sync.Once
+	return func(ctx context.Context, err error) error {
+		var errs []error
+		once.Do(func() {
+			results := processedResults(ctx, read)
+			for idx, r := range requests {
+				if rErr := respond(r.req, results[idx], spec.MessageError(err, idx)); rErr != nil {
+					errs = append(errs, fmt.Errorf("failed to respond to request #%d: %w", idx, rErr))
+				}
+				close(r.done)
+			}
+		})
+		return errors.Join(errs...)
+	}
+}
+
+func respond(req micro.Request, result spec.Message, err error) error {
+	if err != nil {
+		return req.Error(errorCode(err), err.Error(), nil)
+	}
+
+	data, err := result.Raw()
+	if err != nil {
+		return req.Error("500", fmt.Sprintf("payload: %v", err), nil)
+	}
+	return req.Respond(data, micro.WithHeaders(micro.Headers(replyHeaders(result))))
+}
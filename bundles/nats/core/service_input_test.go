@@ -0,0 +1,163 @@
+package core_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go/micro"
+	"github.com/wombatwisdom/components/bundles/nats/core"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ServiceInput", func() {
+	var ctx spec.ComponentContext
+	var input *core.ServiceInput
+
+	// process handles the requests the way a pipeline does, failing the ones to the fail endpoint
+	process := func() {
+		defer GinkgoRecover()
+		for {
+			batch, callback, err := input.Read(ctx)
+			if err != nil {
+				return
+			}
+
+			var procErr error
+			for _, msg := range batch.Messages() {
+				meta := map[string]any{}
+				for k, v := range msg.Metadata() {
+					meta[k] = v
+				}
+
+				if meta["nats_service_endpoint"] == "fail" {
+					procErr = fmt.Errorf("%w: unknown product", spec.ErrPermanent)
+					continue
+				}
+
+				raw, _ := msg.Raw()
+				msg.SetRaw([]byte(fmt.Sprintf(`{"product":%q,"price":42}`, raw)))
+				msg.SetMetadata("currency", "EUR")
+			}
+			_ = callback(spec.WithProcessedBatch(context.Background(), batch), procErr)
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+
+		var err error
+		input, err = core.NewServiceInputFromConfig(newSystem(), spec.NewMapConfig(map[string]any{
+			"name":    "pricing",
+			"version": "1.2.0",
+			"endpoints": []any{
+				map[string]any{"name": "quote", "subject": "pricing.quote"},
+				map[string]any{"name": "fail", "subject": "pricing.fail"},
+			},
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+		DeferCleanup(func() { _ = input.Close(ctx) })
+		Expect(nc.Flush()).To(Succeed())
+
+		go process()
+	})
+
+	It("should respond to requests with the processed message", func() {
+		reply, err := nc.Request("pricing.quote", []byte("widget"), 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reply.Data).To(MatchJSON(`{"product":"widget","price":42}`))
+		Expect(reply.Header.Get("currency")).To(Equal("EUR"))
+		Expect(reply.Header.Get("nats_service_endpoint")).To(BeEmpty())
+	})
+
+	It("should respond with the error code when processing failed", func() {
+		reply, err := nc.Request("pricing.fail", []byte("widget"), 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reply.Header.Get(micro.ErrorCodeHeader)).To(Equal("400"))
+		Expect(reply.Header.Get(micro.ErrorHeader)).To(ContainSubstring("unknown product"))
+	})
+
+	It("should report the outcome of the requests through discovery", func() {
+		_, err := nc.Request("pricing.quote", []byte("widget"), 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = nc.Request("pricing.fail", []byte("widget"), 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		pong, err := nc.Request("$SRV.PING.pricing", nil, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pong.Data).To(ContainSubstring(`"version":"1.2.0"`))
+
+		reply, err := nc.Request("$SRV.STATS.pricing", nil, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		var stats micro.Stats
+		Expect(json.Unmarshal(reply.Data, &stats)).To(Succeed())
+		Expect(stats.Endpoints).To(HaveLen(2))
+		for _, endpoint := range stats.Endpoints {
+			Expect(endpoint.NumRequests).To(Equal(1))
+			Expect(endpoint.Data).To(MatchJSON(`{"in_flight":0,"draining":false}`))
+			if endpoint.Name == "fail" {
+				Expect(endpoint.NumErrors).To(Equal(1))
+				Expect(endpoint.LastError).To(ContainSubstring("unknown product"))
+			}
+		}
+	})
+
+	It("should keep the code of service errors", func() {
+		input2, err := core.NewServiceInputFromConfig(newSystem(), spec.NewMapConfig(map[string]any{
+			"name":      "relay",
+			"version":   "0.1.0",
+			"endpoints": []any{map[string]any{"name": "relay"}},
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input2.Init(ctx)).To(Succeed())
+		DeferCleanup(func() { _ = input2.Close(ctx) })
+		Expect(nc.Flush()).To(Succeed())
+
+		go func() {
+			defer GinkgoRecover()
+			_, callback, err := input2.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			_ = callback(context.Background(), fmt.Errorf("upstream: %w", &core.ServiceError{Code: "404", Description: "not found"}))
+		}()
+
+		reply, err := nc.Request("relay", nil, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reply.Header.Get(micro.ErrorCodeHeader)).To(Equal("404"))
+	})
+
+	It("should serve the endpoints again when initialized after being closed", func() {
+		Expect(input.Close(ctx)).To(Succeed())
+		Expect(input.Init(ctx)).To(Succeed())
+		Expect(nc.Flush()).To(Succeed())
+		go process()
+
+		reply, err := nc.Request("pricing.quote", []byte("gadget"), 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reply.Data).To(MatchJSON(`{"product":"gadget","price":42}`))
+	})
+
+	It("should serve the endpoints again when initialized after being drained and closed", func() {
+		Expect(spec.DrainAndClose(ctx, input, time.Second)).To(Succeed())
+		Expect(input.Init(ctx)).To(Succeed())
+		Expect(nc.Flush()).To(Succeed())
+		go process()
+
+		reply, err := nc.Request("pricing.quote", []byte("gadget"), 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reply.Data).To(MatchJSON(`{"product":"gadget","price":42}`))
+	})
+
+	It("should stop reading once drained", func() {
+		Expect(input.Drain(context.Background())).To(Succeed())
+
+		_, _, err := input.Read(ctx)
+		Expect(err).To(MatchError(spec.ErrNotConnected))
+	})
+})
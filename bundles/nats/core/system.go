@@ -2,9 +2,14 @@ package core
 
 import (
 	"context"
+	"crypto/tls"
 	_ "embed"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
@@ -15,7 +20,8 @@ func NewSystem(rawConfig string) (*System, error) {
 	}
 
 	return &System{
-		cfg: cfg,
+		cfg:    cfg,
+		states: spec.NewConnectionStates(),
 	}, nil
 }
 
@@ -27,41 +33,266 @@ func NewSystemFromConfig(config spec.Config) (*System, error) {
 	}
 
 	return &System{
-		cfg: cfg,
+		cfg:    cfg,
+		states: spec.NewConnectionStates(),
 	}, nil
 }
 
 // System represents a NATS system.
 //
 // In case your NATS server requires authentication, you can provide the necessary credentials through the auth section
-// of the configuration. This section gives you the ability to provide the NKey seed and JWT token for the user. Since
-// both of these are sensitive information, it is recommended to expose them through environment variables and not make
-// them explicit in the configuration.
+// of the configuration. This section gives you the ability to provide a credentials file, the NKey seed and JWT token
+// for the user, an NKey seed on its own, a token, or a user and password. Since these are sensitive information, it is
+// recommended to expose them through environment variables and not make them explicit in the configuration. The tls
+// section secures the connection, with the client certificate in case the server requires mutual TLS.
+//
+// Once connected, the connection is restored automatically when it is lost. The system reports the state of the
+// connection through spec.ConnectionObserver, so components can re-establish their server side state after a
+// reconnect. Wrap the system in a spec.Supervisor to retry the initial connect as well.
 type System struct {
-	cfg SystemConfig
-	nc  *nats.Conn
+	cfg    SystemConfig
+	nc     *nats.Conn
+	states *spec.ConnectionStates
 }
 
 func (c *System) Connect(ctx context.Context) error {
-	var err error
-	var opts []nats.Option
+	if c.nc != nil && !c.nc.IsClosed() {
+		return spec.ErrAlreadyConnected
+	}
 
-	opts = append(opts, nats.Name(c.cfg.Name))
-	if c.cfg.Auth != nil {
-		opts = append(opts, nats.UserJWTAndSeed(c.cfg.Auth.Jwt, c.cfg.Auth.Seed))
+	nc, err := Connect(c.cfg, c.states)
+	if err != nil {
+		return err
 	}
 
-	c.nc, err = nats.Connect(c.cfg.Url, opts...)
-	return err
+	c.nc = nc
+	return nil
+}
+
+// Connect opens a connection using the given configuration. The connection reconnects indefinitely once it has been
+// established, unless the configuration limits the attempts, reporting its state changes to states.
+func Connect(cfg SystemConfig, states *spec.ConnectionStates) (*nats.Conn, error) {
+	opts, err := connectOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts,
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			states.Set(spec.StateReconnecting, err)
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			states.Set(spec.StateConnected, nil)
+		}),
+		nats.ClosedHandler(func(nc *nats.Conn) {
+			states.Set(spec.StateClosed, nc.LastError())
+		}),
+	)
+
+	urls := cfg.Servers
+	if cfg.Url != "" {
+		urls = append([]string{cfg.Url}, urls...)
+	}
+
+	states.Set(spec.StateConnecting, nil)
+
+	nc, err := nats.Connect(strings.Join(urls, ","), opts...)
+	if err != nil {
+		states.Set(spec.StateDisconnected, err)
+		return nil, err
+	}
+
+	states.Set(spec.StateConnected, nil)
+	return nc, nil
+}
+
+// connectOptions translates the configuration into the options of the connection.
+func connectOptions(cfg SystemConfig) ([]nats.Option, error) {
+	maxReconnects := -1
+	if cfg.MaxReconnects != nil {
+		maxReconnects = *cfg.MaxReconnects
+	}
+
+	opts := []nats.Option{
+		nats.Name(cfg.Name),
+		nats.MaxReconnects(maxReconnects),
+	}
+
+	reconnectWait, err := parseDuration("reconnect_wait", cfg.ReconnectWait)
+	if err != nil {
+		return nil, err
+	}
+	if reconnectWait > 0 {
+		opts = append(opts, nats.ReconnectWait(reconnectWait))
+	}
+
+	reconnectJitter, err := parseDuration("reconnect_jitter", cfg.ReconnectJitter)
+	if err != nil {
+		return nil, err
+	}
+	if reconnectJitter > 0 {
+		opts = append(opts, nats.ReconnectJitter(reconnectJitter, reconnectJitter))
+	}
+
+	pingInterval, err := parseDuration("ping_interval", cfg.PingInterval)
+	if err != nil {
+		return nil, err
+	}
+	if pingInterval > 0 {
+		opts = append(opts, nats.PingInterval(pingInterval))
+	}
+
+	if cfg.InboxPrefix != "" {
+		opts = append(opts, nats.CustomInboxPrefix(cfg.InboxPrefix))
+	}
+
+	if cfg.Auth != nil {
+		opt, err := authOption(*cfg.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+		opts = append(opts, opt)
+	}
+
+	if cfg.Tls != nil {
+		tlsOpts, err := tlsOptions(*cfg.Tls)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		opts = append(opts, tlsOpts...)
+	}
+
+	return opts, nil
+}
+
+// parseDuration parses the duration of the named field, which is zero when the field isn't set.
+func parseDuration(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return d, nil
+}
+
+// authOption returns the option authenticating the connection in the one way the configuration allows.
+func authOption(auth SystemConfigAuth) (nats.Option, error) {
+	var methods []string
+	var opt nats.Option
+
+	if auth.CredsFile != "" {
+		methods = append(methods, "creds_file")
+		opt = nats.UserCredentials(auth.CredsFile)
+	}
+	if auth.Jwt != "" || auth.Seed != "" {
+		if auth.Jwt == "" || auth.Seed == "" {
+			return nil, fmt.Errorf("the jwt and seed have to be configured together")
+		}
+		methods = append(methods, "jwt")
+		opt = nats.UserJWTAndSeed(auth.Jwt, auth.Seed)
+	}
+	if auth.NkeySeed != "" {
+		kp, err := nkeys.FromSeed([]byte(auth.NkeySeed))
+		if err != nil {
+			return nil, fmt.Errorf("invalid nkey seed: %w", err)
+		}
+		pub, err := kp.PublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid nkey seed: %w", err)
+		}
+		methods = append(methods, "nkey_seed")
+		opt = nats.Nkey(pub, kp.Sign)
+	}
+	if auth.Token != "" {
+		methods = append(methods, "token")
+		opt = nats.Token(auth.Token)
+	}
+	if auth.User != "" || auth.Password != "" {
+		if auth.User == "" {
+			return nil, fmt.Errorf("a password requires a user")
+		}
+		methods = append(methods, "user")
+		opt = nats.UserInfo(auth.User, auth.Password)
+	}
+
+	switch len(methods) {
+	case 0:
+		return nil, fmt.Errorf("no credentials configured")
+	case 1:
+		return opt, nil
+	default:
+		return nil, fmt.Errorf("only one way of authenticating can be configured, got %s", strings.Join(methods, ", "))
+	}
+}
+
+// tlsOptions returns the options securing the connection with TLS.
+func tlsOptions(cfg SystemConfigTls) ([]nats.Option, error) {
+	opts := []nats.Option{
+		nats.Secure(&tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         cfg.ServerName,
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+		}),
+	}
+
+	if cfg.CaFile != "" {
+		opts = append(opts, nats.RootCAs(cfg.CaFile))
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("the cert_file and key_file have to be configured together")
+		}
+		opts = append(opts, nats.ClientCert(cfg.CertFile, cfg.KeyFile))
+	}
+
+	return opts, nil
+}
+
+func (c *System) ConnectionState() spec.ConnectionState {
+	return c.states.ConnectionState()
+}
+
+func (c *System) Observe(fn func(spec.ConnectionEvent)) (stop func()) {
+	return c.states.Observe(fn)
 }
 
 func (c *System) Client() any {
 	return c.nc
 }
 
+// Close drains the connection, giving the subscriptions the chance to process the messages they already received and
+// flushing the pending publishes, before closing it. If ctx is done before the drain completes, the connection is
+// closed right away.
 func (c *System) Close(ctx context.Context) error {
-	if c.nc != nil {
-		c.nc.Close()
+	return DrainConnection(ctx, c.nc)
+}
+
+// DrainConnection drains the given connection and waits for it to be closed. The connection is closed forcefully if
+// ctx is done first, in which case an error wrapping spec.ErrDrainTimeout is returned.
+func DrainConnection(ctx context.Context, nc *nats.Conn) error {
+	if nc == nil || nc.IsClosed() {
+		return nil
+	}
+
+	if err := nc.Drain(); err != nil {
+		nc.Close()
+		return fmt.Errorf("failed to drain connection: %w", err)
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !nc.IsClosed() {
+		select {
+		case <-ctx.Done():
+			nc.Close()
+			return fmt.Errorf("%w: %w", spec.ErrDrainTimeout, ctx.Err())
+		case <-ticker.C:
+		}
 	}
 
 	return nil
@@ -11,9 +11,46 @@ type SystemConfig struct {
 	//
 	Auth *SystemConfigAuth `json:"auth,omitempty" yaml:"auth,omitempty" mapstructure:"auth,omitempty"`
 
+	// The prefix of the inboxes the connection receives replies on. Accounts which
+	// may only subscribe to specific subjects need it to receive replies at all.
+	//
+	InboxPrefix string `json:"inbox_prefix,omitempty" yaml:"inbox_prefix,omitempty" mapstructure:"inbox_prefix,omitempty"`
+
+	// Optional JetStream settings. Only applies to the JetStream components.
+	//
+	JetStream *SystemConfigJetStream `json:"jetstream,omitempty" yaml:"jetstream,omitempty" mapstructure:"jetstream,omitempty"`
+
+	// The maximum number of attempts to restore a lost connection. Defaults to -1,
+	// which keeps trying indefinitely.
+	//
+	MaxReconnects *int `json:"max_reconnects,omitempty" yaml:"max_reconnects,omitempty" mapstructure:"max_reconnects,omitempty"`
+
 	// An optional name for the connection to distinguish it from others.
 	Name string `json:"name,omitempty" yaml:"name,omitempty" mapstructure:"name,omitempty"`
 
+	// The interval at which the server is pinged to detect a broken connection, as a
+	// duration like 20s.
+	//
+	PingInterval string `json:"ping_interval,omitempty" yaml:"ping_interval,omitempty" mapstructure:"ping_interval,omitempty"`
+
+	// The upper bound of the random delay added to the reconnect wait, so clients
+	// don't reconnect all at once after a server restart, as a duration like 100ms.
+	//
+	ReconnectJitter string `json:"reconnect_jitter,omitempty" yaml:"reconnect_jitter,omitempty" mapstructure:"reconnect_jitter,omitempty"`
+
+	// The time to wait between attempts to reconnect to the same server, as a
+	// duration like 2s.
+	//
+	ReconnectWait string `json:"reconnect_wait,omitempty" yaml:"reconnect_wait,omitempty" mapstructure:"reconnect_wait,omitempty"`
+
+	// Additional urls of servers in the cluster, used as seeds next to the url.
+	//
+	Servers []string `json:"servers,omitempty" yaml:"servers,omitempty" mapstructure:"servers,omitempty"`
+
+	// Optional TLS settings. When set, the connection requires TLS.
+	//
+	Tls *SystemConfigTls `json:"tls,omitempty" yaml:"tls,omitempty" mapstructure:"tls,omitempty"`
+
 	// Url of the NATS server to connect to.  Multiple URLs can be specified by
 	// separating them with commas. If an item of the list contains commas it will  be
 	// expanded into multiple URLs.
@@ -24,18 +61,79 @@ type SystemConfig struct {
 	Url string `json:"url" yaml:"url" mapstructure:"url"`
 }
 
+// Optional JetStream settings. Only applies to the JetStream components.
+type SystemConfigJetStream struct {
+	// The prefix of the JetStream API subjects, for accessing the JetStream of
+	// another account through imports. Can't be combined with a domain.
+	//
+	ApiPrefix string `json:"api_prefix,omitempty" yaml:"api_prefix,omitempty" mapstructure:"api_prefix,omitempty"`
+
+	// The JetStream domain to use, e.g. the hub or a leaf node.
+	//
+	Domain string `json:"domain,omitempty" yaml:"domain,omitempty" mapstructure:"domain,omitempty"`
+}
+
+// Optional TLS settings. When set, the connection requires TLS.
+type SystemConfigTls struct {
+	// The file of the certificate authority to verify the server certificate with.
+	// Without it, the system certificate pool is used.
+	//
+	CaFile string `json:"ca_file,omitempty" yaml:"ca_file,omitempty" mapstructure:"ca_file,omitempty"`
+
+	// The file of the client certificate, for servers which require mutual TLS.
+	// Requires the key file as well.
+	//
+	CertFile string `json:"cert_file,omitempty" yaml:"cert_file,omitempty" mapstructure:"cert_file,omitempty"`
+
+	// Whether to skip verifying the server certificate. Only use this for testing.
+	//
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty" mapstructure:"insecure_skip_verify,omitempty"`
+
+	// The file of the private key of the client certificate.
+	//
+	KeyFile string `json:"key_file,omitempty" yaml:"key_file,omitempty" mapstructure:"key_file,omitempty"`
+
+	// The name to verify the server certificate against, when it differs from the
+	// host of the url.
+	//
+	ServerName string `json:"server_name,omitempty" yaml:"server_name,omitempty" mapstructure:"server_name,omitempty"`
+}
+
 // Optional authentication information for the NATS server.  If not provided, the
-// connection will be made without authentication.
+// connection will be made without authentication. Only one way of authenticating
+// can be configured: a credentials file, a user JWT and seed, an nkey seed, a
+// token, or a user and password.
 type SystemConfigAuth struct {
+	// The file holding the user JWT and seed, as generated by nsc.
+	//
+	CredsFile string `json:"creds_file,omitempty" yaml:"creds_file,omitempty" mapstructure:"creds_file,omitempty"`
+
 	// The user JWT token. This is a sensitive field and you may want to use
-	// environment variables instead of defining a constant value.
+	// environment variables instead of defining a constant value. Requires the seed.
+	//
+	Jwt string `json:"jwt,omitempty" yaml:"jwt,omitempty" mapstructure:"jwt,omitempty"`
+
+	// The seed of an nkey user, for servers which authenticate users by their nkey
+	// without a JWT. This is a sensitive field.
+	//
+	NkeySeed string `json:"nkey_seed,omitempty" yaml:"nkey_seed,omitempty" mapstructure:"nkey_seed,omitempty"`
+
+	// The password of the user. This is a sensitive field.
 	//
-	Jwt string `json:"jwt" yaml:"jwt" mapstructure:"jwt"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty" mapstructure:"password,omitempty"`
 
 	// The user seed.  This is a sensitive field and you may want to use environment
-	// variables instead of defining a constant value.
+	// variables instead of defining a constant value. Requires the JWT.
+	//
+	Seed string `json:"seed,omitempty" yaml:"seed,omitempty" mapstructure:"seed,omitempty"`
+
+	// The token to authenticate with. This is a sensitive field.
+	//
+	Token string `json:"token,omitempty" yaml:"token,omitempty" mapstructure:"token,omitempty"`
+
+	// The name of the user to authenticate with, along with the password.
 	//
-	Seed string `json:"seed" yaml:"seed" mapstructure:"seed"`
+	User string `json:"user,omitempty" yaml:"user,omitempty" mapstructure:"user,omitempty"`
 }
 
 // UnmarshalJSON implements json.Unmarshaler.
@@ -44,10 +142,12 @@ func (j *SystemConfigAuth) UnmarshalJSON(value []byte) error {
 	if err := json.Unmarshal(value, &raw); err != nil {
 		return err
 	}
-	if _, ok := raw["jwt"]; raw != nil && !ok {
+	_, hasJwt := raw["jwt"]
+	_, hasSeed := raw["seed"]
+	if hasSeed && !hasJwt {
 		return fmt.Errorf("field jwt in SystemConfigAuth: required")
 	}
-	if _, ok := raw["seed"]; raw != nil && !ok {
+	if hasJwt && !hasSeed {
 		return fmt.Errorf("field seed in SystemConfigAuth: required")
 	}
 	type Plain SystemConfigAuth
@@ -73,7 +173,7 @@ func (j *SystemConfig) UnmarshalJSON(value []byte) error {
 	if v, ok := raw["name"]; !ok || v == nil {
 		plain.Name = "wombat"
 	}
-	if v, ok := raw["url"]; !ok || v == nil {
+	if v, ok := raw["url"]; (!ok || v == nil) && len(plain.Servers) == 0 {
 		plain.Url = "nats://localhost:4222"
 	}
 	*j = SystemConfig(plain)
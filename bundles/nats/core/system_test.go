@@ -2,11 +2,18 @@ package core_test
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"time"
 
+	"github.com/nats-io/nats-server/v2/server"
+	natsserver "github.com/nats-io/nats-server/v2/test"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/wombatwisdom/components/bundles/nats/core"
+	"github.com/wombatwisdom/components/bundles/nats/test"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
@@ -74,4 +81,235 @@ auth:
 			defer nc.Close()
 		})
 	})
+
+	When("the system is closed", func() {
+		It("should drain the connection", func() {
+			jwt, seed := acc.Creds()
+			config := spec.NewYamlConfig(`
+url: ##url##
+auth:
+  jwt: ##jwt##
+  seed: ##seed##
+`, "##url##", srv.ClientURL(), "##jwt##", jwt, "##seed##", string(seed))
+
+			system, err := core.NewSystemFromConfig(config)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(system.Connect(context.Background())).To(Succeed())
+
+			nc := system.Client().(*nats.Conn)
+
+			received := make(chan *nats.Msg, 10)
+			_, err = nc.ChanSubscribe("drain.test", received)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nc.Flush()).To(Succeed())
+
+			for range 5 {
+				Expect(nc.Publish("drain.test", []byte("pending"))).To(Succeed())
+			}
+
+			Expect(system.Close(context.Background())).To(Succeed())
+			Expect(nc.IsClosed()).To(BeTrue())
+			Expect(received).To(HaveLen(5))
+		})
+	})
+
+	When("the connection is lost", func() {
+		It("should report the reconnect and restore the connection", func() {
+			opts := natsserver.DefaultTestOptions
+			opts.Port = -1
+			plain := natsserver.RunServer(&opts)
+			defer func() { plain.Shutdown() }()
+
+			system, err := core.NewSystemFromConfig(spec.NewYamlConfig(`url: ` + plain.ClientURL()))
+			Expect(err).ToNot(HaveOccurred())
+
+			events := make(chan spec.ConnectionEvent, 10)
+			system.Observe(func(evt spec.ConnectionEvent) { events <- evt })
+
+			Expect(system.Connect(context.Background())).To(Succeed())
+			defer func() { _ = system.Close(context.Background()) }()
+			Eventually(events).Should(Receive(HaveField("State", spec.StateConnecting)))
+			Eventually(events).Should(Receive(HaveField("State", spec.StateConnected)))
+
+			opts.Port = plain.Addr().(*net.TCPAddr).Port
+			plain.Shutdown()
+			Eventually(events).Should(Receive(HaveField("State", spec.StateReconnecting)))
+			Expect(system.ConnectionState()).To(Equal(spec.StateReconnecting))
+
+			plain = natsserver.RunServer(&opts)
+			var evt spec.ConnectionEvent
+			Eventually(events, 10*time.Second).Should(Receive(&evt))
+			Expect(evt.Reconnected()).To(BeTrue())
+		})
+	})
+
+	Describe("connection options", func() {
+		runServer := func(configure func(opts *server.Options)) *server.Server {
+			opts := natsserver.DefaultTestOptions
+			opts.Port = -1
+			configure(&opts)
+			s := natsserver.RunServer(&opts)
+			DeferCleanup(s.Shutdown)
+			return s
+		}
+
+		connect := func(config string, replacements ...string) (*core.System, error) {
+			system, err := core.NewSystemFromConfig(spec.NewYamlConfig(config, replacements...))
+			Expect(err).ToNot(HaveOccurred())
+
+			if err := system.Connect(context.Background()); err != nil {
+				return nil, err
+			}
+			DeferCleanup(func() { _ = system.Close(context.Background()) })
+			return system, nil
+		}
+
+		It("should authenticate with a credentials file", func() {
+			_, err := connect(`
+url: ##url##
+auth:
+  creds_file: ##file##
+`, "##url##", srv.ClientURL(), "##file##", acc.CredsFile(GinkgoT().TempDir()))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should authenticate with a token", func() {
+			s := runServer(func(opts *server.Options) { opts.Authorization = "s3cret" })
+
+			_, err := connect("url: ##url##\nauth:\n  token: s3cret", "##url##", s.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = connect("url: ##url##\nauth:\n  token: wrong", "##url##", s.ClientURL())
+			Expect(err).To(MatchError(nats.ErrAuthorization))
+		})
+
+		It("should authenticate with a user and password", func() {
+			s := runServer(func(opts *server.Options) {
+				opts.Username = "wombat"
+				opts.Password = "s3cret"
+			})
+
+			_, err := connect(`
+url: ##url##
+auth:
+  user: wombat
+  password: s3cret
+`, "##url##", s.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should authenticate with an nkey seed", func() {
+			user, err := nkeys.CreateUser()
+			Expect(err).ToNot(HaveOccurred())
+			pub, err := user.PublicKey()
+			Expect(err).ToNot(HaveOccurred())
+			seed, err := user.Seed()
+			Expect(err).ToNot(HaveOccurred())
+
+			s := runServer(func(opts *server.Options) {
+				opts.Nkeys = []*server.NkeyUser{{Nkey: pub}}
+			})
+
+			_, err = connect("url: ##url##\nauth:\n  nkey_seed: ##seed##", "##url##", s.ClientURL(), "##seed##", string(seed))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should refuse more than one way of authenticating", func() {
+			_, err := connect(`
+url: ##url##
+auth:
+  token: s3cret
+  user: wombat
+`, "##url##", srv.ClientURL())
+			Expect(err).To(MatchError(ContainSubstring("only one way of authenticating can be configured, got token, user")))
+		})
+
+		It("should connect with mutual TLS", func() {
+			certs := test.NewCertificates(GinkgoT().TempDir())
+			s := runServer(func(opts *server.Options) {
+				tlsConfig, err := server.GenTLSConfig(&server.TLSConfigOpts{
+					CertFile: certs.ServerCertFile,
+					KeyFile:  certs.ServerKeyFile,
+					CaFile:   certs.CaFile,
+					Verify:   true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				opts.TLSConfig = tlsConfig
+				opts.TLS = true
+				opts.TLSVerify = true
+			})
+
+			system, err := connect(`
+url: ##url##
+tls:
+  ca_file: ##ca##
+  cert_file: ##cert##
+  key_file: ##key##
+`, "##url##", s.ClientURL(), "##ca##", certs.CaFile, "##cert##", certs.ClientCertFile, "##key##", certs.ClientKeyFile)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(system.Client().(*nats.Conn).TLSRequired()).To(BeTrue())
+
+			_, err = connect("url: ##url##\ntls:\n  ca_file: ##ca##", "##url##", s.ClientURL(), "##ca##", certs.CaFile)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should use the servers as seeds", func() {
+			s := runServer(func(*server.Options) {})
+
+			system, err := connect(`
+url: nats://127.0.0.1:1
+servers:
+  - ##url##
+max_reconnects: 3
+reconnect_wait: 100ms
+reconnect_jitter: 10ms
+ping_interval: 5s
+`, "##url##", s.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+
+			nc := system.Client().(*nats.Conn)
+			Expect(nc.ConnectedUrl()).To(Equal(s.ClientURL()))
+			Expect(nc.Opts.MaxReconnect).To(Equal(3))
+			Expect(nc.Opts.ReconnectWait).To(Equal(100 * time.Millisecond))
+			Expect(nc.Opts.PingInterval).To(Equal(5 * time.Second))
+		})
+
+		It("should read the durations of a JSON configuration", func() {
+			s := runServer(func(*server.Options) {})
+
+			system, err := core.NewSystem(fmt.Sprintf(
+				`{"url": %q, "reconnect_wait": "100ms", "reconnect_jitter": "10ms", "ping_interval": "5s"}`, s.ClientURL()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(system.Connect(context.Background())).To(Succeed())
+			DeferCleanup(func() { _ = system.Close(context.Background()) })
+
+			nc := system.Client().(*nats.Conn)
+			Expect(nc.Opts.ReconnectWait).To(Equal(100 * time.Millisecond))
+			Expect(nc.Opts.ReconnectJitter).To(Equal(10 * time.Millisecond))
+			Expect(nc.Opts.PingInterval).To(Equal(5 * time.Second))
+		})
+
+		It("should refuse to connect with an invalid duration", func() {
+			_, err := connect("url: nats://127.0.0.1:1\nping_interval: often")
+			Expect(err).To(MatchError(ContainSubstring("ping_interval")))
+		})
+
+		It("should receive replies on the inbox prefix", func() {
+			s := runServer(func(*server.Options) {})
+
+			system, err := connect("url: ##url##\ninbox_prefix: _INBOX.wombat", "##url##", s.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+
+			nc := system.Client().(*nats.Conn)
+			Expect(nc.NewRespInbox()).To(HavePrefix("_INBOX.wombat."))
+
+			sub, err := nc.Subscribe("echo", func(msg *nats.Msg) { _ = msg.Respond(msg.Data) })
+			Expect(err).ToNot(HaveOccurred())
+			defer func() { _ = sub.Unsubscribe() }()
+
+			reply, err := nc.Request("echo", []byte("hello"), time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reply.Subject).To(HavePrefix("_INBOX.wombat."))
+		})
+	})
 })
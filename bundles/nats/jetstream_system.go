@@ -6,15 +6,21 @@ import (
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
+	"github.com/wombatwisdom/components/bundles/nats/core"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
 // JetStreamSystem represents a NATS JetStream system that provides access to
 // JetStream features including streams, key-value stores, and object stores.
+//
+// Like the core System, the connection is restored automatically when it is lost and its state is reported through
+// spec.ConnectionObserver. The connection is configured the same way as the one of the core System, the jetstream
+// section selects the JetStream domain or API prefix to use.
 type JetStreamSystem struct {
-	cfg SystemConfig
-	nc  *nats.Conn
-	js  jetstream.JetStream
+	cfg    SystemConfig
+	nc     *nats.Conn
+	js     jetstream.JetStream
+	states *spec.ConnectionStates
 }
 
 // NewJetStreamSystemFromConfig creates a JetStream system from a spec.Config interface
@@ -25,43 +31,62 @@ func NewJetStreamSystemFromConfig(config spec.Config) (*JetStreamSystem, error)
 	}
 
 	return &JetStreamSystem{
-		cfg: cfg,
+		cfg:    cfg,
+		states: spec.NewConnectionStates(),
 	}, nil
 }
 
 func (js *JetStreamSystem) Connect(ctx context.Context) error {
-	var err error
-	var opts []nats.Option
-
-	opts = append(opts, nats.Name(js.cfg.Name))
-	if js.cfg.Auth != nil {
-		opts = append(opts, nats.UserJWTAndSeed(js.cfg.Auth.Jwt, js.cfg.Auth.Seed))
+	if js.nc != nil && !js.nc.IsClosed() {
+		return spec.ErrAlreadyConnected
 	}
 
-	js.nc, err = nats.Connect(js.cfg.Url, opts...)
+	nc, err := core.Connect(js.cfg, js.states)
 	if err != nil {
 		return fmt.Errorf("failed to connect to NATS: %w", err)
 	}
 
-	// Create JetStream context with default options
-	js.js, err = jetstream.New(js.nc)
+	jsc, err := newJetStream(nc, js.cfg.JetStream)
 	if err != nil {
-		js.nc.Close()
+		nc.Close()
 		return fmt.Errorf("failed to create JetStream context: %w", err)
 	}
 
+	js.nc, js.js = nc, jsc
 	return nil
 }
 
+// newJetStream creates the JetStream context for the configured domain or API prefix.
+func newJetStream(nc *nats.Conn, cfg *core.SystemConfigJetStream) (jetstream.JetStream, error) {
+	switch {
+	case cfg == nil:
+		return jetstream.New(nc)
+	case cfg.Domain != "" && cfg.ApiPrefix != "":
+		return nil, fmt.Errorf("a domain and api prefix can't be combined")
+	case cfg.Domain != "":
+		return jetstream.NewWithDomain(nc, cfg.Domain)
+	case cfg.ApiPrefix != "":
+		return jetstream.NewWithAPIPrefix(nc, cfg.ApiPrefix)
+	default:
+		return jetstream.New(nc)
+	}
+}
+
+func (js *JetStreamSystem) ConnectionState() spec.ConnectionState {
+	return js.states.ConnectionState()
+}
+
+func (js *JetStreamSystem) Observe(fn func(spec.ConnectionEvent)) (stop func()) {
+	return js.states.Observe(fn)
+}
+
 func (js *JetStreamSystem) Client() any {
 	return js.js
 }
 
+// Close drains the connection before closing it, see core.DrainConnection.
 func (js *JetStreamSystem) Close(ctx context.Context) error {
-	if js.nc != nil {
-		js.nc.Close()
-	}
-	return nil
+	return core.DrainConnection(ctx, js.nc)
 }
 
 // NATSConn returns the underlying NATS connection for advanced use cases
@@ -0,0 +1,80 @@
+package nats_test
+
+import (
+	"context"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go/jetstream"
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/framework/spec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JetStreamSystem", func() {
+	var hub *server.Server
+
+	BeforeEach(func() {
+		opts := natsserver.DefaultTestOptions
+		opts.Port = -1
+		opts.JetStream = true
+		opts.JetStreamDomain = "hub"
+		opts.StoreDir = GinkgoT().TempDir()
+		hub = natsserver.RunServer(&opts)
+		DeferCleanup(hub.Shutdown)
+	})
+
+	connect := func(jetstreamConfig string) jetstream.JetStream {
+		sys, err := wwnats.NewJetStreamSystemFromConfig(spec.NewYamlConfig("url: ##url##\n"+jetstreamConfig, "##url##", hub.ClientURL()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sys.Connect(context.Background())).To(Succeed())
+		DeferCleanup(func() { _ = sys.Close(context.Background()) })
+		return sys.JetStream()
+	}
+
+	When("it is already connected", func() {
+		It("should refuse to connect again until it is closed", func() {
+			sys, err := wwnats.NewJetStreamSystemFromConfig(spec.NewYamlConfig("url: ##url##\n", "##url##", hub.ClientURL()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sys.Connect(context.Background())).To(Succeed())
+			Expect(sys.Connect(context.Background())).To(MatchError(spec.ErrAlreadyConnected))
+
+			Expect(sys.Close(context.Background())).To(Succeed())
+			Expect(sys.Connect(context.Background())).To(Succeed())
+			Expect(sys.Close(context.Background())).To(Succeed())
+		})
+	})
+
+	When("a domain is configured", func() {
+		It("should use the JetStream of that domain", func() {
+			info, err := connect("jetstream:\n  domain: hub").AccountInfo(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Domain).To(Equal("hub"))
+
+			_, err = connect("jetstream:\n  domain: leaf").AccountInfo(context.Background())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("an api prefix is configured", func() {
+		It("should send the JetStream requests to it", func() {
+			_, err := connect("jetstream:\n  api_prefix: $JS.hub.API").AccountInfo(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("both a domain and an api prefix are configured", func() {
+		It("should fail to connect", func() {
+			sys, err := wwnats.NewJetStreamSystemFromConfig(spec.NewYamlConfig(`
+url: ##url##
+jetstream:
+  domain: hub
+  api_prefix: $JS.hub.API
+`, "##url##", hub.ClientURL()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sys.Connect(context.Background())).To(MatchError(ContainSubstring("a domain and api prefix can't be combined")))
+		})
+	})
+})
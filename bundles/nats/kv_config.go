@@ -0,0 +1,73 @@
+package nats
+
+import (
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+type KVInputConfig struct {
+	// The name of the key-value bucket to watch.
+	Bucket string `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+
+	// The key pattern to watch, which may contain wildcards. Defaults to all keys of
+	// the bucket.
+	Key string `json:"key,omitempty" yaml:"key,omitempty" mapstructure:"key,omitempty"`
+
+	// Whether to replay all revisions of the matching keys the bucket holds instead
+	// of only their latest value.
+	IncludeHistory bool `json:"include_history,omitempty" yaml:"include_history,omitempty" mapstructure:"include_history,omitempty"`
+
+	// Whether to skip delete and purge operations.
+	IgnoreDeletes bool `json:"ignore_deletes,omitempty" yaml:"ignore_deletes,omitempty" mapstructure:"ignore_deletes,omitempty"`
+
+	// Whether to only emit changes made after the watch started, without the
+	// current values.
+	UpdatesOnly bool `json:"updates_only,omitempty" yaml:"updates_only,omitempty" mapstructure:"updates_only,omitempty"`
+
+	// The maximum number of changes to read at a time. Defaults to 1.
+	BatchCount int `json:"batch_count,omitempty" yaml:"batch_count,omitempty" mapstructure:"batch_count,omitempty"`
+}
+
+// KVOperation defines what the key-value output does with the key of a message.
+type KVOperation string
+
+const (
+	KVOperationPut    KVOperation = "put"
+	KVOperationCreate KVOperation = "create"
+	KVOperationUpdate KVOperation = "update"
+	KVOperationDelete KVOperation = "delete"
+	KVOperationPurge  KVOperation = "purge"
+)
+
+type KVOutputConfig struct {
+	// The name of the key-value bucket to write to.
+	Bucket string `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+
+	// The expression producing the key of each message.
+	Key spec.Expression `json:"key" yaml:"key" mapstructure:"key"`
+
+	// The operation to perform. Put stores the message regardless of the current
+	// value, create only stores it when the key doesn't exist yet and update only
+	// when the key is at the expected revision. Delete and purge remove the key,
+	// purge removes its history as well. Defaults to put.
+	Operation KVOperation `json:"operation,omitempty" yaml:"operation,omitempty" mapstructure:"operation,omitempty"`
+
+	// The expression producing the revision the key is expected to be at. Required
+	// for update, optional for delete and purge.
+	Revision spec.Expression `json:"revision,omitempty" yaml:"revision,omitempty" mapstructure:"revision,omitempty"`
+}
+
+type KVProcessorConfig struct {
+	// The name of the key-value bucket to read from.
+	Bucket string `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+
+	// The expression producing the key to get for each message.
+	Key spec.Expression `json:"key" yaml:"key" mapstructure:"key"`
+
+	// The field of the message to store the value under. Without it, the value has
+	// to be a JSON object whose fields are merged into the message.
+	Field string `json:"field,omitempty" yaml:"field,omitempty" mapstructure:"field,omitempty"`
+
+	// Whether to leave messages unchanged when their key doesn't exist, instead of
+	// failing them.
+	IgnoreMissing bool `json:"ignore_missing,omitempty" yaml:"ignore_missing,omitempty" mapstructure:"ignore_missing,omitempty"`
+}
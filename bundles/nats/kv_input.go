@@ -0,0 +1,215 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	KVComponentName = "nats_kv"
+)
+
+// The metadata set on the messages of the key-value components.
+const (
+	MetadataKVBucket    = "kv_bucket"
+	MetadataKVKey       = "kv_key"
+	MetadataKVRevision  = "kv_revision"
+	MetadataKVOperation = "kv_operation"
+)
+
+// KVInput watches a NATS JetStream key-value bucket and emits a message for every change to its keys.
+//
+// The watch starts with the current value of each matching key, or all revisions the bucket holds when the history
+// is included, followed by the changes made while the input runs. Deleted and purged keys produce empty messages
+// with the delete or purge operation, unless deletes are ignored.
+type KVInput struct {
+	sys spec.System
+	cfg KVInputConfig
+
+	// lock guards the watch, which is reset when the input is closed so it can be initialized again
+	lock    sync.Mutex
+	watcher jetstream.KeyWatcher
+	cancel  context.CancelFunc
+
+	// inflight tracks the batches which haven't been processed yet
+	inflight *spec.InFlight
+}
+
+// NewKVInputFromConfig creates a new NATS key-value input from configuration
+func NewKVInputFromConfig(sys spec.System, config spec.Config) (*KVInput, error) {
+	var cfg KVInputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode kv input config: %w", err)
+	}
+
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("a bucket is required")
+	}
+	if cfg.Key == "" {
+		cfg.Key = jetstream.AllKeys
+	}
+	if cfg.BatchCount <= 0 {
+		cfg.BatchCount = 1
+	}
+
+	return &KVInput{
+		sys:      sys,
+		cfg:      cfg,
+		inflight: spec.NewInFlight(),
+	}, nil
+}
+
+func (ki *KVInput) Init(ctx spec.ComponentContext) error {
+	ki.lock.Lock()
+	defer ki.lock.Unlock()
+
+	if ki.watcher != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	kv, err := bindKeyValue(ctx.Context(), ki.sys, ki.cfg.Bucket)
+	if err != nil {
+		return err
+	}
+
+	var opts []jetstream.WatchOpt
+	if ki.cfg.IncludeHistory {
+		opts = append(opts, jetstream.IncludeHistory())
+	}
+	if ki.cfg.IgnoreDeletes {
+		opts = append(opts, jetstream.IgnoreDeletes())
+	}
+	if ki.cfg.UpdatesOnly {
+		opts = append(opts, jetstream.UpdatesOnly())
+	}
+
+	// -- the watch outlives the context of Init, it is stopped when the input is drained or closed
+	watchCtx, cancel := context.WithCancel(context.Background())
+	watcher, err := kv.Watch(watchCtx, ki.cfg.Key, opts...)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to watch %s in bucket %s: %w", ki.cfg.Key, ki.cfg.Bucket, err)
+	}
+	ki.watcher = watcher
+	ki.cancel = cancel
+
+	// -- a drained input reads again once it is initialized
+	ki.inflight.Reset()
+	return nil
+}
+
+// Drain stops watching the bucket and waits until the batches which were already read have been processed.
+func (ki *KVInput) Drain(ctx context.Context) error {
+	ki.inflight.Drain()
+	ki.stop()
+	return ki.inflight.Wait(ctx)
+}
+
+func (ki *KVInput) Close(ctx spec.ComponentContext) error {
+	ki.stop()
+	return nil
+}
+
+func (ki *KVInput) stop() {
+	ki.lock.Lock()
+	defer ki.lock.Unlock()
+
+	if ki.watcher != nil {
+		_ = ki.watcher.Stop()
+		ki.watcher = nil
+	}
+	if ki.cancel != nil {
+		ki.cancel()
+		ki.cancel = nil
+	}
+}
+
+func (ki *KVInput) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
+	ki.lock.Lock()
+	watcher := ki.watcher
+	ki.lock.Unlock()
+
+	if watcher == nil || ki.inflight.Draining() {
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	// -- wait for the first change, then add the ones which are already pending up to the batch count
+	var entries []jetstream.KeyValueEntry
+	for len(entries) == 0 {
+		select {
+		case <-ctx.Context().Done():
+			return nil, nil, ctx.Context().Err()
+		case entry, ok := <-watcher.Updates():
+			if !ok {
+				return nil, nil, spec.ErrNotConnected
+			}
+			// -- a nil entry marks the end of the initial values
+			if entry != nil {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+pending:
+	for len(entries) < ki.cfg.BatchCount {
+		select {
+		case entry, ok := <-watcher.Updates():
+			if !ok {
+				break pending
+			}
+			if entry != nil {
+				entries = append(entries, entry)
+			}
+		default:
+			break pending
+		}
+	}
+
+	batch := ctx.NewBatch()
+	for _, entry := range entries {
+		m := ctx.NewMessage()
+		m.SetRaw(entry.Value())
+		setKVMetadata(m, entry)
+		m.SetMetadata(MetadataKVOperation, kvOperationName(entry.Operation()))
+		batch.Append(m)
+	}
+
+	return batch, ki.inflight.Track(spec.NoopCallback), nil
+}
+
+// bindKeyValue looks up the key-value bucket with the given name using the JetStream client of the system.
+func bindKeyValue(ctx context.Context, sys spec.System, bucket string) (jetstream.KeyValue, error) {
+	js, ok := sys.Client().(jetstream.JetStream)
+	if !ok || js == nil {
+		return nil, fmt.Errorf("system client is not a JetStream instance")
+	}
+
+	kv, err := js.KeyValue(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind key-value bucket %s: %w", bucket, err)
+	}
+	return kv, nil
+}
+
+func setKVMetadata(m spec.Message, entry jetstream.KeyValueEntry) {
+	m.SetMetadata(MetadataKVBucket, entry.Bucket())
+	m.SetMetadata(MetadataKVKey, entry.Key())
+	m.SetMetadata(MetadataKVRevision, strconv.FormatUint(entry.Revision(), 10))
+}
+
+// kvOperationName returns the name of an operation as used in the configuration of the output.
+func kvOperationName(op jetstream.KeyValueOp) string {
+	switch op {
+	case jetstream.KeyValueDelete:
+		return string(KVOperationDelete)
+	case jetstream.KeyValuePurge:
+		return string(KVOperationPurge)
+	default:
+		return string(KVOperationPut)
+	}
+}
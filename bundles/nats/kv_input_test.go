@@ -0,0 +1,147 @@
+package nats_test
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KVInput", func() {
+	var ctx spec.ComponentContext
+	var kv jetstream.KeyValue
+	var bucket string
+
+	BeforeEach(func() {
+		bucket = "KV_IN_" + uuid.NewString()[:8]
+
+		var err error
+		kv, err = js.CreateKeyValue(context.Background(), jetstream.KeyValueConfig{
+			Bucket:  bucket,
+			History: 5,
+			Storage: jetstream.MemoryStorage,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		DeferCleanup(func() {
+			_ = js.DeleteKeyValue(context.Background(), bucket)
+		})
+
+		timeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		DeferCleanup(cancel)
+		ctx = test.NewMockComponentContextWithContext(timeout)
+	})
+
+	newInput := func(cfg map[string]any) *wwnats.KVInput {
+		cfg["bucket"] = bucket
+		input, err := wwnats.NewKVInputFromConfig(newJetStreamSystem(), spec.NewMapConfig(cfg))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+		DeferCleanup(func() { _ = input.Close(ctx) })
+		return input
+	}
+
+	read := func(input *wwnats.KVInput) []spec.Message {
+		batch, callback, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(callback(context.Background(), nil)).To(Succeed())
+
+		var msgs []spec.Message
+		for _, msg := range batch.Messages() {
+			msgs = append(msgs, msg)
+		}
+		return msgs
+	}
+
+	It("should emit the current values followed by the changes with their metadata", func() {
+		_, err := kv.Put(context.Background(), "orders.1", []byte("created"))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = kv.Put(context.Background(), "users.1", []byte("alice"))
+		Expect(err).ToNot(HaveOccurred())
+
+		input := newInput(map[string]any{"key": "orders.>"})
+
+		msgs := read(input)
+		Expect(msgs).To(HaveLen(1))
+		Expect(msgs[0].Raw()).To(Equal([]byte("created")))
+		Expect(maps.Collect(msgs[0].Metadata())).To(Equal(map[string]any{
+			wwnats.MetadataKVBucket:    bucket,
+			wwnats.MetadataKVKey:       "orders.1",
+			wwnats.MetadataKVRevision:  "1",
+			wwnats.MetadataKVOperation: "put",
+		}))
+
+		Expect(kv.Delete(context.Background(), "orders.1")).To(Succeed())
+
+		msgs = read(input)
+		Expect(msgs).To(HaveLen(1))
+		Expect(maps.Collect(msgs[0].Metadata())).To(And(
+			HaveKeyWithValue(wwnats.MetadataKVKey, "orders.1"),
+			HaveKeyWithValue(wwnats.MetadataKVRevision, "3"),
+			HaveKeyWithValue(wwnats.MetadataKVOperation, "delete"),
+		))
+	})
+
+	It("should replay the history of the keys when configured", func() {
+		for _, value := range []string{"a", "b", "c"} {
+			_, err := kv.Put(context.Background(), "config", []byte(value))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		input := newInput(map[string]any{"include_history": true, "batch_count": 10})
+
+		var values []string
+		Eventually(func() []string {
+			for _, msg := range read(input) {
+				raw, _ := msg.Raw()
+				values = append(values, string(raw))
+			}
+			return values
+		}).Should(Equal([]string{"a", "b", "c"}))
+	})
+
+	It("should watch the bucket again when initialized after being closed", func() {
+		input := newInput(map[string]any{"key": "orders.>"})
+		Expect(input.Close(ctx)).To(Succeed())
+
+		_, _, err := input.Read(ctx)
+		Expect(err).To(MatchError(spec.ErrNotConnected))
+
+		Expect(input.Init(ctx)).To(Succeed())
+		_, err = kv.Put(context.Background(), "orders.1", []byte("after reconnect"))
+		Expect(err).ToNot(HaveOccurred())
+
+		msgs := read(input)
+		Expect(msgs).To(HaveLen(1))
+		Expect(msgs[0].Raw()).To(Equal([]byte("after reconnect")))
+	})
+
+	It("should read again when initialized after being drained and closed", func() {
+		input := newInput(map[string]any{"key": "orders.>"})
+		Expect(spec.DrainAndClose(ctx, input, time.Second)).To(Succeed())
+
+		Expect(input.Init(ctx)).To(Succeed())
+		_, err := kv.Put(context.Background(), "orders.1", []byte("after restart"))
+		Expect(err).ToNot(HaveOccurred())
+
+		msgs := read(input)
+		Expect(msgs).To(HaveLen(1))
+		Expect(msgs[0].Raw()).To(Equal([]byte("after restart")))
+	})
+
+	It("should stop reading once drained", func() {
+		input := newInput(map[string]any{})
+		Expect(input.Drain(context.Background())).To(Succeed())
+
+		_, _, err := input.Read(ctx)
+		Expect(err).To(MatchError(spec.ErrNotConnected))
+	})
+})
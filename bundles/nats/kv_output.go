@@ -0,0 +1,145 @@
+package nats
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// KVOutput writes messages to the keys of a NATS JetStream key-value bucket.
+//
+// The key of each message is produced by an expression. Create and update provide optimistic concurrency: create
+// fails when the key already exists and update fails when the key isn't at the expected revision anymore. These
+// conflicts won't resolve by writing again, so the messages fail with spec.ErrPermanent.
+type KVOutput struct {
+	sys spec.System
+	cfg KVOutputConfig
+
+	kv jetstream.KeyValue
+}
+
+// NewKVOutputFromConfig creates a new NATS key-value output from configuration
+func NewKVOutputFromConfig(sys spec.System, config spec.Config) (*KVOutput, error) {
+	var cfg KVOutputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode kv output config: %w", err)
+	}
+
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("a bucket is required")
+	}
+	if cfg.Key == nil {
+		return nil, fmt.Errorf("a key is required")
+	}
+
+	switch cfg.Operation {
+	case "":
+		cfg.Operation = KVOperationPut
+	case KVOperationPut, KVOperationCreate, KVOperationDelete, KVOperationPurge:
+	case KVOperationUpdate:
+		if cfg.Revision == nil {
+			return nil, fmt.Errorf("the update operation requires a revision")
+		}
+	default:
+		return nil, fmt.Errorf("invalid operation %q (must be put, create, update, delete or purge)", cfg.Operation)
+	}
+
+	return &KVOutput{
+		sys: sys,
+		cfg: cfg,
+	}, nil
+}
+
+func (ko *KVOutput) Init(ctx spec.ComponentContext) error {
+	kv, err := bindKeyValue(ctx.Context(), ko.sys, ko.cfg.Bucket)
+	if err != nil {
+		return err
+	}
+	ko.kv = kv
+	return nil
+}
+
+func (ko *KVOutput) Close(ctx spec.ComponentContext) error {
+	return nil
+}
+
+// Write writes all messages of the batch. A message which fails to write doesn't stop the others from being written,
+// the failed messages are reported through a spec.BatchError instead.
+func (ko *KVOutput) Write(ctx spec.ComponentContext, batch spec.Batch) error {
+	batchErr := spec.NewBatchError(nil)
+	for idx, message := range batch.Messages() {
+		if err := ko.WriteMessage(ctx, message); err != nil {
+			batchErr.Failed(idx, err)
+		}
+	}
+
+	if batchErr.Len() > 0 {
+		return batchErr
+	}
+	return nil
+}
+
+func (ko *KVOutput) WriteMessage(ctx spec.ComponentContext, message spec.Message) error {
+	exprCtx := spec.MessageExpressionContext(message)
+
+	key, err := ko.cfg.Key.Eval(exprCtx)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate key: %w", err)
+	}
+
+	var revision uint64
+	if ko.cfg.Revision != nil {
+		rev, err := ko.cfg.Revision.Eval(exprCtx)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate revision: %w", err)
+		}
+		if revision, err = strconv.ParseUint(rev, 10, 64); err != nil {
+			return fmt.Errorf("%w: invalid revision %q", spec.ErrPermanent, rev)
+		}
+	}
+
+	var deleteOpts []jetstream.KVDeleteOpt
+	if revision > 0 {
+		deleteOpts = append(deleteOpts, jetstream.LastRevision(revision))
+	}
+
+	switch ko.cfg.Operation {
+	case KVOperationDelete:
+		err = ko.kv.Delete(ctx.Context(), key, deleteOpts...)
+	case KVOperationPurge:
+		err = ko.kv.Purge(ctx.Context(), key, deleteOpts...)
+	default:
+		var data []byte
+		if data, err = message.Raw(); err != nil {
+			return fmt.Errorf("failed to get message data: %w", err)
+		}
+
+		switch ko.cfg.Operation {
+		case KVOperationCreate:
+			_, err = ko.kv.Create(ctx.Context(), key, data)
+		case KVOperationUpdate:
+			_, err = ko.kv.Update(ctx.Context(), key, data, revision)
+		default:
+			_, err = ko.kv.Put(ctx.Context(), key, data)
+		}
+	}
+
+	if err != nil {
+		if revisionConflict(err) {
+			err = fmt.Errorf("%w: %w", spec.ErrPermanent, err)
+		}
+		return fmt.Errorf("failed to %s key %s in bucket %s: %w", ko.cfg.Operation, key, ko.cfg.Bucket, err)
+	}
+	return nil
+}
+
+// revisionConflict reports whether the error indicates the key wasn't at the expected revision. Create reports an
+// existing key the same way.
+func revisionConflict(err error) bool {
+	var apiErr *jetstream.APIError
+	return errors.Is(err, jetstream.ErrKeyExists) ||
+		(errors.As(err, &apiErr) && apiErr.ErrorCode == jetstream.JSErrCodeStreamWrongLastSequence)
+}
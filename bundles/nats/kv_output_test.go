@@ -0,0 +1,128 @@
+package nats_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KVOutput", func() {
+	var ctx spec.ComponentContext
+	var kv jetstream.KeyValue
+	var bucket string
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		bucket = "KV_OUT_" + uuid.NewString()[:8]
+
+		var err error
+		kv, err = js.CreateKeyValue(context.Background(), jetstream.KeyValueConfig{
+			Bucket:  bucket,
+			Storage: jetstream.MemoryStorage,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		DeferCleanup(func() {
+			_ = js.DeleteKeyValue(context.Background(), bucket)
+		})
+	})
+
+	newOutput := func(cfg map[string]any) *wwnats.KVOutput {
+		cfg["bucket"] = bucket
+		output, err := wwnats.NewKVOutputFromConfig(newJetStreamSystem(), spec.NewMapConfig(cfg))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Init(ctx)).To(Succeed())
+		DeferCleanup(func() { _ = output.Close(ctx) })
+		return output
+	}
+
+	message := func(data string, metadata map[string]string) spec.Message {
+		msg := spec.NewBytesMessage([]byte(data))
+		for k, v := range metadata {
+			msg.SetMetadata(k, v)
+		}
+		return msg
+	}
+
+	It("should put the messages under the keys produced by the expression", func() {
+		output := newOutput(map[string]any{"key": "${! \"orders.\" + metadata.id }"})
+		Expect(output.Write(ctx, ctx.NewBatch(
+			message("first", map[string]string{"id": "1"}),
+			message("second", map[string]string{"id": "2"}),
+		))).To(Succeed())
+
+		entry, err := kv.Get(context.Background(), "orders.2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(entry.Value())).To(Equal("second"))
+	})
+
+	It("should only create keys which don't exist yet", func() {
+		_, err := kv.Put(context.Background(), "taken", []byte("original"))
+		Expect(err).ToNot(HaveOccurred())
+
+		output := newOutput(map[string]any{"key": "${! metadata.key }", "operation": "create"})
+		err = output.Write(ctx, ctx.NewBatch(
+			message("new", map[string]string{"key": "free"}),
+			message("new", map[string]string{"key": "taken"}),
+		))
+
+		var batchErr *spec.BatchError
+		Expect(errors.As(err, &batchErr)).To(BeTrue())
+		Expect(batchErr.Len()).To(Equal(1))
+		Expect(spec.MessageError(err, 1)).To(MatchError(spec.ErrPermanent))
+
+		entry, err := kv.Get(context.Background(), "taken")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(entry.Value())).To(Equal("original"))
+		_, err = kv.Get(context.Background(), "free")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should only update keys at the expected revision", func() {
+		revision, err := kv.Put(context.Background(), "counter", []byte("1"))
+		Expect(err).ToNot(HaveOccurred())
+
+		output := newOutput(map[string]any{
+			"key":       "counter",
+			"operation": "update",
+			"revision":  "${! metadata.kv_revision }",
+		})
+
+		msg := message("2", map[string]string{wwnats.MetadataKVRevision: "1"})
+		Expect(output.Write(ctx, ctx.NewBatch(msg))).To(Succeed())
+		Expect(spec.MessageError(output.Write(ctx, ctx.NewBatch(msg)), 0)).To(MatchError(spec.ErrPermanent))
+
+		entry, err := kv.Get(context.Background(), "counter")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(entry.Value())).To(Equal("2"))
+		Expect(entry.Revision()).To(Equal(revision + 1))
+	})
+
+	It("should delete keys", func() {
+		_, err := kv.Put(context.Background(), "obsolete", []byte("value"))
+		Expect(err).ToNot(HaveOccurred())
+
+		output := newOutput(map[string]any{"key": "obsolete", "operation": "delete"})
+		Expect(output.Write(ctx, ctx.NewBatch(message("", nil)))).To(Succeed())
+
+		_, err = kv.Get(context.Background(), "obsolete")
+		Expect(err).To(MatchError(jetstream.ErrKeyNotFound))
+	})
+
+	It("should require a revision for updates", func() {
+		_, err := wwnats.NewKVOutputFromConfig(nil, spec.NewMapConfig(map[string]any{
+			"bucket":    bucket,
+			"key":       "counter",
+			"operation": "update",
+		}))
+		Expect(err).To(MatchError(ContainSubstring("requires a revision")))
+	})
+})
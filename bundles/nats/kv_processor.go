@@ -0,0 +1,120 @@
+package nats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// KVProcessor enriches messages with the value of a key in a NATS JetStream key-value bucket.
+//
+// The key of each message is produced by an expression. When a field is configured, the value is stored under that
+// field of the JSON object in the message, as JSON when it is valid JSON and as a string otherwise. Without a field,
+// the value has to be a JSON object whose fields are merged into the message, overwriting the fields it has in
+// common with the message. The bucket, key and revision of the value are added to the metadata of the message.
+type KVProcessor struct {
+	sys spec.System
+	cfg KVProcessorConfig
+
+	kv jetstream.KeyValue
+}
+
+// NewKVProcessorFromConfig creates a new NATS key-value processor from configuration
+func NewKVProcessorFromConfig(sys spec.System, config spec.Config) (*KVProcessor, error) {
+	var cfg KVProcessorConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode kv processor config: %w", err)
+	}
+
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("a bucket is required")
+	}
+	if cfg.Key == nil {
+		return nil, fmt.Errorf("a key is required")
+	}
+
+	return &KVProcessor{
+		sys: sys,
+		cfg: cfg,
+	}, nil
+}
+
+func (kp *KVProcessor) Init(ctx spec.ComponentContext) error {
+	kv, err := bindKeyValue(ctx.Context(), kp.sys, kp.cfg.Bucket)
+	if err != nil {
+		return err
+	}
+	kp.kv = kv
+	return nil
+}
+
+func (kp *KVProcessor) Close(ctx spec.ComponentContext) error {
+	return nil
+}
+
+func (kp *KVProcessor) Process(ctx spec.ComponentContext, batch spec.Batch) (spec.Batch, spec.ProcessedCallback, error) {
+	result := ctx.NewBatch()
+
+	for idx, msg := range batch.Messages() {
+		if err := kp.processMessage(ctx, msg); err != nil {
+			return nil, nil, fmt.Errorf("batch #%d: %w", idx, err)
+		}
+		result.Append(msg)
+	}
+
+	return result, spec.NoopCallback, nil
+}
+
+func (kp *KVProcessor) processMessage(ctx spec.ComponentContext, msg spec.Message) error {
+	key, err := kp.cfg.Key.Eval(spec.MessageExpressionContext(msg))
+	if err != nil {
+		return fmt.Errorf("failed to evaluate key: %w", err)
+	}
+
+	entry, err := kp.kv.Get(ctx.Context(), key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) && kp.cfg.IgnoreMissing {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get key %s from bucket %s: %w", key, kp.cfg.Bucket, err)
+	}
+
+	raw, err := msg.Raw()
+	if err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+
+	doc := map[string]any{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return fmt.Errorf("payload is not a JSON object: %w", err)
+		}
+	}
+
+	if kp.cfg.Field != "" {
+		var value any
+		if err := json.Unmarshal(entry.Value(), &value); err != nil {
+			value = string(entry.Value())
+		}
+		doc[kp.cfg.Field] = value
+	} else {
+		var value map[string]any
+		if err := json.Unmarshal(entry.Value(), &value); err != nil {
+			return fmt.Errorf("value of key %s is not a JSON object: %w", key, err)
+		}
+		maps.Copy(doc, value)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	msg.SetRaw(data)
+	setKVMetadata(msg, entry)
+	return nil
+}
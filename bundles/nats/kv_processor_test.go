@@ -0,0 +1,93 @@
+package nats_test
+
+import (
+	"context"
+	"maps"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KVProcessor", func() {
+	var ctx spec.ComponentContext
+	var bucket string
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		bucket = "KV_PROC_" + uuid.NewString()[:8]
+
+		kv, err := js.CreateKeyValue(context.Background(), jetstream.KeyValueConfig{
+			Bucket:  bucket,
+			Storage: jetstream.MemoryStorage,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		DeferCleanup(func() {
+			_ = js.DeleteKeyValue(context.Background(), bucket)
+		})
+
+		_, err = kv.Put(context.Background(), "customers.7", []byte(`{"name":"alice","tier":"gold"}`))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = kv.Put(context.Background(), "greeting", []byte(`hello`))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	newProcessor := func(cfg map[string]any) *wwnats.KVProcessor {
+		cfg["bucket"] = bucket
+		processor, err := wwnats.NewKVProcessorFromConfig(newJetStreamSystem(), spec.NewMapConfig(cfg))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(processor.Init(ctx)).To(Succeed())
+		DeferCleanup(func() { _ = processor.Close(ctx) })
+		return processor
+	}
+
+	process := func(processor *wwnats.KVProcessor, data string) (spec.Message, error) {
+		out, _, err := processor.Process(ctx, ctx.NewBatch(spec.NewBytesMessage([]byte(data))))
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range out.Messages() {
+			return msg, nil
+		}
+		return nil, nil
+	}
+
+	It("should merge the fields of the value into the message", func() {
+		processor := newProcessor(map[string]any{"key": "${! \"customers.\" + string(json.customer) }"})
+
+		msg, err := process(processor, `{"customer":7,"tier":"basic"}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Raw()).To(MatchJSON(`{"customer":7,"name":"alice","tier":"gold"}`))
+		Expect(maps.Collect(msg.Metadata())).To(And(
+			HaveKeyWithValue(wwnats.MetadataKVKey, "customers.7"),
+			HaveKeyWithValue(wwnats.MetadataKVRevision, "1"),
+		))
+	})
+
+	It("should store the value under the configured field", func() {
+		processor := newProcessor(map[string]any{"key": "${! json.key }", "field": "enriched"})
+
+		msg, err := process(processor, `{"key":"customers.7"}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Raw()).To(MatchJSON(`{"key":"customers.7","enriched":{"name":"alice","tier":"gold"}}`))
+
+		msg, err = process(processor, `{"key":"greeting"}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Raw()).To(MatchJSON(`{"key":"greeting","enriched":"hello"}`))
+	})
+
+	It("should fail messages whose key doesn't exist unless configured to ignore them", func() {
+		_, err := process(newProcessor(map[string]any{"key": "missing"}), `{}`)
+		Expect(err).To(MatchError(jetstream.ErrKeyNotFound))
+
+		msg, err := process(newProcessor(map[string]any{"key": "missing", "ignore_missing": true}), `{"a":1}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Raw()).To(MatchJSON(`{"a":1}`))
+	})
+})
@@ -0,0 +1,84 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// NewKVStateStore creates a state store backed by the given JetStream key-value bucket.
+func NewKVStateStore(kv jetstream.KeyValue) spec.StateStore {
+	return &kvStateStore{kv: kv}
+}
+
+// NewKVStateStoreFromSystem creates a state store backed by the key-value bucket with the given name, creating the
+// bucket if it doesn't exist yet.
+func NewKVStateStoreFromSystem(ctx context.Context, sys *JetStreamSystem, bucket string) (spec.StateStore, error) {
+	js := sys.JetStream()
+	if js == nil {
+		return nil, spec.ErrNotConnected
+	}
+
+	kv, err := js.KeyValue(ctx, bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "wombatwisdom component state",
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind key-value bucket %s: %w", bucket, err)
+	}
+
+	return NewKVStateStore(kv), nil
+}
+
+// kvStateStore implements spec.StateStore on top of a JetStream key-value bucket. This allows multiple instances
+// of a component to share their state through the NATS cluster instead of the local disk.
+type kvStateStore struct {
+	kv jetstream.KeyValue
+}
+
+func (s *kvStateStore) Get(ctx context.Context, key string) ([]byte, error) {
+	entry, err := s.kv.Get(ctx, kvKey(key))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, spec.ErrStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry.Value(), nil
+}
+
+func (s *kvStateStore) Set(ctx context.Context, key string, value []byte) error {
+	_, err := s.kv.Put(ctx, kvKey(key), value)
+	return err
+}
+
+func (s *kvStateStore) Delete(ctx context.Context, key string) error {
+	err := s.kv.Delete(ctx, kvKey(key))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+// kvKey escapes the characters which are not allowed in key-value keys, using = followed by the hex value of the
+// byte. Dots are escaped as well since keys may not start or end with them.
+func kvKey(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '/':
+			sb.WriteByte(c)
+		default:
+			_, _ = fmt.Fprintf(&sb, "=%02X", c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+package nats_test
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+var _ = Describe("KVStateStore", func() {
+	var store spec.StateStore
+	var bucket string
+
+	BeforeEach(func() {
+		bucket = "STATE_" + uuid.NewString()[:8]
+
+		var err error
+		store, err = wwnats.NewKVStateStoreFromSystem(context.Background(), newJetStreamSystem(), bucket)
+		Expect(err).ToNot(HaveOccurred())
+
+		DeferCleanup(func() {
+			_ = js.DeleteKeyValue(context.Background(), bucket)
+		})
+	})
+
+	It("should return ErrStateNotFound for unknown keys", func() {
+		_, err := store.Get(context.Background(), "unknown")
+		Expect(err).To(MatchError(spec.ErrStateNotFound))
+	})
+
+	It("should store, replace and delete values with arbitrary keys", func() {
+		key := "s3/my.bucket/logs 2024/"
+		Expect(store.Set(context.Background(), key, []byte("first"))).To(Succeed())
+		Expect(store.Set(context.Background(), key, []byte("second"))).To(Succeed())
+
+		value, err := store.Get(context.Background(), key)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(value)).To(Equal("second"))
+
+		Expect(store.Delete(context.Background(), key)).To(Succeed())
+		_, err = store.Get(context.Background(), key)
+		Expect(err).To(MatchError(spec.ErrStateNotFound))
+	})
+
+	It("should share the state with stores bound to the same bucket", func() {
+		Expect(store.Set(context.Background(), "position", []byte("42"))).To(Succeed())
+
+		other, err := wwnats.NewKVStateStoreFromSystem(context.Background(), newJetStreamSystem(), bucket)
+		Expect(err).ToNot(HaveOccurred())
+
+		value, err := other.Get(context.Background(), "position")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(value)).To(Equal("42"))
+	})
+})
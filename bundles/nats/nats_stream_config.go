@@ -1,6 +1,7 @@
 package nats
 
 import (
+	"github.com/wombatwisdom/components/bundles/compress"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
@@ -10,14 +11,64 @@ type StreamConfig struct {
 	//
 	BatchSize int
 
+	// The compression algorithm to apply to message payloads before publishing. Only
+	// applies to outputs. One of none, gzip, zstd, snappy or lz4. The algorithm is
+	// recorded in the compression header so consumers know how to decode the payload.
+	//
+	Compression compress.Algorithm
+
 	// Consumer configuration for input components. Only applies to inputs.
 	//
 	Consumer *StreamConfigConsumer
 
+	// The expression producing the sequence the stream is expected to be at when a
+	// message is published. Only applies to outputs. The message is rejected when
+	// another message was published to the stream in the meantime.
+	//
+	ExpectedLastSequence spec.Expression
+
+	// The expression producing the sequence of the last message on the subject of a
+	// message when it is published. Only applies to outputs. The message is rejected
+	// when another message was published to the subject in the meantime.
+	//
+	ExpectedLastSubjectSequence spec.Expression
+
+	// The maximum number of published messages awaiting their acknowledgment from
+	// JetStream. Only applies to outputs. Defaults to 256.
+	//
+	MaxPending int
+
 	// Metadata handling configuration.
 	//
 	MetadataFilter spec.MetadataFilter
 
+	// The expression producing the ID of each message, which JetStream uses to drop
+	// duplicates published within the duplicate window of the stream. Only applies to
+	// outputs. Use an ID which stays the same when a message is written again, like
+	// ${! metadata.mq_message_id }. Without it, messages are published without an ID.
+	//
+	MsgID spec.Expression
+
+	// Number of messages the consumer buffers ahead of reads. Only applies to inputs.
+	// Defaults to twice the batch size. Buffered messages count against the ack wait
+	// of the consumer, so keep it small when processing is slow.
+	//
+	Prefetch int
+
+	// Provisioning of the stream. When set, the stream is created when it doesn't
+	// exist yet, or only verified, and an existing stream whose configuration
+	// differs from the one given here fails to initialize, listing the differences.
+	// Existing streams are never updated.
+	//
+	Provision *StreamConfigProvision
+
+	// The backoff schedule for redelivering messages which failed to process, based
+	// on the number of times a message was delivered. Only applies to inputs. When
+	// not set, failed messages are redelivered right away unless the error requested
+	// a delay.
+	//
+	Redelivery *spec.Backoff
+
 	// The name of the JetStream stream to consume from or publish to. This can be an
 	// expression that is evaluated for each message.
 	//
@@ -43,6 +94,12 @@ type StreamConfigConsumer struct {
 	//
 	AckWait string
 
+	// The delays between redeliveries of a message which wasn't acknowledged, as Go
+	// durations. The last delay applies to all further redeliveries. Requires a max
+	// deliver larger than the number of delays.
+	//
+	BackOff []string
+
 	// The delivery policy for the consumer. - all: Deliver all messages in the stream
 	// - last: Deliver only the last message per subject - new: Deliver only new
 	// messages (from now)
@@ -59,15 +116,103 @@ type StreamConfigConsumer struct {
 	//
 	FilterSubject spec.Expression
 
+	// The subjects the consumer filters on, instead of the subject of the input. Use
+	// it to consume several subjects which can't be expressed as one pattern.
+	//
+	FilterSubjects []string
+
+	// Whether to deliver only the headers of messages, without their payload. The
+	// size of the payload is added as the Nats-Msg-Size header.
+	//
+	HeadersOnly bool
+
+	// Time after which the server removes a consumer which has no reads pending. Use
+	// Go duration format (e.g., "30s", "5m", "1h"). Meant for consumers which aren't
+	// durable, so they are cleaned up once the input is gone.
+	//
+	InactiveThreshold string
+
 	// Maximum number of delivery attempts for a message. After this many attempts,
 	// the message will be considered failed.
 	//
 	MaxDeliver int
 
+	// Maximum number of delivered messages awaiting acknowledgment. The server stops
+	// delivering messages to the consumer once it is reached.
+	//
+	MaxAckPending int
+
 	// The name of the consumer. If not provided, an ephemeral consumer will be
 	// created.
 	//
 	Name spec.Expression
+
+	// Whether to read through an ordered consumer. Ordered consumers deliver the
+	// messages in stream order without acknowledgments and recreate themselves when
+	// they fall behind, which suits replaying a stream. The name, durable, ack policy,
+	// ack wait and max deliver settings don't apply to them.
+	//
+	Ordered bool
+
+	// The percentage of acknowledgments to sample for observability, e.g. "10%".
+	//
+	SampleFrequency string
+
+	// The stream sequence to start delivering from. Takes precedence over the
+	// delivery policy.
+	//
+	StartSequence uint64
+
+	// The time to start delivering messages from, in RFC 3339 format. Takes
+	// precedence over the delivery policy.
+	//
+	StartTime string
+}
+
+// Provisioning of the stream. When set, the stream is created when it doesn't
+// exist yet, or only verified, and an existing stream whose configuration
+// differs from the one given here fails to initialize, listing the differences.
+// Existing streams are never updated.
+type StreamConfigProvision struct {
+	// How long duplicate messages are tracked, based on their message ID. Use Go
+	// duration format (e.g., "2m").
+	//
+	DuplicateWindow string
+
+	// Maximum age of the messages in the stream. Use Go duration format (e.g.,
+	// "24h"). Older messages are removed.
+	//
+	MaxAge string
+
+	// Maximum size of the stream in bytes. The oldest messages are removed to stay
+	// within it.
+	//
+	MaxBytes int64
+
+	// What to do about the stream. - create: Create the stream when it doesn't
+	// exist - verify: Only verify that the stream exists and matches the
+	// configuration
+	//
+	Mode StreamConfigProvisionMode
+
+	// Number of replicas of the stream in a clustered JetStream.
+	//
+	Replicas int
+
+	// The retention policy of the stream. - limits: Keep messages within the limits
+	// - interest: Keep messages while consumers haven't acknowledged them -
+	// workqueue: Remove messages once a consumer acknowledged them
+	//
+	Retention StreamConfigProvisionRetention
+
+	// The storage backend of the stream. - file: Store messages on disk - memory:
+	// Store messages in memory
+	//
+	Storage StreamConfigProvisionStorage
+
+	// The subjects the stream captures. Defaults to the name of the stream.
+	//
+	Subjects []string
 }
 
 type StreamConfigConsumerAckPolicy string
@@ -81,3 +226,19 @@ type StreamConfigConsumerDeliverPolicy string
 const StreamConfigConsumerDeliverPolicyAll StreamConfigConsumerDeliverPolicy = "all"
 const StreamConfigConsumerDeliverPolicyLast StreamConfigConsumerDeliverPolicy = "last"
 const StreamConfigConsumerDeliverPolicyNew StreamConfigConsumerDeliverPolicy = "new"
+
+type StreamConfigProvisionMode string
+
+const StreamConfigProvisionModeCreate StreamConfigProvisionMode = "create"
+const StreamConfigProvisionModeVerify StreamConfigProvisionMode = "verify"
+
+type StreamConfigProvisionRetention string
+
+const StreamConfigProvisionRetentionInterest StreamConfigProvisionRetention = "interest"
+const StreamConfigProvisionRetentionLimits StreamConfigProvisionRetention = "limits"
+const StreamConfigProvisionRetentionWorkqueue StreamConfigProvisionRetention = "workqueue"
+
+type StreamConfigProvisionStorage string
+
+const StreamConfigProvisionStorageFile StreamConfigProvisionStorage = "file"
+const StreamConfigProvisionStorageMemory StreamConfigProvisionStorage = "memory"
@@ -2,20 +2,40 @@ package nats
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
 const (
 	StreamInputComponentName = "nats_stream"
+
+	// streamBatchLinger is how long Read waits for more messages once the first message of a batch arrived
+	streamBatchLinger = 10 * time.Millisecond
 )
 
 // StreamInput reads messages from a NATS JetStream stream using pull consumers.
 // It provides reliable message delivery with acknowledgment support and consumer management.
+//
+// The consumer delivers messages continuously into a buffer of the configured prefetch size, from which Read takes
+// the messages of a batch. Read blocks until the first message arrives or its context is done, then adds the messages
+// arriving shortly after up to the batch size.
+//
+// While a batch is being processed, its messages are reported to be in progress at half the ack wait of the
+// consumer, so slow processing doesn't cause them to be redelivered. Once processed, the messages are acknowledged,
+// handed back for redelivery, or terminated when they failed permanently.
+//
+// When the system reports that its connection was restored, or the consumer turns out to be gone, the consumer is
+// created again before the next read. This recovers ephemeral consumers which the server removed while the
+// connection was down. Ordered consumers recover by themselves instead.
 type StreamInput struct {
 	sys spec.System
 	cfg StreamConfig
@@ -24,6 +44,21 @@ type StreamInput struct {
 	consumer jetstream.Consumer
 	ctx      context.Context
 	cancel   context.CancelFunc
+
+	// ackNone is set when the messages of the consumer aren't acknowledged
+	ackNone bool
+	// ackWait is the time the server waits for an acknowledgment before redelivering a message
+	ackWait time.Duration
+
+	lock   sync.Mutex
+	reader *streamReader
+
+	// inflight tracks the batches which haven't been acknowledged yet
+	inflight *spec.InFlight
+
+	// recreate is set when the consumer needs to be created again before the next read
+	recreate      atomic.Bool
+	stopObserving func()
 }
 
 // NewStreamInputFromConfig creates a new NATS Stream input from configuration
@@ -34,12 +69,17 @@ func NewStreamInputFromConfig(sys spec.System, config spec.Config) (*StreamInput
 	}
 
 	return &StreamInput{
-		sys: sys,
-		cfg: cfg,
+		sys:      sys,
+		cfg:      cfg,
+		inflight: spec.NewInFlight(),
 	}, nil
 }
 
 func (si *StreamInput) Init(ctx spec.ComponentContext) error {
+	if si.connected() {
+		return spec.ErrAlreadyConnected
+	}
+
 	// Get JetStream context from system
 	js, ok := si.sys.Client().(jetstream.JetStream)
 	if !ok {
@@ -50,7 +90,87 @@ func (si *StreamInput) Init(ctx spec.ComponentContext) error {
 	// Create context for consumer operations
 	si.ctx, si.cancel = context.WithCancel(context.Background())
 
-	return si.createConsumer(ctx)
+	if obs, ok := si.sys.(spec.ConnectionObserver); ok {
+		si.stopObserving = obs.Observe(func(evt spec.ConnectionEvent) {
+			if evt.Reconnected() {
+				si.markRecreate()
+			}
+		})
+	}
+
+	if err := si.start(ctx); err != nil {
+		_ = si.Close(ctx)
+		return err
+	}
+
+	// -- a drained input reads again once it is initialized
+	si.inflight.Reset()
+	return nil
+}
+
+// start creates the consumer and starts reading its messages.
+func (si *StreamInput) start(ctx spec.ComponentContext) error {
+	if err := si.createConsumer(ctx); err != nil {
+		return err
+	}
+
+	batchSize := si.cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	prefetch := si.cfg.Prefetch
+	if prefetch <= 0 {
+		prefetch = 2 * batchSize
+	}
+
+	iter, err := si.consumer.Messages(jetstream.PullMaxMessages(prefetch))
+	if err != nil {
+		return fmt.Errorf("failed to read messages from consumer: %w", err)
+	}
+
+	reader := &streamReader{
+		iter:    iter,
+		reads:   make(chan streamRead),
+		stopped: make(chan struct{}),
+	}
+	go reader.run()
+
+	si.lock.Lock()
+	si.reader = reader
+	si.lock.Unlock()
+	return nil
+}
+
+// connected reports whether the input was initialized and not closed since.
+func (si *StreamInput) connected() bool {
+	si.lock.Lock()
+	defer si.lock.Unlock()
+
+	return si.reader != nil
+}
+
+// stop stops reading messages from the consumer.
+func (si *StreamInput) stop() {
+	si.lock.Lock()
+	reader := si.reader
+	si.lock.Unlock()
+
+	if reader != nil {
+		reader.stop()
+	}
+}
+
+// markRecreate requests the consumer to be created again before the next read. Ordered consumers are left alone,
+// since they recreate themselves from the last message they delivered.
+func (si *StreamInput) markRecreate() {
+	if si.ordered() {
+		return
+	}
+	si.recreate.Store(true)
+}
+
+func (si *StreamInput) ordered() bool {
+	return si.cfg.Consumer != nil && si.cfg.Consumer.Ordered
 }
 
 func (si *StreamInput) createConsumer(ctx spec.ComponentContext) error {
@@ -60,10 +180,13 @@ func (si *StreamInput) createConsumer(ctx spec.ComponentContext) error {
 		return fmt.Errorf("failed to evaluate stream name: %w", err)
 	}
 
-	// Get or create the stream (assuming it exists for now)
-	stream, err := si.js.Stream(si.ctx, streamName)
+	stream, err := si.stream(streamName)
 	if err != nil {
-		return fmt.Errorf("failed to get stream %s: %w", streamName, err)
+		return err
+	}
+
+	if si.ordered() {
+		return si.createOrderedConsumer(ctx, streamName)
 	}
 
 	// Create consumer configuration
@@ -75,15 +198,9 @@ func (si *StreamInput) createConsumer(ctx spec.ComponentContext) error {
 	// Configure consumer based on config
 	if si.cfg.Consumer != nil {
 		// Set delivery policy
-		switch si.cfg.Consumer.DeliverPolicy {
-		case "all":
-			consumerConfig.DeliverPolicy = jetstream.DeliverAllPolicy
-		case "last":
-			consumerConfig.DeliverPolicy = jetstream.DeliverLastPolicy
-		case "new":
-			consumerConfig.DeliverPolicy = jetstream.DeliverNewPolicy
-		default:
-			consumerConfig.DeliverPolicy = jetstream.DeliverNewPolicy
+		consumerConfig.DeliverPolicy, consumerConfig.OptStartSeq, consumerConfig.OptStartTime, err = startPosition(si.cfg.Consumer)
+		if err != nil {
+			return err
 		}
 
 		// Set ack policy
@@ -112,6 +229,28 @@ func (si *StreamInput) createConsumer(ctx spec.ComponentContext) error {
 			consumerConfig.AckWait = ackWait
 		}
 
+		// Set redelivery backoff
+		for _, delay := range si.cfg.Consumer.BackOff {
+			d, err := time.ParseDuration(delay)
+			if err != nil {
+				return fmt.Errorf("failed to parse backoff duration: %w", err)
+			}
+			consumerConfig.BackOff = append(consumerConfig.BackOff, d)
+		}
+
+		consumerConfig.MaxAckPending = si.cfg.Consumer.MaxAckPending
+		consumerConfig.HeadersOnly = si.cfg.Consumer.HeadersOnly
+		consumerConfig.SampleFrequency = si.cfg.Consumer.SampleFrequency
+
+		// Set inactive threshold
+		if si.cfg.Consumer.InactiveThreshold != "" {
+			threshold, err := time.ParseDuration(si.cfg.Consumer.InactiveThreshold)
+			if err != nil {
+				return fmt.Errorf("failed to parse inactive_threshold duration: %w", err)
+			}
+			consumerConfig.InactiveThreshold = threshold
+		}
+
 		// Set filter subject if provided
 		if si.cfg.Subject != nil {
 			filterSubject, err := si.cfg.Subject.Eval(spec.MessageExpressionContext(ctx.NewMessage()))
@@ -122,6 +261,12 @@ func (si *StreamInput) createConsumer(ctx spec.ComponentContext) error {
 			consumerConfig.FilterSubject = filterSubject
 		}
 
+		// Filter subjects replace the subject of the input
+		if len(si.cfg.Consumer.FilterSubjects) > 0 {
+			consumerConfig.FilterSubject = ""
+			consumerConfig.FilterSubjects = si.cfg.Consumer.FilterSubjects
+		}
+
 		// Set consumer name and durable
 		if si.cfg.Consumer != nil && si.cfg.Consumer.Name != nil {
 			consumerName, err := si.cfg.Consumer.Name.Eval(spec.MessageExpressionContext(ctx.NewMessage()))
@@ -143,102 +288,386 @@ func (si *StreamInput) createConsumer(ctx spec.ComponentContext) error {
 		return fmt.Errorf("failed to create consumer: %w", err)
 	}
 
+	info := si.consumer.CachedInfo()
+	si.ackNone = info.Config.AckPolicy == jetstream.AckNonePolicy
+	si.ackWait = info.Config.AckWait
+
 	return nil
 }
 
+// createOrderedConsumer creates an ordered consumer, which delivers the messages of the stream in order without
+// acknowledgments.
+func (si *StreamInput) createOrderedConsumer(ctx spec.ComponentContext, streamName string) error {
+	consumerConfig := jetstream.OrderedConsumerConfig{
+		HeadersOnly: si.cfg.Consumer.HeadersOnly,
+	}
+
+	var err error
+	consumerConfig.DeliverPolicy, consumerConfig.OptStartSeq, consumerConfig.OptStartTime, err = startPosition(si.cfg.Consumer)
+	if err != nil {
+		return err
+	}
+
+	if len(si.cfg.Consumer.FilterSubjects) > 0 {
+		consumerConfig.FilterSubjects = si.cfg.Consumer.FilterSubjects
+	} else if si.cfg.Subject != nil {
+		filterSubject, err := si.cfg.Subject.Eval(spec.MessageExpressionContext(ctx.NewMessage()))
+		if err != nil {
+			return fmt.Errorf("failed to evaluate filter subject: %w", err)
+		}
+		consumerConfig.FilterSubjects = []string{filterSubject}
+	}
+
+	if si.cfg.Consumer.InactiveThreshold != "" {
+		threshold, err := time.ParseDuration(si.cfg.Consumer.InactiveThreshold)
+		if err != nil {
+			return fmt.Errorf("failed to parse inactive_threshold duration: %w", err)
+		}
+		consumerConfig.InactiveThreshold = threshold
+	}
+
+	consumer, err := si.js.OrderedConsumer(si.ctx, streamName, consumerConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create ordered consumer: %w", err)
+	}
+
+	si.consumer = consumer
+	si.ackNone = true
+	si.ackWait = 0
+	return nil
+}
+
+// stream looks up the stream to consume from, provisioning it when configured.
+func (si *StreamInput) stream(name string) (jetstream.Stream, error) {
+	if si.cfg.Provision != nil {
+		return provisionStream(si.ctx, si.js, name, si.cfg.Provision)
+	}
+
+	stream, err := si.js.Stream(si.ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream %s: %w", name, err)
+	}
+	return stream, nil
+}
+
+// startPosition returns the delivery policy of the consumer, along with the sequence or time to start delivering
+// from. A start sequence or time takes precedence over the configured delivery policy.
+func startPosition(c *StreamConfigConsumer) (jetstream.DeliverPolicy, uint64, *time.Time, error) {
+	if c.StartSequence > 0 {
+		return jetstream.DeliverByStartSequencePolicy, c.StartSequence, nil, nil
+	}
+
+	if c.StartTime != "" {
+		start, err := time.Parse(time.RFC3339, c.StartTime)
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("failed to parse start_time: %w", err)
+		}
+		return jetstream.DeliverByStartTimePolicy, 0, &start, nil
+	}
+
+	return deliverPolicy(c.DeliverPolicy), 0, nil, nil
+}
+
+func deliverPolicy(policy StreamConfigConsumerDeliverPolicy) jetstream.DeliverPolicy {
+	switch policy {
+	case StreamConfigConsumerDeliverPolicyAll:
+		return jetstream.DeliverAllPolicy
+	case StreamConfigConsumerDeliverPolicyLast:
+		return jetstream.DeliverLastPolicy
+	default:
+		return jetstream.DeliverNewPolicy
+	}
+}
+
+// Drain stops the input from reading new messages and waits until the batches which were already read have been
+// acknowledged.
+func (si *StreamInput) Drain(ctx context.Context) error {
+	si.inflight.Drain()
+	si.stop()
+	return si.inflight.Wait(ctx)
+}
+
 func (si *StreamInput) Close(ctx spec.ComponentContext) error {
+	si.stop()
+
+	// -- forget the consumer, so the input reports being closed and can be initialized again
+	si.lock.Lock()
+	si.reader = nil
+	si.lock.Unlock()
+	si.consumer = nil
+	si.recreate.Store(false)
+
+	if si.stopObserving != nil {
+		si.stopObserving()
+		si.stopObserving = nil
+	}
+
 	if si.cancel != nil {
 		si.cancel()
+		si.cancel = nil
 	}
 	return nil
 }
 
 func (si *StreamInput) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
-	if si.consumer == nil {
-		return nil, nil, fmt.Errorf("consumer not initialized")
+	if !si.connected() || si.inflight.Draining() {
+		return nil, nil, spec.ErrNotConnected
 	}
 
-	// Fetch messages from the consumer
 	batchSize := si.cfg.BatchSize
 	if batchSize <= 0 {
 		batchSize = 1
 	}
 
-	msgs, err := si.consumer.Fetch(batchSize, jetstream.FetchMaxWait(30*time.Second))
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch messages: %w", err)
+	if si.recreate.CompareAndSwap(true, false) {
+		ctx.Debugf("Recreating consumer after reconnect")
+		si.stop()
+		if err := si.start(ctx); err != nil {
+			si.recreate.Store(true)
+			return nil, nil, err
+		}
 	}
 
-	batch := ctx.NewBatch()
+	si.lock.Lock()
+	reader := si.reader
+	si.lock.Unlock()
+
+	// -- wait for the first message, then add the ones arriving shortly after up to the batch size
 	var jetStreamMsgs []jetstream.Msg
+	select {
+	case r, ok := <-reader.reads:
+		if !ok {
+			if si.inflight.Draining() {
+				return nil, nil, spec.ErrNotConnected
+			}
+			si.markRecreate()
+			return nil, nil, fmt.Errorf("failed to read messages: %w", jetstream.ErrMsgIteratorClosed)
+		}
+		if r.err != nil {
+			if consumerGone(r.err) {
+				si.markRecreate()
+			}
+			return nil, nil, fmt.Errorf("failed to read messages: %w", r.err)
+		}
+		jetStreamMsgs = append(jetStreamMsgs, r.msg)
+	case <-ctx.Context().Done():
+		return nil, nil, ctx.Context().Err()
+	}
 
-	for msg := range msgs.Messages() {
-		if msg == nil {
-			continue
-		}
-
-		jetStreamMsgs = append(jetStreamMsgs, msg)
-
-		// Create message
-		m := ctx.NewMessage()
-		m.SetRaw(msg.Data())
-
-		// Add NATS headers
-		for key, values := range msg.Headers() {
-			if len(values) > 0 {
-				// For multiple values, join them with commas
-				if len(values) == 1 {
-					m.SetMetadata(key, values[0])
-				} else {
-					var combined string
-					for i, v := range values {
-						if i > 0 {
-							combined += ","
-						}
-						combined += v
-					}
-					m.SetMetadata(key, combined)
+	linger := time.NewTimer(streamBatchLinger)
+	defer linger.Stop()
+
+buffered:
+	for len(jetStreamMsgs) < batchSize {
+		select {
+		case r, ok := <-reader.reads:
+			if !ok {
+				break buffered
+			}
+			if r.err != nil {
+				// -- the error surfaces again on the next read when the reader stopped because of it
+				if consumerGone(r.err) {
+					si.markRecreate()
 				}
+				ctx.Debugf("Stopped filling batch: %v", r.err)
+				break buffered
 			}
+			jetStreamMsgs = append(jetStreamMsgs, r.msg)
+		case <-linger.C:
+			break buffered
+		case <-ctx.Context().Done():
+			break buffered
 		}
+	}
 
-		// Add JetStream metadata if configured
-		metadata, err := msg.Metadata()
-		if err == nil && metadata != nil {
-			m.SetMetadata("jetstream_stream", metadata.Stream)
-			m.SetMetadata("jetstream_consumer", metadata.Consumer)
-			m.SetMetadata("jetstream_sequence_stream", strconv.FormatUint(metadata.Sequence.Stream, 10))
-			m.SetMetadata("jetstream_sequence_consumer", strconv.FormatUint(metadata.Sequence.Consumer, 10))
-			m.SetMetadata("jetstream_pending", strconv.FormatUint(metadata.NumPending, 10))
-			m.SetMetadata("jetstream_delivered", strconv.FormatUint(metadata.NumDelivered, 10))
-			m.SetMetadata("jetstream_timestamp", metadata.Timestamp.Format(time.RFC3339))
-		}
+	if si.inflight.Draining() {
+		// -- the input started draining while reading, hand the messages back for redelivery
+		si.nakAll(jetStreamMsgs)
+		return nil, nil, spec.ErrNotConnected
+	}
 
-		// Add basic NATS message metadata
-		m.SetMetadata("nats_subject", msg.Subject())
-		if reply := msg.Reply(); reply != "" {
-			m.SetMetadata("nats_reply", reply)
+	batch := ctx.NewBatch()
+	for _, msg := range jetStreamMsgs {
+		batch.Append(newStreamMessage(ctx, msg))
+	}
+
+	return batch, si.inflight.Track(si.ackCallback(jetStreamMsgs)), nil
+}
+
+// newStreamMessage creates a message from a JetStream message, with its headers and JetStream details as metadata.
+func newStreamMessage(ctx spec.ComponentContext, msg jetstream.Msg) spec.Message {
+	m := ctx.NewMessage()
+	m.SetRaw(msg.Data())
+
+	// Add NATS headers
+	for key, values := range msg.Headers() {
+		switch len(values) {
+		case 0:
+		case 1:
+			m.SetMetadata(key, values[0])
+		default:
+			// For multiple values, join them with commas
+			m.SetMetadata(key, strings.Join(values, ","))
 		}
+	}
 
-		batch.Append(m)
+	// Add JetStream metadata
+	metadata, err := msg.Metadata()
+	if err == nil && metadata != nil {
+		m.SetMetadata("jetstream_stream", metadata.Stream)
+		m.SetMetadata("jetstream_consumer", metadata.Consumer)
+		m.SetMetadata("jetstream_sequence_stream", strconv.FormatUint(metadata.Sequence.Stream, 10))
+		m.SetMetadata("jetstream_sequence_consumer", strconv.FormatUint(metadata.Sequence.Consumer, 10))
+		m.SetMetadata("jetstream_pending", strconv.FormatUint(metadata.NumPending, 10))
+		m.SetMetadata("jetstream_delivered", strconv.FormatUint(metadata.NumDelivered, 10))
+		m.SetMetadata("jetstream_timestamp", metadata.Timestamp.Format(time.RFC3339))
 	}
 
-	// Create acknowledgment callback
-	ackCallback := func(ctx context.Context, err error) error {
-		for _, msg := range jetStreamMsgs {
-			if err != nil {
-				// On error, we could implement negative acknowledgment or let it time out
-				// For now, we'll just let it timeout and retry
-				continue
+	// Add basic NATS message metadata
+	m.SetMetadata("nats_subject", msg.Subject())
+	if reply := msg.Reply(); reply != "" {
+		m.SetMetadata("nats_reply", reply)
+	}
+
+	return m
+}
+
+// ackCallback creates the acknowledgment callback of a batch. Messages are acknowledged individually, so when the
+// batch failed partially only the failed messages are redelivered, after the delay requested by the error or the
+// redelivery schedule. Permanent failures are terminated instead of redelivered. A message can only be acknowledged
+// once, so calling the callback again has no effect.
+func (si *StreamInput) ackCallback(msgs []jetstream.Msg) spec.ProcessedCallback {
+	processed := make(chan struct{})
+	if !si.ackNone {
+		go si.keepInProgress(msgs, processed)
+	}
+
+	var once sync.Once
+	return func(ctx context.Context, err error) error {
+		var errs error
+		once.Do(func() {
+			close(processed)
+			if si.ackNone {
+				return
+			}
+
+			for idx, msg := range msgs {
+				msgErr := spec.MessageError(err, idx)
+
+				var ackErr error
+				switch {
+				case msgErr == nil:
+					ackErr = msg.Ack()
+				case errors.Is(msgErr, spec.ErrPermanent):
+					ackErr = msg.TermWithReason(msgErr.Error())
+				default:
+					ackErr = si.nak(msg, msgErr)
+				}
+
+				if ackErr != nil {
+					errs = errors.Join(errs, fmt.Errorf("failed to acknowledge message #%d: %w", idx, ackErr))
+				}
 			}
+		})
+		return errs
+	}
+}
+
+// keepInProgress reports the messages to be in progress at half the ack wait, until they have been processed.
+func (si *StreamInput) keepInProgress(msgs []jetstream.Msg, processed <-chan struct{}) {
+	interval := si.ackWait / 2
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-processed:
+			return
+		case <-si.ctx.Done():
+			return
+		case <-ticker.C:
+			for _, msg := range msgs {
+				// -- a lost signal only risks a redelivery, which the acknowledgment reports later on
+				_ = msg.InProgress()
+			}
+		}
+	}
+}
 
-			// Acknowledge successful processing
-			if err := msg.Ack(); err != nil {
-				return fmt.Errorf("failed to acknowledge message: %w", err)
+// nakAll hands messages which weren't read back to the server.
+func (si *StreamInput) nakAll(msgs []jetstream.Msg) {
+	if si.ackNone {
+		return
+	}
+	for _, msg := range msgs {
+		_ = msg.Nak()
+	}
+}
+
+// nak hands a failed message back to the server, delaying its redelivery when requested.
+func (si *StreamInput) nak(msg jetstream.Msg, err error) error {
+	deliveries := 1
+	if metadata, mErr := msg.Metadata(); mErr == nil {
+		deliveries = int(metadata.NumDelivered)
+	}
+
+	if delay := spec.RedeliveryDelay(err, si.cfg.Redelivery, deliveries); delay > 0 {
+		return msg.NakWithDelay(delay)
+	}
+	return msg.Nak()
+}
+
+// streamReader hands the messages delivered by a consumer to Read. It holds on to at most one message, the rest is
+// buffered by the consumer.
+type streamReader struct {
+	iter    jetstream.MessagesContext
+	reads   chan streamRead
+	stopped chan struct{}
+	once    sync.Once
+}
+
+type streamRead struct {
+	msg jetstream.Msg
+	err error
+}
+
+func (r *streamReader) run() {
+	defer close(r.reads)
+
+	for {
+		msg, err := r.iter.Next()
+		if errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+			return
+		}
+
+		select {
+		case r.reads <- streamRead{msg: msg, err: err}:
+		case <-r.stopped:
+			// -- hand the message back, nobody is going to read it
+			if msg != nil {
+				_ = msg.Nak()
 			}
+			return
 		}
-		return nil
 	}
+}
+
+func (r *streamReader) stop() {
+	r.once.Do(func() {
+		close(r.stopped)
+		r.iter.Stop()
+	})
+}
 
-	return batch, ackCallback, nil
+// consumerGone reports whether the error indicates the consumer no longer exists on the server. Pull requests for a
+// consumer which was removed go unanswered, so a lack of responders or heartbeats counts as well.
+func consumerGone(err error) bool {
+	return errors.Is(err, jetstream.ErrConsumerNotFound) ||
+		errors.Is(err, jetstream.ErrConsumerDeleted) ||
+		errors.Is(err, jetstream.ErrNoHeartbeat) ||
+		errors.Is(err, nats.ErrNoResponders)
 }
@@ -0,0 +1,356 @@
+package nats_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StreamInput", func() {
+	var ctx spec.ComponentContext
+	var streamName string
+	var input *wwnats.StreamInput
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		streamName = "IN_" + uuid.NewString()[:8]
+
+		_, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+			Name:     streamName,
+			Subjects: []string{streamName + ".>"},
+			Storage:  jetstream.MemoryStorage,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		DeferCleanup(func() {
+			_ = js.DeleteStream(context.Background(), streamName)
+		})
+
+		input, err = wwnats.NewStreamInputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+			"Stream":  expr(streamName),
+			"Subject": expr(streamName + ".>"),
+			"Consumer": map[string]any{
+				"DeliverPolicy": "all",
+				"AckPolicy":     "explicit",
+			},
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Init(ctx)).To(Succeed())
+
+		DeferCleanup(func() {
+			_ = input.Close(ctx)
+		})
+	})
+
+	When("draining", func() {
+		It("should wait for the batches in flight to be acknowledged", func() {
+			_, err := js.Publish(context.Background(), streamName+".data", []byte("in flight"))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			drained := make(chan error, 1)
+			go func() { drained <- input.Drain(context.Background()) }()
+			Consistently(drained, 100*time.Millisecond).ShouldNot(Receive())
+
+			_, _, err = input.Read(ctx)
+			Expect(err).To(MatchError(spec.ErrNotConnected))
+
+			Expect(callback(context.Background(), nil)).To(Succeed())
+			Eventually(drained).Should(Receive(BeNil()))
+		})
+
+		It("should give up waiting once the deadline passes", func() {
+			_, err := js.Publish(context.Background(), streamName+".data", []byte("never acknowledged"))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			drainCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			Expect(input.Drain(drainCtx)).To(MatchError(spec.ErrDrainTimeout))
+		})
+
+		It("should read again when initialized after being drained and closed", func() {
+			Expect(spec.DrainAndClose(ctx, input, time.Second)).To(Succeed())
+			Expect(input.Init(ctx)).To(Succeed())
+
+			_, err := js.Publish(context.Background(), streamName+".data", []byte("after restart"))
+			Expect(err).ToNot(HaveOccurred())
+
+			batch, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			var payloads []string
+			for _, msg := range batch.Messages() {
+				raw, err := msg.Raw()
+				Expect(err).ToNot(HaveOccurred())
+				payloads = append(payloads, string(raw))
+			}
+			Expect(payloads).To(Equal([]string{"after restart"}))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+		})
+	})
+
+	When("the consumer is removed", func() {
+		It("should create the consumer again", func() {
+			consumerName := "C_" + uuid.NewString()[:8]
+			named, err := wwnats.NewStreamInputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":  expr(streamName),
+				"Subject": expr(streamName + ".>"),
+				"Consumer": map[string]any{
+					"Name":          expr(consumerName),
+					"DeliverPolicy": "all",
+				},
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(named.Init(ctx)).To(Succeed())
+			defer func() { _ = named.Close(ctx) }()
+
+			// -- remove the consumer while the input is waiting for messages
+			consumer, err := js.Consumer(context.Background(), streamName, consumerName)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(func() int {
+				info, err := consumer.Info(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				return info.NumWaiting
+			}).Should(BeNumerically(">", 0))
+
+			Expect(js.DeleteConsumer(context.Background(), streamName, consumerName)).To(Succeed())
+
+			_, err = js.Publish(context.Background(), streamName+".data", []byte("after removal"))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = named.Read(ctx)
+			Expect(err).To(HaveOccurred())
+
+			batch, callback, err := named.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(callback(context.Background(), nil)).To(Succeed())
+
+			var payloads []string
+			for _, msg := range batch.Messages() {
+				raw, err := msg.Raw()
+				Expect(err).ToNot(HaveOccurred())
+				payloads = append(payloads, string(raw))
+			}
+			Expect(payloads).To(Equal([]string{"after removal"}))
+		})
+	})
+
+	When("a batch fails partially", func() {
+		It("should only redeliver the failed messages", func() {
+			partial, err := wwnats.NewStreamInputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":    expr(streamName),
+				"Subject":   expr(streamName + ".>"),
+				"BatchSize": 3,
+				"Consumer": map[string]any{
+					"DeliverPolicy": "all",
+					"AckPolicy":     "explicit",
+				},
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(partial.Init(ctx)).To(Succeed())
+			defer func() { _ = partial.Close(ctx) }()
+
+			publish := func(payloads ...string) {
+				for _, p := range payloads {
+					_, err := js.Publish(context.Background(), streamName+".data", []byte(p))
+					Expect(err).ToNot(HaveOccurred())
+				}
+			}
+
+			read := func() ([]string, spec.ProcessedCallback) {
+				batch, callback, err := partial.Read(ctx)
+				Expect(err).ToNot(HaveOccurred())
+
+				var payloads []string
+				for _, msg := range batch.Messages() {
+					raw, err := msg.Raw()
+					Expect(err).ToNot(HaveOccurred())
+					payloads = append(payloads, string(raw))
+				}
+				return payloads, callback
+			}
+
+			publish("one", "two", "three")
+			payloads, callback := read()
+			Expect(payloads).To(Equal([]string{"one", "two", "three"}))
+
+			batchErr := spec.NewBatchError(nil).
+				Failed(0, fmt.Errorf("%w: invalid payload", spec.ErrPermanent)).
+				Failed(2, errors.New("temporarily unavailable"))
+			Expect(callback(context.Background(), batchErr)).To(Succeed())
+
+			publish("four", "five")
+			payloads, callback = read()
+			Expect(payloads).To(ConsistOf("three", "four", "five"))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+		})
+	})
+
+	When("a message fails with a redelivery schedule", func() {
+		It("should hold the message back before redelivering it", func() {
+			delayed, err := wwnats.NewStreamInputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":    expr(streamName),
+				"Subject":   expr(streamName + ".>"),
+				"BatchSize": 1,
+				"Consumer": map[string]any{
+					"DeliverPolicy": "all",
+					"AckPolicy":     "explicit",
+				},
+				"Redelivery": map[string]any{
+					"initial": time.Second,
+				},
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(delayed.Init(ctx)).To(Succeed())
+			defer func() { _ = delayed.Close(ctx) }()
+
+			read := func() (string, spec.ProcessedCallback) {
+				batch, callback, err := delayed.Read(ctx)
+				Expect(err).ToNot(HaveOccurred())
+
+				var payloads []string
+				for _, msg := range batch.Messages() {
+					raw, err := msg.Raw()
+					Expect(err).ToNot(HaveOccurred())
+					payloads = append(payloads, string(raw))
+				}
+				Expect(payloads).To(HaveLen(1))
+				return payloads[0], callback
+			}
+
+			for _, p := range []string{"one", "two"} {
+				_, err := js.Publish(context.Background(), streamName+".data", []byte(p))
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			payload, callback := read()
+			Expect(payload).To(Equal("one"))
+			failedAt := time.Now()
+			Expect(callback(context.Background(), errors.New("temporarily unavailable"))).To(Succeed())
+
+			payload, callback = read()
+			Expect(payload).To(Equal("two"))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+
+			payload, callback = read()
+			Expect(payload).To(Equal("one"))
+			Expect(time.Since(failedAt)).To(BeNumerically(">=", 800*time.Millisecond))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+		})
+	})
+
+	When("no messages are available", func() {
+		It("should return once the context is done", func() {
+			readCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			started := time.Now()
+			_, _, err := input.Read(test.NewMockComponentContextWithContext(readCtx))
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(time.Since(started)).To(BeNumerically("<", time.Second))
+		})
+	})
+
+	When("processing takes longer than the ack wait", func() {
+		It("should keep the messages from being redelivered", func() {
+			slow, err := wwnats.NewStreamInputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":  expr(streamName),
+				"Subject": expr(streamName + ".>"),
+				"Consumer": map[string]any{
+					"DeliverPolicy": "all",
+					"AckPolicy":     "explicit",
+					"AckWait":       "1s",
+				},
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(slow.Init(ctx)).To(Succeed())
+			defer func() { _ = slow.Close(ctx) }()
+
+			_, err = js.Publish(context.Background(), streamName+".data", []byte("slow"))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, callback, err := slow.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			time.Sleep(2500 * time.Millisecond)
+
+			readCtx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			_, _, err = slow.Read(test.NewMockComponentContextWithContext(readCtx))
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+
+			Expect(callback(context.Background(), nil)).To(Succeed())
+		})
+	})
+
+	When("an ordered consumer is configured", func() {
+		It("should read the messages in stream order without acknowledging them", func() {
+			ordered, err := wwnats.NewStreamInputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":    expr(streamName),
+				"Subject":   expr(streamName + ".>"),
+				"BatchSize": 3,
+				"Consumer": map[string]any{
+					"DeliverPolicy": "all",
+					"Ordered":       true,
+				},
+			}))
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, p := range []string{"one", "two", "three"} {
+				_, err := js.Publish(context.Background(), streamName+".data", []byte(p))
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(ordered.Init(ctx)).To(Succeed())
+			defer func() { _ = ordered.Close(ctx) }()
+
+			var payloads []string
+			for len(payloads) < 3 {
+				batch, callback, err := ordered.Read(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				for _, msg := range batch.Messages() {
+					raw, err := msg.Raw()
+					Expect(err).ToNot(HaveOccurred())
+					payloads = append(payloads, string(raw))
+				}
+				Expect(callback(context.Background(), nil)).To(Succeed())
+			}
+			Expect(payloads).To(Equal([]string{"one", "two", "three"}))
+		})
+	})
+
+	When("an inactive threshold is configured", func() {
+		It("should create the consumer with it", func() {
+			consumerName := "C_" + uuid.NewString()[:8]
+			ephemeral, err := wwnats.NewStreamInputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":  expr(streamName),
+				"Subject": expr(streamName + ".>"),
+				"Consumer": map[string]any{
+					"Name":              expr(consumerName),
+					"InactiveThreshold": "5m",
+				},
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ephemeral.Init(ctx)).To(Succeed())
+			defer func() { _ = ephemeral.Close(ctx) }()
+
+			consumer, err := js.Consumer(context.Background(), streamName, consumerName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(consumer.CachedInfo().Config.InactiveThreshold).To(Equal(5 * time.Minute))
+		})
+	})
+})
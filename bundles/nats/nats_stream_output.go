@@ -1,26 +1,47 @@
 package nats
 
 import (
-	"context"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
+	"github.com/wombatwisdom/components/bundles/compress"
 	"github.com/wombatwisdom/components/framework/spec"
 )
 
 const (
 	StreamOutputComponentName = "nats_stream"
+
+	// defaultMaxPending is the number of published messages which may await their acknowledgment by default
+	defaultMaxPending = 256
 )
 
 // StreamOutput publishes messages to a NATS JetStream stream.
 // It provides reliable message publishing with acknowledgment support.
+//
+// The messages of a batch are published asynchronously, with at most the configured number of messages awaiting
+// their acknowledgment at a time. The batch only succeeds once JetStream acknowledged all of its messages. Messages
+// which weren't acknowledged are reported through a spec.BatchError, so they can be written again. Configure an ID
+// expression to have JetStream drop the duplicates this may cause.
 type StreamOutput struct {
 	sys spec.System
 	cfg StreamConfig
 
 	js jetstream.JetStream
+
+	// streams holds the names of the streams which are known to exist
+	lock    sync.Mutex
+	streams map[string]bool
+}
+
+// pendingPublish is a published message awaiting its acknowledgment.
+type pendingPublish struct {
+	idx    int
+	stream string
+	future jetstream.PubAckFuture
 }
 
 // NewStreamOutputFromConfig creates a new NATS Stream output from configuration
@@ -30,53 +51,155 @@ func NewStreamOutputFromConfig(sys spec.System, config spec.Config) (*StreamOutp
 		return nil, fmt.Errorf("failed to decode stream output config: %w", err)
 	}
 
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = defaultMaxPending
+	}
+
 	return &StreamOutput{
-		sys: sys,
-		cfg: cfg,
+		sys:     sys,
+		cfg:     cfg,
+		streams: map[string]bool{},
 	}, nil
 }
 
 func (so *StreamOutput) Init(ctx spec.ComponentContext) error {
+	if so.js != nil {
+		return spec.ErrAlreadyConnected
+	}
+
 	// Get JetStream context from system
 	js, ok := so.sys.Client().(jetstream.JetStream)
 	if !ok {
 		return fmt.Errorf("system client is not a JetStream instance")
 	}
-	so.js = js
 
+	algo, err := compress.ParseAlgorithm(string(so.cfg.Compression))
+	if err != nil {
+		return fmt.Errorf("compression: %w", err)
+	}
+	so.cfg.Compression = algo
+
+	// -- provision the stream the output publishes to when it doesn't depend on the messages
+	if so.cfg.Provision != nil {
+		name, err := so.cfg.Stream.Eval(spec.MessageExpressionContext(ctx.NewMessage()))
+		if err != nil {
+			return fmt.Errorf("failed to evaluate stream name: %w", err)
+		}
+		if _, err := provisionStream(ctx.Context(), js, name, so.cfg.Provision); err != nil {
+			return err
+		}
+
+		so.lock.Lock()
+		so.streams[name] = true
+		so.lock.Unlock()
+	}
+
+	so.js = js
 	return nil
 }
 
 func (so *StreamOutput) Close(ctx spec.ComponentContext) error {
+	so.js = nil
 	return nil
 }
 
+// Write publishes all messages of the batch. A message which fails to publish doesn't stop the others from being
+// published, the failed messages are reported through a spec.BatchError instead.
 func (so *StreamOutput) Write(ctx spec.ComponentContext, batch spec.Batch) error {
+	if so.js == nil {
+		return spec.ErrNotConnected
+	}
+
+	batchErr := spec.NewBatchError(nil)
+
+	var pending []pendingPublish
 	for idx, message := range batch.Messages() {
-		if err := so.WriteMessage(ctx, message); err != nil {
-			return fmt.Errorf("batch #%d: %w", idx, err)
+		// -- wait for the oldest message to be acknowledged when the window is full
+		if len(pending) >= so.cfg.MaxPending {
+			if err := so.await(ctx, pending[0]); err != nil {
+				batchErr.Failed(pending[0].idx, err)
+			}
+			pending = pending[1:]
+		}
+
+		msg, opts, stream, err := so.prepare(ctx, message)
+		if err != nil {
+			batchErr.Failed(idx, err)
+			continue
+		}
+
+		future, err := so.js.PublishMsgAsync(msg, opts...)
+		if err != nil {
+			batchErr.Failed(idx, fmt.Errorf("failed to publish message to stream %s: %w", stream, err))
+			continue
 		}
+		pending = append(pending, pendingPublish{idx: idx, stream: stream, future: future})
+	}
+
+	for _, p := range pending {
+		if err := so.await(ctx, p); err != nil {
+			batchErr.Failed(p.idx, err)
+		}
+	}
+
+	if batchErr.Len() > 0 {
+		return batchErr
 	}
 	return nil
 }
 
+// WriteMessage publishes a single message and waits for its acknowledgment.
 func (so *StreamOutput) WriteMessage(ctx spec.ComponentContext, message spec.Message) error {
+	if so.js == nil {
+		return spec.ErrNotConnected
+	}
+
+	msg, opts, stream, err := so.prepare(ctx, message)
+	if err != nil {
+		return err
+	}
+
+	future, err := so.js.PublishMsgAsync(msg, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to publish message to stream %s: %w", stream, err)
+	}
+	return so.await(ctx, pendingPublish{stream: stream, future: future})
+}
+
+// await waits for the acknowledgment of a published message. Messages rejected because the stream or subject wasn't
+// at the expected sequence fail with spec.ErrPermanent, since publishing them again won't change that.
+func (so *StreamOutput) await(ctx spec.ComponentContext, p pendingPublish) error {
+	select {
+	case <-p.future.Ok():
+		return nil
+	case err := <-p.future.Err():
+		if revisionConflict(err) {
+			err = fmt.Errorf("%w: %w", spec.ErrPermanent, err)
+		}
+		return fmt.Errorf("failed to publish message to stream %s: %w", p.stream, err)
+	case <-ctx.Context().Done():
+		return fmt.Errorf("no acknowledgment from stream %s: %w", p.stream, ctx.Context().Err())
+	}
+}
+
+// prepare creates the NATS message and the publish options for a message, returning the name of its stream.
+func (so *StreamOutput) prepare(ctx spec.ComponentContext, message spec.Message) (*nats.Msg, []jetstream.PublishOpt, string, error) {
+	exprCtx := spec.MessageExpressionContext(message)
+
 	// Evaluate stream name
-	streamName, err := so.cfg.Stream.Eval(spec.MessageExpressionContext(message))
+	streamName, err := so.cfg.Stream.Eval(exprCtx)
 	if err != nil {
-		return fmt.Errorf("failed to evaluate stream name: %w", err)
+		return nil, nil, "", fmt.Errorf("failed to evaluate stream name: %w", err)
 	}
 
 	// Evaluate subject
-	subject, err := so.cfg.Subject.Eval(spec.MessageExpressionContext(message))
+	subject, err := so.cfg.Subject.Eval(exprCtx)
 	if err != nil {
-		return fmt.Errorf("failed to evaluate subject: %w", err)
+		return nil, nil, "", fmt.Errorf("failed to evaluate subject: %w", err)
 	}
 
-	// Verify stream exists (optional check)
-	_, err = so.js.Stream(context.Background(), streamName)
-	if err != nil {
-		return fmt.Errorf("failed to get stream %s: %w", streamName, err)
+	if err := so.ensureStream(ctx, streamName); err != nil {
+		return nil, nil, "", err
 	}
 
 	// Create publish options with headers
@@ -123,28 +246,88 @@ func (so *StreamOutput) WriteMessage(ctx spec.ComponentContext, message spec.Mes
 		headers.Set("wombat_source", hostname)
 	}
 
-	// Note: JetStream publish options don't directly support headers in this version
-	// Headers would need to be embedded in the message data or handled differently
-
 	// Add message ID for deduplication
-	msgID := fmt.Sprintf("%d", time.Now().UnixNano())
-	publishOpts = append(publishOpts, jetstream.WithMsgID(msgID))
+	if so.cfg.MsgID != nil {
+		msgID, err := so.cfg.MsgID.Eval(exprCtx)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to evaluate message id: %w", err)
+		}
+		if msgID != "" {
+			publishOpts = append(publishOpts, jetstream.WithMsgID(msgID))
+		}
+	}
+
+	// Add the expected sequences for optimistic concurrency
+	if so.cfg.ExpectedLastSequence != nil {
+		seq, err := evalSequence(so.cfg.ExpectedLastSequence, exprCtx)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("expected last sequence: %w", err)
+		}
+		publishOpts = append(publishOpts, jetstream.WithExpectLastSequence(seq))
+	}
+	if so.cfg.ExpectedLastSubjectSequence != nil {
+		seq, err := evalSequence(so.cfg.ExpectedLastSubjectSequence, exprCtx)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("expected last subject sequence: %w", err)
+		}
+		publishOpts = append(publishOpts, jetstream.WithExpectLastSequencePerSubject(seq))
+	}
 
 	// Get message data
 	msgData, err := message.Raw()
 	if err != nil {
-		return fmt.Errorf("failed to get message data: %w", err)
+		return nil, nil, "", fmt.Errorf("failed to get message data: %w", err)
 	}
 
-	// Publish message using JetStream context
-	_, err = so.js.Publish(context.Background(), subject, msgData, publishOpts...)
-	if err != nil {
-		return fmt.Errorf("failed to publish message to stream %s: %w", streamName, err)
+	// Compress the payload if configured
+	if so.cfg.Compression != "" && so.cfg.Compression != compress.None {
+		msgData, err = compress.Compress(so.cfg.Compression, msgData)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to compress message data: %w", err)
+		}
+		headers.Set(compress.MetadataCompression, string(so.cfg.Compression))
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = msgData
+	msg.Header = headers
+
+	return msg, publishOpts, streamName, nil
+}
+
+// ensureStream checks that the stream exists the first time a message is published to it.
+func (so *StreamOutput) ensureStream(ctx spec.ComponentContext, name string) error {
+	so.lock.Lock()
+	known := so.streams[name]
+	so.lock.Unlock()
+	if known {
+		return nil
 	}
 
+	if _, err := so.js.Stream(ctx.Context(), name); err != nil {
+		return fmt.Errorf("failed to get stream %s: %w", name, err)
+	}
+
+	so.lock.Lock()
+	so.streams[name] = true
+	so.lock.Unlock()
 	return nil
 }
 
+// evalSequence evaluates an expression producing a stream sequence.
+func evalSequence(expr spec.Expression, exprCtx spec.ExpressionContext) (uint64, error) {
+	value, err := expr.Eval(exprCtx)
+	if err != nil {
+		return 0, err
+	}
+
+	seq, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid sequence %q", spec.ErrPermanent, value)
+	}
+	return seq, nil
+}
+
 // getHostname returns the hostname for source tracking
 func getHostname() string {
 	// For now, return empty string. In a real implementation,
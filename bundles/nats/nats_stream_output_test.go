@@ -0,0 +1,170 @@
+package nats_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/wombatwisdom/components/bundles/compress"
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StreamOutput", func() {
+	var ctx spec.ComponentContext
+	var stream jetstream.Stream
+	var streamName string
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		streamName = "OUT_" + uuid.NewString()[:8]
+
+		var err error
+		stream, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+			Name:     streamName,
+			Subjects: []string{streamName + ".>"},
+			Storage:  jetstream.MemoryStorage,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		DeferCleanup(func() {
+			_ = js.DeleteStream(context.Background(), streamName)
+		})
+	})
+
+	When("compression is configured", func() {
+		It("should publish the compressed payload with a compression header", func() {
+			output, err := wwnats.NewStreamOutputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":      expr(streamName),
+				"Subject":     expr(streamName + ".data"),
+				"Compression": "gzip",
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.Init(ctx)).To(Succeed())
+			defer func() { _ = output.Close(ctx) }()
+
+			msg := spec.NewBytesMessage([]byte("hello, world"))
+			msg.SetMetadata("origin", "test")
+			Expect(output.Write(ctx, ctx.NewBatch(msg))).To(Succeed())
+
+			stored, err := stream.GetLastMsgForSubject(context.Background(), streamName+".data")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stored.Header.Get(compress.MetadataCompression)).To(Equal("gzip"))
+			Expect(stored.Header.Get("origin")).To(Equal("test"))
+
+			data, err := compress.Decompress(compress.Gzip, stored.Data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("hello, world"))
+		})
+	})
+
+	When("some messages of a batch fail", func() {
+		It("should publish the other messages and report the failed ones", func() {
+			output, err := wwnats.NewStreamOutputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":  expr("${! json.stream }"),
+				"Subject": expr(streamName + ".data"),
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.Init(ctx)).To(Succeed())
+			defer func() { _ = output.Close(ctx) }()
+
+			err = output.Write(ctx, ctx.NewBatch(
+				spec.NewBytesMessage([]byte(`{"stream":"`+streamName+`"}`)),
+				spec.NewBytesMessage([]byte(`{"stream":"MISSING"}`)),
+				spec.NewBytesMessage([]byte(`{"stream":"`+streamName+`"}`)),
+			))
+
+			var batchErr *spec.BatchError
+			Expect(errors.As(err, &batchErr)).To(BeTrue())
+			Expect(batchErr.Indexes()).To(Equal([]int{1}))
+
+			info, err := stream.Info(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.State.Msgs).To(Equal(uint64(2)))
+		})
+	})
+
+	When("a message id is configured", func() {
+		It("should publish the messages with the same id only once", func() {
+			output, err := wwnats.NewStreamOutputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":  expr(streamName),
+				"Subject": expr(streamName + ".data"),
+				"MsgID":   expr("${! metadata.mq_message_id }"),
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.Init(ctx)).To(Succeed())
+			defer func() { _ = output.Close(ctx) }()
+
+			newMsg := func(id string) spec.Message {
+				msg := spec.NewBytesMessage([]byte("order " + id))
+				msg.SetMetadata("mq_message_id", id)
+				return msg
+			}
+
+			Expect(output.Write(ctx, ctx.NewBatch(newMsg("a"), newMsg("b")))).To(Succeed())
+			Expect(output.Write(ctx, ctx.NewBatch(newMsg("a"), newMsg("b"), newMsg("c")))).To(Succeed())
+
+			info, err := stream.Info(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.State.Msgs).To(Equal(uint64(3)))
+		})
+	})
+
+	When("the batch is larger than the pending window", func() {
+		It("should publish all messages", func() {
+			output, err := wwnats.NewStreamOutputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":     expr(streamName),
+				"Subject":    expr(streamName + ".data"),
+				"MaxPending": 4,
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.Init(ctx)).To(Succeed())
+			defer func() { _ = output.Close(ctx) }()
+
+			batch := ctx.NewBatch()
+			for i := 0; i < 50; i++ {
+				batch.Append(spec.NewBytesMessage([]byte("hello")))
+			}
+			Expect(output.Write(ctx, batch)).To(Succeed())
+
+			info, err := stream.Info(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.State.Msgs).To(Equal(uint64(50)))
+		})
+	})
+
+	When("an expected last subject sequence is configured", func() {
+		It("should fail permanently when the subject is at another sequence", func() {
+			output, err := wwnats.NewStreamOutputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":                      expr(streamName),
+				"Subject":                     expr(streamName + ".data"),
+				"ExpectedLastSubjectSequence": expr("${! metadata.seq }"),
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.Init(ctx)).To(Succeed())
+			defer func() { _ = output.Close(ctx) }()
+
+			first := spec.NewBytesMessage([]byte("first"))
+			first.SetMetadata("seq", "0")
+			Expect(output.Write(ctx, ctx.NewBatch(first))).To(Succeed())
+
+			stale := spec.NewBytesMessage([]byte("stale"))
+			stale.SetMetadata("seq", "0")
+			err = output.Write(ctx, ctx.NewBatch(stale))
+
+			var batchErr *spec.BatchError
+			Expect(errors.As(err, &batchErr)).To(BeTrue())
+			Expect(batchErr.Indexes()).To(Equal([]int{0}))
+			Expect(errors.Is(err, spec.ErrPermanent)).To(BeTrue())
+
+			next := spec.NewBytesMessage([]byte("next"))
+			next.SetMetadata("seq", "1")
+			Expect(output.Write(ctx, ctx.NewBatch(next))).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,149 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// provisionStream looks up the stream with the given name, creating it when it doesn't exist and the provisioning
+// mode allows it. An existing stream is verified against the provisioned configuration instead of being updated, so
+// a stream which differs from it fails with an error listing every difference.
+func provisionStream(ctx context.Context, js jetstream.JetStream, name string, p *StreamConfigProvision) (jetstream.Stream, error) {
+	desired, err := p.streamConfig(name)
+	if err != nil {
+		return nil, fmt.Errorf("stream %s: %w", name, err)
+	}
+
+	stream, err := js.Stream(ctx, name)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		if p.Mode == StreamConfigProvisionModeVerify {
+			return nil, fmt.Errorf("stream %s doesn't exist", name)
+		}
+
+		stream, err = js.CreateStream(ctx, desired)
+		if err == nil {
+			return stream, nil
+		}
+		if !errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+			return nil, fmt.Errorf("failed to create stream %s: %w", name, err)
+		}
+
+		// -- someone else created the stream in the meantime, verify theirs
+		stream, err = js.Stream(ctx, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream %s: %w", name, err)
+	}
+
+	if drift := p.drift(stream.CachedInfo().Config); len(drift) > 0 {
+		return nil, fmt.Errorf("stream %s differs from its configuration: %s", name, strings.Join(drift, "; "))
+	}
+	return stream, nil
+}
+
+// streamConfig creates the configuration of the stream to provision.
+func (p *StreamConfigProvision) streamConfig(name string) (jetstream.StreamConfig, error) {
+	cfg := jetstream.StreamConfig{
+		Name:     name,
+		Subjects: p.Subjects,
+		Replicas: p.Replicas,
+		MaxBytes: p.MaxBytes,
+	}
+
+	switch p.Mode {
+	case "", StreamConfigProvisionModeCreate, StreamConfigProvisionModeVerify:
+	default:
+		return cfg, fmt.Errorf("unknown provisioning mode %q", p.Mode)
+	}
+
+	var err error
+	if cfg.Retention, err = p.retention(); err != nil {
+		return cfg, err
+	}
+	if cfg.Storage, err = p.storage(); err != nil {
+		return cfg, err
+	}
+
+	if p.MaxAge != "" {
+		if cfg.MaxAge, err = time.ParseDuration(p.MaxAge); err != nil {
+			return cfg, fmt.Errorf("failed to parse max_age duration: %w", err)
+		}
+	}
+	if p.DuplicateWindow != "" {
+		if cfg.Duplicates, err = time.ParseDuration(p.DuplicateWindow); err != nil {
+			return cfg, fmt.Errorf("failed to parse duplicate_window duration: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func (p *StreamConfigProvision) retention() (jetstream.RetentionPolicy, error) {
+	switch p.Retention {
+	case "", StreamConfigProvisionRetentionLimits:
+		return jetstream.LimitsPolicy, nil
+	case StreamConfigProvisionRetentionInterest:
+		return jetstream.InterestPolicy, nil
+	case StreamConfigProvisionRetentionWorkqueue:
+		return jetstream.WorkQueuePolicy, nil
+	default:
+		return 0, fmt.Errorf("unknown retention policy %q", p.Retention)
+	}
+}
+
+func (p *StreamConfigProvision) storage() (jetstream.StorageType, error) {
+	switch p.Storage {
+	case "", StreamConfigProvisionStorageFile:
+		return jetstream.FileStorage, nil
+	case StreamConfigProvisionStorageMemory:
+		return jetstream.MemoryStorage, nil
+	default:
+		return 0, fmt.Errorf("unknown storage type %q", p.Storage)
+	}
+}
+
+// drift lists the differences between the provisioned configuration and the actual configuration of a stream.
+// Settings which weren't provisioned are left out, so the server defaults don't count as differences.
+func (p *StreamConfigProvision) drift(actual jetstream.StreamConfig) []string {
+	desired, _ := p.streamConfig(actual.Name)
+
+	var drift []string
+	report := func(setting string, want, got any) {
+		drift = append(drift, fmt.Sprintf("%s is %v instead of %v", setting, got, want))
+	}
+
+	if len(p.Subjects) > 0 {
+		want, got := slices.Clone(p.Subjects), slices.Clone(actual.Subjects)
+		slices.Sort(want)
+		slices.Sort(got)
+		if !slices.Equal(want, got) {
+			report("subjects", want, got)
+		}
+	}
+	if p.Retention != "" && desired.Retention != actual.Retention {
+		report("retention", desired.Retention, actual.Retention)
+	}
+	if p.Storage != "" && desired.Storage != actual.Storage {
+		report("storage", desired.Storage, actual.Storage)
+	}
+	if p.Replicas > 0 && desired.Replicas != actual.Replicas {
+		report("replicas", desired.Replicas, actual.Replicas)
+	}
+	if p.MaxAge != "" && desired.MaxAge != actual.MaxAge {
+		report("max_age", desired.MaxAge, actual.MaxAge)
+	}
+	if p.MaxBytes != 0 && desired.MaxBytes != actual.MaxBytes {
+		report("max_bytes", desired.MaxBytes, actual.MaxBytes)
+	}
+	if p.DuplicateWindow != "" && desired.Duplicates != actual.Duplicates {
+		report("duplicate_window", desired.Duplicates, actual.Duplicates)
+	}
+
+	return drift
+}
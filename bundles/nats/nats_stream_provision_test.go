@@ -0,0 +1,203 @@
+package nats_test
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream provisioning", func() {
+	var ctx spec.ComponentContext
+	var streamName string
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		streamName = "PROV_" + uuid.NewString()[:8]
+
+		DeferCleanup(func() {
+			_ = js.DeleteStream(context.Background(), streamName)
+		})
+	})
+
+	newInput := func(cfg map[string]any) *wwnats.StreamInput {
+		input, err := wwnats.NewStreamInputFromConfig(newJetStreamSystem(), spec.NewMapConfig(cfg))
+		Expect(err).ToNot(HaveOccurred())
+		return input
+	}
+
+	provision := func(settings map[string]any) map[string]any {
+		provision := map[string]any{
+			"Subjects":        []string{streamName + ".>"},
+			"Storage":         "memory",
+			"MaxAge":          "1h",
+			"DuplicateWindow": "30s",
+		}
+		for k, v := range settings {
+			provision[k] = v
+		}
+		return provision
+	}
+
+	When("the stream doesn't exist", func() {
+		It("should create it for an input", func() {
+			input := newInput(map[string]any{
+				"Stream":    expr(streamName),
+				"Subject":   expr(streamName + ".>"),
+				"Provision": provision(nil),
+				"Consumer":  map[string]any{"DeliverPolicy": "all"},
+			})
+			Expect(input.Init(ctx)).To(Succeed())
+			defer func() { _ = input.Close(ctx) }()
+
+			stream, err := js.Stream(context.Background(), streamName)
+			Expect(err).ToNot(HaveOccurred())
+			cfg := stream.CachedInfo().Config
+			Expect(cfg.Subjects).To(Equal([]string{streamName + ".>"}))
+			Expect(cfg.Storage).To(Equal(jetstream.MemoryStorage))
+			Expect(cfg.MaxAge).To(Equal(time.Hour))
+			Expect(cfg.Duplicates).To(Equal(30 * time.Second))
+
+			_, err = js.Publish(context.Background(), streamName+".data", []byte("provisioned"))
+			Expect(err).ToNot(HaveOccurred())
+
+			batch, callback, err := input.Read(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(batch.Messages()).ToNot(BeEmpty())
+			Expect(callback(context.Background(), nil)).To(Succeed())
+		})
+
+		It("should create it for an output", func() {
+			output, err := wwnats.NewStreamOutputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"Stream":    expr(streamName),
+				"Subject":   expr(streamName + ".data"),
+				"Provision": provision(nil),
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.Init(ctx)).To(Succeed())
+			defer func() { _ = output.Close(ctx) }()
+
+			Expect(output.Write(ctx, ctx.NewBatch(spec.NewBytesMessage([]byte("hello"))))).To(Succeed())
+
+			stream, err := js.Stream(context.Background(), streamName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stream.CachedInfo().State.Msgs).To(Equal(uint64(1)))
+		})
+
+		It("should fail when only verifying it", func() {
+			input := newInput(map[string]any{
+				"Stream":    expr(streamName),
+				"Provision": provision(map[string]any{"Mode": "verify"}),
+			})
+			Expect(input.Init(ctx)).To(MatchError(ContainSubstring("stream " + streamName + " doesn't exist")))
+		})
+	})
+
+	When("the stream differs from its configuration", func() {
+		It("should report every difference", func() {
+			_, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+				Name:     streamName,
+				Subjects: []string{streamName + ".>"},
+				Storage:  jetstream.MemoryStorage,
+				MaxAge:   2 * time.Hour,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			input := newInput(map[string]any{
+				"Stream":    expr(streamName),
+				"Provision": provision(nil),
+			})
+			err = input.Init(ctx)
+			Expect(err).To(MatchError(ContainSubstring("stream " + streamName + " differs from its configuration")))
+			Expect(err).To(MatchError(ContainSubstring("max_age is 2h0m0s instead of 1h0m0s")))
+			Expect(err).To(MatchError(ContainSubstring("duplicate_window is 2m0s instead of 30s")))
+		})
+
+		It("should accept a stream which matches", func() {
+			_, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+				Name:       streamName,
+				Subjects:   []string{streamName + ".>"},
+				Storage:    jetstream.MemoryStorage,
+				MaxAge:     time.Hour,
+				Duplicates: 30 * time.Second,
+				Replicas:   1,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			input := newInput(map[string]any{
+				"Stream":    expr(streamName),
+				"Provision": provision(map[string]any{"Mode": "verify"}),
+			})
+			Expect(input.Init(ctx)).To(Succeed())
+			_ = input.Close(ctx)
+		})
+	})
+
+	When("consumer settings are configured", func() {
+		It("should create the consumer with them", func() {
+			_, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+				Name:     streamName,
+				Subjects: []string{streamName + ".>"},
+				Storage:  jetstream.MemoryStorage,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, subject := range []string{"a", "b", "c", "a"} {
+				_, err := js.Publish(context.Background(), streamName+"."+subject, []byte(subject))
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			consumerName := "C_" + uuid.NewString()[:8]
+			input := newInput(map[string]any{
+				"Stream":    expr(streamName),
+				"Subject":   expr(streamName + ".>"),
+				"BatchSize": 10,
+				"Consumer": map[string]any{
+					"Name":            expr(consumerName),
+					"FilterSubjects":  []string{streamName + ".a", streamName + ".c"},
+					"StartSequence":   2,
+					"MaxAckPending":   10,
+					"MaxDeliver":      5,
+					"BackOff":         []string{"1s", "5s"},
+					"HeadersOnly":     true,
+					"SampleFrequency": "50%",
+				},
+			})
+			Expect(input.Init(ctx)).To(Succeed())
+			defer func() { _ = input.Close(ctx) }()
+
+			consumer, err := js.Consumer(context.Background(), streamName, consumerName)
+			Expect(err).ToNot(HaveOccurred())
+			cfg := consumer.CachedInfo().Config
+			Expect(cfg.FilterSubjects).To(ConsistOf(streamName+".a", streamName+".c"))
+			Expect(cfg.DeliverPolicy).To(Equal(jetstream.DeliverByStartSequencePolicy))
+			Expect(cfg.OptStartSeq).To(Equal(uint64(2)))
+			Expect(cfg.MaxAckPending).To(Equal(10))
+			Expect(cfg.BackOff).To(Equal([]time.Duration{time.Second, 5 * time.Second}))
+			Expect(cfg.HeadersOnly).To(BeTrue())
+			Expect(cfg.SampleFrequency).To(Equal("50%"))
+
+			var subjects []any
+			for len(subjects) < 2 {
+				batch, callback, err := input.Read(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				for _, msg := range batch.Messages() {
+					raw, err := msg.Raw()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(raw).To(BeEmpty())
+					subjects = append(subjects, maps.Collect(msg.Metadata())["nats_subject"])
+				}
+				Expect(callback(context.Background(), nil)).To(Succeed())
+			}
+			Expect(subjects).To(Equal([]any{streamName + ".c", streamName + ".a"}))
+		})
+	})
+})
@@ -0,0 +1,68 @@
+package nats_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/bundles/nats/test"
+	"github.com/wombatwisdom/components/framework/spec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var acc test.Acc
+var srv *server.Server
+var nc *nats.Conn
+var js jetstream.JetStream
+
+func TestNats(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	BeforeSuite(func() {
+		acc = test.Account("TEST_ACCOUNT")
+		srv = test.NewDecentralizedServer().WithAccount(acc).Run()
+		nc = acc.Connect(srv)
+
+		var err error
+		js, err = jetstream.New(nc)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterSuite(func() {
+		nc.Close()
+		srv.Shutdown()
+	})
+
+	RunSpecs(t, "Nats Suite")
+}
+
+// newJetStreamSystem creates a connected JetStream system for the test account.
+func newJetStreamSystem() *wwnats.JetStreamSystem {
+	jwt, seed := acc.Creds()
+	sys, err := wwnats.NewJetStreamSystemFromConfig(spec.NewYamlConfig(`
+url: ##url##
+auth:
+  jwt: ##jwt##
+  seed: ##seed##
+`, "##url##", srv.ClientURL(), "##jwt##", jwt, "##seed##", string(seed)))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(sys.Connect(context.Background())).To(Succeed())
+
+	DeferCleanup(func() {
+		_ = sys.Close(context.Background())
+	})
+
+	return sys
+}
+
+// expr compiles the given expression, failing the test if it is invalid.
+func expr(s string) spec.Expression {
+	e, err := spec.NewExprLangExpression(s)
+	Expect(err).ToNot(HaveOccurred())
+	return e
+}
@@ -0,0 +1,32 @@
+package nats
+
+import (
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+type ObjectStoreTriggerConfig struct {
+	// The name of the object store bucket to watch.
+	Bucket string `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+
+	// Whether to only trigger on objects put after the watch started, without the
+	// objects the bucket already holds.
+	UpdatesOnly bool `json:"updates_only,omitempty" yaml:"updates_only,omitempty" mapstructure:"updates_only,omitempty"`
+
+	// The maximum number of triggers to read at a time. Defaults to 1.
+	MaxBatchSize int `json:"max_batch_size,omitempty" yaml:"max_batch_size,omitempty" mapstructure:"max_batch_size,omitempty"`
+}
+
+type ObjectStoreRetrievalConfig struct {
+	// The name of the object store bucket to retrieve from. Without it, the bucket is
+	// taken from the metadata of each trigger.
+	Bucket string `json:"bucket,omitempty" yaml:"bucket,omitempty" mapstructure:"bucket,omitempty"`
+}
+
+type ObjectStoreOutputConfig struct {
+	// The name of the object store bucket to upload to.
+	Bucket string `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+
+	// The expression producing the name of the object for each message. An existing
+	// object with the same name is replaced.
+	Name spec.Expression `json:"name" yaml:"name" mapstructure:"name"`
+}
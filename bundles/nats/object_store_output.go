@@ -0,0 +1,90 @@
+package nats
+
+import (
+	"fmt"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// ObjectStoreOutput uploads messages as objects to a NATS JetStream object store bucket.
+//
+// The name of each object is produced by an expression. The payload of messages which can be streamed, like the
+// ones read by the ObjectStoreRetrievalProcessor, is uploaded while it is being read instead of being buffered in
+// memory first.
+type ObjectStoreOutput struct {
+	sys spec.System
+	cfg ObjectStoreOutputConfig
+
+	store jetstream.ObjectStore
+}
+
+// NewObjectStoreOutputFromConfig creates a new NATS object store output from configuration
+func NewObjectStoreOutputFromConfig(sys spec.System, config spec.Config) (*ObjectStoreOutput, error) {
+	var cfg ObjectStoreOutputConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode object store output config: %w", err)
+	}
+
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("a bucket is required")
+	}
+	if cfg.Name == nil {
+		return nil, fmt.Errorf("a name is required")
+	}
+
+	return &ObjectStoreOutput{
+		sys: sys,
+		cfg: cfg,
+	}, nil
+}
+
+func (oo *ObjectStoreOutput) Init(ctx spec.ComponentContext) error {
+	store, err := bindObjectStore(ctx.Context(), oo.sys, oo.cfg.Bucket)
+	if err != nil {
+		return err
+	}
+	oo.store = store
+	return nil
+}
+
+func (oo *ObjectStoreOutput) Close(ctx spec.ComponentContext) error {
+	return nil
+}
+
+// Write uploads all messages of the batch. A message which fails to upload doesn't stop the others from being
+// uploaded, the failed messages are reported through a spec.BatchError instead.
+func (oo *ObjectStoreOutput) Write(ctx spec.ComponentContext, batch spec.Batch) error {
+	batchErr := spec.NewBatchError(nil)
+	for idx, message := range batch.Messages() {
+		if err := oo.WriteMessage(ctx, message); err != nil {
+			batchErr.Failed(idx, err)
+		}
+	}
+
+	if batchErr.Len() > 0 {
+		return batchErr
+	}
+	return nil
+}
+
+func (oo *ObjectStoreOutput) WriteMessage(ctx spec.ComponentContext, message spec.Message) error {
+	name, err := oo.cfg.Name.Eval(spec.MessageExpressionContext(message))
+	if err != nil {
+		return fmt.Errorf("failed to evaluate name: %w", err)
+	}
+	if name == "" {
+		return fmt.Errorf("%w: the name evaluated to an empty string", spec.ErrPermanent)
+	}
+
+	body, err := spec.MessageReader(message)
+	if err != nil {
+		return fmt.Errorf("failed to read message data: %w", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	if _, err := oo.store.Put(ctx.Context(), jetstream.ObjectMeta{Name: name}, body); err != nil {
+		return fmt.Errorf("failed to upload object %s to bucket %s: %w", name, oo.cfg.Bucket, err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// ObjectStoreRetrievalProcessor implements spec.RetrievalProcessor for NATS JetStream object stores. It reads the
+// object referenced by the name metadata of a trigger, or by its reference, into a message whose payload is streamed
+// from the object store.
+//
+// Every trigger results in a message, in the order of the triggers, so a spec.BatchError for the messages applies
+// to the triggers of the same index. Triggers referencing objects which don't exist fail with spec.ErrPermanent.
+type ObjectStoreRetrievalProcessor struct {
+	sys spec.System
+	cfg ObjectStoreRetrievalConfig
+
+	lock   sync.Mutex
+	stores map[string]jetstream.ObjectStore
+}
+
+// NewObjectStoreRetrievalProcessorFromConfig creates a new NATS object store retrieval processor from configuration
+func NewObjectStoreRetrievalProcessorFromConfig(sys spec.System, config spec.Config) (*ObjectStoreRetrievalProcessor, error) {
+	var cfg ObjectStoreRetrievalConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode object store retrieval config: %w", err)
+	}
+
+	return &ObjectStoreRetrievalProcessor{
+		sys: sys,
+		cfg: cfg,
+	}, nil
+}
+
+func (r *ObjectStoreRetrievalProcessor) Init(ctx spec.ComponentContext) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.stores = map[string]jetstream.ObjectStore{}
+	if r.cfg.Bucket == "" {
+		return nil
+	}
+
+	store, err := bindObjectStore(ctx.Context(), r.sys, r.cfg.Bucket)
+	if err != nil {
+		return err
+	}
+	r.stores[r.cfg.Bucket] = store
+	return nil
+}
+
+func (r *ObjectStoreRetrievalProcessor) Close(ctx spec.ComponentContext) error {
+	return nil
+}
+
+// Retrieve reads the objects referenced by the trigger events
+func (r *ObjectStoreRetrievalProcessor) Retrieve(ctx spec.ComponentContext, triggers spec.TriggerBatch) (spec.Batch, spec.ProcessedCallback, error) {
+	batch := ctx.NewBatch()
+
+	for idx, trigger := range triggers.Triggers() {
+		store, name, err := r.resolve(ctx.Context(), trigger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("trigger #%d: %w", idx, err)
+		}
+
+		info, err := store.GetInfo(ctx.Context(), name)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrObjectNotFound) {
+				return nil, nil, fmt.Errorf("trigger #%d: %w: %w", idx, spec.ErrPermanent, err)
+			}
+			return nil, nil, fmt.Errorf("trigger #%d: %w", idx, err)
+		}
+
+		msg := spec.NewReaderMessage(&objectReader{store: store, name: name})
+		for key, value := range objectMetadata(info) {
+			msg.SetMetadata(key, value)
+		}
+
+		msg.SetMetadata("trigger_source", trigger.Source())
+		msg.SetMetadata("trigger_timestamp", trigger.Timestamp())
+		for key, value := range trigger.Metadata() {
+			msg.SetMetadata("trigger_"+key, value)
+		}
+
+		batch.Append(msg)
+	}
+
+	return batch, spec.NoopCallback, nil
+}
+
+// resolve returns the object store and the name of the object referenced by the trigger.
+func (r *ObjectStoreRetrievalProcessor) resolve(ctx context.Context, trigger spec.TriggerEvent) (jetstream.ObjectStore, string, error) {
+	name, ok := trigger.Metadata()[MetadataObjectName].(string)
+	if !ok || name == "" {
+		name = trigger.Reference()
+	}
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: the trigger doesn't reference an object", spec.ErrPermanent)
+	}
+
+	bucket := r.cfg.Bucket
+	if bucket == "" {
+		bucket, _ = trigger.Metadata()[spec.MetadataBucket].(string)
+		if bucket == "" {
+			return nil, "", fmt.Errorf("%w: the trigger for %s doesn't reference a bucket", spec.ErrPermanent, name)
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if store, ok := r.stores[bucket]; ok {
+		return store, name, nil
+	}
+
+	store, err := bindObjectStore(ctx, r.sys, bucket)
+	if err != nil {
+		return nil, "", err
+	}
+	r.stores[bucket] = store
+	return store, name, nil
+}
+
+// objectReader gets the object on the first read, so the objects of a batch aren't transferred before their payload
+// is needed.
+type objectReader struct {
+	store jetstream.ObjectStore
+	name  string
+	r     jetstream.ObjectResult
+}
+
+func (o *objectReader) Read(p []byte) (int, error) {
+	if o.r == nil {
+		// -- the payload is read after Retrieve returned, so the transfer isn't bound to its context
+		r, err := o.store.Get(context.Background(), o.name)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get object %s: %w", o.name, err)
+		}
+		o.r = r
+	}
+	return o.r.Read(p)
+}
+
+func (o *objectReader) Close() error {
+	if o.r == nil {
+		return nil
+	}
+	err := o.r.Close()
+	o.r = nil
+	return err
+}
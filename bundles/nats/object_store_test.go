@@ -0,0 +1,228 @@
+package nats_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	wwnats "github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ObjectStore", func() {
+	var ctx spec.ComponentContext
+	var store jetstream.ObjectStore
+	var bucket string
+
+	BeforeEach(func() {
+		bucket = "OBJ_" + uuid.NewString()[:8]
+
+		var err error
+		store, err = js.CreateObjectStore(context.Background(), jetstream.ObjectStoreConfig{
+			Bucket:  bucket,
+			Storage: jetstream.MemoryStorage,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		DeferCleanup(func() {
+			_ = js.DeleteObjectStore(context.Background(), bucket)
+		})
+
+		timeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		DeferCleanup(cancel)
+		ctx = test.NewMockComponentContextWithContext(timeout)
+	})
+
+	payload := func(msg spec.Message) string {
+		r, err := spec.MessageReader(msg)
+		Expect(err).ToNot(HaveOccurred())
+		defer func() { _ = r.Close() }()
+
+		data, err := io.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	Describe("ObjectStoreTriggerInput", func() {
+		newTriggerInput := func(cfg map[string]any) *wwnats.ObjectStoreTriggerInput {
+			cfg["bucket"] = bucket
+			input, err := wwnats.NewObjectStoreTriggerInputFromConfig(newJetStreamSystem(), spec.NewMapConfig(cfg))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(input.Init(ctx)).To(Succeed())
+			DeferCleanup(func() { _ = input.Close(ctx) })
+			return input
+		}
+
+		It("should emit triggers for existing and new objects", func() {
+			existing, err := store.PutString(context.Background(), "reports/existing.csv", "a,b")
+			Expect(err).ToNot(HaveOccurred())
+
+			input := newTriggerInput(map[string]any{})
+
+			batch, callback, err := input.ReadTriggers(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(callback(context.Background(), nil)).To(Succeed())
+			Expect(batch.Triggers()).To(HaveLen(1))
+
+			trigger := batch.Triggers()[0]
+			Expect(trigger.Source()).To(Equal(spec.TriggerSourceObjectStore))
+			Expect(trigger.Reference()).To(Equal("reports/existing.csv"))
+			Expect(trigger.Metadata()).To(And(
+				HaveKeyWithValue(spec.MetadataBucket, bucket),
+				HaveKeyWithValue(spec.MetadataSize, int64(3)),
+				HaveKeyWithValue(wwnats.MetadataObjectDigest, existing.Digest),
+			))
+
+			_, err = store.PutString(context.Background(), "reports/new.csv", "c,d")
+			Expect(err).ToNot(HaveOccurred())
+
+			batch, _, err = input.ReadTriggers(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(batch.Triggers()).To(HaveLen(1))
+			Expect(batch.Triggers()[0].Reference()).To(Equal("reports/new.csv"))
+		})
+
+		It("should skip the existing objects when only updates are requested", func() {
+			_, err := store.PutString(context.Background(), "old", "old")
+			Expect(err).ToNot(HaveOccurred())
+
+			input := newTriggerInput(map[string]any{"updates_only": true})
+
+			_, err = store.PutString(context.Background(), "new", "new")
+			Expect(err).ToNot(HaveOccurred())
+
+			batch, _, err := input.ReadTriggers(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(batch.Triggers()).To(HaveLen(1))
+			Expect(batch.Triggers()[0].Reference()).To(Equal("new"))
+		})
+
+		It("should emit failed triggers again unless they failed permanently", func() {
+			for _, name := range []string{"a", "b"} {
+				_, err := store.PutString(context.Background(), name, name)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			input := newTriggerInput(map[string]any{})
+
+			read := func(err error) string {
+				batch, callback, readErr := input.ReadTriggers(ctx)
+				Expect(readErr).ToNot(HaveOccurred())
+				Expect(batch.Triggers()).To(HaveLen(1))
+				Expect(callback(context.Background(), err)).To(Succeed())
+				return batch.Triggers()[0].Reference()
+			}
+
+			Expect(read(spec.NewBatchError(nil).Failed(0, errors.New("boom")))).To(Equal("a"))
+			Expect(read(fmt.Errorf("%w: gone", spec.ErrPermanent))).To(Equal("a"))
+			Expect(read(nil)).To(Equal("b"))
+		})
+
+		It("should watch the bucket again when initialized after being closed", func() {
+			input := newTriggerInput(map[string]any{"updates_only": true})
+			Expect(input.Close(ctx)).To(Succeed())
+
+			_, _, err := input.ReadTriggers(ctx)
+			Expect(err).To(MatchError(spec.ErrNotConnected))
+
+			Expect(input.Init(ctx)).To(Succeed())
+			_, err = store.PutString(context.Background(), "after", "after")
+			Expect(err).ToNot(HaveOccurred())
+
+			batch, _, err := input.ReadTriggers(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(batch.Triggers()).To(HaveLen(1))
+			Expect(batch.Triggers()[0].Reference()).To(Equal("after"))
+		})
+
+		It("should watch the bucket again when initialized after being drained and closed", func() {
+			input := newTriggerInput(map[string]any{"updates_only": true})
+			Expect(spec.DrainAndClose(ctx, input, time.Second)).To(Succeed())
+
+			Expect(input.Init(ctx)).To(Succeed())
+			_, err := store.PutString(context.Background(), "after", "after")
+			Expect(err).ToNot(HaveOccurred())
+
+			batch, _, err := input.ReadTriggers(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(batch.Triggers()).To(HaveLen(1))
+			Expect(batch.Triggers()[0].Reference()).To(Equal("after"))
+		})
+	})
+
+	Describe("ObjectStoreRetrievalProcessor", func() {
+		var retrieval *wwnats.ObjectStoreRetrievalProcessor
+
+		BeforeEach(func() {
+			var err error
+			retrieval, err = wwnats.NewObjectStoreRetrievalProcessorFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(retrieval.Init(ctx)).To(Succeed())
+		})
+
+		It("should stream the objects referenced by the triggers", func() {
+			_, err := store.PutString(context.Background(), "data.json", `{"id":1}`)
+			Expect(err).ToNot(HaveOccurred())
+
+			triggers := spec.NewTriggerBatch()
+			triggers.Append(spec.NewTriggerEvent(spec.TriggerSourceObjectStore, "data.json", map[string]any{
+				spec.MetadataBucket: bucket,
+			}))
+
+			batch, _, err := retrieval.Retrieve(ctx, triggers)
+			Expect(err).ToNot(HaveOccurred())
+
+			var msgs []spec.Message
+			for _, msg := range batch.Messages() {
+				msgs = append(msgs, msg)
+			}
+			Expect(msgs).To(HaveLen(1))
+			Expect(msgs[0]).To(BeAssignableToTypeOf(spec.NewReaderMessage(nil)))
+			Expect(payload(msgs[0])).To(Equal(`{"id":1}`))
+			Expect(maps.Collect(msgs[0].Metadata())).To(And(
+				HaveKeyWithValue(wwnats.MetadataObjectName, "data.json"),
+				HaveKeyWithValue(spec.MetadataSize, int64(8)),
+				HaveKeyWithValue("trigger_source", spec.TriggerSourceObjectStore),
+			))
+		})
+
+		It("should fail permanently for objects which don't exist", func() {
+			triggers := spec.NewTriggerBatch()
+			triggers.Append(spec.NewTriggerEvent(spec.TriggerSourceObjectStore, "missing", map[string]any{
+				spec.MetadataBucket: bucket,
+			}))
+
+			_, _, err := retrieval.Retrieve(ctx, triggers)
+			Expect(err).To(MatchError(spec.ErrPermanent))
+		})
+	})
+
+	Describe("ObjectStoreOutput", func() {
+		It("should upload the messages under the names produced by the expression", func() {
+			output, err := wwnats.NewObjectStoreOutputFromConfig(newJetStreamSystem(), spec.NewMapConfig(map[string]any{
+				"bucket": bucket,
+				"name":   "${! \"out/\" + metadata.name }",
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.Init(ctx)).To(Succeed())
+			defer func() { _ = output.Close(ctx) }()
+
+			msg := spec.NewBytesMessage([]byte("hello, objects"))
+			msg.SetMetadata("name", "greeting.txt")
+			Expect(output.Write(ctx, ctx.NewBatch(msg))).To(Succeed())
+
+			data, err := store.GetString(context.Background(), "out/greeting.txt")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal("hello, objects"))
+		})
+	})
+})
@@ -0,0 +1,234 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	ObjectStoreComponentName = "nats_object_store"
+)
+
+// Metadata keys set on the triggers and on the retrieved messages, next to spec.MetadataBucket, spec.MetadataSize
+// and spec.MetadataTimestamp.
+const (
+	// MetadataObjectName is the name of the object.
+	MetadataObjectName = "name"
+	// MetadataObjectDigest is the SHA-256 digest of the object, as reported by the object store.
+	MetadataObjectDigest = "digest"
+)
+
+// ObjectStoreTriggerInput watches a NATS JetStream object store bucket and emits a trigger for every object put into
+// it. The triggers reference the name of the object, which the ObjectStoreRetrievalProcessor reads.
+//
+// The watch starts with the objects the bucket already holds, unless only updates are requested. Deleted objects
+// can't be retrieved anymore, so they don't produce triggers. Triggers which fail to be processed are emitted again
+// before any new ones, unless they failed with spec.ErrPermanent.
+type ObjectStoreTriggerInput struct {
+	sys spec.System
+	cfg ObjectStoreTriggerConfig
+
+	// lock guards the watch and the failed triggers
+	lock    sync.Mutex
+	watcher jetstream.ObjectWatcher
+	cancel  context.CancelFunc
+
+	// failed holds the objects of the triggers which have to be emitted again
+	failed []*jetstream.ObjectInfo
+
+	// inflight tracks the batches which haven't been processed yet
+	inflight *spec.InFlight
+}
+
+// NewObjectStoreTriggerInputFromConfig creates a new NATS object store trigger input from configuration
+func NewObjectStoreTriggerInputFromConfig(sys spec.System, config spec.Config) (*ObjectStoreTriggerInput, error) {
+	var cfg ObjectStoreTriggerConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode object store trigger input config: %w", err)
+	}
+
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("a bucket is required")
+	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = 1
+	}
+
+	return &ObjectStoreTriggerInput{
+		sys:      sys,
+		cfg:      cfg,
+		inflight: spec.NewInFlight(),
+	}, nil
+}
+
+func (ti *ObjectStoreTriggerInput) Init(ctx spec.ComponentContext) error {
+	ti.lock.Lock()
+	defer ti.lock.Unlock()
+
+	if ti.watcher != nil {
+		return spec.ErrAlreadyConnected
+	}
+
+	store, err := bindObjectStore(ctx.Context(), ti.sys, ti.cfg.Bucket)
+	if err != nil {
+		return err
+	}
+
+	opts := []jetstream.WatchOpt{jetstream.IgnoreDeletes()}
+	if ti.cfg.UpdatesOnly {
+		opts = append(opts, jetstream.UpdatesOnly())
+	}
+
+	// -- Init only bounds setting up the watch, which keeps running until stop is called
+	watchCtx, cancel := context.WithCancel(context.Background())
+	watcher, err := store.Watch(watchCtx, opts...)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to watch bucket %s: %w", ti.cfg.Bucket, err)
+	}
+	ti.watcher = watcher
+	ti.cancel = cancel
+
+	// -- a drained input reads again once it is initialized
+	ti.inflight.Reset()
+	return nil
+}
+
+// Drain stops watching the bucket and waits until the batches which were already read have been processed.
+func (ti *ObjectStoreTriggerInput) Drain(ctx context.Context) error {
+	ti.inflight.Drain()
+	ti.stop()
+	return ti.inflight.Wait(ctx)
+}
+
+func (ti *ObjectStoreTriggerInput) Close(ctx spec.ComponentContext) error {
+	ti.stop()
+	return nil
+}
+
+// stop stops the watch and forgets it, so the input can be initialized again.
+func (ti *ObjectStoreTriggerInput) stop() {
+	ti.lock.Lock()
+	defer ti.lock.Unlock()
+
+	if ti.watcher != nil {
+		_ = ti.watcher.Stop()
+		ti.watcher = nil
+	}
+	if ti.cancel != nil {
+		ti.cancel()
+		ti.cancel = nil
+	}
+}
+
+// ReadTriggers blocks until an object was put, returning at most the configured number of triggers. Failed triggers
+// are returned first.
+func (ti *ObjectStoreTriggerInput) ReadTriggers(ctx spec.ComponentContext) (spec.TriggerBatch, spec.ProcessedCallback, error) {
+	ti.lock.Lock()
+	watcher := ti.watcher
+	n := min(len(ti.failed), ti.cfg.MaxBatchSize)
+	objects := ti.failed[:n:n]
+	ti.failed = ti.failed[n:]
+	ti.lock.Unlock()
+
+	if watcher == nil || ti.inflight.Draining() {
+		ti.retry(objects)
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	// -- wait for the first object, then add the ones which are already pending up to the batch size
+	for len(objects) == 0 {
+		select {
+		case <-ctx.Context().Done():
+			return nil, nil, ctx.Context().Err()
+		case info, ok := <-watcher.Updates():
+			if !ok {
+				return nil, nil, spec.ErrNotConnected
+			}
+			// -- a nil info marks the end of the existing objects
+			if info != nil && !info.Deleted {
+				objects = append(objects, info)
+			}
+		}
+	}
+
+pending:
+	for len(objects) < ti.cfg.MaxBatchSize {
+		select {
+		case info, ok := <-watcher.Updates():
+			if !ok {
+				break pending
+			}
+			if info != nil && !info.Deleted {
+				objects = append(objects, info)
+			}
+		default:
+			break pending
+		}
+	}
+
+	batch := spec.NewTriggerBatch()
+	for _, info := range objects {
+		batch.Append(spec.NewTriggerEvent(spec.TriggerSourceObjectStore, info.Name, objectMetadata(info)))
+	}
+
+	return batch, ti.inflight.Track(ti.callback(objects)), nil
+}
+
+// callback creates the callback for a batch of triggers, which keeps the objects of the failed triggers to emit them
+// again. Triggers failing with spec.ErrPermanent won't succeed the next time either, so they are dropped.
+func (ti *ObjectStoreTriggerInput) callback(objects []*jetstream.ObjectInfo) spec.ProcessedCallback {
+	var once sync.Once
+	return func(ctx context.Context, err error) error {
+		once.Do(func() {
+			var failed []*jetstream.ObjectInfo
+			for idx, info := range objects {
+				if msgErr := spec.MessageError(err, idx); msgErr != nil && !errors.Is(msgErr, spec.ErrPermanent) {
+					failed = append(failed, info)
+				}
+			}
+			ti.retry(failed)
+		})
+		return nil
+	}
+}
+
+// retry queues the given objects to be emitted again.
+func (ti *ObjectStoreTriggerInput) retry(objects []*jetstream.ObjectInfo) {
+	if len(objects) == 0 {
+		return
+	}
+
+	ti.lock.Lock()
+	defer ti.lock.Unlock()
+	ti.failed = append(objects, ti.failed...)
+}
+
+// bindObjectStore looks up the object store bucket with the given name using the JetStream client of the system.
+func bindObjectStore(ctx context.Context, sys spec.System, bucket string) (jetstream.ObjectStore, error) {
+	js, ok := sys.Client().(jetstream.JetStream)
+	if !ok || js == nil {
+		return nil, fmt.Errorf("system client is not a JetStream instance")
+	}
+
+	store, err := js.ObjectStore(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind object store bucket %s: %w", bucket, err)
+	}
+	return store, nil
+}
+
+func objectMetadata(info *jetstream.ObjectInfo) map[string]any {
+	return map[string]any{
+		spec.MetadataBucket:    info.Bucket,
+		MetadataObjectName:     info.Name,
+		spec.MetadataSize:      int64(info.Size),
+		MetadataObjectDigest:   info.Digest,
+		spec.MetadataTimestamp: info.ModTime.Unix(),
+	}
+}
@@ -0,0 +1,257 @@
+package nats
+
+import (
+	"github.com/wombatwisdom/components/bundles/nats/core"
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const backoffSchema = `{
+	"type": "object",
+	"properties": {
+		"initial": {"type": "string", "default": "500ms"},
+		"max": {"type": "string", "default": "30s"},
+		"multiplier": {"type": "number", "default": 2},
+		"jitter": {"type": "number", "minimum": 0, "maximum": 1, "default": 0.2}
+	},
+	"additionalProperties": false
+}`
+
+const provisionSchema = `{
+	"type": "object",
+	"properties": {
+		"Mode": {"type": "string", "enum": ["create", "verify"], "default": "create", "description": "Whether to create the stream when it doesn't exist, or only verify it."},
+		"Subjects": {"type": "array", "items": {"type": "string"}, "description": "The subjects the stream captures."},
+		"Retention": {"type": "string", "enum": ["limits", "interest", "workqueue"]},
+		"Storage": {"type": "string", "enum": ["file", "memory"]},
+		"Replicas": {"type": "integer", "minimum": 1},
+		"MaxAge": {"type": "string", "description": "The maximum age of the messages in the stream.", "examples": ["24h"]},
+		"MaxBytes": {"type": "integer", "description": "The maximum size of the stream in bytes."},
+		"DuplicateWindow": {"type": "string", "description": "How long duplicate messages are tracked.", "examples": ["2m"]}
+	},
+	"additionalProperties": false
+}`
+
+// The stream configuration is decoded by the names of its fields.
+const streamInputSchema = `{
+	"type": "object",
+	"properties": {
+		"Stream": {"type": "string", "description": "The expression producing the name of the stream to consume from."},
+		"Subject": {"type": "string", "description": "The expression producing the subject filter of the consumer."},
+		"BatchSize": {"type": "integer", "minimum": 1, "description": "The number of messages to fetch in a single batch."},
+		"Consumer": {
+			"type": "object",
+			"properties": {
+				"Name": {"type": "string", "description": "The expression producing the name of the consumer. Without it, an ephemeral consumer is created."},
+				"Durable": {"type": "boolean", "default": false},
+				"AckPolicy": {"type": "string", "enum": ["none", "all", "explicit"]},
+				"AckWait": {"type": "string", "description": "Time to wait for an acknowledgment before redelivering a message.", "examples": ["30s"]},
+				"DeliverPolicy": {"type": "string", "enum": ["all", "last", "new"]},
+				"FilterSubject": {"type": "string", "description": "The expression producing an additional subject filter."},
+				"MaxDeliver": {"type": "integer", "description": "The maximum number of delivery attempts for a message."},
+				"InactiveThreshold": {"type": "string", "description": "Time after which the server removes the consumer when it has no reads pending.", "examples": ["5m"]},
+				"Ordered": {"type": "boolean", "default": false, "description": "Whether to read through an ordered consumer, which delivers the messages in stream order without acknowledgments."},
+				"FilterSubjects": {"type": "array", "items": {"type": "string"}, "description": "The subjects the consumer filters on, instead of the subject of the input."},
+				"BackOff": {"type": "array", "items": {"type": "string"}, "description": "The delays between redeliveries of a message which wasn't acknowledged.", "examples": [["1s", "10s", "1m"]]},
+				"MaxAckPending": {"type": "integer", "description": "The maximum number of delivered messages awaiting acknowledgment."},
+				"StartSequence": {"type": "integer", "minimum": 1, "description": "The stream sequence to start delivering from."},
+				"StartTime": {"type": "string", "description": "The time to start delivering messages from, in RFC 3339 format."},
+				"HeadersOnly": {"type": "boolean", "default": false, "description": "Whether to deliver only the headers of messages."},
+				"SampleFrequency": {"type": "string", "description": "The percentage of acknowledgments to sample.", "examples": ["10%"]}
+			},
+			"additionalProperties": false
+		},
+		"Prefetch": {"type": "integer", "minimum": 1, "description": "The number of messages to buffer ahead of reads. Defaults to twice the batch size."},
+		"Provision": ` + provisionSchema + `,
+		"Redelivery": ` + backoffSchema + `
+	},
+	"required": ["Stream"],
+	"additionalProperties": false
+}`
+
+const streamOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"Stream": {"type": "string", "description": "The expression producing the name of the stream to publish to."},
+		"Subject": {"type": "string", "description": "The expression producing the subject to publish each message to."},
+		"Compression": {"type": "string", "enum": ["none", "gzip", "zstd", "snappy", "lz4"], "default": "none"},
+		"MsgID": {"type": "string", "description": "The expression producing the ID JetStream deduplicates messages on, e.g. ${! metadata.mq_message_id }."},
+		"MaxPending": {"type": "integer", "description": "The maximum number of published messages awaiting their acknowledgment.", "default": 256},
+		"ExpectedLastSequence": {"type": "string", "description": "The expression producing the sequence the stream is expected to be at."},
+		"ExpectedLastSubjectSequence": {"type": "string", "description": "The expression producing the sequence the subject is expected to be at."},
+		"Provision": ` + provisionSchema + `
+	},
+	"required": ["Stream", "Subject"],
+	"additionalProperties": false
+}`
+
+const kvInputSchema = `{
+	"type": "object",
+	"properties": {
+		"bucket": {"type": "string", "description": "The name of the key-value bucket to watch."},
+		"key": {"type": "string", "default": ">", "description": "The key pattern to watch, which may contain wildcards.", "examples": ["orders.>"]},
+		"include_history": {"type": "boolean", "default": false, "description": "Replay all revisions the bucket holds instead of only the latest value of each key."},
+		"ignore_deletes": {"type": "boolean", "default": false, "description": "Skip delete and purge operations."},
+		"updates_only": {"type": "boolean", "default": false, "description": "Only emit changes made after the watch started."},
+		"batch_count": {"type": "integer", "minimum": 1, "default": 1, "description": "The maximum number of changes to read at a time."}
+	},
+	"required": ["bucket"],
+	"additionalProperties": false
+}`
+
+const kvOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"bucket": {"type": "string", "description": "The name of the key-value bucket to write to."},
+		"key": {"type": "string", "description": "The expression producing the key of each message.", "examples": ["${! metadata.kv_key }"]},
+		"operation": {"type": "string", "enum": ["put", "create", "update", "delete", "purge"], "default": "put"},
+		"revision": {"type": "string", "description": "The expression producing the revision the key is expected to be at. Required for update.", "examples": ["${! metadata.kv_revision }"]}
+	},
+	"required": ["bucket", "key"],
+	"additionalProperties": false
+}`
+
+const kvProcessorSchema = `{
+	"type": "object",
+	"properties": {
+		"bucket": {"type": "string", "description": "The name of the key-value bucket to read from."},
+		"key": {"type": "string", "description": "The expression producing the key to get for each message."},
+		"field": {"type": "string", "description": "The field to store the value under. Without it, the fields of the value are merged into the message."},
+		"ignore_missing": {"type": "boolean", "default": false, "description": "Leave messages unchanged when their key doesn't exist."}
+	},
+	"required": ["bucket", "key"],
+	"additionalProperties": false
+}`
+
+const objectStoreTriggerSchema = `{
+	"type": "object",
+	"properties": {
+		"bucket": {"type": "string", "description": "The name of the object store bucket to watch."},
+		"updates_only": {"type": "boolean", "default": false, "description": "Only trigger on objects put after the watch started."},
+		"max_batch_size": {"type": "integer", "minimum": 1, "default": 1, "description": "The maximum number of triggers to read at a time."}
+	},
+	"required": ["bucket"],
+	"additionalProperties": false
+}`
+
+const objectStoreRetrievalSchema = `{
+	"type": "object",
+	"properties": {
+		"bucket": {"type": "string", "description": "The name of the object store bucket to retrieve from. Without it, the bucket is taken from the trigger."}
+	},
+	"additionalProperties": false
+}`
+
+const objectStoreOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"bucket": {"type": "string", "description": "The name of the object store bucket to upload to."},
+		"name": {"type": "string", "description": "The expression producing the name of the object for each message.", "examples": ["${! metadata.name }"]}
+	},
+	"required": ["bucket", "name"],
+	"additionalProperties": false
+}`
+
+// Register adds the NATS core, JetStream stream, key-value and object store components to the registry.
+func Register(r *registry.Registry) error {
+	if err := core.Register(r); err != nil {
+		return err
+	}
+
+	newSystem := func(cfg spec.Config) (spec.System, error) {
+		return NewJetStreamSystemFromConfig(cfg)
+	}
+
+	err := r.RegisterInput(registry.Spec{
+		Name:         StreamInputComponentName,
+		Summary:      "Consumes messages from a NATS JetStream stream.",
+		Schema:       streamInputSchema,
+		SystemSchema: core.SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Input, error) {
+		return NewStreamInputFromConfig(sys, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.RegisterOutput(registry.Spec{
+		Name:         StreamOutputComponentName,
+		Summary:      "Publishes messages to a NATS JetStream stream.",
+		Schema:       streamOutputSchema,
+		SystemSchema: core.SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Output, error) {
+		return NewStreamOutputFromConfig(sys, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.RegisterInput(registry.Spec{
+		Name:         KVComponentName,
+		Summary:      "Watches the keys of a NATS JetStream key-value bucket.",
+		Schema:       kvInputSchema,
+		SystemSchema: core.SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Input, error) {
+		return NewKVInputFromConfig(sys, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.RegisterOutput(registry.Spec{
+		Name:         KVComponentName,
+		Summary:      "Writes messages to the keys of a NATS JetStream key-value bucket.",
+		Schema:       kvOutputSchema,
+		SystemSchema: core.SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Output, error) {
+		return NewKVOutputFromConfig(sys, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.RegisterProcessorWithSystem(registry.Spec{
+		Name:         KVComponentName,
+		Summary:      "Merges the value of a key in a NATS JetStream key-value bucket into messages.",
+		Schema:       kvProcessorSchema,
+		SystemSchema: core.SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Processor, error) {
+		return NewKVProcessorFromConfig(sys, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.RegisterTriggerInput(registry.Spec{
+		Name:         ObjectStoreComponentName,
+		Summary:      "Emits triggers for the objects put into a NATS JetStream object store bucket.",
+		Schema:       objectStoreTriggerSchema,
+		SystemSchema: core.SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.TriggerInput, error) {
+		return NewObjectStoreTriggerInputFromConfig(sys, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.RegisterRetrieval(registry.Spec{
+		Name:         ObjectStoreComponentName,
+		Summary:      "Streams the objects referenced by triggers from a NATS JetStream object store.",
+		Schema:       objectStoreRetrievalSchema,
+		SystemSchema: core.SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.RetrievalProcessor, error) {
+		return NewObjectStoreRetrievalProcessorFromConfig(sys, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterOutput(registry.Spec{
+		Name:         ObjectStoreComponentName,
+		Summary:      "Uploads messages as objects to a NATS JetStream object store bucket.",
+		Schema:       objectStoreOutputSchema,
+		SystemSchema: core.SystemConfigSchema,
+	}, newSystem, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Output, error) {
+		return NewObjectStoreOutputFromConfig(sys, cfg)
+	})
+}
@@ -1,6 +1,9 @@
 package test
 
 import (
+	"os"
+	"path"
+
 	"github.com/nats-io/jwt/v2"
 	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
@@ -47,6 +50,18 @@ func (a *Acc) Creds() (string, []byte) {
 	return userJwt, userSeed
 }
 
+// CredsFile writes the credentials of a new user of the account to a file in the given directory.
+func (a *Acc) CredsFile(dir string) string {
+	userJwt, userSeed := a.Creds()
+
+	creds, err := jwt.FormatUserConfig(userJwt, userSeed)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+	file := path.Join(dir, "user.creds")
+	gomega.Expect(os.WriteFile(file, creds, os.FileMode(0600))).To(gomega.Succeed())
+	return file
+}
+
 func (a *Acc) Connect(srv *server.Server) *nats.Conn {
 	userJwt, userSeed := a.Creds()
 
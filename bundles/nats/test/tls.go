@@ -0,0 +1,88 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+// Certificates holds the files of a certificate authority, a server certificate for localhost and a client
+// certificate signed by it.
+type Certificates struct {
+	CaFile         string
+	ServerCertFile string
+	ServerKeyFile  string
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// NewCertificates generates the certificates into the given directory.
+func NewCertificates(dir string) Certificates {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+	certs := Certificates{
+		CaFile:         path.Join(dir, "ca.pem"),
+		ServerCertFile: path.Join(dir, "server-cert.pem"),
+		ServerKeyFile:  path.Join(dir, "server-key.pem"),
+		ClientCertFile: path.Join(dir, "client-cert.pem"),
+		ClientKeyFile:  path.Join(dir, "client-key.pem"),
+	}
+	writePem(certs.CaFile, "CERTIFICATE", caDer)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage, certFile, keyFile string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+		cert := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			DNSNames:     []string{"localhost"},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(24 * time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, cert, ca, &key.PublicKey, caKey)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+		keyDer, err := x509.MarshalECPrivateKey(key)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+		writePem(certFile, "CERTIFICATE", der)
+		writePem(keyFile, "EC PRIVATE KEY", keyDer)
+	}
+
+	issue(2, x509.ExtKeyUsageServerAuth, certs.ServerCertFile, certs.ServerKeyFile)
+	issue(3, x509.ExtKeyUsageClientAuth, certs.ClientCertFile, certs.ClientKeyFile)
+
+	return certs
+}
+
+func writePem(file string, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	gomega.Expect(os.WriteFile(file, data, os.FileMode(0600))).To(gomega.Succeed())
+}
@@ -0,0 +1,262 @@
+package schema
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// magicByte is the first byte of a payload in the Confluent wire format.
+const magicByte = 0
+
+var ErrInvalidWireFormat = errors.New("invalid wire format")
+
+// Codec converts payloads between their binary encoding and JSON.
+type Codec interface {
+	// Decode converts a binary payload into JSON.
+	Decode(data []byte) ([]byte, error)
+
+	// Encode converts a JSON payload into its binary encoding.
+	Encode(data []byte) ([]byte, error)
+}
+
+// NewCodec creates a codec for the given schema. The message name selects the Protobuf message type within the
+// schema and defaults to the first message defined in it. It is ignored for Avro schemas.
+func NewCodec(s *Schema, message string) (Codec, error) {
+	switch s.Format {
+	case Avro:
+		return newAvroCodec(s)
+	case Protobuf:
+		return newProtobufCodec(s, message)
+	default:
+		return nil, fmt.Errorf("unsupported schema format %q", s.Format)
+	}
+}
+
+type avroCodec struct {
+	codec *goavro.Codec
+}
+
+func newAvroCodec(s *Schema) (*avroCodec, error) {
+	codec, err := goavro.NewCodec(s.Definition)
+	if err != nil {
+		return nil, fmt.Errorf("avro schema %s: %w", s.Subject, err)
+	}
+	return &avroCodec{codec: codec}, nil
+}
+
+func (c *avroCodec) Decode(data []byte) ([]byte, error) {
+	native, _, err := c.codec.NativeFromBinary(data)
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+
+	b, err := c.codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+	return b, nil
+}
+
+func (c *avroCodec) Encode(data []byte) ([]byte, error) {
+	native, _, err := c.codec.NativeFromTextual(data)
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+
+	b, err := c.codec.BinaryFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+	return b, nil
+}
+
+type protobufCodec struct {
+	file    protoreflect.FileDescriptor
+	message protoreflect.MessageDescriptor
+}
+
+func newProtobufCodec(s *Schema, message string) (*protobufCodec, error) {
+	name := s.Subject
+	if path.Ext(name) != ".proto" {
+		name += ".proto"
+	}
+
+	sources := map[string]string{}
+	for k, v := range s.References {
+		sources[k] = v
+	}
+	sources[name] = s.Definition
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+	}
+
+	files, err := compiler.Compile(context.Background(), name)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf schema %s: %w", s.Subject, err)
+	}
+	fd := files[0]
+
+	var md protoreflect.MessageDescriptor
+	if message != "" {
+		d := fd.Messages().ByName(protoreflect.Name(message))
+		if d == nil {
+			d, _ = fd.FindDescriptorByName(protoreflect.FullName(message)).(protoreflect.MessageDescriptor)
+		}
+		if d == nil {
+			return nil, fmt.Errorf("protobuf schema %s: message %s not found", s.Subject, message)
+		}
+		md = d
+	} else if fd.Messages().Len() > 0 {
+		md = fd.Messages().Get(0)
+	} else {
+		return nil, fmt.Errorf("protobuf schema %s: no messages defined", s.Subject)
+	}
+
+	return &protobufCodec{file: fd, message: md}, nil
+}
+
+// withMessage returns a codec for another message type defined in the same schema.
+func (c *protobufCodec) withMessage(md protoreflect.MessageDescriptor) *protobufCodec {
+	return &protobufCodec{file: c.file, message: md}
+}
+
+func (c *protobufCodec) Decode(data []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(c.message)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("protobuf: %w", err)
+	}
+
+	b, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf: %w", err)
+	}
+	return b, nil
+}
+
+func (c *protobufCodec) Encode(data []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(c.message)
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("protobuf: %w", err)
+	}
+
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf: %w", err)
+	}
+	return b, nil
+}
+
+// messageIndexes returns the path of indexes leading to the message within its file, as used by the Confluent wire
+// format for Protobuf payloads.
+func (c *protobufCodec) messageIndexes() []int {
+	var indexes []int
+	var d protoreflect.Descriptor = c.message
+	for {
+		md, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			break
+		}
+		indexes = append([]int{md.Index()}, indexes...)
+		d = md.Parent()
+	}
+	return indexes
+}
+
+// WireHeader is the header of a payload in the Confluent wire format.
+type WireHeader struct {
+	SchemaID int
+
+	// The indexes of the message type within the schema. Only present in Protobuf payloads.
+	MessageIndexes []int
+}
+
+// ReadWireHeader reads the Confluent wire format header from the given payload. The message indexes are only read
+// if the format is Protobuf. The remaining payload is returned along with the header.
+func ReadWireHeader(format Format, data []byte) (WireHeader, []byte, error) {
+	var h WireHeader
+	if len(data) < 5 || data[0] != magicByte {
+		return h, nil, ErrInvalidWireFormat
+	}
+
+	h.SchemaID = int(binary.BigEndian.Uint32(data[1:5]))
+	data = data[5:]
+
+	if format != Protobuf {
+		return h, data, nil
+	}
+
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 {
+		return h, nil, ErrInvalidWireFormat
+	}
+	data = data[n:]
+
+	if count == 0 {
+		// -- a count of zero is a shorthand for the first message in the schema
+		h.MessageIndexes = []int{0}
+		return h, data, nil
+	}
+
+	for i := int64(0); i < count; i++ {
+		idx, n := binary.Varint(data)
+		if n <= 0 || idx < 0 {
+			return h, nil, ErrInvalidWireFormat
+		}
+		h.MessageIndexes = append(h.MessageIndexes, int(idx))
+		data = data[n:]
+	}
+
+	return h, data, nil
+}
+
+// AppendWireHeader appends the Confluent wire format header to the given buffer. The message indexes are only
+// written if the format is Protobuf.
+func AppendWireHeader(buf []byte, format Format, h WireHeader) []byte {
+	buf = append(buf, magicByte)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(h.SchemaID))
+
+	if format != Protobuf {
+		return buf
+	}
+
+	if len(h.MessageIndexes) == 0 || (len(h.MessageIndexes) == 1 && h.MessageIndexes[0] == 0) {
+		return append(buf, 0)
+	}
+
+	buf = binary.AppendVarint(buf, int64(len(h.MessageIndexes)))
+	for _, idx := range h.MessageIndexes {
+		buf = binary.AppendVarint(buf, int64(idx))
+	}
+	return buf
+}
+
+// messageByIndexes resolves the message at the given index path within the file.
+func messageByIndexes(fd protoreflect.FileDescriptor, indexes []int) (protoreflect.MessageDescriptor, error) {
+	var md protoreflect.MessageDescriptor
+	messages := fd.Messages()
+	for _, idx := range indexes {
+		if idx >= messages.Len() {
+			return nil, fmt.Errorf("message index %v not found in %s", indexes, fd.Path())
+		}
+		md = messages.Get(idx)
+		messages = md.Messages()
+	}
+
+	if md == nil {
+		return nil, fmt.Errorf("no message indexes given")
+	}
+	return md, nil
+}
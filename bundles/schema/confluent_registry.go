@@ -0,0 +1,172 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// NewConfluentRegistry creates a registry client for a Confluent compatible schema registry at the given url. The
+// username and password are used for basic authentication when the username is set. Schemas looked up by id report
+// the given subject and their version within it, if they are registered under it.
+func NewConfluentRegistry(baseURL string, username string, password string, subject string) *ConfluentRegistry {
+	return &ConfluentRegistry{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		username: username,
+		password: password,
+		subject:  subject,
+		client:   http.DefaultClient,
+		byID:     map[int]*Schema{},
+	}
+}
+
+// ConfluentRegistry is a SchemaRegistry backed by the REST API of a Confluent compatible schema registry.
+//
+// Schemas looked up by id are cached since they are immutable. The latest schema of a subject is fetched on every
+// call, so callers are expected to cache it themselves if needed.
+type ConfluentRegistry struct {
+	baseURL  string
+	username string
+	password string
+	subject  string
+	client   *http.Client
+
+	byID     map[int]*Schema
+	byIDLock sync.RWMutex
+}
+
+// schemaResponse is the response of the schema registry for schema lookups.
+type schemaResponse struct {
+	Subject    string `json:"subject"`
+	Version    int    `json:"version"`
+	ID         int    `json:"id"`
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType"`
+	References []struct {
+		Name    string `json:"name"`
+		Subject string `json:"subject"`
+		Version int    `json:"version"`
+	} `json:"references"`
+}
+
+// subjectVersion is an entry of the response listing the subjects and versions a schema id is registered under.
+type subjectVersion struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+func (r *ConfluentRegistry) SchemaByID(ctx context.Context, id int) (*Schema, error) {
+	r.byIDLock.RLock()
+	s, ok := r.byID[id]
+	r.byIDLock.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	s, err := r.fetch(ctx, fmt.Sprintf("/schemas/ids/%d", id))
+	if err != nil {
+		return nil, fmt.Errorf("schema id %d: %w", id, err)
+	}
+	s.ID = id
+
+	// -- the schema itself doesn't tell which subject it belongs to, and the same schema can be registered under
+	// several subjects. Only the configured subject is reported, any other would be a guess.
+	if r.subject != "" {
+		var versions []subjectVersion
+		if err := r.get(ctx, fmt.Sprintf("/schemas/ids/%d/versions", id), &versions); err != nil {
+			return nil, fmt.Errorf("schema id %d versions: %w", id, err)
+		}
+		for _, v := range versions {
+			if v.Subject == r.subject {
+				s.Subject = v.Subject
+				s.Version = v.Version
+				break
+			}
+		}
+	}
+
+	r.byIDLock.Lock()
+	r.byID[id] = s
+	r.byIDLock.Unlock()
+
+	return s, nil
+}
+
+func (r *ConfluentRegistry) LatestSchema(ctx context.Context, subject string) (*Schema, error) {
+	s, err := r.fetch(ctx, fmt.Sprintf("/subjects/%s/versions/latest", url.PathEscape(subject)))
+	if err != nil {
+		return nil, fmt.Errorf("subject %s: %w", subject, err)
+	}
+	return s, nil
+}
+
+// get decodes the response of the schema registry for the given path into v.
+func (r *ConfluentRegistry) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrSchemaNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("schema registry returned status %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode schema registry response: %w", err)
+	}
+	return nil
+}
+
+func (r *ConfluentRegistry) fetch(ctx context.Context, path string) (*Schema, error) {
+	var sr schemaResponse
+	if err := r.get(ctx, path, &sr); err != nil {
+		return nil, err
+	}
+
+	format, err := ParseFormat(sr.SchemaType)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Schema{
+		ID:         sr.ID,
+		Subject:    sr.Subject,
+		Version:    sr.Version,
+		Format:     format,
+		Definition: sr.Schema,
+	}
+
+	if len(sr.References) > 0 {
+		s.References = map[string]string{}
+		for _, ref := range sr.References {
+			rs, err := r.fetch(ctx, fmt.Sprintf("/subjects/%s/versions/%d", url.PathEscape(ref.Subject), ref.Version))
+			if err != nil {
+				return nil, fmt.Errorf("reference %s: %w", ref.Name, err)
+			}
+
+			s.References[ref.Name] = rs.Definition
+			for name, def := range rs.References {
+				s.References[name] = def
+			}
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// NewFileRegistry creates a registry from the schema files in the given directory.
+//
+// Files with an .avsc extension are read as Avro schemas and files with a .proto extension as Protobuf schemas. The
+// subject of a schema is its file name without extension. An id can be assigned by appending it to the subject
+// separated by an @, e.g. orders@12.avsc registers the orders subject with id 12. Protobuf schemas can import the
+// other .proto files in the directory by their path relative to it.
+func NewFileRegistry(dir string) (*FileRegistry, error) {
+	r := &FileRegistry{
+		byID:      map[int]*Schema{},
+		bySubject: map[string]*Schema{},
+	}
+
+	protos := map[string]string{}
+	var schemas []*Schema
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		var format Format
+		switch filepath.Ext(path) {
+		case ".avsc":
+			format = Avro
+		case ".proto":
+			format = Protobuf
+		default:
+			return nil
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		s := &Schema{
+			Subject:    strings.TrimSuffix(rel, filepath.Ext(rel)),
+			Version:    1,
+			Format:     format,
+			Definition: string(b),
+		}
+
+		if subject, id, found := strings.Cut(s.Subject, "@"); found {
+			if s.ID, err = strconv.Atoi(id); err != nil {
+				return fmt.Errorf("%s: invalid schema id %q", rel, id)
+			}
+			s.Subject = subject
+		}
+
+		if format == Protobuf {
+			protos[rel] = s.Definition
+		}
+
+		schemas = append(schemas, s)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("schema directory %s: %w", dir, err)
+	}
+
+	for _, s := range schemas {
+		if s.Format == Protobuf {
+			s.References = protos
+		}
+
+		if _, exists := r.bySubject[s.Subject]; exists {
+			return nil, fmt.Errorf("schema directory %s: duplicate subject %s", dir, s.Subject)
+		}
+		r.bySubject[s.Subject] = s
+
+		if s.ID != 0 {
+			if _, exists := r.byID[s.ID]; exists {
+				return nil, fmt.Errorf("schema directory %s: duplicate schema id %d", dir, s.ID)
+			}
+			r.byID[s.ID] = s
+		}
+	}
+
+	return r, nil
+}
+
+// FileRegistry is a SchemaRegistry backed by schema files in a local directory. The files are read once when the
+// registry is created.
+type FileRegistry struct {
+	byID      map[int]*Schema
+	bySubject map[string]*Schema
+}
+
+func (r *FileRegistry) SchemaByID(ctx context.Context, id int) (*Schema, error) {
+	s, ok := r.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("schema id %d: %w", id, ErrSchemaNotFound)
+	}
+	return s, nil
+}
+
+func (r *FileRegistry) LatestSchema(ctx context.Context, subject string) (*Schema, error) {
+	s, ok := r.bySubject[subject]
+	if !ok {
+		return nil, fmt.Errorf("subject %s: %w", subject, ErrSchemaNotFound)
+	}
+	return s, nil
+}
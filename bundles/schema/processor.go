@@ -0,0 +1,264 @@
+package schema
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	ProcessorComponentName = "schema"
+)
+
+// Operation defines whether the processor decodes or encodes payloads.
+type Operation string
+
+const (
+	// OperationDecode converts binary Avro or Protobuf payloads into JSON.
+	OperationDecode Operation = "decode"
+
+	// OperationEncode converts JSON payloads into binary Avro or Protobuf.
+	OperationEncode Operation = "encode"
+)
+
+type ProcessorConfig struct {
+	// Whether to decode binary payloads into JSON or encode JSON payloads into binary. Defaults to decode.
+	Operation Operation `json:"operation,omitempty" yaml:"operation,omitempty" mapstructure:"operation,omitempty"`
+
+	// The subject of the schema to use. Required when encoding, or when decoding payloads that are not in the
+	// Confluent wire format.
+	Subject string `json:"subject,omitempty" yaml:"subject,omitempty" mapstructure:"subject,omitempty"`
+
+	// The full name of the Protobuf message type. Defaults to the first message in the schema, or the message
+	// referenced by the wire format header when decoding.
+	Message string `json:"message,omitempty" yaml:"message,omitempty" mapstructure:"message,omitempty"`
+
+	// Whether payloads are prefixed with the Confluent wire format header holding the schema id.
+	WireFormat bool `json:"wire_format,omitempty" yaml:"wire_format,omitempty" mapstructure:"wire_format,omitempty"`
+
+	// The schema registry to look schemas up in.
+	Registry RegistryConfig `json:"registry" yaml:"registry" mapstructure:"registry"`
+}
+
+// NewProcessor creates a new schema processor using the given registry
+func NewProcessor(config ProcessorConfig, registry SchemaRegistry) (*Processor, error) {
+	if config.Operation == "" {
+		config.Operation = OperationDecode
+	}
+
+	switch config.Operation {
+	case OperationDecode:
+		if config.Subject == "" && !config.WireFormat {
+			return nil, fmt.Errorf("subject must be specified when not using the wire format")
+		}
+	case OperationEncode:
+		if config.Subject == "" {
+			return nil, fmt.Errorf("subject must be specified when encoding")
+		}
+	default:
+		return nil, fmt.Errorf("invalid operation %q (must be decode or encode)", config.Operation)
+	}
+
+	return &Processor{
+		config:   config,
+		registry: registry,
+		codecs:   map[string]Codec{},
+	}, nil
+}
+
+// NewProcessorFromConfig creates a schema processor from a spec.Config interface
+func NewProcessorFromConfig(config spec.Config) (*Processor, error) {
+	var cfg ProcessorConfig
+	if err := config.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode schema processor config: %w", err)
+	}
+
+	cfg.Registry.Subject = cfg.Subject
+	registry, err := NewRegistry(cfg.Registry)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+
+	return NewProcessor(cfg, registry)
+}
+
+// Processor converts Avro or Protobuf payloads to and from JSON.
+//
+// The id and subject of the schema used are added to the message metadata as schema_id and schema_name, so outputs
+// can pass them on as headers or message properties. A schema looked up by the id in the wire format header only has
+// a subject if it is registered under the configured one. The latest schema of the configured subject is looked up once
+// and used for the lifetime of the processor.
+type Processor struct {
+	config   ProcessorConfig
+	registry SchemaRegistry
+
+	latest *Schema
+	codecs map[string]Codec
+	lock   sync.Mutex
+}
+
+func (p *Processor) Init(ctx spec.ComponentContext) error {
+	return nil
+}
+
+func (p *Processor) Close(ctx spec.ComponentContext) error {
+	return nil
+}
+
+func (p *Processor) Process(ctx spec.ComponentContext, batch spec.Batch) (spec.Batch, spec.ProcessedCallback, error) {
+	for idx, msg := range batch.Messages() {
+		var err error
+		if p.config.Operation == OperationEncode {
+			err = p.encode(ctx.Context(), msg)
+		} else {
+			err = p.decode(ctx.Context(), msg)
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("batch #%d: %w", idx, err)
+		}
+	}
+
+	return batch, spec.NoopCallback, nil
+}
+
+func (p *Processor) decode(ctx context.Context, msg spec.Message) error {
+	raw, err := msg.Raw()
+	if err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+
+	var s *Schema
+	var indexes []int
+	payload := raw
+
+	if p.config.WireFormat {
+		if len(raw) < 5 || raw[0] != magicByte {
+			return ErrInvalidWireFormat
+		}
+
+		if s, err = p.registry.SchemaByID(ctx, int(binary.BigEndian.Uint32(raw[1:5]))); err != nil {
+			return err
+		}
+
+		var h WireHeader
+		if h, payload, err = ReadWireHeader(s.Format, raw); err != nil {
+			return err
+		}
+
+		if p.config.Message == "" {
+			indexes = h.MessageIndexes
+		}
+	} else if s, err = p.latestSchema(ctx); err != nil {
+		return err
+	}
+
+	codec, err := p.codec(s, indexes)
+	if err != nil {
+		return err
+	}
+
+	data, err := codec.Decode(payload)
+	if err != nil {
+		return err
+	}
+
+	msg.SetRaw(data)
+	setSchemaMetadata(msg, s)
+	return nil
+}
+
+func (p *Processor) encode(ctx context.Context, msg spec.Message) error {
+	raw, err := msg.Raw()
+	if err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+
+	s, err := p.latestSchema(ctx)
+	if err != nil {
+		return err
+	}
+
+	codec, err := p.codec(s, nil)
+	if err != nil {
+		return err
+	}
+
+	data, err := codec.Encode(raw)
+	if err != nil {
+		return err
+	}
+
+	if p.config.WireFormat {
+		if s.ID == 0 {
+			return fmt.Errorf("schema %s has no id to write in the wire format header", s.Subject)
+		}
+
+		h := WireHeader{SchemaID: s.ID}
+		if pc, ok := codec.(*protobufCodec); ok {
+			h.MessageIndexes = pc.messageIndexes()
+		}
+		data = append(AppendWireHeader(nil, s.Format, h), data...)
+	}
+
+	msg.SetRaw(data)
+	setSchemaMetadata(msg, s)
+	return nil
+}
+
+func (p *Processor) latestSchema(ctx context.Context) (*Schema, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.latest == nil {
+		s, err := p.registry.LatestSchema(ctx, p.config.Subject)
+		if err != nil {
+			return nil, err
+		}
+		p.latest = s
+	}
+
+	return p.latest, nil
+}
+
+// codec returns the codec for the schema, creating it if needed. The indexes select the Protobuf message type if
+// the processor isn't configured with one.
+func (p *Processor) codec(s *Schema, indexes []int) (Codec, error) {
+	key := fmt.Sprintf("%d/%s/%d/%v", s.ID, s.Subject, s.Version, indexes)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if c, ok := p.codecs[key]; ok {
+		return c, nil
+	}
+
+	c, err := NewCodec(s, p.config.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	if pc, ok := c.(*protobufCodec); ok && len(indexes) > 0 {
+		md, err := messageByIndexes(pc.file, indexes)
+		if err != nil {
+			return nil, err
+		}
+		c = pc.withMessage(md)
+	}
+
+	p.codecs[key] = c
+	return c, nil
+}
+
+func setSchemaMetadata(msg spec.Message, s *Schema) {
+	if s.ID != 0 {
+		msg.SetMetadata(MetadataSchemaID, strconv.Itoa(s.ID))
+	}
+	if s.Subject != "" {
+		msg.SetMetadata(MetadataSchemaName, s.Subject)
+	}
+}
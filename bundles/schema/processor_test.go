@@ -0,0 +1,179 @@
+package schema_test
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/schema"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("Processor", func() {
+	var ctx spec.ComponentContext
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+	})
+
+	process := func(proc *schema.Processor, data []byte) spec.Message {
+		batch, _, err := proc.Process(ctx, ctx.NewBatch(spec.NewBytesMessage(data)))
+		Expect(err).ToNot(HaveOccurred())
+
+		msgs := maps.Collect(batch.Messages())
+		Expect(msgs).To(HaveLen(1))
+		return msgs[0]
+	}
+
+	raw := func(msg spec.Message) []byte {
+		b, err := msg.Raw()
+		Expect(err).ToNot(HaveOccurred())
+		return b
+	}
+
+	When("using avro schemas from a directory", func() {
+		var reg schema.SchemaRegistry
+
+		BeforeEach(func() {
+			dir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "orders@7.avsc"), []byte(orderAvsc), 0o644)).To(Succeed())
+
+			var err error
+			reg, err = schema.NewRegistry(schema.RegistryConfig{Directory: dir})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should round trip json through binary avro", func() {
+			encoder, err := schema.NewProcessor(schema.ProcessorConfig{Operation: schema.OperationEncode, Subject: "orders"}, reg)
+			Expect(err).ToNot(HaveOccurred())
+			decoder, err := schema.NewProcessor(schema.ProcessorConfig{Subject: "orders"}, reg)
+			Expect(err).ToNot(HaveOccurred())
+
+			encoded := process(encoder, []byte(`{"id": "o-1", "amount": 1250}`))
+			Expect(maps.Collect(encoded.Metadata())).To(And(
+				HaveKeyWithValue(schema.MetadataSchemaID, "7"),
+				HaveKeyWithValue(schema.MetadataSchemaName, "orders"),
+			))
+
+			// -- avro strings are length prefixed with a zig-zag varint and longs are zig-zag varints
+			Expect(raw(encoded)).To(Equal([]byte{0x06, 'o', '-', '1', 0xc4, 0x13}))
+
+			decoded := process(decoder, raw(encoded))
+			Expect(raw(decoded)).To(MatchJSON(`{"id": "o-1", "amount": 1250}`))
+		})
+
+		It("should write and read the wire format header", func() {
+			encoder, err := schema.NewProcessor(schema.ProcessorConfig{Operation: schema.OperationEncode, Subject: "orders", WireFormat: true}, reg)
+			Expect(err).ToNot(HaveOccurred())
+			decoder, err := schema.NewProcessor(schema.ProcessorConfig{WireFormat: true}, reg)
+			Expect(err).ToNot(HaveOccurred())
+
+			encoded := raw(process(encoder, []byte(`{"id": "o-1", "amount": 1}`)))
+			Expect(encoded[:5]).To(Equal([]byte{0, 0, 0, 0, 7}))
+
+			decoded := process(decoder, encoded)
+			Expect(raw(decoded)).To(MatchJSON(`{"id": "o-1", "amount": 1}`))
+			Expect(maps.Collect(decoded.Metadata())).To(HaveKeyWithValue(schema.MetadataSchemaID, "7"))
+		})
+
+		It("should fail on payloads without a wire format header", func() {
+			decoder, err := schema.NewProcessor(schema.ProcessorConfig{WireFormat: true}, reg)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = decoder.Process(ctx, ctx.NewBatch(spec.NewBytesMessage([]byte{0x06, 'o'})))
+			Expect(err).To(MatchError(schema.ErrInvalidWireFormat))
+		})
+	})
+
+	When("using protobuf schemas from a schema registry", func() {
+		var reg schema.SchemaRegistry
+
+		BeforeEach(func() {
+			srv := newFakeRegistry(
+				map[string]any{"subject": "order-proto", "version": 1, "id": 43, "schema": orderProto, "schemaType": "PROTOBUF",
+					"references": []map[string]any{{"name": "common.proto", "subject": "common", "version": 1}}},
+				map[string]any{"subject": "common", "version": 1, "id": 44, "schema": commonProto, "schemaType": "PROTOBUF"},
+			)
+
+			var err error
+			reg, err = schema.NewRegistry(schema.RegistryConfig{URL: srv.URL, Subject: "order-proto"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should round trip json through binary protobuf", func() {
+			encoder, err := schema.NewProcessor(schema.ProcessorConfig{Operation: schema.OperationEncode, Subject: "order-proto", WireFormat: true}, reg)
+			Expect(err).ToNot(HaveOccurred())
+			decoder, err := schema.NewProcessor(schema.ProcessorConfig{WireFormat: true}, reg)
+			Expect(err).ToNot(HaveOccurred())
+
+			encoded := process(encoder, []byte(`{"id": "o-1", "amount": "1250", "total": {"currency": "EUR"}}`))
+			Expect(raw(encoded)[:6]).To(Equal([]byte{0, 0, 0, 0, 43, 0}))
+
+			decoded := process(decoder, raw(encoded))
+			Expect(raw(decoded)).To(MatchJSON(`{"id": "o-1", "amount": "1250", "total": {"currency": "EUR"}}`))
+			Expect(maps.Collect(decoded.Metadata())).To(And(
+				HaveKeyWithValue(schema.MetadataSchemaID, "43"),
+				HaveKeyWithValue(schema.MetadataSchemaName, "order-proto"),
+			))
+		})
+
+		It("should select nested messages using the message indexes", func() {
+			encoder, err := schema.NewProcessor(schema.ProcessorConfig{
+				Operation: schema.OperationEncode, Subject: "order-proto", Message: "shop.Order.Line", WireFormat: true,
+			}, reg)
+			Expect(err).ToNot(HaveOccurred())
+			decoder, err := schema.NewProcessor(schema.ProcessorConfig{WireFormat: true}, reg)
+			Expect(err).ToNot(HaveOccurred())
+
+			encoded := process(encoder, []byte(`{"sku": "abc"}`))
+
+			// -- the index path [0, 0] is written as a count followed by the zig-zag encoded indexes
+			Expect(raw(encoded)[5:8]).To(Equal([]byte{4, 0, 0}))
+
+			decoded := process(decoder, raw(encoded))
+			Expect(raw(decoded)).To(MatchJSON(`{"sku": "abc"}`))
+		})
+	})
+
+	It("should create the processor and registry from configuration", func() {
+		dir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "orders.avsc"), []byte(orderAvsc), 0o644)).To(Succeed())
+
+		proc, err := schema.NewProcessorFromConfig(spec.NewYamlConfig(`
+operation: encode
+subject: orders
+registry:
+  directory: ##dir##
+`, "##dir##", dir))
+		Expect(err).ToNot(HaveOccurred())
+
+		encoded := process(proc, []byte(`{"id": "o-1", "amount": 1}`))
+		Expect(maps.Collect(encoded.Metadata())).ToNot(HaveKey(schema.MetadataSchemaID))
+		Expect(maps.Collect(encoded.Metadata())).To(HaveKeyWithValue(schema.MetadataSchemaName, "orders"))
+	})
+
+	It("should validate the configuration", func() {
+		_, err := schema.NewProcessor(schema.ProcessorConfig{Operation: schema.OperationEncode}, nil)
+		Expect(err).To(MatchError(ContainSubstring("subject must be specified")))
+
+		_, err = schema.NewProcessor(schema.ProcessorConfig{Operation: "convert", Subject: "orders"}, nil)
+		Expect(err).To(MatchError(ContainSubstring("invalid operation")))
+
+		_, err = schema.NewRegistry(schema.RegistryConfig{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should surface registry lookup failures", func() {
+		reg, err := schema.NewFileRegistry(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+
+		decoder, err := schema.NewProcessor(schema.ProcessorConfig{Subject: "missing"}, reg)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, _, err = decoder.Process(ctx, ctx.NewBatch(spec.NewBytesMessage([]byte{0})))
+		Expect(err).To(MatchError(schema.ErrSchemaNotFound))
+	})
+})
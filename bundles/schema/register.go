@@ -0,0 +1,39 @@
+package schema
+
+import (
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const processorSchema = `{
+	"type": "object",
+	"properties": {
+		"operation": {"type": "string", "enum": ["decode", "encode"], "default": "decode"},
+		"subject": {"type": "string", "description": "The subject of the schema. Required when encoding, or decoding without the wire format."},
+		"message": {"type": "string", "description": "The full name of the Protobuf message type."},
+		"wire_format": {"type": "boolean", "default": false, "description": "Whether payloads are prefixed with the Confluent wire format header."},
+		"registry": {
+			"type": "object",
+			"properties": {
+				"directory": {"type": "string", "description": "A local directory containing .avsc and .proto schema files."},
+				"url": {"type": "string", "description": "The url of a Confluent compatible schema registry."},
+				"username": {"type": "string"},
+				"password": {"type": "string"}
+			},
+			"additionalProperties": false
+		}
+	},
+	"required": ["registry"],
+	"additionalProperties": false
+}`
+
+// Register adds the schema processor to the registry.
+func Register(r *registry.Registry) error {
+	return r.RegisterProcessor(registry.Spec{
+		Name:    ProcessorComponentName,
+		Summary: "Converts between JSON and Avro or Protobuf payloads using a schema registry.",
+		Schema:  processorSchema,
+	}, func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Processor, error) {
+		return NewProcessorFromConfig(cfg)
+	})
+}
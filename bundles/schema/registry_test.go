@@ -0,0 +1,209 @@
+package schema_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/bundles/schema"
+)
+
+// fakeRegistry serves schemas over the subset of the Confluent schema registry API used by the client.
+type fakeRegistry struct {
+	*httptest.Server
+	requests atomic.Int32
+}
+
+func newFakeRegistry(schemas ...map[string]any) *fakeRegistry {
+	f := &fakeRegistry{}
+	mux := http.NewServeMux()
+
+	write := func(w http.ResponseWriter, r *http.Request, match func(map[string]any) bool, fields ...string) {
+		f.requests.Add(1)
+		if user, pass, _ := r.BasicAuth(); user != "" && (user != "user" || pass != "secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		for _, s := range schemas {
+			if match(s) {
+				if len(fields) > 0 {
+					subset := map[string]any{}
+					for _, field := range fields {
+						if v, ok := s[field]; ok {
+							subset[field] = v
+						}
+					}
+					s = subset
+				}
+				w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+				_ = json.NewEncoder(w).Encode(s)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+
+	// -- like the real registry, schemas looked up by id don't carry their subject and version
+	mux.HandleFunc("GET /schemas/ids/{id}", func(w http.ResponseWriter, r *http.Request) {
+		write(w, r, func(s map[string]any) bool { return jsonString(s["id"]) == r.PathValue("id") }, "schema", "schemaType", "references")
+	})
+	mux.HandleFunc("GET /schemas/ids/{id}/versions", func(w http.ResponseWriter, r *http.Request) {
+		f.requests.Add(1)
+
+		var versions []map[string]any
+		for _, s := range schemas {
+			if jsonString(s["id"]) == r.PathValue("id") {
+				versions = append(versions, map[string]any{"subject": s["subject"], "version": s["version"]})
+			}
+		}
+		if len(versions) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(versions)
+	})
+	mux.HandleFunc("GET /subjects/{subject}/versions/{version}", func(w http.ResponseWriter, r *http.Request) {
+		write(w, r, func(s map[string]any) bool {
+			return s["subject"] == r.PathValue("subject") &&
+				(r.PathValue("version") == "latest" || jsonString(s["version"]) == r.PathValue("version"))
+		})
+	})
+
+	f.Server = httptest.NewServer(mux)
+	DeferCleanup(f.Close)
+	return f
+}
+
+func jsonString(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+var _ = Describe("FileRegistry", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "orders@7.avsc"), []byte(orderAvsc), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "order.proto"), []byte(orderProto), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "common.proto"), []byte(commonProto), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a schema"), 0o644)).To(Succeed())
+	})
+
+	It("should look schemas up by subject and id", func() {
+		reg, err := schema.NewFileRegistry(dir)
+		Expect(err).ToNot(HaveOccurred())
+
+		s, err := reg.LatestSchema(context.Background(), "orders")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.ID).To(Equal(7))
+		Expect(s.Format).To(Equal(schema.Avro))
+
+		byID, err := reg.SchemaByID(context.Background(), 7)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(byID).To(BeIdenticalTo(s))
+
+		p, err := reg.LatestSchema(context.Background(), "order")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.Format).To(Equal(schema.Protobuf))
+		Expect(p.References).To(HaveKey("common.proto"))
+	})
+
+	It("should return ErrSchemaNotFound for unknown schemas", func() {
+		reg, err := schema.NewFileRegistry(dir)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = reg.LatestSchema(context.Background(), "unknown")
+		Expect(err).To(MatchError(schema.ErrSchemaNotFound))
+
+		_, err = reg.SchemaByID(context.Background(), 99)
+		Expect(err).To(MatchError(schema.ErrSchemaNotFound))
+	})
+
+	It("should reject duplicate ids", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "other@7.avsc"), []byte(orderAvsc), 0o644)).To(Succeed())
+
+		_, err := schema.NewFileRegistry(dir)
+		Expect(err).To(MatchError(ContainSubstring("duplicate schema id 7")))
+	})
+})
+
+var _ = Describe("ConfluentRegistry", func() {
+	var srv *fakeRegistry
+
+	BeforeEach(func() {
+		srv = newFakeRegistry(
+			map[string]any{"subject": "orders-value", "version": 2, "id": 42, "schema": orderAvsc},
+			map[string]any{"subject": "order-proto", "version": 1, "id": 43, "schema": orderProto, "schemaType": "PROTOBUF",
+				"references": []map[string]any{{"name": "common.proto", "subject": "common", "version": 1}}},
+			map[string]any{"subject": "common", "version": 1, "id": 44, "schema": commonProto, "schemaType": "PROTOBUF"},
+		)
+	})
+
+	It("should fetch the latest schema of a subject", func() {
+		reg := schema.NewConfluentRegistry(srv.URL, "user", "secret", "")
+
+		s, err := reg.LatestSchema(context.Background(), "orders-value")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.ID).To(Equal(42))
+		Expect(s.Version).To(Equal(2))
+		Expect(s.Format).To(Equal(schema.Avro))
+		Expect(s.Definition).To(Equal(orderAvsc))
+	})
+
+	It("should resolve references and cache schemas by id", func() {
+		reg := schema.NewConfluentRegistry(srv.URL, "", "", "order-proto")
+
+		s, err := reg.SchemaByID(context.Background(), 43)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Subject).To(Equal("order-proto"))
+		Expect(s.Version).To(Equal(1))
+		Expect(s.Format).To(Equal(schema.Protobuf))
+		Expect(s.References).To(HaveKeyWithValue("common.proto", commonProto))
+
+		requests := srv.requests.Load()
+		_, err = reg.SchemaByID(context.Background(), 43)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(srv.requests.Load()).To(Equal(requests))
+	})
+
+	It("should only report the configured subject of a schema looked up by id", func() {
+		srv = newFakeRegistry(
+			map[string]any{"subject": "orders-value", "version": 2, "id": 42, "schema": orderAvsc},
+			map[string]any{"subject": "orders-archive", "version": 5, "id": 42, "schema": orderAvsc},
+		)
+
+		s, err := schema.NewConfluentRegistry(srv.URL, "", "", "orders-archive").SchemaByID(context.Background(), 42)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Subject).To(Equal("orders-archive"))
+		Expect(s.Version).To(Equal(5))
+
+		for _, subject := range []string{"", "payments-value"} {
+			s, err = schema.NewConfluentRegistry(srv.URL, "", "", subject).SchemaByID(context.Background(), 42)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.Subject).To(BeEmpty())
+			Expect(s.Version).To(BeZero())
+		}
+	})
+
+	It("should return ErrSchemaNotFound for unknown schemas", func() {
+		reg := schema.NewConfluentRegistry(srv.URL, "", "", "")
+
+		_, err := reg.SchemaByID(context.Background(), 1)
+		Expect(err).To(MatchError(schema.ErrSchemaNotFound))
+	})
+
+	It("should fail on authentication errors", func() {
+		reg := schema.NewConfluentRegistry(srv.URL, "user", "wrong", "")
+
+		_, err := reg.LatestSchema(context.Background(), "orders-value")
+		Expect(err).To(MatchError(ContainSubstring("401")))
+	})
+})
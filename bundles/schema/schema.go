@@ -0,0 +1,103 @@
+// Package schema provides processors to convert Avro and Protobuf payloads to and from JSON. Schemas are looked
+// up in a SchemaRegistry, which can be backed by a local directory or a Confluent compatible schema registry.
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MetadataSchemaID is the metadata key holding the registry id of the schema of a message.
+	MetadataSchemaID = "schema_id"
+
+	// MetadataSchemaName is the metadata key holding the subject or name of the schema of a message.
+	MetadataSchemaName = "schema_name"
+)
+
+var ErrSchemaNotFound = errors.New("schema not found")
+
+// Format identifies the serialization format described by a schema.
+type Format string
+
+const (
+	Avro     Format = "avro"
+	Protobuf Format = "protobuf"
+)
+
+// ParseFormat converts the given name into a Format. Confluent schema types like AVRO and PROTOBUF are accepted as
+// well. An empty name is treated as Avro, which is the default of the Confluent schema registry.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "avro":
+		return Avro, nil
+	case "protobuf", "proto":
+		return Protobuf, nil
+	default:
+		return "", fmt.Errorf("unsupported schema format %q", name)
+	}
+}
+
+// Schema is a schema definition as stored in a registry.
+type Schema struct {
+	// The id of the schema in the registry. Zero if the registry doesn't assign ids.
+	ID int
+
+	// The subject or name the schema is registered under.
+	Subject string
+
+	// The version of the schema within its subject.
+	Version int
+
+	Format Format
+
+	// The schema definition; an Avro schema in JSON or a Protobuf schema in .proto syntax.
+	Definition string
+
+	// The definitions of the schemas referenced by this one, keyed by the name they are referenced as. For Protobuf
+	// schemas this is the import path of the referenced file.
+	References map[string]string
+}
+
+// SchemaRegistry provides access to schemas by their id or subject.
+type SchemaRegistry interface {
+	// SchemaByID returns the schema with the given id, or ErrSchemaNotFound if there is none.
+	SchemaByID(ctx context.Context, id int) (*Schema, error)
+
+	// LatestSchema returns the latest version of the schema registered under the given subject, or ErrSchemaNotFound
+	// if there is none.
+	LatestSchema(ctx context.Context, subject string) (*Schema, error)
+}
+
+// RegistryConfig defines the schema registry to use. Exactly one of Directory or URL must be set.
+type RegistryConfig struct {
+	// A local directory containing .avsc and .proto schema files.
+	Directory string `json:"directory,omitempty" yaml:"directory,omitempty" mapstructure:"directory,omitempty"`
+
+	// The url of a Confluent compatible schema registry.
+	URL string `json:"url,omitempty" yaml:"url,omitempty" mapstructure:"url,omitempty"`
+
+	// Credentials for basic authentication against the schema registry.
+	Username string `json:"username,omitempty" yaml:"username,omitempty" mapstructure:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty" mapstructure:"password,omitempty"`
+
+	// Subject is the subject the registry is used for, set by the processor. Schemas looked up by id only report a
+	// subject and version when they are registered under it.
+	Subject string `json:"-" yaml:"-" mapstructure:"-"`
+}
+
+// NewRegistry creates the schema registry described by the configuration.
+func NewRegistry(cfg RegistryConfig) (SchemaRegistry, error) {
+	switch {
+	case cfg.Directory != "" && cfg.URL != "":
+		return nil, fmt.Errorf("only one of directory or url can be set")
+	case cfg.Directory != "":
+		return NewFileRegistry(cfg.Directory)
+	case cfg.URL != "":
+		return NewConfluentRegistry(cfg.URL, cfg.Username, cfg.Password, cfg.Subject), nil
+	default:
+		return nil, fmt.Errorf("either directory or url must be set")
+	}
+}
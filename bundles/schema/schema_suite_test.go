@@ -0,0 +1,46 @@
+package schema_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSchema(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Schema Suite")
+}
+
+const orderAvsc = `{
+  "type": "record",
+  "name": "Order",
+  "fields": [
+    {"name": "id", "type": "string"},
+    {"name": "amount", "type": "long"}
+  ]
+}`
+
+const orderProto = `syntax = "proto3";
+package shop;
+
+import "common.proto";
+
+message Order {
+  string id = 1;
+  int64 amount = 2;
+  Money total = 3;
+
+  message Line {
+    string sku = 1;
+  }
+}
+`
+
+const commonProto = `syntax = "proto3";
+package shop;
+
+message Money {
+  string currency = 1;
+}
+`
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+	"github.com/wombatwisdom/components/framework/pipeline"
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// newApp creates the ww command line application with the components of the registry. Results are written to out,
+// logs to logs.
+func newApp(reg *registry.Registry, out io.Writer, logs io.Writer) *cli.App {
+	kindFlag := &cli.StringFlag{
+		Name:  "kind",
+		Usage: "only consider components of this kind: " + kindList(),
+	}
+
+	return &cli.App{
+		Name:      "ww",
+		Usage:     "run, lint and inspect WombatWisdom pipelines",
+		Writer:    out,
+		ErrWriter: logs,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "log-level",
+				Value: "info",
+				Usage: "the minimum level of the logs: debug, info, warn or error",
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:      "run",
+				Usage:     "start a pipeline",
+				ArgsUsage: "<config.yaml>",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("run takes a single config")
+					}
+
+					env, err := environment(c, logs)
+					if err != nil {
+						return err
+					}
+
+					return runPipeline(c.Context, reg, env, c.Args().First())
+				},
+			},
+			{
+				Name:      "lint",
+				Usage:     "validate configs against the component schemas and compile their expressions",
+				ArgsUsage: "<config.yaml>...",
+				Action: func(c *cli.Context) error {
+					env, err := environment(c, logs)
+					if err != nil {
+						return err
+					}
+
+					return lintConfigs(out, reg, env, c.Args().Slice())
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "list the registered components",
+				Flags: []cli.Flag{kindFlag},
+				Action: func(c *cli.Context) error {
+					return listComponents(out, reg, registry.Kind(c.String("kind")))
+				},
+			},
+			{
+				Name:      "schema",
+				Usage:     "print the JSON schema of a component",
+				ArgsUsage: "<component>",
+				Flags:     []cli.Flag{kindFlag},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("schema takes a single component")
+					}
+
+					return printSchema(out, reg, registry.Kind(c.String("kind")), c.Args().First())
+				},
+			},
+			{
+				Name:      "test",
+				Usage:     "run the tests embedded in configs against their processors",
+				ArgsUsage: "<config.yaml>...",
+				Action: func(c *cli.Context) error {
+					env, err := environment(c, logs)
+					if err != nil {
+						return err
+					}
+
+					return runTests(c.Context, out, reg, env, c.Args().Slice())
+				},
+			},
+		},
+	}
+}
+
+// environment creates the environment of the components, logging to logs at the configured level.
+func environment(c *cli.Context, logs io.Writer) (spec.Environment, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.String("log-level"))); err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+
+	log := slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: level}))
+	return pipeline.NewEnvironment(pipeline.NewLogger(log)), nil
+}
+
+func runPipeline(ctx context.Context, reg *registry.Registry, env spec.Environment, path string) error {
+	cfg, err := pipeline.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	p, err := pipeline.Build(reg, env, cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return p.Run(ctx)
+}
+
+func lintConfigs(out io.Writer, reg *registry.Registry, env spec.Environment, paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("lint takes at least one config")
+	}
+
+	var problems int
+	for _, path := range paths {
+		cfg, err := pipeline.LoadConfig(path)
+		if err != nil {
+			_, _ = fmt.Fprintln(out, err)
+			problems++
+			continue
+		}
+
+		for _, err := range pipeline.Lint(reg, env, cfg) {
+			_, _ = fmt.Fprintf(out, "%s: %v\n", path, err)
+			problems++
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("found %d problems", problems)
+	}
+	return nil
+}
+
+func listComponents(out io.Writer, reg *registry.Registry, kind registry.Kind) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KIND\tNAME\tSUMMARY")
+	for _, plugin := range reg.Plugins() {
+		if kind == "" || plugin.Kind == kind {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", plugin.Kind, plugin.Name, plugin.Summary)
+		}
+	}
+	return w.Flush()
+}
+
+func printSchema(out io.Writer, reg *registry.Registry, kind registry.Kind, name string) error {
+	var matches []*registry.Plugin
+	for _, plugin := range reg.Plugins() {
+		if plugin.Name == name && (kind == "" || plugin.Kind == kind) {
+			matches = append(matches, plugin)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("unknown component %s", name)
+	case 1:
+	default:
+		kinds := make([]string, len(matches))
+		for idx, plugin := range matches {
+			kinds[idx] = string(plugin.Kind)
+		}
+		return fmt.Errorf("%s is registered as %s, select one with --kind", name, strings.Join(kinds, " and "))
+	}
+
+	s, err := matches[0].ConfigSchema()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func runTests(ctx context.Context, out io.Writer, reg *registry.Registry, env spec.Environment, paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("test takes at least one config")
+	}
+
+	var passed, failed int
+	var errs []error
+	for _, path := range paths {
+		cfg, err := pipeline.LoadConfig(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		results, err := pipeline.RunTests(ctx, reg, env, cfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+
+		for _, result := range results {
+			if result.Passed() {
+				_, _ = fmt.Fprintf(out, "PASS %s: %s\n", path, result.Name)
+				passed++
+				continue
+			}
+
+			_, _ = fmt.Fprintf(out, "FAIL %s: %s\n", path, result.Name)
+			for _, failure := range result.Failures {
+				_, _ = fmt.Fprintf(out, "    %s\n", failure)
+			}
+			failed++
+		}
+	}
+
+	_, _ = fmt.Fprintf(out, "%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		errs = append(errs, fmt.Errorf("%d tests failed", failed))
+	}
+	return errors.Join(errs...)
+}
+
+func kindList() string {
+	kinds := make([]string, len(registry.Kinds))
+	for idx, kind := range registry.Kinds {
+		kinds[idx] = string(kind)
+	}
+	return strings.Join(kinds, ", ")
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ww", func() {
+	var out *bytes.Buffer
+
+	run := func(args ...string) error {
+		reg, err := newRegistry()
+		Expect(err).ToNot(HaveOccurred())
+
+		out = &bytes.Buffer{}
+		return newApp(reg, out, GinkgoWriter).Run(append([]string{"ww", "--log-level", "error"}, args...))
+	}
+
+	config := func(yaml string) string {
+		path := filepath.Join(GinkgoT().TempDir(), "pipeline.yaml")
+		Expect(os.WriteFile(path, []byte(yaml), 0o644)).To(Succeed())
+		return path
+	}
+
+	It("should list the registered components", func() {
+		Expect(run("list", "--kind", "processor")).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("compress"))
+		Expect(out.String()).To(ContainSubstring("Compresses or decompresses message payloads."))
+		Expect(out.String()).ToNot(ContainSubstring("nats_core"))
+	})
+
+	It("should print the schema of a component with its system", func() {
+		Expect(run("schema", "nats_core")).To(MatchError(ContainSubstring("select one with --kind")))
+
+		Expect(run("schema", "--kind", "input", "nats_core")).To(Succeed())
+		var schema map[string]any
+		Expect(json.Unmarshal(out.Bytes(), &schema)).To(Succeed())
+		Expect(schema).To(HaveKeyWithValue("required", ConsistOf("subject")))
+		Expect(schema).To(HaveKeyWithValue("properties", HaveKey("system")))
+	})
+
+	It("should lint configs", func() {
+		valid := config(`
+input:
+  generate:
+    interval: 1s
+    mapping: 'hello ${! counter }'
+pipeline:
+  processors:
+    - compress:
+        algorithm: gzip
+output:
+  nats_core:
+    subject: 'greetings'
+    system:
+      url: nats://localhost:4222
+`)
+		Expect(run("lint", valid)).To(Succeed())
+		Expect(out.String()).To(BeEmpty())
+
+		invalid := config(`
+input:
+  generate:
+    mapping: 'hello ${! counter + }'
+output:
+  nats_core: {}
+`)
+		Expect(run("lint", invalid)).To(MatchError("found 2 problems"))
+		Expect(out.String()).To(ContainSubstring("input.generate.mapping: failed to compile expression"))
+		Expect(out.String()).To(ContainSubstring("output.nats_core: subject is required"))
+	})
+
+	It("should lint configs using the MQTT, AWS and memory components", func() {
+		paths := []string{
+			config(`
+input:
+  mqtt:
+    urls: [tcp://localhost:1883]
+    filters:
+      'sensors/#': 1
+output:
+  aws_sqs:
+    queue_url: https://sqs.eu-west-1.amazonaws.com/123456789012/readings
+    region: eu-west-1
+    message_group_id: '${! metadata.mqtt_topic }'
+`),
+			config(`
+input:
+  aws_sqs_s3_trigger:
+    queue_url: https://sqs.eu-west-1.amazonaws.com/123456789012/uploads
+    credentials:
+      access_key_id: key
+      secret_access_key: secret
+    redelivery:
+      initial: 1s
+retrieval:
+  aws_s3:
+    decompress: true
+output:
+  mqtt:
+    urls: [tcp://localhost:1883]
+    topic_expr: 'uploads/${! metadata.key }'
+`),
+			config(`
+input:
+  aws_eventbridge:
+    event_source: aws.s3
+    rule_name: uploads
+    sqs_queue_url: https://sqs.eu-west-1.amazonaws.com/123456789012/events
+retrieval:
+  aws_s3:
+    endpoint_url: http://localhost:9000
+    force_path_style_urls: true
+output:
+  memory:
+    channel: out
+`),
+			config(`
+input:
+  aws_s3:
+    bucket: archive
+    prefix: '2024/'
+output:
+  memory:
+    channel: out
+`),
+		}
+		for _, path := range paths {
+			Expect(run("lint", path)).To(Succeed(), out.String())
+		}
+
+		invalid := config(`
+input:
+  aws_sqs:
+    region: eu-west-1
+output:
+  mqtt:
+    urls: [tcp://localhost:1883]
+`)
+		Expect(run("lint", invalid)).To(MatchError("found 2 problems"))
+		Expect(out.String()).To(ContainSubstring("input.aws_sqs: queue_url is required"))
+		Expect(out.String()).To(ContainSubstring("output.mqtt: topic_expr is required"))
+	})
+
+	It("should run the tests of configs", func() {
+		path := config(`
+pipeline:
+  processors:
+    - compress:
+        algorithm: gzip
+    - compress:
+        algorithm: auto
+        operation: decompress
+tests:
+  - name: roundtrip
+    input:
+      - content: hello
+    expected:
+      - content: hello
+  - name: broken
+    input:
+      - content: hello
+    expected:
+      - content: bye
+`)
+		Expect(run("test", path)).To(MatchError("1 tests failed"))
+		Expect(out.String()).To(ContainSubstring("PASS " + path + ": roundtrip"))
+		Expect(out.String()).To(ContainSubstring("FAIL " + path + ": broken"))
+		Expect(out.String()).To(ContainSubstring(`expected content "bye", got "hello"`))
+		Expect(out.String()).To(ContainSubstring("1 passed, 1 failed"))
+	})
+
+	It("should run a pipeline until its input ends", func() {
+		dir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644)).To(Succeed())
+
+		path := config(`
+input:
+  generate_trigger:
+    count: 1
+    reference: '` + filepath.Join(dir, "a.txt") + `'
+retrieval:
+  file_retrieval: {}
+output:
+  nats_core:
+    subject: out
+    system:
+      url: nats://127.0.0.1:1
+`)
+		Expect(run("run", path)).To(MatchError(ContainSubstring("failed to connect")))
+	})
+})
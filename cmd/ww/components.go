@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/wombatwisdom/components/bundles/archive"
+	eventbridge "github.com/wombatwisdom/components/bundles/aws-eventbridge"
+	s3 "github.com/wombatwisdom/components/bundles/aws-s3"
+	sqs "github.com/wombatwisdom/components/bundles/aws-sqs"
+	"github.com/wombatwisdom/components/bundles/compress"
+	"github.com/wombatwisdom/components/bundles/file"
+	"github.com/wombatwisdom/components/bundles/generate"
+	"github.com/wombatwisdom/components/bundles/mqtt"
+	"github.com/wombatwisdom/components/bundles/nats"
+	"github.com/wombatwisdom/components/bundles/schema"
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+// bundles are the Register functions of the bundles available in ww. The memory components share a single broker for
+// the lifetime of the process.
+var bundles = []func(r *registry.Registry) error{
+	archive.Register,
+	compress.Register,
+	eventbridge.Register,
+	file.Register,
+	generate.Register,
+	mqtt.Register,
+	nats.Register,
+	s3.Register,
+	schema.Register,
+	sqs.Register,
+	test.NewMemoryBroker().Register,
+}
+
+// newRegistry creates a registry with the components of all bundles.
+func newRegistry() (*registry.Registry, error) {
+	reg := registry.New()
+	for _, register := range bundles {
+		if err := register(reg); err != nil {
+			return nil, err
+		}
+	}
+	return reg, nil
+}
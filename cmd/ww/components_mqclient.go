@@ -0,0 +1,12 @@
+//go:build mqclient
+
+package main
+
+import (
+	ibmmq "github.com/wombatwisdom/components/bundles/ibm-mq"
+)
+
+// -- the IBM MQ components need the IBM MQ client libraries, so they are only available in builds with the mqclient tag
+func init() {
+	bundles = append(bundles, ibmmq.Register)
+}
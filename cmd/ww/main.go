@@ -0,0 +1,27 @@
+// Command ww runs, lints and inspects pipeline configurations built from the WombatWisdom components, so pipelines
+// can be deployed without writing Go.
+//
+//	ww run pipeline.yaml         start a pipeline
+//	ww lint pipeline.yaml ...    validate configurations and compile their expressions
+//	ww list                      list the registered components
+//	ww schema nats_core          print the JSON schema of a component
+//	ww test pipeline.yaml ...    run the tests embedded in configurations
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	reg, err := newRegistry()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to register components: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := newApp(reg, os.Stdout, os.Stderr).Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,398 @@
+package benthos_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	_ "github.com/redpanda-data/benthos/v4/public/components/pure"
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/wombatwisdom/components/framework/benthos"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+// componentSpec describes the in-memory components registered by the specs
+type componentSpec struct {
+	name         string
+	inputSchema  string
+	outputSchema string
+}
+
+func (c componentSpec) Name() string               { return c.name }
+func (c componentSpec) Summary() string            { return "In-memory " + c.name }
+func (c componentSpec) Description() string        { return "" }
+func (c componentSpec) InputConfigSchema() string  { return c.inputSchema }
+func (c componentSpec) OutputConfigSchema() string { return c.outputSchema }
+func (c componentSpec) SystemConfigSchema() string { return "" }
+
+const memorySchema = `{
+	"type": "object",
+	"properties": {
+		"channel": {"type": "string"},
+		"batch_size": {"type": "integer", "default": 1}
+	},
+	"required": ["channel"]
+}`
+
+type memoryConfig struct {
+	Channel   string `mapstructure:"channel"`
+	BatchSize int    `mapstructure:"batch_size"`
+}
+
+// upperProcessor uppercases payloads
+type upperProcessor struct{}
+
+func (p *upperProcessor) Init(ctx spec.ComponentContext) error  { return nil }
+func (p *upperProcessor) Close(ctx spec.ComponentContext) error { return nil }
+
+func (p *upperProcessor) Process(ctx spec.ComponentContext, batch spec.Batch) (spec.Batch, spec.ProcessedCallback, error) {
+	for _, msg := range batch.Messages() {
+		raw, err := msg.Raw()
+		if err != nil {
+			return nil, nil, err
+		}
+		msg.SetRaw(bytes.ToUpper(raw))
+		msg.SetMetadata("processed", true)
+	}
+	return batch, spec.NoopCallback, nil
+}
+
+// rejectingOutput fails the messages containing "reject" permanently and writes the others to the channel
+type rejectingOutput struct {
+	*test.MemoryOutput
+}
+
+func (o *rejectingOutput) Write(ctx spec.ComponentContext, batch spec.Batch) error {
+	batchErr := spec.NewBatchError(nil)
+	accepted := ctx.NewBatch()
+	for idx, msg := range batch.Messages() {
+		raw, _ := msg.Raw()
+		if bytes.Contains(raw, []byte("reject")) {
+			batchErr.Failed(idx, fmt.Errorf("%w: rejected", spec.ErrPermanent))
+			continue
+		}
+		accepted.Append(msg)
+	}
+
+	if err := o.MemoryOutput.Write(ctx, accepted); err != nil {
+		return err
+	}
+	if batchErr.Len() > 0 {
+		return batchErr
+	}
+	return nil
+}
+
+// listTrigger emits a single batch with a trigger for each of its references and records the outcome
+type listTrigger struct {
+	references []string
+	read       bool
+	acked      chan error
+}
+
+func (t *listTrigger) Init(ctx spec.ComponentContext) error  { return nil }
+func (t *listTrigger) Close(ctx spec.ComponentContext) error { return nil }
+
+func (t *listTrigger) ReadTriggers(ctx spec.ComponentContext) (spec.TriggerBatch, spec.ProcessedCallback, error) {
+	if t.read {
+		return nil, nil, spec.ErrEndOfInput
+	}
+	t.read = true
+
+	batch := spec.NewTriggerBatch()
+	for _, reference := range t.references {
+		batch.Append(spec.NewTriggerEvent(spec.TriggerSourceGenerate, reference, nil))
+	}
+	return batch, func(_ context.Context, err error) error {
+		t.acked <- err
+		return nil
+	}, nil
+}
+
+// referenceRetrieval retrieves a message with the reference of every trigger as its payload
+type referenceRetrieval struct{}
+
+func (r *referenceRetrieval) Init(ctx spec.ComponentContext) error  { return nil }
+func (r *referenceRetrieval) Close(ctx spec.ComponentContext) error { return nil }
+
+func (r *referenceRetrieval) Retrieve(ctx spec.ComponentContext, triggers spec.TriggerBatch) (spec.Batch, spec.ProcessedCallback, error) {
+	batch := ctx.NewBatch()
+	for _, trigger := range triggers.Triggers() {
+		msg := ctx.NewMessage()
+		msg.SetRaw([]byte(trigger.Reference()))
+		batch.Append(msg)
+	}
+	return batch, spec.NoopCallback, nil
+}
+
+// flakySystem fails to connect once before it connects
+type flakySystem struct {
+	attempts int
+}
+
+func (s *flakySystem) Connect(ctx context.Context) error {
+	s.attempts++
+	if s.attempts == 1 {
+		return fmt.Errorf("attempt %d failed", s.attempts)
+	}
+	return nil
+}
+
+func (s *flakySystem) Close(ctx context.Context) error { return nil }
+func (s *flakySystem) Client() any                     { return nil }
+
+// recordingExporter is a Benthos metrics exporter keeping the last value of every gauge and the total of every
+// counter
+type recordingExporter struct {
+	lock   sync.Mutex
+	values map[string]int64
+}
+
+func (e *recordingExporter) record(name string, fn func(int64) int64) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.values[name] = fn(e.values[name])
+}
+
+func (e *recordingExporter) Values() map[string]int64 {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return maps.Clone(e.values)
+}
+
+func (e *recordingExporter) NewCounterCtor(name string, _ ...string) service.MetricsExporterCounterCtor {
+	return func(...string) service.MetricsExporterCounter { return recordingCounter{e, name} }
+}
+
+func (e *recordingExporter) NewTimerCtor(name string, _ ...string) service.MetricsExporterTimerCtor {
+	return func(...string) service.MetricsExporterTimer { return recordingTimer{} }
+}
+
+func (e *recordingExporter) NewGaugeCtor(name string, _ ...string) service.MetricsExporterGaugeCtor {
+	return func(...string) service.MetricsExporterGauge { return recordingGauge{e, name} }
+}
+
+func (e *recordingExporter) Close(ctx context.Context) error { return nil }
+
+type recordingCounter struct {
+	e    *recordingExporter
+	name string
+}
+
+func (c recordingCounter) Incr(count int64) {
+	c.e.record(c.name, func(v int64) int64 { return v + count })
+}
+
+type recordingGauge struct {
+	e    *recordingExporter
+	name string
+}
+
+func (g recordingGauge) Set(value int64) {
+	g.e.record(g.name, func(int64) int64 { return value })
+}
+
+type recordingTimer struct{}
+
+func (recordingTimer) Timing(int64) {}
+
+var _ = Describe("Adapter", func() {
+	var broker *test.MemoryBroker
+	var env *service.Environment
+
+	BeforeEach(func() {
+		broker = test.NewMemoryBroker()
+		env = service.NewEnvironment()
+
+		Expect(benthos.RegisterInput(env, componentSpec{name: "ww_memory", inputSchema: memorySchema}, nil,
+			func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Input, error) {
+				var c memoryConfig
+				if err := cfg.Decode(&c); err != nil {
+					return nil, err
+				}
+				return broker.NewInput(c.Channel, test.MemoryInputConfig{BatchSize: c.BatchSize}), nil
+			})).To(Succeed())
+
+		Expect(benthos.RegisterOutput(env, componentSpec{name: "ww_memory", outputSchema: memorySchema}, nil,
+			func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Output, error) {
+				var c memoryConfig
+				if err := cfg.Decode(&c); err != nil {
+					return nil, err
+				}
+				return &rejectingOutput{broker.NewOutput(c.Channel)}, nil
+			})).To(Succeed())
+
+		Expect(benthos.RegisterProcessor(env, "ww_upper", `{"type": "object"}`,
+			func(_ spec.Environment, _ spec.System, _ spec.Config) (spec.Processor, error) {
+				return &upperProcessor{}, nil
+			})).To(Succeed())
+	})
+
+	run := func(yaml string) {
+		builder := env.NewStreamBuilder()
+		Expect(builder.SetLoggerYAML(`level: none`)).To(Succeed())
+		Expect(builder.SetYAML(yaml)).To(Succeed())
+
+		stream, err := builder.Build()
+		Expect(err).ToNot(HaveOccurred())
+
+		go func() {
+			_ = stream.Run(context.Background())
+		}()
+
+		DeferCleanup(func() {
+			_ = stream.StopWithin(5 * time.Second)
+		})
+	}
+
+	write := func(channel string, payloads ...string) {
+		ctx := test.NewMockComponentContext()
+		output := broker.NewOutput(channel)
+		Expect(output.Init(ctx)).To(Succeed())
+
+		batch := ctx.NewBatch()
+		for _, payload := range payloads {
+			msg := ctx.NewMessage()
+			msg.SetRaw([]byte(payload))
+			msg.SetMetadata("origin", "test")
+			batch.Append(msg)
+		}
+		Expect(output.Write(ctx, batch)).To(Succeed())
+	}
+
+	payloads := func(msgs []spec.Message) []string {
+		var result []string
+		for _, msg := range msgs {
+			raw, err := msg.Raw()
+			Expect(err).ToNot(HaveOccurred())
+			result = append(result, string(raw))
+		}
+		return result
+	}
+
+	It("should run the components in a Benthos stream", func() {
+		run(`
+input:
+  ww_memory:
+    channel: in
+pipeline:
+  processors:
+    - ww_upper: {}
+output:
+  ww_memory:
+    channel: out
+`)
+		write("in", "hello", "world")
+
+		out := broker.Channel("out")
+		Expect(out.WaitForWritten(2, 5*time.Second)).To(BeTrue())
+		Expect(payloads(out.Written())).To(ConsistOf("HELLO", "WORLD"))
+
+		metadata := map[string]any{}
+		for key, value := range out.Written()[0].Metadata() {
+			metadata[key] = value
+		}
+		Expect(metadata).To(HaveKeyWithValue("origin", "test"))
+		Expect(metadata).To(HaveKeyWithValue("processed", true))
+
+		Expect(broker.Channel("in").WaitForAcked(2, 5*time.Second)).To(BeTrue())
+	})
+
+	It("should only fail the messages the output rejected", func() {
+		run(`
+input:
+  ww_memory:
+    channel: in
+    batch_size: 3
+output:
+  ww_memory:
+    channel: out
+`)
+		write("in", "one", "reject me", "three")
+
+		in := broker.Channel("in")
+		Expect(in.WaitForAcked(2, 5*time.Second)).To(BeTrue())
+		Eventually(func() []string { return payloads(in.Dropped()) }, 5*time.Second).Should(Equal([]string{"reject me"}))
+		Expect(payloads(in.Acked())).To(ConsistOf("one", "three"))
+		Expect(payloads(broker.Channel("out").Written())).To(ConsistOf("one", "three"))
+	})
+
+	It("should read the data of triggers through the retrieval processor", func() {
+		trigger := &listTrigger{references: []string{"a.json", "reject b.json"}, acked: make(chan error, 1)}
+		Expect(benthos.RegisterTriggerInput(env, componentSpec{name: "ww_trigger", inputSchema: `{"type": "object"}`}, nil,
+			func(_ spec.Environment, _ spec.System, _ spec.Config) (spec.TriggerInput, error) {
+				return trigger, nil
+			},
+			func(_ spec.Environment, _ spec.System, _ spec.Config) (spec.RetrievalProcessor, error) {
+				return &referenceRetrieval{}, nil
+			})).To(Succeed())
+
+		run(`
+input:
+  ww_trigger: {}
+output:
+  ww_memory:
+    channel: out
+`)
+
+		var err error
+		Eventually(trigger.acked, 5*time.Second).Should(Receive(&err))
+		Expect(spec.MessageError(err, 0)).ToNot(HaveOccurred())
+		Expect(spec.MessageError(err, 1)).To(MatchError(spec.ErrPermanent))
+		Expect(payloads(broker.Channel("out").Written())).To(Equal([]string{"a.json"}))
+	})
+
+	It("should report the connection state of the systems as metrics", func() {
+		exporter := &recordingExporter{values: map[string]int64{}}
+		Expect(env.RegisterMetricsExporter("ww_recording", service.NewConfigSpec(),
+			func(*service.ParsedConfig, *service.Logger) (service.MetricsExporter, error) {
+				return exporter, nil
+			})).To(Succeed())
+
+		Expect(benthos.RegisterOutput(env, componentSpec{name: "ww_flaky", outputSchema: memorySchema},
+			func(spec.Config) (spec.System, error) {
+				return &flakySystem{}, nil
+			},
+			func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Output, error) {
+				var c memoryConfig
+				if err := cfg.Decode(&c); err != nil {
+					return nil, err
+				}
+				return broker.NewOutput(c.Channel), nil
+			})).To(Succeed())
+
+		run(`
+input:
+  ww_memory:
+    channel: in
+output:
+  ww_flaky:
+    channel: out
+metrics:
+  ww_recording: {}
+`)
+		write("in", "hello")
+
+		Expect(broker.Channel("out").WaitForWritten(1, 10*time.Second)).To(BeTrue())
+		Expect(exporter.Values()).To(HaveKeyWithValue("ww_flaky_connection_state", int64(spec.StateConnected)))
+	})
+
+	It("should expose the component spec as the config spec of the plugin", func() {
+		var summary string
+		env.WalkInputs(func(name string, config *service.ConfigView) {
+			if name == "ww_memory" {
+				summary = config.Summary()
+			}
+		})
+		Expect(summary).To(Equal("In-memory ww_memory"))
+
+		builder := env.NewStreamBuilder()
+		Expect(builder.AddInputYAML(`ww_memory: { batch_size: 2 }`)).To(MatchError(ContainSubstring("channel")))
+	})
+})
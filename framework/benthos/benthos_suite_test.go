@@ -0,0 +1,13 @@
+package benthos_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBenthos(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Benthos Suite")
+}
@@ -0,0 +1,189 @@
+package benthos
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// SystemField is the field of the plugin configuration holding the configuration of the system.
+const SystemField = "system"
+
+// NewConfigSpec translates the JSON schema of a component configuration, as returned by a spec.ComponentSpec or
+// built with spec.NewConfigSchema, into a Benthos config spec. Every property of the schema becomes a field, nested
+// objects become object fields. Properties which aren't required and have no default are optional.
+func NewConfigSpec(schema string) (*service.ConfigSpec, error) {
+	root, err := parseSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.NewConfigSpec().Fields(objectFields(root)...), nil
+}
+
+// componentConfigSpec creates the config spec of a component with the given configuration schema. The configuration
+// of the system becomes the system field, if the component uses one.
+func componentConfigSpec(cs spec.ComponentSpec, schema string, withSystem bool) (*service.ConfigSpec, error) {
+	conf, err := NewConfigSpec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cs.Name(), err)
+	}
+	conf = conf.Summary(cs.Summary()).Description(cs.Description())
+
+	if withSystem {
+		root, err := parseSchema(cs.SystemConfigSchema())
+		if err != nil {
+			return nil, fmt.Errorf("%s: system: %w", cs.Name(), err)
+		}
+
+		field := service.NewObjectField(SystemField, objectFields(root)...).Description("The connection used by the component.")
+		if len(root.Required) == 0 {
+			field = field.Optional()
+		}
+		conf = conf.Field(field)
+	}
+
+	return conf, nil
+}
+
+// Config returns the parsed configuration of a plugin as a spec.Config, for the constructors of the components. The
+// system field is left out.
+func Config(conf *service.ParsedConfig) (spec.Config, error) {
+	raw, err := rawConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(raw, SystemField)
+	return spec.NewMapConfig(raw), nil
+}
+
+// SystemConfig returns the system field of the parsed configuration of a plugin as a spec.Config.
+func SystemConfig(conf *service.ParsedConfig) (spec.Config, error) {
+	raw, err := rawConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	sys, _ := raw[SystemField].(map[string]any)
+	return spec.NewMapConfig(sys), nil
+}
+
+func rawConfig(conf *service.ParsedConfig) (map[string]any, error) {
+	v, err := conf.FieldAny()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := v.(map[string]any)
+	if !ok {
+		return map[string]any{}, nil
+	}
+	return maps.Clone(raw), nil
+}
+
+// schemaProperty is the part of a JSON schema property translated into a Benthos field
+type schemaProperty struct {
+	Type        string                    `json:"type"`
+	Description string                    `json:"description"`
+	Default     any                       `json:"default"`
+	Examples    []any                     `json:"examples"`
+	Enum        []string                  `json:"enum"`
+	Properties  map[string]schemaProperty `json:"properties"`
+	Required    []string                  `json:"required"`
+	Items       *schemaProperty           `json:"items"`
+}
+
+// parseSchema parses an object schema, an empty schema has no properties.
+func parseSchema(schema string) (schemaProperty, error) {
+	var root schemaProperty
+	if schema == "" {
+		return root, nil
+	}
+
+	if err := json.Unmarshal([]byte(schema), &root); err != nil {
+		return root, fmt.Errorf("invalid config schema: %w", err)
+	}
+	return root, nil
+}
+
+// objectFields returns the fields for the properties of an object schema, in alphabetical order.
+func objectFields(object schemaProperty) []*service.ConfigField {
+	var fields []*service.ConfigField
+	for _, name := range slices.Sorted(maps.Keys(object.Properties)) {
+		fields = append(fields, schemaField(name, object.Properties[name], slices.Contains(object.Required, name)))
+	}
+	return fields
+}
+
+func schemaField(name string, prop schemaProperty, required bool) *service.ConfigField {
+	var field *service.ConfigField
+	switch prop.Type {
+	case "string":
+		if len(prop.Enum) > 0 {
+			field = service.NewStringEnumField(name, prop.Enum...)
+		} else {
+			field = service.NewStringField(name)
+		}
+	case "integer":
+		field = service.NewIntField(name)
+		// -- JSON numbers decode as floats
+		if f, ok := prop.Default.(float64); ok {
+			prop.Default = int(f)
+		}
+	case "number":
+		field = service.NewFloatField(name)
+	case "boolean":
+		field = service.NewBoolField(name)
+	case "array":
+		field = listField(name, prop.Items)
+	case "object":
+		if len(prop.Properties) > 0 {
+			field = service.NewObjectField(name, objectFields(prop)...)
+		} else {
+			field = service.NewAnyMapField(name)
+		}
+	default:
+		field = service.NewAnyField(name)
+	}
+
+	if prop.Description != "" {
+		field = field.Description(prop.Description)
+	}
+	if len(prop.Examples) > 0 {
+		field = field.Examples(prop.Examples...)
+	}
+
+	switch {
+	case prop.Default != nil:
+		field = field.Default(prop.Default)
+	case !required:
+		field = field.Optional()
+	}
+
+	return field
+}
+
+func listField(name string, items *schemaProperty) *service.ConfigField {
+	if items == nil {
+		return service.NewAnyListField(name)
+	}
+
+	switch items.Type {
+	case "string":
+		return service.NewStringListField(name)
+	case "integer":
+		return service.NewIntListField(name)
+	case "number":
+		return service.NewFloatListField(name)
+	case "object":
+		if len(items.Properties) > 0 {
+			return service.NewObjectListField(name, objectFields(*items)...)
+		}
+	}
+	return service.NewAnyListField(name)
+}
@@ -0,0 +1,102 @@
+package benthos_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/benthos"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+var _ = Describe("NewConfigSpec", func() {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"subject": {"type": "string", "description": "The subject to read from."},
+			"batch_size": {"type": "integer", "default": 10},
+			"ratio": {"type": "number"},
+			"durable": {"type": "boolean", "default": false},
+			"mode": {"type": "string", "enum": ["fast", "safe"], "default": "safe"},
+			"headers": {"type": "array", "items": {"type": "string"}},
+			"tls": {
+				"type": "object",
+				"properties": {
+					"enabled": {"type": "boolean", "default": true},
+					"ca_file": {"type": "string"}
+				}
+			}
+		},
+		"required": ["subject"]
+	}`
+
+	decode := func(yaml string) (map[string]any, error) {
+		conf, err := benthos.NewConfigSpec(schema)
+		Expect(err).ToNot(HaveOccurred())
+
+		parsed, err := conf.ParseYAML(yaml, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := benthos.Config(parsed)
+		Expect(err).ToNot(HaveOccurred())
+
+		result := map[string]any{}
+		Expect(cfg.Decode(&result)).To(Succeed())
+		return result, nil
+	}
+
+	It("should apply the defaults of the schema", func() {
+		cfg, err := decode(`subject: orders`)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cfg).To(HaveKeyWithValue("subject", "orders"))
+		Expect(cfg).To(HaveKeyWithValue("batch_size", 10))
+		Expect(cfg).To(HaveKeyWithValue("durable", false))
+		Expect(cfg).To(HaveKeyWithValue("mode", "safe"))
+		Expect(cfg).ToNot(HaveKey("ratio"))
+	})
+
+	It("should keep the configured values", func() {
+		cfg, err := decode(`
+subject: orders
+batch_size: 5
+headers: [a, b]
+tls:
+  ca_file: ca.pem
+`)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cfg).To(HaveKeyWithValue("batch_size", 5))
+		Expect(cfg).To(HaveKeyWithValue("headers", []any{"a", "b"}))
+		Expect(cfg).To(HaveKeyWithValue("tls", HaveKeyWithValue("ca_file", "ca.pem")))
+		Expect(cfg).To(HaveKeyWithValue("tls", HaveKeyWithValue("enabled", true)))
+	})
+
+	It("should reject a config without a required field", func() {
+		_, err := decode(`batch_size: 5`)
+		Expect(err).To(MatchError(ContainSubstring("subject")))
+	})
+
+	It("should accept schemas built with NewConfigSchema", func() {
+		schema, err := spec.NewConfigSchema().
+			AddField(spec.SchemaField{Name: "url", Type: "string", Required: true}).
+			AddField(spec.SchemaField{Name: "timeout", Type: "string", Default: "5s"}).
+			ToJSON()
+		Expect(err).ToNot(HaveOccurred())
+
+		conf, err := benthos.NewConfigSpec(schema)
+		Expect(err).ToNot(HaveOccurred())
+
+		parsed, err := conf.ParseYAML(`url: nats://localhost:4222`, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		timeout, err := parsed.FieldString("timeout")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(timeout).To(Equal("5s"))
+	})
+
+	It("should reject an invalid schema", func() {
+		_, err := benthos.NewConfigSpec(`{`)
+		Expect(err).To(MatchError(ContainSubstring("invalid config schema")))
+	})
+})
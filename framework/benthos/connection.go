@@ -0,0 +1,104 @@
+package benthos
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// connection manages the lifecycle of the components wrapped for Benthos and of the system they use. Benthos calls
+// Connect again after a component reported it isn't connected, so the components are closed and initialized again on
+// every connect, while the system is only connected once.
+type connection struct {
+	sys        spec.System
+	components []spec.Component
+	log        spec.Logger
+
+	// ctx outlives the calls to Connect, components may hold on to the context they were initialized with
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock         sync.Mutex
+	sysConnected bool
+	initialized  bool
+}
+
+func newConnection(sys spec.System, log spec.Logger, components ...spec.Component) *connection {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &connection{
+		sys:        sys,
+		components: components,
+		log:        log,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+func (c *connection) connect(ctx context.Context) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.sys != nil && !c.sysConnected {
+		if err := c.sys.Connect(ctx); err != nil && !errors.Is(err, spec.ErrAlreadyConnected) {
+			return err
+		}
+		c.sysConnected = true
+	}
+
+	if c.initialized {
+		if err := c.closeComponents(); err != nil {
+			c.log.Warnf("Failed to close components before reconnecting: %v", err)
+		}
+		c.initialized = false
+	}
+
+	cctx := newComponentContext(c.ctx, c.log)
+	for idx, component := range c.components {
+		if err := component.Init(cctx); err != nil {
+			for _, initialized := range c.components[:idx] {
+				_ = initialized.Close(cctx)
+			}
+			return err
+		}
+	}
+
+	c.initialized = true
+	return nil
+}
+
+// componentContext returns the context for a call made by Benthos with the given context.
+func (c *connection) componentContext(ctx context.Context) spec.ComponentContext {
+	return newComponentContext(ctx, c.log)
+}
+
+func (c *connection) close(ctx context.Context) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var errs []error
+	if c.initialized {
+		errs = append(errs, c.closeComponents())
+		c.initialized = false
+	}
+
+	if c.sysConnected {
+		errs = append(errs, c.sys.Close(ctx))
+		c.sysConnected = false
+	}
+
+	c.cancel()
+	return errors.Join(errs...)
+}
+
+// closeComponents closes the components in the reverse order they were initialized in. The caller must hold the lock.
+func (c *connection) closeComponents() error {
+	cctx := newComponentContext(c.ctx, c.log)
+
+	var errs []error
+	for idx := len(c.components) - 1; idx >= 0; idx-- {
+		errs = append(errs, c.components[idx].Close(cctx))
+	}
+	return errors.Join(errs...)
+}
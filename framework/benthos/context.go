@@ -0,0 +1,133 @@
+package benthos
+
+import (
+	"context"
+	"iter"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// NewEnvironment creates an environment logging to the given Benthos logger and reading its values from the
+// environment variables of the process.
+func NewEnvironment(log *service.Logger) spec.Environment {
+	return &environment{Logger: log}
+}
+
+type environment struct {
+	spec.Logger
+}
+
+func (e *environment) GetString(key string) string {
+	return os.Getenv(key)
+}
+
+func (e *environment) GetInt(key string) int {
+	v, _ := strconv.Atoi(os.Getenv(key))
+	return v
+}
+
+func (e *environment) GetBool(key string) bool {
+	v, _ := strconv.ParseBool(os.Getenv(key))
+	return v
+}
+
+// newComponentContext creates the context passed to the components for a single call. Messages created through it
+// are backed by Benthos messages, so they pass between the components and Benthos without being copied.
+func newComponentContext(ctx context.Context, log spec.Logger) spec.ComponentContext {
+	return &componentContext{Logger: log, ctx: ctx}
+}
+
+type componentContext struct {
+	spec.Logger
+	ctx context.Context
+}
+
+func (c *componentContext) Context() context.Context {
+	return c.ctx
+}
+
+func (c *componentContext) NewBatch(msgs ...spec.Message) spec.Batch {
+	return &batch{msgs: msgs}
+}
+
+func (c *componentContext) NewMessage() spec.Message {
+	return &message{msg: service.NewMessage(nil)}
+}
+
+func (c *componentContext) BuildMetadataFilter(patterns []string, invert bool) (spec.MetadataFilter, error) {
+	regexes := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		regexes = append(regexes, re)
+	}
+
+	return &metadataFilter{patterns: regexes, invert: invert}, nil
+}
+
+type metadataFilter struct {
+	patterns []*regexp.Regexp
+	invert   bool
+}
+
+func (f *metadataFilter) Include(key string) bool {
+	for _, re := range f.patterns {
+		if re.MatchString(key) {
+			return !f.invert
+		}
+	}
+	return f.invert
+}
+
+// message is a spec.Message backed by a Benthos message.
+type message struct {
+	msg *service.Message
+}
+
+func (m *message) SetMetadata(key string, value any) {
+	m.msg.MetaSetMut(key, value)
+}
+
+func (m *message) SetRaw(b []byte) {
+	m.msg.SetBytes(b)
+}
+
+func (m *message) Raw() ([]byte, error) {
+	return m.msg.AsBytes()
+}
+
+func (m *message) Metadata() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		_ = m.msg.MetaWalkMut(func(key string, value any) error {
+			if !yield(key, value) {
+				return errStopWalk
+			}
+			return nil
+		})
+	}
+}
+
+// batch is the spec.Batch created by the component context.
+type batch struct {
+	msgs []spec.Message
+}
+
+func (b *batch) Messages() iter.Seq2[int, spec.Message] {
+	return func(yield func(int, spec.Message) bool) {
+		for idx, msg := range b.msgs {
+			if !yield(idx, msg) {
+				return
+			}
+		}
+	}
+}
+
+func (b *batch) Append(msg spec.Message) {
+	b.msgs = append(b.msgs, msg)
+}
@@ -0,0 +1,127 @@
+package benthos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// noDataDelay is how long ReadBatch waits before reading again when an input had no data
+const noDataDelay = 100 * time.Millisecond
+
+// NewBatchInput wraps an input as a Benthos batch input. The system is optional; when given, it is connected before
+// the input is initialized and closed after the input.
+//
+// The ProcessedCallback of a batch becomes its Benthos ack function. When Benthos reports that only some messages of
+// the batch failed, the callback receives a spec.BatchError for the indexes of the batch the input returned.
+func NewBatchInput(sys spec.System, input spec.Input, log *service.Logger) service.BatchInput {
+	return &batchInput{
+		conn: newConnection(sys, log, input),
+		read: func(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
+			return input.Read(ctx)
+		},
+	}
+}
+
+// NewTriggerInput wraps a trigger input and the retrieval processor fetching the data of its triggers as a single
+// Benthos batch input. The batch is acknowledged through the callback of the retrieval processor first and the one
+// of the trigger input afterwards.
+//
+// Since the retrieval processor may skip triggers, a spec.BatchError is only passed on to the trigger input as is
+// when every trigger resulted in a message. Otherwise all triggers of the batch are considered failed.
+func NewTriggerInput(sys spec.System, trigger spec.TriggerInput, retrieval spec.RetrievalProcessor, log *service.Logger) service.BatchInput {
+	return &batchInput{
+		conn: newConnection(sys, log, trigger, retrieval),
+		read: func(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
+			return readTriggers(ctx, trigger, retrieval)
+		},
+	}
+}
+
+func readTriggers(ctx spec.ComponentContext, trigger spec.TriggerInput, retrieval spec.RetrievalProcessor) (spec.Batch, spec.ProcessedCallback, error) {
+	triggers, triggerCb, err := trigger.ReadTriggers(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, retrievalCb, err := retrieval.Retrieve(ctx, triggers)
+	if err != nil {
+		_ = triggerCb(ctx.Context(), err)
+		return nil, nil, err
+	}
+
+	var count int
+	for range b.Messages() {
+		count++
+	}
+
+	return b, func(ctx context.Context, err error) error {
+		// -- callbacks may hand back the error they were given, only report what the retrieval added to it
+		retrievalErr := retrievalCb(ctx, err)
+		if errors.Is(retrievalErr, err) {
+			retrievalErr = nil
+		}
+
+		var batchErr *spec.BatchError
+		if errors.As(err, &batchErr) && count != len(triggers.Triggers()) {
+			err = fmt.Errorf("%d of %d retrieved messages failed", batchErr.Len(), count)
+		}
+
+		return errors.Join(retrievalErr, triggerCb(ctx, err))
+	}, nil
+}
+
+type batchInput struct {
+	conn *connection
+	read func(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error)
+}
+
+func (i *batchInput) Connect(ctx context.Context) error {
+	return i.conn.connect(ctx)
+}
+
+func (i *batchInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	cctx := i.conn.componentContext(ctx)
+
+	for {
+		b, callback, err := i.read(cctx)
+		if errors.Is(err, spec.ErrNoData) {
+			select {
+			case <-time.After(noDataDelay):
+				continue
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+		if err != nil {
+			return nil, nil, toBenthosError(err, nil)
+		}
+
+		msgs, err := toBenthos(b)
+		if err != nil {
+			_ = callback(ctx, err)
+			return nil, nil, err
+		}
+
+		if len(msgs) == 0 {
+			// -- nothing to pass on, but the input still expects to hear back
+			if err := callback(ctx, nil); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		index := msgs.Index()
+		return msgs, func(ctx context.Context, err error) error {
+			return callback(ctx, toSpecError(err, index))
+		}, nil
+	}
+}
+
+func (i *batchInput) Close(ctx context.Context) error {
+	return i.conn.close(ctx)
+}
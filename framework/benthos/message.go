@@ -0,0 +1,90 @@
+package benthos
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+var errStopWalk = errors.New("stop walking metadata")
+
+// fromBenthos wraps a Benthos message batch, so components read and modify the Benthos messages in place.
+func fromBenthos(msgs service.MessageBatch) spec.Batch {
+	b := &batch{msgs: make([]spec.Message, len(msgs))}
+	for idx, msg := range msgs {
+		b.msgs[idx] = &message{msg: msg}
+	}
+	return b
+}
+
+// toBenthos converts a batch to a Benthos message batch. Messages backed by a Benthos message are passed on as is,
+// other messages are copied with their metadata.
+func toBenthos(b spec.Batch) (service.MessageBatch, error) {
+	var msgs service.MessageBatch
+	for idx, msg := range b.Messages() {
+		if m, ok := msg.(*message); ok {
+			msgs = append(msgs, m.msg)
+			continue
+		}
+
+		raw, err := msg.Raw()
+		if err != nil {
+			return nil, fmt.Errorf("message #%d: %w", idx, err)
+		}
+
+		converted := service.NewMessage(raw)
+		for key, value := range msg.Metadata() {
+			converted.MetaSetMut(key, value)
+		}
+		msgs = append(msgs, converted)
+	}
+	return msgs, nil
+}
+
+// toSpecError converts the error Benthos passes to an ack function. A Benthos batch error is converted to a
+// spec.BatchError with the indexes of the batch the input returned, so the input only redelivers the messages which
+// failed.
+func toSpecError(err error, index *service.Indexer) error {
+	var batchErr *service.BatchError
+	if err == nil || !errors.As(err, &batchErr) {
+		return err
+	}
+
+	result := spec.NewBatchError(nil)
+	batchErr.WalkMessagesIndexedBy(index, func(idx int, _ *service.Message, msgErr error) bool {
+		if msgErr != nil && idx >= 0 {
+			result.Failed(idx, msgErr)
+		}
+		return true
+	})
+
+	if result.Len() == 0 {
+		return err
+	}
+	return result
+}
+
+// toBenthosError converts an error returned by a component to the error Benthos expects.
+func toBenthosError(err error, msgs service.MessageBatch) error {
+	var batchErr *spec.BatchError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &batchErr) && msgs != nil:
+		result := service.NewBatchError(msgs, err)
+		for _, idx := range batchErr.Indexes() {
+			if idx >= 0 && idx < len(msgs) {
+				result.Failed(idx, batchErr.IndexErr(idx))
+			}
+		}
+		return result
+	case errors.Is(err, spec.ErrNotConnected):
+		return service.ErrNotConnected
+	case errors.Is(err, spec.ErrEndOfInput):
+		return service.ErrEndOfInput
+	default:
+		return err
+	}
+}
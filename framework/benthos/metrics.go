@@ -0,0 +1,41 @@
+package benthos
+
+import (
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// NewMetrics creates metrics reporting to the given Benthos metrics, so they are exported along with the metrics of
+// the stream.
+func NewMetrics(m *service.Metrics) spec.Metrics {
+	return &metrics{m: m}
+}
+
+type metrics struct {
+	m *service.Metrics
+}
+
+func (m *metrics) Counter(name string) spec.Counter {
+	return counter{m.m.NewCounter(name)}
+}
+
+func (m *metrics) Gauge(name string) spec.Gauge {
+	return gauge{m.m.NewGauge(name)}
+}
+
+func (m *metrics) Timer(name string) spec.Timer {
+	return timer{m.m.NewTimer(name)}
+}
+
+type counter struct{ c *service.MetricCounter }
+
+func (c counter) Inc(delta int64) { c.c.Incr(delta) }
+
+type gauge struct{ g *service.MetricGauge }
+
+func (g gauge) Set(value float64) { g.g.SetFloat64(value) }
+
+// timer records durations given in seconds as the nanoseconds Benthos timers expect.
+type timer struct{ t *service.MetricTimer }
+
+func (t timer) Record(duration float64) { t.t.Timing(int64(duration * 1e9)) }
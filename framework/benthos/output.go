@@ -0,0 +1,37 @@
+package benthos
+
+import (
+	"context"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// NewBatchOutput wraps an output as a Benthos batch output. The system is optional; when given, it is connected
+// before the output is initialized and closed after the output.
+//
+// A spec.BatchError returned by Write becomes a Benthos batch error, so Benthos only retries the messages which
+// failed.
+func NewBatchOutput(sys spec.System, output spec.Output, log *service.Logger) service.BatchOutput {
+	return &batchOutput{
+		conn:   newConnection(sys, log, output),
+		output: output,
+	}
+}
+
+type batchOutput struct {
+	conn   *connection
+	output spec.Output
+}
+
+func (o *batchOutput) Connect(ctx context.Context) error {
+	return o.conn.connect(ctx)
+}
+
+func (o *batchOutput) WriteBatch(ctx context.Context, msgs service.MessageBatch) error {
+	return toBenthosError(o.output.Write(o.conn.componentContext(ctx), fromBenthos(msgs)), msgs)
+}
+
+func (o *batchOutput) Close(ctx context.Context) error {
+	return o.conn.close(ctx)
+}
@@ -0,0 +1,60 @@
+package benthos
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// NewBatchProcessor wraps a processor as a Benthos batch processor. The processor is initialized when the first
+// batch arrives.
+//
+// Benthos doesn't tell processors when a batch was delivered, so the ProcessedCallback of the processor is called
+// as soon as the processed batch was handed to Benthos, with the error of the conversion if it failed.
+func NewBatchProcessor(processor spec.Processor, log *service.Logger) service.BatchProcessor {
+	return &batchProcessor{
+		conn:      newConnection(nil, log, processor),
+		processor: processor,
+	}
+}
+
+type batchProcessor struct {
+	conn      *connection
+	processor spec.Processor
+
+	initOnce sync.Once
+	initErr  error
+}
+
+func (p *batchProcessor) ProcessBatch(ctx context.Context, msgs service.MessageBatch) ([]service.MessageBatch, error) {
+	p.initOnce.Do(func() {
+		p.initErr = p.conn.connect(ctx)
+	})
+	if p.initErr != nil {
+		return nil, p.initErr
+	}
+
+	b, callback, err := p.processor.Process(p.conn.componentContext(ctx), fromBenthos(msgs))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := toBenthos(b)
+	if callback != nil {
+		_ = callback(ctx, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return []service.MessageBatch{result}, nil
+}
+
+func (p *batchProcessor) Close(ctx context.Context) error {
+	return p.conn.close(ctx)
+}
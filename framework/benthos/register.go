@@ -0,0 +1,151 @@
+// Package benthos runs WombatWisdom components inside Benthos and Redpanda Connect. Inputs, outputs, processors and
+// trigger-retrieval pairs are wrapped as Benthos batch plugins: ProcessedCallbacks become ack functions, messages
+// are backed by Benthos messages with their metadata, and the configuration schema of a spec.ComponentSpec becomes
+// the Benthos config spec of the plugin. The connection state of the system of a plugin is exported with the metrics
+// of the stream, as <plugin>_connection_state, <plugin>_connection_lost and <plugin>_reconnects.
+//
+// Register the components with the Benthos environment of your deployment before building the streams:
+//
+//	env := service.NewEnvironment()
+//	err := benthos.RegisterInput(env, generateSpec, nil, func(env spec.Environment, _ spec.System, cfg spec.Config) (spec.Input, error) {
+//		return generate.NewInputFromConfig(env, cfg)
+//	})
+package benthos
+
+import (
+	"github.com/redpanda-data/benthos/v4/public/service"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// Constructor creates a component of a plugin from its configuration, without the system field. The system is nil
+// for plugins registered without a system constructor.
+type Constructor[T spec.Component] func(env spec.Environment, sys spec.System, cfg spec.Config) (T, error)
+
+// RegisterInput registers the input described by the component spec as a Benthos batch input. The system constructor
+// is optional; when given, the system is created from the system field of the plugin configuration.
+func RegisterInput(env *service.Environment, cs spec.ComponentSpec, newSystem spec.SystemConstructor, newInput Constructor[spec.Input]) error {
+	conf, err := componentConfigSpec(cs, cs.InputConfigSchema(), newSystem != nil)
+	if err != nil {
+		return err
+	}
+
+	return env.RegisterBatchInput(cs.Name(), conf, func(pConf *service.ParsedConfig, res *service.Resources) (service.BatchInput, error) {
+		sys, cfg, err := construct(cs.Name(), pConf, res, newSystem)
+		if err != nil {
+			return nil, err
+		}
+
+		input, err := newInput(NewEnvironment(res.Logger()), sys, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewBatchInput(sys, input, res.Logger()), nil
+	})
+}
+
+// RegisterTriggerInput registers a trigger input together with the retrieval processor fetching the data of its
+// triggers as a single Benthos batch input, configured by the input schema of the component spec. Both constructors
+// receive the whole plugin configuration.
+func RegisterTriggerInput(env *service.Environment, cs spec.ComponentSpec, newSystem spec.SystemConstructor, newTrigger Constructor[spec.TriggerInput], newRetrieval Constructor[spec.RetrievalProcessor]) error {
+	conf, err := componentConfigSpec(cs, cs.InputConfigSchema(), newSystem != nil)
+	if err != nil {
+		return err
+	}
+
+	return env.RegisterBatchInput(cs.Name(), conf, func(pConf *service.ParsedConfig, res *service.Resources) (service.BatchInput, error) {
+		sys, cfg, err := construct(cs.Name(), pConf, res, newSystem)
+		if err != nil {
+			return nil, err
+		}
+
+		trigger, err := newTrigger(NewEnvironment(res.Logger()), sys, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		retrieval, err := newRetrieval(NewEnvironment(res.Logger()), sys, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewTriggerInput(sys, trigger, retrieval, res.Logger()), nil
+	})
+}
+
+// RegisterOutput registers the output described by the component spec as a Benthos batch output, writing one batch
+// at a time. The system constructor is optional; when given, the system is created from the system field of the
+// plugin configuration.
+func RegisterOutput(env *service.Environment, cs spec.ComponentSpec, newSystem spec.SystemConstructor, newOutput Constructor[spec.Output]) error {
+	conf, err := componentConfigSpec(cs, cs.OutputConfigSchema(), newSystem != nil)
+	if err != nil {
+		return err
+	}
+
+	return env.RegisterBatchOutput(cs.Name(), conf, func(pConf *service.ParsedConfig, res *service.Resources) (service.BatchOutput, service.BatchPolicy, int, error) {
+		sys, cfg, err := construct(cs.Name(), pConf, res, newSystem)
+		if err != nil {
+			return nil, service.BatchPolicy{}, 0, err
+		}
+
+		output, err := newOutput(NewEnvironment(res.Logger()), sys, cfg)
+		if err != nil {
+			return nil, service.BatchPolicy{}, 0, err
+		}
+
+		return NewBatchOutput(sys, output, res.Logger()), service.BatchPolicy{}, 1, nil
+	})
+}
+
+// RegisterProcessor registers a processor as a Benthos batch processor with the given name, configured by the given
+// JSON schema. Processors don't use a system, the constructor receives nil.
+func RegisterProcessor(env *service.Environment, name string, schema string, newProcessor Constructor[spec.Processor]) error {
+	conf, err := NewConfigSpec(schema)
+	if err != nil {
+		return err
+	}
+
+	return env.RegisterBatchProcessor(name, conf, func(pConf *service.ParsedConfig, res *service.Resources) (service.BatchProcessor, error) {
+		cfg, err := Config(pConf)
+		if err != nil {
+			return nil, err
+		}
+
+		processor, err := newProcessor(NewEnvironment(res.Logger()), nil, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewBatchProcessor(processor, res.Logger()), nil
+	})
+}
+
+// construct creates the system of a plugin, if it has one, and returns the configuration of its component. The system
+// is wrapped in a spec.Supervisor reporting its connection state to the metrics of the stream, under the name of the
+// plugin. Benthos retries connecting itself, so the supervisor makes a single attempt per call.
+func construct(name string, pConf *service.ParsedConfig, res *service.Resources, newSystem spec.SystemConstructor) (spec.System, spec.Config, error) {
+	cfg, err := Config(pConf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if newSystem == nil {
+		return nil, cfg, nil
+	}
+
+	sysCfg, err := SystemConfig(pConf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sys, err := newSystem(sysCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return spec.NewSupervisor(sys, res.Logger(), spec.SupervisorConfig{
+		Name:        name,
+		MaxAttempts: 1,
+		Metrics:     NewMetrics(res.Metrics()),
+	}), cfg, nil
+}
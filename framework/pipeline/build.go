@@ -0,0 +1,222 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// stage is a constructed component of a pipeline.
+type stage struct {
+	// path identifies the component within the configuration, e.g. pipeline.processors.0.compress
+	path      string
+	plugin    *registry.Plugin
+	sys       spec.System
+	component spec.Component
+}
+
+// builder constructs the components of a pipeline, collecting the problems of all of them.
+type builder struct {
+	reg     *registry.Registry
+	env     spec.Environment
+	connect ConnectConfig
+	errs    []error
+}
+
+func (b *builder) fail(path string, err error) {
+	b.errs = append(b.errs, fmt.Errorf("%s: %w", path, err))
+}
+
+// pipeline builds the input, retrieval, processors and output of the configuration.
+func (b *builder) pipeline(cfg *Config) *Pipeline {
+	p := &Pipeline{env: b.env}
+
+	if cfg.Input == nil {
+		b.fail("input", fmt.Errorf("an input is required"))
+	} else {
+		p.input = b.stage("input", *cfg.Input, registry.KindInput, registry.KindTriggerInput)
+	}
+
+	trigger := p.input != nil && p.input.plugin.Kind == registry.KindTriggerInput
+	switch {
+	case cfg.Retrieval != nil:
+		p.retrieval = b.stage("retrieval", *cfg.Retrieval, registry.KindRetrieval)
+		if p.input != nil && !trigger {
+			b.fail("retrieval", fmt.Errorf("%s is not a trigger input, it doesn't use a retrieval", p.input.plugin.Name))
+		}
+	case trigger:
+		b.fail("retrieval", fmt.Errorf("trigger input %s requires a retrieval", p.input.plugin.Name))
+	}
+
+	p.processors = b.processors(cfg)
+
+	if cfg.Output == nil {
+		b.fail("output", fmt.Errorf("an output is required"))
+	} else {
+		p.output = b.stage("output", *cfg.Output, registry.KindOutput)
+	}
+
+	return p
+}
+
+func (b *builder) processors(cfg *Config) []*stage {
+	var result []*stage
+	for idx, c := range cfg.Pipeline.Processors {
+		result = append(result, b.stage(fmt.Sprintf("pipeline.processors.%d", idx), c, registry.KindProcessor))
+	}
+	return result
+}
+
+// stage validates the configuration of a component and constructs it. It returns nil if the component isn't
+// registered as one of the given kinds. The component is only constructed when its configuration is valid.
+func (b *builder) stage(path string, c Component, kinds ...registry.Kind) *stage {
+	var plugin *registry.Plugin
+	for _, kind := range kinds {
+		if p, ok := b.reg.Lookup(kind, c.Name); ok {
+			plugin = p
+			break
+		}
+	}
+	if plugin == nil {
+		b.fail(path, fmt.Errorf("unknown %s %s", kindNames(kinds), c.Name))
+		return nil
+	}
+
+	s := &stage{path: path + "." + c.Name, plugin: plugin}
+
+	errs := len(b.errs)
+	b.validate(s, c.Config)
+	b.expressions(s.path, c.Config)
+	if len(b.errs) > errs {
+		return s
+	}
+
+	cfg := maps.Clone(c.Config)
+	delete(cfg, registry.SystemField)
+
+	if plugin.HasSystem() {
+		sysCfg, _ := c.Config[registry.SystemField].(map[string]any)
+		sys, err := plugin.NewSystem(spec.NewMapConfig(sysCfg))
+		if err != nil {
+			b.fail(s.path+"."+registry.SystemField, err)
+			return s
+		}
+		s.sys = b.supervise(s.path, sys)
+	}
+
+	component, err := plugin.New(b.env, s.sys, spec.NewMapConfig(cfg))
+	if err != nil {
+		b.fail(s.path, err)
+		return s
+	}
+	s.component = component
+	return s
+}
+
+// supervise wraps the system of a component in a supervisor, which connects it as configured and logs when its
+// connection is lost and restored.
+func (b *builder) supervise(path string, sys spec.System) spec.System {
+	maxAttempts := 1
+	if b.connect.MaxAttempts != nil {
+		maxAttempts = *b.connect.MaxAttempts
+	}
+
+	sup := spec.NewSupervisor(sys, b.env, spec.SupervisorConfig{
+		Name:        path,
+		Backoff:     b.connect.Backoff,
+		MaxAttempts: maxAttempts,
+	})
+	sup.Observe(func(evt spec.ConnectionEvent) {
+		switch {
+		case evt.State == spec.StateReconnecting:
+			b.env.Warnf("%s: connection lost, reconnecting: %v", path, evt.Err)
+		case evt.Reconnected():
+			b.env.Infof("%s: connection restored", path)
+		}
+	})
+	return sup
+}
+
+// validate checks the configuration of a component against its schema.
+func (b *builder) validate(s *stage, cfg map[string]any) {
+	schema, err := s.plugin.ConfigSchema()
+	if err != nil {
+		b.fail(s.path, err)
+		return
+	}
+
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(schema), gojsonschema.NewGoLoader(cfg))
+	if err != nil {
+		b.fail(s.path, fmt.Errorf("failed to validate config: %w", err))
+		return
+	}
+
+	for _, e := range result.Errors() {
+		field := e.Field()
+		if field == "(root)" {
+			b.fail(s.path, errors.New(e.Description()))
+		} else {
+			b.fail(s.path+"."+field, errors.New(e.Description()))
+		}
+	}
+}
+
+// expressions compiles the expressions in the configuration of a component, the strings containing ${! ... }.
+func (b *builder) expressions(path string, value any) {
+	switch v := value.(type) {
+	case string:
+		if strings.Contains(v, "${!") {
+			if _, err := spec.NewExprLangExpression(v); err != nil {
+				b.fail(path, err)
+			}
+		}
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			b.expressions(path+"."+key, v[key])
+		}
+	case []any:
+		for idx, item := range v {
+			b.expressions(fmt.Sprintf("%s.%d", path, idx), item)
+		}
+	}
+}
+
+func kindNames(kinds []registry.Kind) string {
+	names := make([]string, len(kinds))
+	for idx, kind := range kinds {
+		names[idx] = strings.ReplaceAll(string(kind), "_", " ")
+	}
+	return strings.Join(names, " or ")
+}
+
+// Build validates the configuration and constructs the components of the pipeline, without connecting them. All
+// problems found are returned as a single error.
+func Build(reg *registry.Registry, env spec.Environment, cfg *Config) (*Pipeline, error) {
+	b := &builder{reg: reg, env: env, connect: cfg.Connect}
+	p := b.pipeline(cfg)
+	if err := errors.Join(b.errs...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Lint checks a pipeline configuration without running it. The configuration of every component is validated
+// against its schema, the expressions in it are compiled and the component is constructed. Tests are checked for a
+// name, they are run by RunTests.
+func Lint(reg *registry.Registry, env spec.Environment, cfg *Config) []error {
+	b := &builder{reg: reg, env: env, connect: cfg.Connect}
+	b.pipeline(cfg)
+
+	for idx, test := range cfg.Tests {
+		if test.Name == "" {
+			b.fail(fmt.Sprintf("tests.%d", idx), fmt.Errorf("a name is required"))
+		}
+	}
+	return b.errs
+}
@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/wombatwisdom/components/framework/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a pipeline configuration. A pipeline reads batches from its input, passes them through its processors
+// in order and writes them to its output:
+//
+//	input:
+//	  generate:
+//	    interval: 1s
+//	    mapping: 'hello'
+//	pipeline:
+//	  processors:
+//	    - compress:
+//	        algorithm: gzip
+//	output:
+//	  nats_core:
+//	    subject: greetings
+//	    system:
+//	      url: nats://localhost:4222
+//
+// When the input is a trigger input, the retrieval processor fetching the data of its triggers is configured as the
+// retrieval of the pipeline.
+type Config struct {
+	Input     *Component `yaml:"input"`
+	Retrieval *Component `yaml:"retrieval,omitempty"`
+	Pipeline  Processors `yaml:"pipeline,omitempty"`
+	Output    *Component `yaml:"output"`
+
+	// Connect controls how the systems of the components are connected.
+	Connect ConnectConfig `yaml:"connect,omitempty"`
+
+	// Tests run sample messages through the processors of the pipeline, see RunTests.
+	Tests []Test `yaml:"tests,omitempty"`
+}
+
+// ConnectConfig controls how the systems of the components are connected. Every system is wrapped in a
+// spec.Supervisor, which retries connecting it and follows its connection being lost and restored:
+//
+//	connect:
+//	  max_attempts: 10
+//	  backoff:
+//	    initial: 1s
+//	    max: 1m
+type ConnectConfig struct {
+	// MaxAttempts limits the attempts to connect a system before the pipeline fails. Zero keeps trying until the
+	// pipeline is stopped. Defaults to 1.
+	MaxAttempts *int `yaml:"max_attempts,omitempty"`
+
+	// Backoff is the schedule of the attempts to connect a system. Defaults to spec.DefaultBackoff.
+	Backoff *spec.Backoff `yaml:"backoff,omitempty"`
+}
+
+// Processors holds the processors of a pipeline.
+type Processors struct {
+	Processors []Component `yaml:"processors"`
+}
+
+// Component is a component of a pipeline, configured as a single key with the name of the component holding its
+// configuration. The system of the component, if it uses one, is configured by the system field.
+type Component struct {
+	Name   string
+	Config map[string]any
+}
+
+func (c *Component) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode || len(node.Content) != 2 {
+		return fmt.Errorf("line %d: a component is configured by a single key with its name", node.Line)
+	}
+
+	if err := node.Content[0].Decode(&c.Name); err != nil {
+		return err
+	}
+
+	c.Config = map[string]any{}
+	if value := node.Content[1]; value.Tag != "!!null" {
+		if err := value.Decode(&c.Config); err != nil {
+			return fmt.Errorf("line %d: %s: %w", value.Line, c.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c Component) MarshalYAML() (any, error) {
+	return map[string]any{c.Name: c.Config}, nil
+}
+
+// Test is a unit test of the processors of a pipeline. The input messages are processed as a single batch, after
+// which the resulting messages are compared with the expected ones.
+type Test struct {
+	Name     string        `yaml:"name"`
+	Input    []TestMessage `yaml:"input"`
+	Expected []TestMessage `yaml:"expected"`
+}
+
+// TestMessage is a message of a test. For expected messages, a missing content isn't checked and only the metadata
+// listed is compared.
+type TestMessage struct {
+	Content  *string        `yaml:"content,omitempty"`
+	Metadata map[string]any `yaml:"metadata,omitempty"`
+}
+
+// ParseConfig parses a pipeline configuration from YAML.
+func ParseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid pipeline config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// LoadConfig reads a pipeline configuration from a YAML file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,90 @@
+package pipeline_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/pipeline"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("Config", func() {
+	It("should parse the components by their name", func() {
+		cfg := parse(`
+input:
+  memory:
+    channel: in
+pipeline:
+  processors:
+    - upper:
+    - upper: {key: id}
+output:
+  memory:
+    channel: out
+    system:
+      url: memory://
+`)
+		Expect(cfg.Input.Name).To(Equal("memory"))
+		Expect(cfg.Input.Config).To(HaveKeyWithValue("channel", "in"))
+		Expect(cfg.Pipeline.Processors).To(HaveLen(2))
+		Expect(cfg.Pipeline.Processors[0].Config).To(BeEmpty())
+		Expect(cfg.Pipeline.Processors[1].Config).To(HaveKeyWithValue("key", "id"))
+		Expect(cfg.Output.Config).To(HaveKeyWithValue("system", HaveKeyWithValue("url", "memory://")))
+	})
+
+	It("should refuse a component with more than one name", func() {
+		_, err := pipeline.ParseConfig([]byte(`
+input:
+  memory: {channel: in}
+  other: {}
+`))
+		Expect(err).To(MatchError(ContainSubstring("a component is configured by a single key")))
+	})
+})
+
+var _ = Describe("Lint", func() {
+	It("should report every problem of the configuration", func() {
+		reg := newRegistry(test.NewMemoryBroker(), &listTrigger{})
+
+		errs := pipeline.Lint(reg, test.TestEnvironment(), parse(`
+input:
+  memory:
+    channel: in
+    batch_size: 0
+retrieval:
+  reference: {}
+pipeline:
+  processors:
+    - upper:
+        key: '${! 1 + }'
+    - unknown: {}
+tests:
+  - input: []
+`))
+		Expect(errs).To(ConsistOf(
+			MatchError(ContainSubstring("input.memory.batch_size: Must be greater than or equal to 1")),
+			MatchError("retrieval: memory is not a trigger input, it doesn't use a retrieval"),
+			MatchError(ContainSubstring("pipeline.processors.0.upper.key: failed to compile expression")),
+			MatchError("pipeline.processors.1: unknown processor unknown"),
+			MatchError("output: an output is required"),
+			MatchError("tests.0: a name is required"),
+		))
+	})
+
+	It("should accept a valid configuration", func() {
+		reg := newRegistry(test.NewMemoryBroker(), &listTrigger{})
+
+		Expect(pipeline.Lint(reg, test.TestEnvironment(), parse(`
+input:
+  list: {}
+retrieval:
+  reference: {}
+pipeline:
+  processors:
+    - upper:
+        key: 'orders.${! metadata.region }'
+output:
+  memory:
+    channel: out
+`))).To(BeEmpty())
+	})
+})
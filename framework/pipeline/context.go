@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// NewLogger creates a spec.Logger writing to the given slog logger.
+func NewLogger(log *slog.Logger) spec.Logger {
+	return &logger{log: log}
+}
+
+type logger struct {
+	log *slog.Logger
+}
+
+func (l *logger) Debugf(format string, args ...interface{}) {
+	l.log.Debug(fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Infof(format string, args ...interface{}) {
+	l.log.Info(fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Warnf(format string, args ...interface{}) {
+	l.log.Warn(fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Errorf(format string, args ...interface{}) {
+	l.log.Error(fmt.Sprintf(format, args...))
+}
+
+// with returns a logger adding the given attributes to every record, when the logger is backed by slog.
+func with(log spec.Logger, args ...any) spec.Logger {
+	switch l := log.(type) {
+	case *logger:
+		return &logger{log: l.log.With(args...)}
+	case *environment:
+		return with(l.Logger, args...)
+	}
+	return log
+}
+
+// NewEnvironment creates an environment logging to the given logger and reading its values from the environment
+// variables of the process.
+func NewEnvironment(log spec.Logger) spec.Environment {
+	return &environment{Logger: log}
+}
+
+type environment struct {
+	spec.Logger
+}
+
+func (e *environment) GetString(key string) string {
+	return os.Getenv(key)
+}
+
+func (e *environment) GetInt(key string) int {
+	v, _ := strconv.Atoi(os.Getenv(key))
+	return v
+}
+
+func (e *environment) GetBool(key string) bool {
+	v, _ := strconv.ParseBool(os.Getenv(key))
+	return v
+}
+
+// newComponentContext creates the context passed to a component of the pipeline.
+func newComponentContext(ctx context.Context, log spec.Logger) spec.ComponentContext {
+	return &componentContext{Logger: log, ctx: ctx}
+}
+
+type componentContext struct {
+	spec.Logger
+	ctx context.Context
+}
+
+func (c *componentContext) Context() context.Context {
+	return c.ctx
+}
+
+func (c *componentContext) NewBatch(msgs ...spec.Message) spec.Batch {
+	return &batch{msgs: msgs}
+}
+
+func (c *componentContext) NewMessage() spec.Message {
+	return spec.NewBytesMessage(nil)
+}
+
+func (c *componentContext) BuildMetadataFilter(patterns []string, invert bool) (spec.MetadataFilter, error) {
+	regexes := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		regexes = append(regexes, re)
+	}
+
+	return &metadataFilter{patterns: regexes, invert: invert}, nil
+}
+
+type metadataFilter struct {
+	patterns []*regexp.Regexp
+	invert   bool
+}
+
+func (f *metadataFilter) Include(key string) bool {
+	for _, re := range f.patterns {
+		if re.MatchString(key) {
+			return !f.invert
+		}
+	}
+	return f.invert
+}
+
+// batch is the spec.Batch created by the component context.
+type batch struct {
+	msgs []spec.Message
+}
+
+func (b *batch) Messages() iter.Seq2[int, spec.Message] {
+	return func(yield func(int, spec.Message) bool) {
+		for idx, msg := range b.msgs {
+			if !yield(idx, msg) {
+				return
+			}
+		}
+	}
+}
+
+func (b *batch) Append(msg spec.Message) {
+	b.msgs = append(b.msgs, msg)
+}
+
+// messages returns the messages of a batch, which may be nil.
+func messages(b spec.Batch) []spec.Message {
+	if b == nil {
+		return nil
+	}
+
+	var result []spec.Message
+	for _, msg := range b.Messages() {
+		result = append(result, msg)
+	}
+	return result
+}
@@ -0,0 +1,228 @@
+package pipeline_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/pipeline"
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+func TestPipeline(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pipeline Suite")
+}
+
+const memorySchema = `{
+	"type": "object",
+	"properties": {
+		"channel": {"type": "string"},
+		"batch_size": {"type": "integer", "minimum": 1}
+	},
+	"required": ["channel"],
+	"additionalProperties": false
+}`
+
+type memoryConfig struct {
+	Channel   string `mapstructure:"channel"`
+	BatchSize int    `mapstructure:"batch_size"`
+}
+
+// newRegistry creates a registry with in-memory inputs and outputs using the broker, processors uppercasing
+// payloads, with and without a system, and a trigger input emitting the given references once.
+func newRegistry(broker *test.MemoryBroker, trigger *listTrigger) *registry.Registry {
+	reg := registry.New()
+
+	Expect(reg.RegisterInput(registry.Spec{Name: "memory", Summary: "Reads from a memory channel.", Schema: memorySchema}, nil,
+		func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Input, error) {
+			var c memoryConfig
+			if err := cfg.Decode(&c); err != nil {
+				return nil, err
+			}
+			return broker.NewInput(c.Channel, test.MemoryInputConfig{BatchSize: c.BatchSize, MaxDeliveries: 1}), nil
+		})).To(Succeed())
+
+	Expect(reg.RegisterOutput(registry.Spec{Name: "memory", Summary: "Writes to a memory channel.", Schema: memorySchema}, nil,
+		func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Output, error) {
+			var c memoryConfig
+			if err := cfg.Decode(&c); err != nil {
+				return nil, err
+			}
+			return &rejectingOutput{broker.NewOutput(c.Channel)}, nil
+		})).To(Succeed())
+
+	Expect(reg.RegisterProcessor(registry.Spec{Name: "upper", Schema: `{"type": "object", "properties": {"key": {"type": "string"}}}`},
+		func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Processor, error) {
+			var c struct {
+				Key spec.Expression `mapstructure:"key"`
+			}
+			if err := cfg.Decode(&c); err != nil {
+				return nil, err
+			}
+			return &upperProcessor{key: c.Key}, nil
+		})).To(Succeed())
+
+	Expect(reg.RegisterProcessorWithSystem(registry.Spec{Name: "lookup"},
+		func(cfg spec.Config) (spec.System, error) {
+			sys := &flakySystem{}
+			if err := cfg.Decode(sys); err != nil {
+				return nil, err
+			}
+			return sys, nil
+		},
+		func(_ spec.Environment, _ spec.System, _ spec.Config) (spec.Processor, error) {
+			return &upperProcessor{}, nil
+		})).To(Succeed())
+
+	Expect(reg.RegisterTriggerInput(registry.Spec{Name: "list"}, nil,
+		func(_ spec.Environment, _ spec.System, _ spec.Config) (spec.TriggerInput, error) {
+			return trigger, nil
+		})).To(Succeed())
+
+	Expect(reg.RegisterRetrieval(registry.Spec{Name: "reference"}, nil,
+		func(_ spec.Environment, _ spec.System, _ spec.Config) (spec.RetrievalProcessor, error) {
+			return &referenceRetrieval{}, nil
+		})).To(Succeed())
+
+	return reg
+}
+
+// upperProcessor uppercases payloads, and sets the key metadata to its expression if it has one
+type upperProcessor struct {
+	key spec.Expression
+}
+
+func (p *upperProcessor) Init(ctx spec.ComponentContext) error  { return nil }
+func (p *upperProcessor) Close(ctx spec.ComponentContext) error { return nil }
+
+func (p *upperProcessor) Process(ctx spec.ComponentContext, batch spec.Batch) (spec.Batch, spec.ProcessedCallback, error) {
+	for _, msg := range batch.Messages() {
+		raw, err := msg.Raw()
+		if err != nil {
+			return nil, nil, err
+		}
+		if bytes.Contains(raw, []byte("fail")) {
+			return nil, nil, fmt.Errorf("can't process %s", raw)
+		}
+
+		if p.key != nil {
+			key, err := p.key.Eval(spec.MessageExpressionContext(msg))
+			if err != nil {
+				return nil, nil, err
+			}
+			msg.SetMetadata("key", key)
+		}
+		msg.SetRaw(bytes.ToUpper(raw))
+	}
+	return batch, spec.NoopCallback, nil
+}
+
+// flakySystem fails to connect the configured number of times before it connects
+type flakySystem struct {
+	Failures int `mapstructure:"failures"`
+	attempts int
+}
+
+func (s *flakySystem) Connect(ctx context.Context) error {
+	s.attempts++
+	if s.attempts <= s.Failures {
+		return fmt.Errorf("attempt %d failed", s.attempts)
+	}
+	return nil
+}
+
+func (s *flakySystem) Close(ctx context.Context) error { return nil }
+func (s *flakySystem) Client() any                     { return nil }
+
+// rejectingOutput fails the messages containing "reject" permanently and writes the others to the channel
+type rejectingOutput struct {
+	*test.MemoryOutput
+}
+
+func (o *rejectingOutput) Write(ctx spec.ComponentContext, batch spec.Batch) error {
+	batchErr := spec.NewBatchError(nil)
+	accepted := ctx.NewBatch()
+	for idx, msg := range batch.Messages() {
+		raw, _ := msg.Raw()
+		if bytes.Contains(bytes.ToLower(raw), []byte("reject")) {
+			batchErr.Failed(idx, fmt.Errorf("%w: rejected", spec.ErrPermanent))
+			continue
+		}
+		accepted.Append(msg)
+	}
+
+	if err := o.MemoryOutput.Write(ctx, accepted); err != nil {
+		return err
+	}
+	if batchErr.Len() > 0 {
+		return batchErr
+	}
+	return nil
+}
+
+// listTrigger emits a single batch with a trigger for each of its references and records the outcome, along with
+// the processed batch its callback was given
+type listTrigger struct {
+	references []string
+	read       bool
+	acked      chan error
+	processed  spec.Batch
+}
+
+func (t *listTrigger) Init(ctx spec.ComponentContext) error  { return nil }
+func (t *listTrigger) Close(ctx spec.ComponentContext) error { return nil }
+
+func (t *listTrigger) ReadTriggers(ctx spec.ComponentContext) (spec.TriggerBatch, spec.ProcessedCallback, error) {
+	if t.read {
+		return nil, nil, spec.ErrEndOfInput
+	}
+	t.read = true
+
+	batch := spec.NewTriggerBatch()
+	for _, reference := range t.references {
+		batch.Append(spec.NewTriggerEvent(spec.TriggerSourceGenerate, reference, nil))
+	}
+	return batch, func(ctx context.Context, err error) error {
+		t.processed, _ = spec.ProcessedBatch(ctx)
+		t.acked <- err
+		return nil
+	}, nil
+}
+
+// referenceRetrieval retrieves a message with the reference of every trigger as its payload
+type referenceRetrieval struct{}
+
+func (r *referenceRetrieval) Init(ctx spec.ComponentContext) error  { return nil }
+func (r *referenceRetrieval) Close(ctx spec.ComponentContext) error { return nil }
+
+func (r *referenceRetrieval) Retrieve(ctx spec.ComponentContext, triggers spec.TriggerBatch) (spec.Batch, spec.ProcessedCallback, error) {
+	batch := ctx.NewBatch()
+	for _, trigger := range triggers.Triggers() {
+		msg := ctx.NewMessage()
+		msg.SetRaw([]byte(trigger.Reference()))
+		batch.Append(msg)
+	}
+	return batch, spec.NoopCallback, nil
+}
+
+func payloads(msgs []spec.Message) []string {
+	var result []string
+	for _, msg := range msgs {
+		raw, err := msg.Raw()
+		Expect(err).ToNot(HaveOccurred())
+		result = append(result, string(raw))
+	}
+	return result
+}
+
+func parse(yaml string) *pipeline.Config {
+	cfg, err := pipeline.ParseConfig([]byte(yaml))
+	Expect(err).ToNot(HaveOccurred())
+	return cfg
+}
@@ -0,0 +1,240 @@
+// Package pipeline runs pipelines described by YAML configurations, built from the components of a registry. A
+// pipeline reads batches from an input, or from a trigger input and its retrieval processor, passes them through its
+// processors and writes them to its output. Once a batch was written, or failed, the callbacks of the processors and
+// the input are called with the outcome, so the input can acknowledge or redeliver its messages.
+//
+// Configurations can embed unit tests for their processors, which RunTests runs against sample messages.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	// noDataDelay is the time to wait before reading again from an input which had no data.
+	noDataDelay = 100 * time.Millisecond
+
+	// retryDelay is the time to wait before reading again after the input failed.
+	retryDelay = time.Second
+
+	// drainTimeout bounds how long the input may take to finish the batches in flight when the pipeline stops.
+	drainTimeout = 10 * time.Second
+)
+
+// Pipeline is a pipeline built from a configuration.
+type Pipeline struct {
+	env        spec.Environment
+	input      *stage
+	retrieval  *stage
+	processors []*stage
+	output     *stage
+}
+
+// Run connects the systems of the components, initializes the components and moves batches from the input to the
+// output until the input ends or the context is cancelled. Batches are handled one at a time. The components are
+// closed when Run returns, the input is drained first if it supports it.
+func (p *Pipeline) Run(ctx context.Context) error {
+	started, err := p.start(ctx)
+	defer p.stop(context.WithoutCancel(ctx), started)
+	if err != nil {
+		return err
+	}
+
+	for {
+		b, callback, err := p.read(ctx)
+		switch {
+		case err == nil:
+			p.deliver(ctx, b, callback)
+			continue
+		case errors.Is(err, spec.ErrEndOfInput):
+			p.env.Infof("input %s has ended", p.input.path)
+			return nil
+		case ctx.Err() != nil:
+			return nil
+		case errors.Is(err, spec.ErrNoData):
+			wait(ctx, noDataDelay)
+		case errors.Is(err, spec.ErrNotConnected):
+			p.env.Warnf("input %s is not connected, reconnecting", p.input.path)
+			wait(ctx, retryDelay)
+			p.reconnect(ctx)
+		default:
+			p.env.Errorf("failed to read from input %s: %v", p.input.path, err)
+			wait(ctx, retryDelay)
+		}
+	}
+}
+
+// stages returns the stages of the pipeline from the output to the input, the order in which they are started.
+func (p *Pipeline) stages() []*stage {
+	result := []*stage{p.output}
+	for i := len(p.processors) - 1; i >= 0; i-- {
+		result = append(result, p.processors[i])
+	}
+	if p.retrieval != nil {
+		result = append(result, p.retrieval)
+	}
+	return append(result, p.input)
+}
+
+// start connects the systems and initializes the components, downstream first. It returns the stages which were
+// started, to be stopped again.
+func (p *Pipeline) start(ctx context.Context) ([]*stage, error) {
+	var started []*stage
+	for _, s := range p.stages() {
+		if s.sys != nil {
+			// -- the system is supervised, its error already names the component
+			if err := s.sys.Connect(ctx); err != nil && !errors.Is(err, spec.ErrAlreadyConnected) {
+				return started, err
+			}
+		}
+
+		if err := s.component.Init(p.componentContext(ctx, s)); err != nil && !errors.Is(err, spec.ErrAlreadyConnected) {
+			if s.sys != nil {
+				_ = s.sys.Close(ctx)
+			}
+			return started, fmt.Errorf("%s: failed to initialize: %w", s.path, err)
+		}
+		started = append(started, s)
+	}
+	return started, nil
+}
+
+// stop closes the started stages, upstream first, and the systems they use.
+func (p *Pipeline) stop(ctx context.Context, started []*stage) {
+	for i := len(started) - 1; i >= 0; i-- {
+		s := started[i]
+		sctx := p.componentContext(ctx, s)
+
+		var err error
+		if s == p.input {
+			err = spec.DrainAndClose(sctx, s.component, drainTimeout)
+		} else {
+			err = s.component.Close(sctx)
+		}
+		if err != nil {
+			p.env.Warnf("%s: failed to close: %v", s.path, err)
+		}
+
+		if s.sys != nil {
+			if err := s.sys.Close(ctx); err != nil {
+				p.env.Warnf("%s: failed to close system: %v", s.path, err)
+			}
+		}
+	}
+}
+
+// reconnect initializes the input again after it reported it isn't connected.
+func (p *Pipeline) reconnect(ctx context.Context) {
+	if err := p.input.component.Close(p.componentContext(ctx, p.input)); err != nil {
+		p.env.Debugf("%s: failed to close: %v", p.input.path, err)
+	}
+	if err := p.input.component.Init(p.componentContext(ctx, p.input)); err != nil && !errors.Is(err, spec.ErrAlreadyConnected) {
+		p.env.Errorf("%s: failed to initialize: %v", p.input.path, err)
+	}
+}
+
+func (p *Pipeline) componentContext(ctx context.Context, s *stage) spec.ComponentContext {
+	return newComponentContext(ctx, with(p.env, "component", s.path))
+}
+
+// read reads the next batch from the input, or the triggers from the trigger input and their data through the
+// retrieval processor.
+func (p *Pipeline) read(ctx context.Context) (spec.Batch, spec.ProcessedCallback, error) {
+	if p.retrieval == nil {
+		return p.input.component.(spec.Input).Read(p.componentContext(ctx, p.input))
+	}
+
+	triggers, triggerCb, err := p.input.component.(spec.TriggerInput).ReadTriggers(p.componentContext(ctx, p.input))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, retrievalCb, err := p.retrieval.component.(spec.RetrievalProcessor).Retrieve(p.componentContext(ctx, p.retrieval), triggers)
+	if err != nil {
+		_ = triggerCb(ctx, err)
+		return nil, nil, err
+	}
+
+	count := len(messages(b))
+	return b, func(ctx context.Context, err error) error {
+		// -- callbacks may hand back the error they were given, only report what the retrieval added to it
+		retrievalErr := retrievalCb(ctx, err)
+		if errors.Is(retrievalErr, err) {
+			retrievalErr = nil
+		}
+
+		return errors.Join(retrievalErr, triggerCb(ctx, collapse(err, count, len(triggers.Triggers()))))
+	}, nil
+}
+
+// deliver passes the batch through the processors and writes it to the output, after which the callbacks are called
+// with the outcome. The context of the callbacks carries the processed batch, see spec.ProcessedBatch.
+func (p *Pipeline) deliver(ctx context.Context, b spec.Batch, callback spec.ProcessedCallback) {
+	count := len(messages(b))
+	callbacks := []spec.ProcessedCallback{callback}
+
+	for _, s := range p.processors {
+		processed, cb, err := s.component.(spec.Processor).Process(p.componentContext(ctx, s), b)
+		if err != nil {
+			p.env.Errorf("%s: failed to process batch: %v", s.path, err)
+			p.settle(ctx, callbacks, err)
+			return
+		}
+		if cb != nil {
+			callbacks = append(callbacks, cb)
+		}
+
+		b = processed
+		if len(messages(b)) == 0 {
+			p.settle(spec.WithProcessedBatch(ctx, b), callbacks, nil)
+			return
+		}
+	}
+
+	err := p.output.component.(spec.Output).Write(p.componentContext(ctx, p.output), b)
+	if err != nil {
+		p.env.Errorf("%s: failed to write batch: %v", p.output.path, err)
+	}
+
+	// -- the messages of a batch error only match the read batch if the processors kept the messages as they were
+	p.settle(spec.WithProcessedBatch(ctx, b), callbacks, collapse(err, len(messages(b)), count))
+}
+
+// settle calls the callbacks of the processors, the last one first, and of the input with the outcome of a batch.
+// The callbacks are called even if the pipeline is stopping, so the batch can be acknowledged.
+func (p *Pipeline) settle(ctx context.Context, callbacks []spec.ProcessedCallback, err error) {
+	ctx = context.WithoutCancel(ctx)
+	for i := len(callbacks) - 1; i >= 0; i-- {
+		if callbacks[i] == nil {
+			continue
+		}
+
+		// -- callbacks may hand back the error they were given
+		if cbErr := callbacks[i](ctx, err); cbErr != nil && !errors.Is(cbErr, err) {
+			p.env.Warnf("failed to acknowledge batch: %v", cbErr)
+		}
+	}
+}
+
+// collapse replaces a spec.BatchError by an error failing the whole batch when the number of messages it refers to
+// doesn't match the number of messages the error is passed on for.
+func collapse(err error, got int, want int) error {
+	var batchErr *spec.BatchError
+	if errors.As(err, &batchErr) && got != want {
+		return fmt.Errorf("%d of %d messages failed", batchErr.Len(), got)
+	}
+	return err
+}
+
+// wait blocks for the given duration or until the context is done.
+func wait(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,230 @@
+package pipeline_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/pipeline"
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("Pipeline", func() {
+	var broker *test.MemoryBroker
+	var trigger *listTrigger
+	var reg *registry.Registry
+
+	BeforeEach(func() {
+		broker = test.NewMemoryBroker()
+		trigger = &listTrigger{acked: make(chan error, 1)}
+		reg = newRegistry(broker, trigger)
+	})
+
+	// run builds the pipeline and runs it until the spec ends, returning a channel with the result of Run
+	run := func(yaml string) <-chan error {
+		p, err := pipeline.Build(reg, test.TestEnvironment(), parse(yaml))
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			done <- p.Run(ctx)
+		}()
+
+		DeferCleanup(func() {
+			cancel()
+			Eventually(stopped, 5*time.Second).Should(BeClosed())
+		})
+		return done
+	}
+
+	write := func(channel string, payloads ...string) {
+		ctx := test.NewMockComponentContext()
+		output := broker.NewOutput(channel)
+		Expect(output.Init(ctx)).To(Succeed())
+
+		batch := ctx.NewBatch()
+		for _, payload := range payloads {
+			msg := ctx.NewMessage()
+			msg.SetRaw([]byte(payload))
+			msg.SetMetadata("region", "eu")
+			batch.Append(msg)
+		}
+		Expect(output.Write(ctx, batch)).To(Succeed())
+	}
+
+	It("should move batches from the input through the processors to the output", func() {
+		run(`
+input:
+  memory:
+    channel: in
+pipeline:
+  processors:
+    - upper:
+        key: 'orders.${! metadata.region }'
+output:
+  memory:
+    channel: out
+`)
+		write("in", "hello", "world")
+
+		out := broker.Channel("out")
+		Expect(out.WaitForWritten(2, 5*time.Second)).To(BeTrue())
+		Expect(payloads(out.Written())).To(ConsistOf("HELLO", "WORLD"))
+
+		var key any
+		for k, v := range out.Written()[0].Metadata() {
+			if k == "key" {
+				key = v
+			}
+		}
+		Expect(key).To(Equal("orders.eu"))
+
+		Expect(broker.Channel("in").WaitForAcked(2, 5*time.Second)).To(BeTrue())
+	})
+
+	It("should only fail the messages the output rejected", func() {
+		run(`
+input:
+  memory:
+    channel: in
+    batch_size: 3
+output:
+  memory:
+    channel: out
+`)
+		write("in", "one", "reject me", "three")
+
+		in := broker.Channel("in")
+		Expect(in.WaitForAcked(2, 5*time.Second)).To(BeTrue())
+		Eventually(func() []string { return payloads(in.Dropped()) }, 5*time.Second).Should(Equal([]string{"reject me"}))
+		Expect(payloads(broker.Channel("out").Written())).To(ConsistOf("one", "three"))
+	})
+
+	It("should fail the batch when a processor fails", func() {
+		run(`
+input:
+  memory:
+    channel: in
+pipeline:
+  processors:
+    - upper: {}
+output:
+  memory:
+    channel: out
+`)
+		write("in", "fail")
+
+		in := broker.Channel("in")
+		Eventually(func() []string { return payloads(in.Dropped()) }, 5*time.Second).Should(Equal([]string{"fail"}))
+		Expect(broker.Channel("out").Written()).To(BeEmpty())
+	})
+
+	It("should retrieve the data of triggers and stop when the trigger input ends", func() {
+		trigger.references = []string{"a.json", "reject b.json"}
+
+		done := run(`
+input:
+  list: {}
+retrieval:
+  reference: {}
+output:
+  memory:
+    channel: out
+`)
+
+		var err error
+		Eventually(trigger.acked, 5*time.Second).Should(Receive(&err))
+		Expect(spec.MessageError(err, 0)).ToNot(HaveOccurred())
+		Expect(spec.MessageError(err, 1)).To(MatchError(spec.ErrPermanent))
+		Expect(payloads(broker.Channel("out").Written())).To(Equal([]string{"a.json"}))
+
+		Eventually(done, 5*time.Second).Should(Receive(BeNil()))
+	})
+
+	It("should hand the processed batch to the callbacks", func() {
+		trigger.references = []string{"a.json", "b.json"}
+
+		run(`
+input:
+  list: {}
+retrieval:
+  reference: {}
+pipeline:
+  processors:
+    - upper: {}
+output:
+  memory:
+    channel: out
+`)
+
+		Eventually(trigger.acked, 5*time.Second).Should(Receive(BeNil()))
+		Expect(trigger.processed).ToNot(BeNil())
+
+		var processed []spec.Message
+		for _, msg := range trigger.processed.Messages() {
+			processed = append(processed, msg)
+		}
+		Expect(payloads(processed)).To(Equal([]string{"A.JSON", "B.JSON"}))
+	})
+
+	It("should retry connecting systems as configured", func() {
+		run(`
+input:
+  memory:
+    channel: in
+pipeline:
+  processors:
+    - lookup:
+        system:
+          failures: 2
+output:
+  memory:
+    channel: out
+connect:
+  max_attempts: 3
+  backoff:
+    initial: 1ms
+`)
+		write("in", "hello")
+
+		out := broker.Channel("out")
+		Expect(out.WaitForWritten(1, 5*time.Second)).To(BeTrue())
+		Expect(payloads(out.Written())).To(Equal([]string{"HELLO"}))
+	})
+
+	It("should fail when a system can't be connected", func() {
+		done := run(`
+input:
+  memory:
+    channel: in
+pipeline:
+  processors:
+    - lookup:
+        system:
+          failures: 1
+output:
+  memory:
+    channel: out
+`)
+		var err error
+		Eventually(done, 5*time.Second).Should(Receive(&err))
+		Expect(err).To(MatchError(ContainSubstring("failed to connect pipeline.processors.0.lookup after 1 attempts: attempt 1 failed")))
+	})
+
+	It("should refuse to build an invalid configuration", func() {
+		_, err := pipeline.Build(reg, test.TestEnvironment(), parse(`
+input:
+  list: {}
+output:
+  memory: {}
+`))
+		Expect(err).To(MatchError(ContainSubstring("retrieval: trigger input list requires a retrieval")))
+		Expect(err).To(MatchError(ContainSubstring("output.memory: channel is required")))
+	})
+})
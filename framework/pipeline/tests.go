@@ -0,0 +1,136 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// TestResult is the outcome of a test of a pipeline configuration.
+type TestResult struct {
+	Name string
+
+	// Failures describes the differences between the processed and the expected messages.
+	Failures []string
+}
+
+// Passed reports whether the processed messages matched the expected ones.
+func (r TestResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// RunTests runs the tests of a pipeline configuration. The messages of each test are processed as a single batch by
+// the processors of the pipeline, the input and output aren't used. Processing succeeds when the processed messages
+// have the expected content and metadata, in the same order.
+//
+// An error is returned when the processors can't be constructed or initialized.
+func RunTests(ctx context.Context, reg *registry.Registry, env spec.Environment, cfg *Config) ([]TestResult, error) {
+	b := &builder{reg: reg, env: env}
+	processors := b.processors(cfg)
+	if err := errors.Join(b.errs...); err != nil {
+		return nil, err
+	}
+
+	cctx := newComponentContext(ctx, env)
+
+	var initialized []*stage
+	defer func() {
+		for i := len(initialized) - 1; i >= 0; i-- {
+			_ = initialized[i].component.Close(cctx)
+		}
+	}()
+
+	for _, s := range processors {
+		if err := s.component.Init(cctx); err != nil {
+			return nil, fmt.Errorf("%s: failed to initialize: %w", s.path, err)
+		}
+		initialized = append(initialized, s)
+	}
+
+	var results []TestResult
+	for _, test := range cfg.Tests {
+		results = append(results, runTest(cctx, processors, test))
+	}
+	return results, nil
+}
+
+func runTest(cctx spec.ComponentContext, processors []*stage, test Test) TestResult {
+	result := TestResult{Name: test.Name}
+
+	b := cctx.NewBatch()
+	for _, m := range test.Input {
+		msg := cctx.NewMessage()
+		if m.Content != nil {
+			msg.SetRaw([]byte(*m.Content))
+		}
+		for key, value := range m.Metadata {
+			msg.SetMetadata(key, value)
+		}
+		b.Append(msg)
+	}
+
+	var callbacks []spec.ProcessedCallback
+	defer func() {
+		for i := len(callbacks) - 1; i >= 0; i-- {
+			_ = callbacks[i](cctx.Context(), nil)
+		}
+	}()
+
+	for _, s := range processors {
+		processed, cb, err := s.component.(spec.Processor).Process(cctx, b)
+		if err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("%s: %v", s.path, err))
+			return result
+		}
+		if cb != nil {
+			callbacks = append(callbacks, cb)
+		}
+		b = processed
+	}
+
+	actual := messages(b)
+	if len(actual) != len(test.Expected) {
+		result.Failures = append(result.Failures, fmt.Sprintf("expected %d messages, got %d", len(test.Expected), len(actual)))
+		return result
+	}
+
+	for idx, expected := range test.Expected {
+		result.Failures = append(result.Failures, compare(idx, expected, actual[idx])...)
+	}
+	return result
+}
+
+// compare returns the differences between an expected and a processed message.
+func compare(idx int, expected TestMessage, actual spec.Message) []string {
+	var failures []string
+
+	if expected.Content != nil {
+		raw, err := actual.Raw()
+		switch {
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("message %d: failed to read content: %v", idx, err))
+		case string(raw) != *expected.Content:
+			failures = append(failures, fmt.Sprintf("message %d: expected content %q, got %q", idx, *expected.Content, raw))
+		}
+	}
+
+	metadata := maps.Collect(actual.Metadata())
+	for _, key := range slices.Sorted(maps.Keys(expected.Metadata)) {
+		want := expected.Metadata[key]
+		got, ok := metadata[key]
+		switch {
+		case !ok:
+			failures = append(failures, fmt.Sprintf("message %d: expected metadata %s to be %v, it is missing", idx, key, want))
+		// -- values are compared by their text, YAML doesn't tell strings and other scalars apart the way components do
+		case fmt.Sprint(got) != fmt.Sprint(want):
+			failures = append(failures, fmt.Sprintf("message %d: expected metadata %s to be %v, got %v", idx, key, want, got))
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,70 @@
+package pipeline_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/pipeline"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("RunTests", func() {
+	It("should compare the processed messages with the expected ones", func() {
+		reg := newRegistry(test.NewMemoryBroker(), &listTrigger{})
+
+		results, err := pipeline.RunTests(context.Background(), reg, test.TestEnvironment(), parse(`
+pipeline:
+  processors:
+    - upper:
+        key: '${! metadata.region + "-" + metadata.id }'
+tests:
+  - name: uppercases
+    input:
+      - content: hello
+        metadata: {region: eu, id: "1"}
+    expected:
+      - content: HELLO
+        metadata: {key: eu-1, region: eu}
+  - name: wrong expectations
+    input:
+      - content: hello
+        metadata: {region: eu, id: "1"}
+    expected:
+      - content: hello
+        metadata: {key: us-1, missing: true}
+  - name: wrong count
+    input:
+      - content: hello
+        metadata: {region: eu, id: "2"}
+    expected: []
+  - name: failing processor
+    input:
+      - content: fail
+    expected:
+      - content: FAIL
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(4))
+
+		Expect(results[0].Passed()).To(BeTrue(), "%v", results[0].Failures)
+		Expect(results[1].Failures).To(ConsistOf(
+			`message 0: expected content "hello", got "HELLO"`,
+			"message 0: expected metadata key to be us-1, got eu-1",
+			"message 0: expected metadata missing to be true, it is missing",
+		))
+		Expect(results[2].Failures).To(ConsistOf("expected 0 messages, got 1"))
+		Expect(results[3].Failures).To(ConsistOf(ContainSubstring("can't process fail")))
+	})
+
+	It("should fail when the processors can't be constructed", func() {
+		reg := newRegistry(test.NewMemoryBroker(), &listTrigger{})
+
+		_, err := pipeline.RunTests(context.Background(), reg, test.TestEnvironment(), parse(`
+pipeline:
+  processors:
+    - unknown: {}
+`))
+		Expect(err).To(MatchError(ContainSubstring("unknown processor unknown")))
+	})
+})
@@ -0,0 +1,244 @@
+// Package registry keeps track of the components which can be used in pipeline configurations. Bundles register
+// their components together with the JSON schema of their configuration, so tools like the ww command can list
+// them, validate configurations and construct the components by name.
+//
+// Bundles expose a Register function adding their components to a registry:
+//
+//	reg := registry.New()
+//	if err := generate.Register(reg); err != nil {
+//		return err
+//	}
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// Kind is the role a component plays in a pipeline.
+type Kind string
+
+const (
+	KindInput        Kind = "input"
+	KindTriggerInput Kind = "trigger_input"
+	KindRetrieval    Kind = "retrieval"
+	KindProcessor    Kind = "processor"
+	KindOutput       Kind = "output"
+)
+
+// Kinds lists the kinds of components in the order they appear in a pipeline.
+var Kinds = []Kind{KindInput, KindTriggerInput, KindRetrieval, KindProcessor, KindOutput}
+
+// SystemField is the field of a component configuration holding the configuration of its system.
+const SystemField = "system"
+
+// Constructor creates a component from its configuration, without the system field. The system is nil for
+// components registered without a system constructor.
+type Constructor[T spec.Component] func(env spec.Environment, sys spec.System, cfg spec.Config) (T, error)
+
+// Spec describes a registered component.
+type Spec struct {
+	// Name is the name the component is configured by. Names are unique per kind.
+	Name string
+
+	// Summary is a single line describing the component.
+	Summary string
+
+	// Description is the documentation of the component.
+	Description string
+
+	// Schema is the JSON schema of the component configuration.
+	Schema string
+
+	// SystemSchema is the JSON schema of the system configuration, for components using a system.
+	SystemSchema string
+}
+
+// InputSpec returns the spec of the input described by a spec.ComponentSpec.
+func InputSpec(cs spec.ComponentSpec) Spec {
+	return Spec{
+		Name:         cs.Name(),
+		Summary:      cs.Summary(),
+		Description:  cs.Description(),
+		Schema:       cs.InputConfigSchema(),
+		SystemSchema: cs.SystemConfigSchema(),
+	}
+}
+
+// OutputSpec returns the spec of the output described by a spec.ComponentSpec.
+func OutputSpec(cs spec.ComponentSpec) Spec {
+	return Spec{
+		Name:         cs.Name(),
+		Summary:      cs.Summary(),
+		Description:  cs.Description(),
+		Schema:       cs.OutputConfigSchema(),
+		SystemSchema: cs.SystemConfigSchema(),
+	}
+}
+
+// Plugin is a registered component.
+type Plugin struct {
+	Kind Kind
+	Spec
+
+	newSystem    spec.SystemConstructor
+	newComponent Constructor[spec.Component]
+}
+
+// HasSystem reports whether the component uses a system, configured by the system field.
+func (p *Plugin) HasSystem() bool {
+	return p.newSystem != nil
+}
+
+// NewSystem creates the system of the component from the system field of its configuration.
+func (p *Plugin) NewSystem(cfg spec.Config) (spec.System, error) {
+	if p.newSystem == nil {
+		return nil, fmt.Errorf("%s %s doesn't use a system", p.Kind, p.Name)
+	}
+	return p.newSystem(cfg)
+}
+
+// New creates the component. The system is nil for components which don't use one.
+func (p *Plugin) New(env spec.Environment, sys spec.System, cfg spec.Config) (spec.Component, error) {
+	return p.newComponent(env, sys, cfg)
+}
+
+// ConfigSchema returns the JSON schema of the whole component configuration, with the system schema as the system
+// property for components using a system.
+func (p *Plugin) ConfigSchema() (map[string]any, error) {
+	root := map[string]any{}
+	if p.Schema != "" {
+		if err := json.Unmarshal([]byte(p.Schema), &root); err != nil {
+			return nil, fmt.Errorf("%s %s: invalid config schema: %w", p.Kind, p.Name, err)
+		}
+	}
+	if _, ok := root["type"]; !ok {
+		root["type"] = "object"
+	}
+
+	if !p.HasSystem() {
+		return root, nil
+	}
+
+	system := map[string]any{"type": "object"}
+	if p.SystemSchema != "" {
+		if err := json.Unmarshal([]byte(p.SystemSchema), &system); err != nil {
+			return nil, fmt.Errorf("%s %s: invalid system schema: %w", p.Kind, p.Name, err)
+		}
+	}
+
+	properties, _ := root["properties"].(map[string]any)
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	properties[SystemField] = system
+	root["properties"] = properties
+	return root, nil
+}
+
+// Registry holds the registered components by kind and name. It is safe for concurrent use.
+type Registry struct {
+	lock    sync.RWMutex
+	plugins map[Kind]map[string]*Plugin
+}
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{plugins: map[Kind]map[string]*Plugin{}}
+}
+
+// RegisterInput registers an input. The system constructor is optional.
+func (r *Registry) RegisterInput(s Spec, newSystem spec.SystemConstructor, newInput Constructor[spec.Input]) error {
+	return r.register(KindInput, s, newSystem, erase(newInput))
+}
+
+// RegisterTriggerInput registers a trigger input. Pipelines using it configure a retrieval processor to fetch the
+// data of its triggers. The system constructor is optional.
+func (r *Registry) RegisterTriggerInput(s Spec, newSystem spec.SystemConstructor, newTrigger Constructor[spec.TriggerInput]) error {
+	return r.register(KindTriggerInput, s, newSystem, erase(newTrigger))
+}
+
+// RegisterRetrieval registers a retrieval processor. The system constructor is optional.
+func (r *Registry) RegisterRetrieval(s Spec, newSystem spec.SystemConstructor, newRetrieval Constructor[spec.RetrievalProcessor]) error {
+	return r.register(KindRetrieval, s, newSystem, erase(newRetrieval))
+}
+
+// RegisterProcessor registers a processor which doesn't use a system, the constructor receives nil.
+func (r *Registry) RegisterProcessor(s Spec, newProcessor Constructor[spec.Processor]) error {
+	return r.register(KindProcessor, s, nil, erase(newProcessor))
+}
+
+// RegisterProcessorWithSystem registers a processor which uses a system, like one looking up data in a remote store.
+func (r *Registry) RegisterProcessorWithSystem(s Spec, newSystem spec.SystemConstructor, newProcessor Constructor[spec.Processor]) error {
+	if newSystem == nil {
+		return fmt.Errorf("%s %s: a system constructor is required", KindProcessor, s.Name)
+	}
+	return r.register(KindProcessor, s, newSystem, erase(newProcessor))
+}
+
+// RegisterOutput registers an output. The system constructor is optional.
+func (r *Registry) RegisterOutput(s Spec, newSystem spec.SystemConstructor, newOutput Constructor[spec.Output]) error {
+	return r.register(KindOutput, s, newSystem, erase(newOutput))
+}
+
+func (r *Registry) register(kind Kind, s Spec, newSystem spec.SystemConstructor, newComponent Constructor[spec.Component]) error {
+	if s.Name == "" {
+		return fmt.Errorf("%s: a name is required", kind)
+	}
+
+	plugin := &Plugin{Kind: kind, Spec: s, newSystem: newSystem, newComponent: newComponent}
+	if _, err := plugin.ConfigSchema(); err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.plugins[kind] == nil {
+		r.plugins[kind] = map[string]*Plugin{}
+	}
+	if _, ok := r.plugins[kind][s.Name]; ok {
+		return fmt.Errorf("%s %s is already registered", kind, s.Name)
+	}
+	r.plugins[kind][s.Name] = plugin
+	return nil
+}
+
+// Lookup returns the component of the given kind and name.
+func (r *Registry) Lookup(kind Kind, name string) (*Plugin, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	plugin, ok := r.plugins[kind][name]
+	return plugin, ok
+}
+
+// Plugins returns the registered components, ordered by kind and name.
+func (r *Registry) Plugins() []*Plugin {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var result []*Plugin
+	for _, kind := range Kinds {
+		for _, name := range slices.Sorted(maps.Keys(r.plugins[kind])) {
+			result = append(result, r.plugins[kind][name])
+		}
+	}
+	return result
+}
+
+// erase turns the constructor of a specific component type into one returning a spec.Component.
+func erase[T spec.Component](fn Constructor[T]) Constructor[spec.Component] {
+	return func(env spec.Environment, sys spec.System, cfg spec.Config) (spec.Component, error) {
+		component, err := fn(env, sys, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return component, nil
+	}
+}
@@ -0,0 +1,126 @@
+package registry_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+// fakeSystem records the configuration it was created with
+type fakeSystem struct {
+	cfg map[string]any
+}
+
+func (s *fakeSystem) Connect(ctx context.Context) error { return nil }
+func (s *fakeSystem) Close(ctx context.Context) error   { return nil }
+func (s *fakeSystem) Client() any                       { return s.cfg }
+
+var _ = Describe("Registry", func() {
+	var reg *registry.Registry
+	var broker *test.MemoryBroker
+
+	newOutput := func(_ spec.Environment, _ spec.System, _ spec.Config) (spec.Output, error) {
+		return broker.NewOutput("out"), nil
+	}
+
+	BeforeEach(func() {
+		reg = registry.New()
+		broker = test.NewMemoryBroker()
+	})
+
+	It("should construct registered components with their system", func() {
+		Expect(reg.RegisterInput(registry.Spec{
+			Name:         "memory",
+			Schema:       `{"type": "object", "properties": {"channel": {"type": "string"}}}`,
+			SystemSchema: `{"type": "object", "properties": {"url": {"type": "string"}}}`,
+		}, func(cfg spec.Config) (spec.System, error) {
+			sys := &fakeSystem{}
+			return sys, cfg.Decode(&sys.cfg)
+		}, func(_ spec.Environment, sys spec.System, cfg spec.Config) (spec.Input, error) {
+			Expect(sys.Client()).To(HaveKeyWithValue("url", "memory://"))
+
+			var c struct{ Channel string }
+			Expect(cfg.Decode(&c)).To(Succeed())
+			return broker.NewInput(c.Channel, test.MemoryInputConfig{}), nil
+		})).To(Succeed())
+
+		plugin, ok := reg.Lookup(registry.KindInput, "memory")
+		Expect(ok).To(BeTrue())
+		Expect(plugin.HasSystem()).To(BeTrue())
+
+		sys, err := plugin.NewSystem(spec.NewMapConfig(map[string]any{"url": "memory://"}))
+		Expect(err).ToNot(HaveOccurred())
+
+		input, err := plugin.New(test.TestEnvironment(), sys, spec.NewMapConfig(map[string]any{"channel": "in"}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.(*test.MemoryInput).Channel().Name()).To(Equal("in"))
+
+		_, ok = reg.Lookup(registry.KindOutput, "memory")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should include the system schema in the config schema", func() {
+		Expect(reg.RegisterOutput(registry.Spec{
+			Name:         "memory",
+			Schema:       `{"type": "object", "properties": {"channel": {"type": "string"}}, "required": ["channel"]}`,
+			SystemSchema: `{"type": "object", "properties": {"url": {"type": "string"}}}`,
+		}, func(cfg spec.Config) (spec.System, error) {
+			return &fakeSystem{}, nil
+		}, newOutput)).To(Succeed())
+
+		plugin, _ := reg.Lookup(registry.KindOutput, "memory")
+		schema, err := plugin.ConfigSchema()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schema).To(HaveKeyWithValue("required", ConsistOf("channel")))
+		Expect(schema).To(HaveKeyWithValue("properties", HaveKey("channel")))
+		Expect(schema).To(HaveKeyWithValue("properties", HaveKeyWithValue(registry.SystemField, HaveKey("properties"))))
+	})
+
+	It("should pass the system to processors registered with one", func() {
+		newProcessor := func(_ spec.Environment, sys spec.System, _ spec.Config) (spec.Processor, error) {
+			Expect(sys).ToNot(BeNil())
+			return nil, nil
+		}
+
+		Expect(reg.RegisterProcessorWithSystem(registry.Spec{Name: "lookup"}, nil, newProcessor)).To(MatchError(ContainSubstring("system constructor is required")))
+		Expect(reg.RegisterProcessorWithSystem(registry.Spec{Name: "lookup"}, func(cfg spec.Config) (spec.System, error) {
+			return &fakeSystem{}, nil
+		}, newProcessor)).To(Succeed())
+
+		plugin, ok := reg.Lookup(registry.KindProcessor, "lookup")
+		Expect(ok).To(BeTrue())
+		Expect(plugin.HasSystem()).To(BeTrue())
+
+		sys, err := plugin.NewSystem(spec.NewMapConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = plugin.New(test.TestEnvironment(), sys, spec.NewMapConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should refuse to register a component twice", func() {
+		Expect(reg.RegisterOutput(registry.Spec{Name: "memory"}, nil, newOutput)).To(Succeed())
+		Expect(reg.RegisterOutput(registry.Spec{Name: "memory"}, nil, newOutput)).To(MatchError(ContainSubstring("already registered")))
+	})
+
+	It("should refuse an invalid schema", func() {
+		Expect(reg.RegisterOutput(registry.Spec{Name: "memory", Schema: `{`}, nil, newOutput)).To(MatchError(ContainSubstring("invalid config schema")))
+	})
+
+	It("should list the components by kind and name", func() {
+		Expect(reg.RegisterOutput(registry.Spec{Name: "b"}, nil, newOutput)).To(Succeed())
+		Expect(reg.RegisterOutput(registry.Spec{Name: "a"}, nil, newOutput)).To(Succeed())
+		Expect(reg.RegisterInput(registry.Spec{Name: "c"}, nil, func(_ spec.Environment, _ spec.System, _ spec.Config) (spec.Input, error) {
+			return broker.NewInput("in", test.MemoryInputConfig{}), nil
+		})).To(Succeed())
+
+		var names []string
+		for _, plugin := range reg.Plugins() {
+			names = append(names, string(plugin.Kind)+"/"+plugin.Name)
+		}
+		Expect(names).To(Equal([]string{"input/c", "output/a", "output/b"}))
+	})
+})
@@ -4,9 +4,26 @@ import "context"
 
 // ProcessedCallback is a function signature for a callback to be called after a
 // message or message batch has been processed. The provided error indicates whether the processing was successful.
+// Wrap the error with RedeliverAfter to ask the input to delay the redelivery of the failed messages.
 // TODO: add extra information on what happens if an error is returned
 type ProcessedCallback func(ctx context.Context, err error) error
 
 func NoopCallback(ctx context.Context, err error) error {
 	return err
 }
+
+type processedBatchKey struct{}
+
+// WithProcessedBatch returns a context carrying the batch as it was written to the output, for the callbacks of a
+// batch. Inputs which respond to their messages, like request/reply inputs, use it to respond with the result of
+// processing instead of the messages they read.
+func WithProcessedBatch(ctx context.Context, batch Batch) context.Context {
+	return context.WithValue(ctx, processedBatchKey{}, batch)
+}
+
+// ProcessedBatch returns the batch as it was written to the output, if the callback was given one. Processors may
+// have added or dropped messages, so the messages only match the ones that were read when their number does.
+func ProcessedBatch(ctx context.Context) (Batch, bool) {
+	batch, ok := ctx.Value(processedBatchKey{}).(Batch)
+	return batch, ok
+}
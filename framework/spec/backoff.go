@@ -0,0 +1,61 @@
+package spec
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff describes an exponential backoff schedule. The delay before attempt n (starting at 0) is
+// Initial * Multiplier^n, capped at Max, with up to Jitter of the delay randomly added or removed.
+type Backoff struct {
+	// Initial is the delay before the first retry. Defaults to 500ms.
+	Initial time.Duration `json:"initial,omitempty" yaml:"initial,omitempty" mapstructure:"initial,omitempty"`
+
+	// Max caps the delay between retries. Defaults to 30s.
+	Max time.Duration `json:"max,omitempty" yaml:"max,omitempty" mapstructure:"max,omitempty"`
+
+	// Multiplier is the factor the delay grows with after every attempt. Defaults to 2.
+	Multiplier float64 `json:"multiplier,omitempty" yaml:"multiplier,omitempty" mapstructure:"multiplier,omitempty"`
+
+	// Jitter is the fraction of the delay, between 0 and 1, which is randomized to avoid retries in lockstep.
+	Jitter float64 `json:"jitter,omitempty" yaml:"jitter,omitempty" mapstructure:"jitter,omitempty"`
+}
+
+// DefaultBackoff returns the backoff schedule used when none is configured.
+func DefaultBackoff() Backoff {
+	return Backoff{
+		Initial:    500 * time.Millisecond,
+		Max:        30 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+}
+
+// Delay returns the delay before the given attempt, starting at 0.
+func (b Backoff) Delay(attempt int) time.Duration {
+	def := DefaultBackoff()
+	if b.Initial <= 0 {
+		b.Initial = def.Initial
+	}
+	if b.Max <= 0 {
+		b.Max = def.Max
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = def.Multiplier
+	}
+
+	delay := float64(b.Initial)
+	for range max(attempt, 0) {
+		delay *= b.Multiplier
+		if delay >= float64(b.Max) {
+			delay = float64(b.Max)
+			break
+		}
+	}
+
+	if jitter := min(b.Jitter, 1); jitter > 0 {
+		delay += delay * jitter * (2*rand.Float64() - 1)
+	}
+
+	return min(time.Duration(delay), b.Max)
+}
@@ -0,0 +1,38 @@
+package spec_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+var _ = Describe("Backoff", func() {
+	It("should grow the delay exponentially up to the maximum", func() {
+		b := spec.Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+
+		Expect(b.Delay(0)).To(Equal(100 * time.Millisecond))
+		Expect(b.Delay(1)).To(Equal(200 * time.Millisecond))
+		Expect(b.Delay(3)).To(Equal(800 * time.Millisecond))
+		Expect(b.Delay(4)).To(Equal(time.Second))
+		Expect(b.Delay(1000)).To(Equal(time.Second))
+	})
+
+	It("should keep the jittered delay within bounds", func() {
+		b := spec.Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2, Jitter: 0.5}
+
+		for range 100 {
+			Expect(b.Delay(1)).To(BetweenInclusive(100*time.Millisecond, 300*time.Millisecond))
+			Expect(b.Delay(10)).To(BetweenInclusive(500*time.Millisecond, time.Second))
+		}
+	})
+
+	It("should fall back to the defaults for unset fields", func() {
+		Expect(spec.Backoff{}.Delay(0)).To(Equal(spec.DefaultBackoff().Initial))
+	})
+})
+
+func BetweenInclusive(low, high time.Duration) OmegaMatcher {
+	return SatisfyAll(BeNumerically(">=", low), BeNumerically("<=", high))
+}
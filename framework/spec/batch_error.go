@@ -0,0 +1,111 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// BatchError reports that only some of the messages of a batch failed. Outputs return it from Write when they
+// managed to deliver part of a batch, and the error is passed on to the ProcessedCallback of the input, which can
+// then acknowledge the messages which succeeded and only redeliver the ones which failed. Messages are identified by
+// their index within the batch.
+//
+// Components which don't know about BatchError treat it like any other error, failing the whole batch. Processors
+// which change the number or the order of the messages in a batch must not pass a BatchError on unchanged, since the
+// indexes would no longer match the batch the input produced.
+type BatchError struct {
+	err    error
+	failed map[int]error
+}
+
+// NewBatchError creates a BatchError with an optional error describing the failure as a whole. Use Failed to mark
+// the individual messages which failed.
+func NewBatchError(err error) *BatchError {
+	return &BatchError{
+		err:    err,
+		failed: make(map[int]error),
+	}
+}
+
+// Failed marks the message at the given index as failed with the given error.
+func (e *BatchError) Failed(index int, err error) *BatchError {
+	if err == nil {
+		err = errors.New("message failed")
+	}
+	e.failed[index] = err
+	return e
+}
+
+// Len returns the number of messages which failed.
+func (e *BatchError) Len() int {
+	return len(e.failed)
+}
+
+// Indexes returns the indexes of the messages which failed, in ascending order.
+func (e *BatchError) Indexes() []int {
+	indexes := make([]int, 0, len(e.failed))
+	for idx := range e.failed {
+		indexes = append(indexes, idx)
+	}
+	slices.Sort(indexes)
+	return indexes
+}
+
+// IndexErr returns the error of the message at the given index, or nil if it didn't fail.
+func (e *BatchError) IndexErr(index int) error {
+	return e.failed[index]
+}
+
+func (e *BatchError) Error() string {
+	var sb strings.Builder
+	if e.err != nil {
+		sb.WriteString(e.err.Error())
+		sb.WriteString(": ")
+	}
+
+	fmt.Fprintf(&sb, "%d messages failed", len(e.failed))
+	for i, idx := range e.Indexes() {
+		if i == 0 {
+			sb.WriteString(" (")
+		} else {
+			sb.WriteString("; ")
+		}
+		fmt.Fprintf(&sb, "#%d: %v", idx, e.failed[idx])
+	}
+	if len(e.failed) > 0 {
+		sb.WriteString(")")
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the overall error followed by the errors of the failed messages, so errors.Is and errors.As match
+// any of them.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.failed)+1)
+	if e.err != nil {
+		errs = append(errs, e.err)
+	}
+	for _, idx := range e.Indexes() {
+		errs = append(errs, e.failed[idx])
+	}
+	return errs
+}
+
+// MessageError returns the outcome for the message at the given index from the error a batch was processed with. If
+// err is, or wraps, a BatchError only the messages it marks as failed get an error. Any other error applies to all
+// messages of the batch.
+func MessageError(err error, index int) error {
+	if err == nil {
+		return nil
+	}
+
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return batchErr.IndexErr(index)
+	}
+
+	return err
+}
@@ -0,0 +1,46 @@
+package spec_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+var _ = Describe("BatchError", func() {
+	It("should report the failed indexes in order", func() {
+		err := spec.NewBatchError(errors.New("partial write")).
+			Failed(3, errors.New("too large")).
+			Failed(1, spec.ErrNotConnected)
+
+		Expect(err.Len()).To(Equal(2))
+		Expect(err.Indexes()).To(Equal([]int{1, 3}))
+		Expect(err.IndexErr(0)).To(BeNil())
+		Expect(err.IndexErr(3)).To(MatchError("too large"))
+		Expect(err.Error()).To(Equal("partial write: 2 messages failed (#1: not connected; #3: too large)"))
+	})
+
+	It("should match the errors of the failed messages", func() {
+		err := spec.NewBatchError(nil).Failed(0, spec.ErrNotConnected)
+		Expect(err).To(MatchError(spec.ErrNotConnected))
+	})
+
+	Describe("MessageError", func() {
+		It("should only fail the marked messages of a batch error", func() {
+			err := fmt.Errorf("output: %w", spec.NewBatchError(nil).Failed(1, errors.New("rejected")))
+
+			Expect(spec.MessageError(err, 0)).To(BeNil())
+			Expect(spec.MessageError(err, 1)).To(MatchError("rejected"))
+		})
+
+		It("should fail all messages for other errors", func() {
+			err := errors.New("failed")
+
+			Expect(spec.MessageError(err, 0)).To(MatchError(err))
+			Expect(spec.MessageError(err, 5)).To(MatchError(err))
+			Expect(spec.MessageError(nil, 0)).To(BeNil())
+		})
+	})
+})
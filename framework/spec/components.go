@@ -37,7 +37,8 @@ type Component interface {
 	Init(ctx ComponentContext) error
 
 	// Close releases any resources held by the component.
-	// This is called when the component is being shut down.
+	// This is called when the component is being shut down. Close doesn't wait for work in flight,
+	// components which support a graceful shutdown implement Drainer, see DrainAndClose.
 	Close(ctx ComponentContext) error
 }
 
@@ -1,6 +1,7 @@
 package spec
 
 import (
+	"reflect"
 	"strings"
 
 	mapstructure "github.com/go-viper/mapstructure/v2"
@@ -17,6 +18,9 @@ func NewYamlConfig(raw string, replacements ...string) Config {
 	}
 }
 
+// NewMapConfig creates a config decoding the given map with mapstructure. Strings are compiled into expressions for
+// the fields of type Expression and parsed as durations for the fields of type time.Duration, so configurations
+// loaded from YAML or JSON can hold them as plain strings.
 func NewMapConfig(raw map[string]any) Config {
 	return &mapConfig{
 		raw: raw,
@@ -40,5 +44,22 @@ type mapConfig struct {
 }
 
 func (c *mapConfig) Decode(target any) error {
-	return mapstructure.Decode(c.raw, target)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(stringToExpressionHook, mapstructure.StringToTimeDurationHookFunc()),
+		Result:     target,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(c.raw)
+}
+
+var expressionType = reflect.TypeFor[Expression]()
+
+// stringToExpressionHook compiles strings decoded into an Expression.
+func stringToExpressionHook(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != expressionType {
+		return data, nil
+	}
+	return NewExprLangExpression(data.(string))
 }
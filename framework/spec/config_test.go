@@ -1,6 +1,8 @@
 package spec_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/wombatwisdom/components/framework/spec"
@@ -194,5 +196,46 @@ invalid: [unclosed
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("expected type 'int'"))
 		})
+
+		It("should compile strings into expressions", func() {
+			cfg := spec.NewMapConfig(map[string]any{
+				"subject": `orders.${! region }`,
+				"headers": map[string]any{"source": "static"},
+			})
+
+			type TestConfig struct {
+				Subject spec.Expression            `mapstructure:"subject"`
+				Headers map[string]spec.Expression `mapstructure:"headers"`
+			}
+
+			var result TestConfig
+			Expect(cfg.Decode(&result)).To(Succeed())
+
+			subject, err := result.Subject.Eval(spec.ExpressionContext{"region": "eu"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subject).To(Equal("orders.eu"))
+
+			source, err := result.Headers["source"].Eval(spec.ExpressionContext{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(source).To(Equal("static"))
+		})
+
+		It("should parse strings into durations", func() {
+			cfg := spec.NewMapConfig(map[string]any{"initial": "250ms", "max": "1m"})
+
+			var result spec.Backoff
+			Expect(cfg.Decode(&result)).To(Succeed())
+			Expect(result.Initial).To(Equal(250 * time.Millisecond))
+			Expect(result.Max).To(Equal(time.Minute))
+		})
+
+		It("should fail on invalid expressions", func() {
+			cfg := spec.NewMapConfig(map[string]any{"subject": `${! 1 + }`})
+
+			var result struct {
+				Subject spec.Expression `mapstructure:"subject"`
+			}
+			Expect(cfg.Decode(&result)).To(MatchError(ContainSubstring("failed to compile expression")))
+		})
 	})
 })
@@ -0,0 +1,157 @@
+package spec
+
+import (
+	"sync"
+	"time"
+)
+
+// ConnectionState is the state of the connection a System maintains.
+type ConnectionState int
+
+const (
+	// StateDisconnected means the system hasn't connected yet, or failed to.
+	StateDisconnected ConnectionState = iota
+	// StateConnecting means the system is making its initial connection.
+	StateConnecting
+	// StateConnected means the connection is established.
+	StateConnected
+	// StateReconnecting means the connection was lost and the system is trying to restore it.
+	StateReconnecting
+	// StateClosed means the connection was closed and won't be restored.
+	StateClosed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// ConnectionEvent describes a change of the connection state.
+type ConnectionEvent struct {
+	// State is the new state of the connection.
+	State ConnectionState
+	// Previous is the state the connection was in before.
+	Previous ConnectionState
+	// Err is the reason for the change, if any.
+	Err error
+	// Time is when the state changed.
+	Time time.Time
+}
+
+// Reconnected reports whether the event marks a connection which was restored after it had been lost. Components
+// which hold server side state, like subscriptions or consumers, use it to re-establish that state.
+func (e ConnectionEvent) Reconnected() bool {
+	return e.State == StateConnected && e.Previous == StateReconnecting
+}
+
+// ConnectionObserver is implemented by systems which report the state of their connection.
+type ConnectionObserver interface {
+	// ConnectionState returns the current state of the connection.
+	ConnectionState() ConnectionState
+
+	// Observe registers fn to be called for every change of the connection state. The returned function removes the
+	// registration again. fn is called synchronously, so it should not block or change the state itself.
+	Observe(fn func(ConnectionEvent)) (stop func())
+}
+
+// NewConnectionStates creates a ConnectionObserver in the disconnected state. Systems use it to track the state of
+// their connection and notify the observers when it changes.
+func NewConnectionStates() *ConnectionStates {
+	return &ConnectionStates{
+		observers: make(map[int]func(ConnectionEvent)),
+	}
+}
+
+// ConnectionStates tracks the state of a connection and notifies the registered observers of its changes.
+type ConnectionStates struct {
+	state     ConnectionState
+	observers map[int]func(ConnectionEvent)
+	nextId    int
+	lock      sync.Mutex
+
+	// notify serializes the notifications, so observers see the changes in order
+	notify sync.Mutex
+}
+
+// Set changes the connection state. The observers are only notified if the state actually changed.
+func (c *ConnectionStates) Set(state ConnectionState, err error) {
+	c.notify.Lock()
+	defer c.notify.Unlock()
+
+	c.lock.Lock()
+	if c.state == state {
+		c.lock.Unlock()
+		return
+	}
+
+	evt := ConnectionEvent{State: state, Previous: c.state, Err: err, Time: time.Now()}
+	c.state = state
+
+	observers := make([]func(ConnectionEvent), 0, len(c.observers))
+	for _, fn := range c.observers {
+		observers = append(observers, fn)
+	}
+	c.lock.Unlock()
+
+	for _, fn := range observers {
+		fn(evt)
+	}
+}
+
+func (c *ConnectionStates) ConnectionState() ConnectionState {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.state
+}
+
+func (c *ConnectionStates) Observe(fn func(ConnectionEvent)) (stop func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	id := c.nextId
+	c.nextId++
+	c.observers[id] = fn
+
+	return func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		delete(c.observers, id)
+	}
+}
+
+// ConnectionMetrics returns an observer which reports the connection state of the named system through the given
+// metrics. It maintains the following metrics:
+//   - <name>_connection_state: a gauge holding the numeric ConnectionState
+//   - <name>_connection_lost: a counter of the times the connection was lost
+//   - <name>_reconnects: a counter of the times the connection was restored
+func ConnectionMetrics(metrics Metrics, name string) func(ConnectionEvent) {
+	state := metrics.Gauge(name + "_connection_state")
+	lost := metrics.Counter(name + "_connection_lost")
+	reconnects := metrics.Counter(name + "_reconnects")
+
+	return func(evt ConnectionEvent) {
+		state.Set(float64(evt.State))
+
+		if evt.State == StateReconnecting {
+			lost.Inc(1)
+		}
+
+		if evt.Reconnected() {
+			reconnects.Inc(1)
+		}
+	}
+}
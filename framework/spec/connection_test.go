@@ -0,0 +1,99 @@
+package spec_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+var _ = Describe("ConnectionStates", func() {
+	var states *spec.ConnectionStates
+	var events []spec.ConnectionEvent
+
+	BeforeEach(func() {
+		states = spec.NewConnectionStates()
+		events = nil
+		states.Observe(func(evt spec.ConnectionEvent) {
+			events = append(events, evt)
+		})
+	})
+
+	It("should start disconnected", func() {
+		Expect(states.ConnectionState()).To(Equal(spec.StateDisconnected))
+	})
+
+	It("should notify the observers of state changes", func() {
+		lost := errors.New("connection reset")
+
+		states.Set(spec.StateConnecting, nil)
+		states.Set(spec.StateConnected, nil)
+		states.Set(spec.StateReconnecting, lost)
+		states.Set(spec.StateConnected, nil)
+
+		Expect(events).To(HaveLen(4))
+		Expect(events[2].State).To(Equal(spec.StateReconnecting))
+		Expect(events[2].Err).To(MatchError(lost))
+		Expect(events[1].Reconnected()).To(BeFalse())
+		Expect(events[3].Reconnected()).To(BeTrue())
+		Expect(states.ConnectionState()).To(Equal(spec.StateConnected))
+	})
+
+	It("should not notify the observers if the state didn't change", func() {
+		states.Set(spec.StateConnected, nil)
+		states.Set(spec.StateConnected, nil)
+
+		Expect(events).To(HaveLen(1))
+	})
+
+	It("should stop notifying an observer once it is removed", func() {
+		var count int
+		stop := states.Observe(func(evt spec.ConnectionEvent) { count++ })
+
+		states.Set(spec.StateConnected, nil)
+		stop()
+		states.Set(spec.StateClosed, nil)
+
+		Expect(count).To(Equal(1))
+		Expect(events).To(HaveLen(2))
+	})
+
+	It("should report the state changes through metrics", func() {
+		metrics := &recordingMetrics{gauges: map[string]float64{}, counters: map[string]int64{}}
+		states.Observe(spec.ConnectionMetrics(metrics, "nats"))
+
+		states.Set(spec.StateConnected, nil)
+		states.Set(spec.StateReconnecting, errors.New("connection reset"))
+		states.Set(spec.StateConnected, nil)
+
+		Expect(metrics.gauges["nats_connection_state"]).To(Equal(float64(spec.StateConnected)))
+		Expect(metrics.counters["nats_connection_lost"]).To(Equal(int64(1)))
+		Expect(metrics.counters["nats_reconnects"]).To(Equal(int64(1)))
+	})
+})
+
+type recordingMetrics struct {
+	gauges   map[string]float64
+	counters map[string]int64
+}
+
+func (m *recordingMetrics) Counter(name string) spec.Counter {
+	return recordingCounter(func(delta int64) { m.counters[name] += delta })
+}
+
+func (m *recordingMetrics) Gauge(name string) spec.Gauge {
+	return recordingGauge(func(value float64) { m.gauges[name] = value })
+}
+
+func (m *recordingMetrics) Timer(name string) spec.Timer {
+	return nil
+}
+
+type recordingCounter func(delta int64)
+
+func (c recordingCounter) Inc(delta int64) { c(delta) }
+
+type recordingGauge func(value float64)
+
+func (g recordingGauge) Set(value float64) { g(value) }
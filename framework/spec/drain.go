@@ -0,0 +1,132 @@
+package spec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrDrainTimeout is returned when a component couldn't finish its outstanding work before the drain deadline.
+var ErrDrainTimeout = errors.New("drain deadline exceeded")
+
+// Drainer is implemented by components that can shut down gracefully. Drain stops the component from accepting new
+// work and waits until the work in flight has finished, or until ctx is done. For inputs this means Read no longer
+// returns new batches, while the ProcessedCallbacks of the batches already returned are still honoured so they can
+// be acknowledged or committed. Close is expected to be called once Drain returns, regardless of its outcome.
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// DrainAndClose drains the component if it implements Drainer and closes it afterwards. The drain is bounded by the
+// given timeout; a timeout of zero or less skips waiting for the work in flight. The component is always closed,
+// even if draining failed.
+func DrainAndClose(ctx ComponentContext, c Component, timeout time.Duration) error {
+	var drainErr error
+	if d, ok := c.(Drainer); ok {
+		drainCtx, cancel := context.WithTimeout(ctx.Context(), max(timeout, 0))
+		drainErr = d.Drain(drainCtx)
+		cancel()
+	}
+
+	return errors.Join(drainErr, c.Close(ctx))
+}
+
+// InFlight keeps track of the batches an input returned for which the ProcessedCallback hasn't been called yet. It
+// is used by inputs to implement Drainer.
+//
+// The zero value is not usable, use NewInFlight instead.
+type InFlight struct {
+	count    int
+	draining bool
+	idle     chan struct{}
+	lock     sync.Mutex
+}
+
+// NewInFlight creates a tracker without any work in flight.
+func NewInFlight() *InFlight {
+	idle := make(chan struct{})
+	close(idle)
+
+	return &InFlight{idle: idle}
+}
+
+// Track registers a batch as being in flight and returns a callback wrapping cb which releases the batch once it is
+// called. Calling the returned callback more than once only releases the batch the first time.
+func (f *InFlight) Track(cb ProcessedCallback) ProcessedCallback {
+	f.lock.Lock()
+	if f.count == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.count++
+	f.lock.Unlock()
+
+	var once sync.Once
+	return func(ctx context.Context, err error) error {
+		defer once.Do(f.release)
+
+		if cb == nil {
+			return nil
+		}
+		return cb(ctx, err)
+	}
+}
+
+func (f *InFlight) release() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.count--
+	if f.count == 0 {
+		close(f.idle)
+	}
+}
+
+// Count returns the number of batches in flight.
+func (f *InFlight) Count() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.count
+}
+
+// Drain marks the tracker as draining until Reset is called. Inputs check Draining before returning a new batch.
+func (f *InFlight) Drain() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.draining = true
+}
+
+// Reset clears the draining state, so an input which was drained and closed can be initialized again. Batches which
+// are still in flight remain tracked.
+func (f *InFlight) Reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.draining = false
+}
+
+// Draining reports whether Drain has been called.
+func (f *InFlight) Draining() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.draining
+}
+
+// Wait blocks until there are no batches in flight. If ctx is done before that, an error wrapping ErrDrainTimeout
+// is returned.
+func (f *InFlight) Wait(ctx context.Context) error {
+	f.lock.Lock()
+	idle := f.idle
+	f.lock.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %d batches still in flight", ErrDrainTimeout, f.Count())
+	}
+}
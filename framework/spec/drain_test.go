@@ -0,0 +1,132 @@
+package spec_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("InFlight", func() {
+	var inflight *spec.InFlight
+
+	BeforeEach(func() {
+		inflight = spec.NewInFlight()
+	})
+
+	It("should not wait when nothing is in flight", func() {
+		Expect(inflight.Wait(context.Background())).To(Succeed())
+	})
+
+	It("should wait until all callbacks have been called", func() {
+		first := inflight.Track(spec.NoopCallback)
+		second := inflight.Track(spec.NoopCallback)
+		Expect(inflight.Count()).To(Equal(2))
+
+		done := make(chan error, 1)
+		go func() { done <- inflight.Wait(context.Background()) }()
+
+		Expect(first(context.Background(), nil)).To(Succeed())
+		Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+
+		Expect(second(context.Background(), nil)).To(Succeed())
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("should only release a batch once", func() {
+		cb := inflight.Track(nil)
+		inflight.Track(nil)
+
+		Expect(cb(context.Background(), nil)).To(Succeed())
+		Expect(cb(context.Background(), nil)).To(Succeed())
+		Expect(inflight.Count()).To(Equal(1))
+	})
+
+	It("should pass the outcome to the wrapped callback", func() {
+		var received error
+		cb := inflight.Track(func(ctx context.Context, err error) error {
+			received = err
+			return errors.New("ack failed")
+		})
+
+		Expect(cb(context.Background(), errors.New("failed"))).To(MatchError("ack failed"))
+		Expect(received).To(MatchError("failed"))
+		Expect(inflight.Count()).To(BeZero())
+	})
+
+	It("should give up once the context is done", func() {
+		inflight.Track(spec.NoopCallback)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		Expect(inflight.Wait(ctx)).To(MatchError(spec.ErrDrainTimeout))
+	})
+
+	It("should report when it is draining", func() {
+		Expect(inflight.Draining()).To(BeFalse())
+		inflight.Drain()
+		Expect(inflight.Draining()).To(BeTrue())
+	})
+
+	It("should stop draining when reset, keeping the batches in flight", func() {
+		inflight.Track(spec.NoopCallback)
+		inflight.Drain()
+
+		inflight.Reset()
+		Expect(inflight.Draining()).To(BeFalse())
+		Expect(inflight.Count()).To(Equal(1))
+	})
+})
+
+var _ = Describe("DrainAndClose", func() {
+	It("should drain the component before closing it", func() {
+		c := &drainingComponent{}
+		Expect(spec.DrainAndClose(test.NewMockComponentContext(), c, time.Second)).To(Succeed())
+		Expect(c.calls).To(Equal([]string{"drain", "close"}))
+	})
+
+	It("should close the component even if draining failed", func() {
+		c := &drainingComponent{drainErr: spec.ErrDrainTimeout}
+		Expect(spec.DrainAndClose(test.NewMockComponentContext(), c, time.Second)).To(MatchError(spec.ErrDrainTimeout))
+		Expect(c.calls).To(Equal([]string{"drain", "close"}))
+	})
+
+	It("should only close components which can't be drained", func() {
+		c := &closingComponent{}
+		Expect(spec.DrainAndClose(test.NewMockComponentContext(), c, time.Second)).To(Succeed())
+		Expect(c.closed).To(BeTrue())
+	})
+})
+
+type closingComponent struct {
+	closed bool
+}
+
+func (c *closingComponent) Init(ctx spec.ComponentContext) error { return nil }
+
+func (c *closingComponent) Close(ctx spec.ComponentContext) error {
+	c.closed = true
+	return nil
+}
+
+type drainingComponent struct {
+	calls    []string
+	drainErr error
+}
+
+func (c *drainingComponent) Init(ctx spec.ComponentContext) error { return nil }
+
+func (c *drainingComponent) Drain(ctx context.Context) error {
+	c.calls = append(c.calls, "drain")
+	return c.drainErr
+}
+
+func (c *drainingComponent) Close(ctx spec.ComponentContext) error {
+	c.calls = append(c.calls, "close")
+	return nil
+}
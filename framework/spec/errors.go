@@ -5,3 +5,12 @@ import "errors"
 var ErrAlreadyConnected = errors.New("already connected")
 var ErrNotConnected = errors.New("not connected")
 var ErrNoData = errors.New("no data available")
+
+// ErrPermanent marks a processing failure which won't go away when the message is retried. Inputs which support it
+// discard such messages, or move them to a dead letter destination, instead of redelivering them. Wrap it to give
+// the reason, e.g. fmt.Errorf("%w: invalid payload", spec.ErrPermanent).
+var ErrPermanent = errors.New("permanent failure")
+
+// ErrEndOfInput is returned by inputs which have run out of data for good, like a generator which produced the
+// configured number of messages. Unlike ErrNoData, reading again won't produce anything.
+var ErrEndOfInput = errors.New("end of input")
@@ -1,6 +1,15 @@
 package spec
 
-import "iter"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"sync"
+)
+
+// ErrStreamConsumed is returned when the payload stream of a message has already been handed out.
+var ErrStreamConsumed = errors.New("message stream already consumed")
 
 type MessageFactory interface {
 	NewBatch(msg ...Message) Batch
@@ -15,6 +24,31 @@ type Message interface {
 	Metadata() iter.Seq2[string, any]
 }
 
+// StreamingMessage is implemented by messages whose payload can be consumed as a stream instead of being
+// materialized in memory through Raw. Large payloads, like S3 objects or files, should be exposed this way so
+// processors can work on them without buffering the whole body.
+type StreamingMessage interface {
+	Message
+
+	// Reader returns a reader over the message payload. The caller is responsible for closing it. Once the
+	// stream has been consumed, Reader may return ErrStreamConsumed unless the payload was materialized with Raw.
+	Reader() (io.ReadCloser, error)
+}
+
+// MessageReader returns a reader over the payload of the given message. The payload is streamed if the message
+// implements StreamingMessage, otherwise a reader over Raw is returned.
+func MessageReader(msg Message) (io.ReadCloser, error) {
+	if sm, ok := msg.(StreamingMessage); ok {
+		return sm.Reader()
+	}
+
+	raw, err := msg.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(raw)), nil
+}
+
 // NewBytesMessage creates a simple message from bytes for testing
 func NewBytesMessage(data []byte) Message {
 	return &bytesMessage{
@@ -49,3 +83,90 @@ func (b *bytesMessage) Metadata() iter.Seq2[string, any] {
 		}
 	}
 }
+
+// NewReaderMessage creates a message whose payload is read from the given reader. The reader is only consumed
+// when the payload is requested, either as a stream through Reader or materialized through Raw.
+func NewReaderMessage(r io.ReadCloser) StreamingMessage {
+	return &readerMessage{
+		r:        r,
+		metadata: make(map[string]any),
+	}
+}
+
+type readerMessage struct {
+	mu       sync.Mutex
+	r        io.ReadCloser
+	data     []byte
+	loaded   bool
+	metadata map[string]any
+}
+
+func (m *readerMessage) SetMetadata(key string, value any) {
+	m.metadata[key] = value
+}
+
+func (m *readerMessage) SetRaw(b []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.r != nil {
+		_ = m.r.Close()
+		m.r = nil
+	}
+	m.data = b
+	m.loaded = true
+}
+
+func (m *readerMessage) Raw() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.loaded {
+		return m.data, nil
+	}
+
+	if m.r == nil {
+		return nil, ErrStreamConsumed
+	}
+
+	defer func() {
+		_ = m.r.Close()
+		m.r = nil
+	}()
+
+	data, err := io.ReadAll(m.r)
+	if err != nil {
+		return nil, err
+	}
+
+	m.data = data
+	m.loaded = true
+	return m.data, nil
+}
+
+func (m *readerMessage) Reader() (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.loaded {
+		return io.NopCloser(bytes.NewReader(m.data)), nil
+	}
+
+	if m.r == nil {
+		return nil, ErrStreamConsumed
+	}
+
+	r := m.r
+	m.r = nil
+	return r, nil
+}
+
+func (m *readerMessage) Metadata() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for k, v := range m.metadata {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
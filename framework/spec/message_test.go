@@ -0,0 +1,55 @@
+package spec_test
+
+import (
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+var _ = Describe("Messages", func() {
+	Describe("ReaderMessage", func() {
+		It("should stream the payload through Reader", func() {
+			msg := spec.NewReaderMessage(io.NopCloser(strings.NewReader("hello, world")))
+
+			r, err := msg.Reader()
+			Expect(err).ToNot(HaveOccurred())
+			b, err := io.ReadAll(r)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(Equal("hello, world"))
+
+			_, err = msg.Reader()
+			Expect(err).To(MatchError(spec.ErrStreamConsumed))
+		})
+
+		It("should materialize the payload once through Raw", func() {
+			msg := spec.NewReaderMessage(io.NopCloser(strings.NewReader("hello, world")))
+
+			b, err := msg.Raw()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(Equal("hello, world"))
+
+			b, err = msg.Raw()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(Equal("hello, world"))
+
+			r, err := msg.Reader()
+			Expect(err).ToNot(HaveOccurred())
+			b, err = io.ReadAll(r)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(Equal("hello, world"))
+		})
+	})
+
+	Describe("MessageReader", func() {
+		It("should fall back to Raw for non-streaming messages", func() {
+			r, err := spec.MessageReader(spec.NewBytesMessage([]byte("hello")))
+			Expect(err).ToNot(HaveOccurred())
+			b, err := io.ReadAll(r)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(Equal("hello"))
+		})
+	})
+})
@@ -0,0 +1,48 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RedeliverAfter wraps err to request that the failed message is redelivered no sooner than after the given delay.
+// Pass it to a ProcessedCallback, on its own or for individual messages of a BatchError. Inputs which can't delay
+// redelivery treat it like any other error.
+func RedeliverAfter(err error, delay time.Duration) error {
+	if err == nil {
+		err = errors.New("redelivery requested")
+	}
+	return &RedeliveryError{Err: err, Delay: delay}
+}
+
+// RedeliveryError is a processing error which carries the delay after which the message should be redelivered.
+type RedeliveryError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (e *RedeliveryError) Error() string {
+	return fmt.Sprintf("%v (redeliver after %s)", e.Err, e.Delay)
+}
+
+func (e *RedeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// RedeliveryDelay returns how long an input should hold back a message which failed with err before it is
+// redelivered. A delay requested through RedeliverAfter takes precedence. Otherwise the delay follows the schedule for
+// the number of times the message has been delivered, starting at 1. Without a schedule the message is redelivered
+// right away.
+func RedeliveryDelay(err error, schedule *Backoff, deliveries int) time.Duration {
+	var redelivery *RedeliveryError
+	if errors.As(err, &redelivery) {
+		return max(redelivery.Delay, 0)
+	}
+
+	if schedule == nil {
+		return 0
+	}
+
+	return schedule.Delay(deliveries - 1)
+}
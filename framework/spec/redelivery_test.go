@@ -0,0 +1,48 @@
+package spec_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+var _ = Describe("RedeliveryDelay", func() {
+	schedule := &spec.Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 2}
+
+	It("should redeliver right away without a schedule", func() {
+		Expect(spec.RedeliveryDelay(errors.New("failed"), nil, 3)).To(BeZero())
+	})
+
+	It("should follow the schedule for the number of deliveries", func() {
+		Expect(spec.RedeliveryDelay(errors.New("failed"), schedule, 1)).To(Equal(time.Second))
+		Expect(spec.RedeliveryDelay(errors.New("failed"), schedule, 3)).To(Equal(4 * time.Second))
+		Expect(spec.RedeliveryDelay(errors.New("failed"), schedule, 10)).To(Equal(10 * time.Second))
+	})
+
+	It("should prefer the delay requested by the error", func() {
+		err := fmt.Errorf("write: %w", spec.RedeliverAfter(errors.New("rate limited"), time.Minute))
+
+		Expect(spec.RedeliveryDelay(err, schedule, 1)).To(Equal(time.Minute))
+		Expect(spec.RedeliveryDelay(err, nil, 1)).To(Equal(time.Minute))
+	})
+
+	It("should find the delay of a message in a batch error", func() {
+		err := spec.NewBatchError(nil).
+			Failed(0, spec.RedeliverAfter(errors.New("rate limited"), time.Minute)).
+			Failed(1, errors.New("failed"))
+
+		Expect(spec.RedeliveryDelay(spec.MessageError(err, 0), schedule, 1)).To(Equal(time.Minute))
+		Expect(spec.RedeliveryDelay(spec.MessageError(err, 1), schedule, 1)).To(Equal(time.Second))
+	})
+
+	It("should keep the wrapped error", func() {
+		err := spec.RedeliverAfter(spec.ErrPermanent, time.Second)
+
+		Expect(err).To(MatchError(spec.ErrPermanent))
+		Expect(err.Error()).To(Equal("permanent failure (redeliver after 1s)"))
+	})
+})
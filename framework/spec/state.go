@@ -0,0 +1,138 @@
+package spec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var ErrStateNotFound = errors.New("state not found")
+
+// StateStore persists small pieces of state, like the position of an input within its source, so a component can
+// resume where it left off after a restart. Inputs should only store a position once the ProcessedCallback of the
+// batch it belongs to has succeeded.
+type StateStore interface {
+	// Get returns the value stored under the given key, or ErrStateNotFound if there is none.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores the value under the given key, replacing any previous value.
+	Set(ctx context.Context, key string, value []byte) error
+
+	// Delete removes the value stored under the given key. Deleting a key which doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewMemoryStateStore creates a state store which keeps its state in memory. The state is lost when the process
+// exits, which makes it suitable for tests and for inputs which don't need to resume.
+func NewMemoryStateStore() StateStore {
+	return &memoryStateStore{
+		data: make(map[string][]byte),
+	}
+}
+
+type memoryStateStore struct {
+	data map[string][]byte
+	lock sync.RWMutex
+}
+
+func (m *memoryStateStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	value, ok := m.data[key]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (m *memoryStateStore) Set(ctx context.Context, key string, value []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.data[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (m *memoryStateStore) Delete(ctx context.Context, key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.data, key)
+	return nil
+}
+
+// NewFileStateStore creates a state store which keeps each key in a file within the given directory. The directory
+// is created if it doesn't exist. Values are written to a temporary file first and then renamed, so a crash while
+// writing never leaves a partially written value behind.
+func NewFileStateStore(dir string) (StateStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory %s: %w", dir, err)
+	}
+
+	return &fileStateStore{dir: dir}, nil
+}
+
+type fileStateStore struct {
+	dir  string
+	lock sync.Mutex
+}
+
+// path returns the file holding the given key. Keys are escaped, including dots, so they can't point outside of
+// the directory.
+func (f *fileStateStore) path(key string) string {
+	return filepath.Join(f.dir, strings.ReplaceAll(url.PathEscape(key), ".", "%2E"))
+}
+
+func (f *fileStateStore) Get(ctx context.Context, key string) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	value, err := os.ReadFile(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrStateNotFound
+	}
+	return value, err
+}
+
+func (f *fileStateStore) Set(ctx context.Context, key string, value []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	tmp, err := os.CreateTemp(f.dir, ".state-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(value); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+func (f *fileStateStore) Delete(ctx context.Context, key string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err := os.Remove(f.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package spec_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+var _ = Describe("StateStore", func() {
+	stores := map[string]func() spec.StateStore{
+		"memory": spec.NewMemoryStateStore,
+		"file": func() spec.StateStore {
+			store, err := spec.NewFileStateStore(filepath.Join(GinkgoT().TempDir(), "state"))
+			Expect(err).ToNot(HaveOccurred())
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		Describe(name, func() {
+			var store spec.StateStore
+			var ctx context.Context
+
+			BeforeEach(func() {
+				store = newStore()
+				ctx = context.Background()
+			})
+
+			It("should return ErrStateNotFound for unknown keys", func() {
+				_, err := store.Get(ctx, "unknown")
+				Expect(err).To(MatchError(spec.ErrStateNotFound))
+			})
+
+			It("should store and replace values", func() {
+				Expect(store.Set(ctx, "s3/bucket/prefix", []byte("first"))).To(Succeed())
+				Expect(store.Set(ctx, "s3/bucket/prefix", []byte("second"))).To(Succeed())
+
+				value, err := store.Get(ctx, "s3/bucket/prefix")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(value)).To(Equal("second"))
+			})
+
+			It("should delete values", func() {
+				Expect(store.Set(ctx, "key", []byte("value"))).To(Succeed())
+				Expect(store.Delete(ctx, "key")).To(Succeed())
+				Expect(store.Delete(ctx, "key")).To(Succeed())
+
+				_, err := store.Get(ctx, "key")
+				Expect(err).To(MatchError(spec.ErrStateNotFound))
+			})
+		})
+	}
+
+	Describe("file", func() {
+		It("should keep the state across store instances", func() {
+			dir := GinkgoT().TempDir()
+
+			store, err := spec.NewFileStateStore(dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.Set(context.Background(), "../escape", []byte("value"))).To(Succeed())
+			Expect(store.Set(context.Background(), "..", []byte("value"))).To(Succeed())
+
+			entries, err := os.ReadDir(dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+
+			reopened, err := spec.NewFileStateStore(dir)
+			Expect(err).ToNot(HaveOccurred())
+			value, err := reopened.Get(context.Background(), "../escape")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(value)).To(Equal("value"))
+		})
+	})
+})
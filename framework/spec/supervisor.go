@@ -0,0 +1,153 @@
+package spec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type SupervisorConfig struct {
+	// Name identifies the system in logs and metrics.
+	Name string
+
+	// Backoff is the schedule for retrying the initial connect. Defaults to DefaultBackoff.
+	Backoff *Backoff
+
+	// MaxAttempts limits the number of connect attempts. Zero means the supervisor keeps trying until the context
+	// passed to Connect is done.
+	MaxAttempts int
+
+	// Metrics receives the connection state of the system, see ConnectionMetrics. Optional.
+	Metrics Metrics
+}
+
+// NewSupervisor wraps the given system in a Supervisor.
+func NewSupervisor(sys System, logger Logger, cfg SupervisorConfig) *Supervisor {
+	if cfg.Name == "" {
+		cfg.Name = "system"
+	}
+
+	if cfg.Backoff == nil {
+		b := DefaultBackoff()
+		cfg.Backoff = &b
+	}
+
+	s := &Supervisor{
+		sys:    sys,
+		cfg:    cfg,
+		log:    logger,
+		states: NewConnectionStates(),
+	}
+
+	if cfg.Metrics != nil {
+		s.states.Observe(ConnectionMetrics(cfg.Metrics, cfg.Name))
+	}
+
+	if obs, ok := sys.(ConnectionObserver); ok {
+		s.inner = obs
+	}
+
+	return s
+}
+
+// Supervisor is a System which retries the initial connect of the system it wraps with a backoff, instead of giving
+// up after the first failure. A system which is already connected isn't retried, its ErrAlreadyConnected is passed
+// on. It reports the connection state of the wrapped system, so components and metrics can follow connections being
+// lost and restored, regardless of whether the system reports its state itself.
+type Supervisor struct {
+	sys    System
+	cfg    SupervisorConfig
+	log    Logger
+	states *ConnectionStates
+	inner  ConnectionObserver
+
+	// stopObserving stops following the connection state of the wrapped system, it is set while connected
+	lock          sync.Mutex
+	stopObserving func()
+}
+
+func (s *Supervisor) Connect(ctx context.Context) error {
+	s.states.Set(StateConnecting, nil)
+	s.observe()
+
+	for attempt := 0; ; attempt++ {
+		err := s.sys.Connect(ctx)
+		if err == nil || errors.Is(err, ErrAlreadyConnected) {
+			state := StateConnected
+			if s.inner != nil {
+				state = s.inner.ConnectionState()
+			}
+			s.states.Set(state, nil)
+			return err
+		}
+
+		if s.cfg.MaxAttempts > 0 && attempt+1 >= s.cfg.MaxAttempts {
+			s.states.Set(StateDisconnected, err)
+			return fmt.Errorf("failed to connect %s after %d attempts: %w", s.cfg.Name, attempt+1, err)
+		}
+
+		delay := s.cfg.Backoff.Delay(attempt)
+		s.log.Warnf("Failed to connect %s, retrying in %s: %v", s.cfg.Name, delay, err)
+
+		select {
+		case <-ctx.Done():
+			s.states.Set(StateDisconnected, err)
+			return fmt.Errorf("failed to connect %s: %w", s.cfg.Name, err)
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *Supervisor) Close(ctx context.Context) error {
+	err := s.sys.Close(ctx)
+
+	s.lock.Lock()
+	if s.stopObserving != nil {
+		s.stopObserving()
+		s.stopObserving = nil
+	}
+	s.lock.Unlock()
+
+	s.states.Set(StateClosed, err)
+	return err
+}
+
+// observe follows the connection state of systems which report it, from the initial connect until the supervisor is
+// closed.
+func (s *Supervisor) observe() {
+	if s.inner == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stopObserving != nil {
+		return
+	}
+
+	s.stopObserving = s.inner.Observe(func(evt ConnectionEvent) {
+		if s.states.ConnectionState() != StateConnecting {
+			s.states.Set(evt.State, evt.Err)
+		}
+	})
+}
+
+func (s *Supervisor) Client() any {
+	return s.sys.Client()
+}
+
+// System returns the wrapped system.
+func (s *Supervisor) System() System {
+	return s.sys
+}
+
+func (s *Supervisor) ConnectionState() ConnectionState {
+	return s.states.ConnectionState()
+}
+
+func (s *Supervisor) Observe(fn func(ConnectionEvent)) (stop func()) {
+	return s.states.Observe(fn)
+}
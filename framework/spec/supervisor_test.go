@@ -0,0 +1,145 @@
+package spec_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var _ = Describe("Supervisor", func() {
+	var backoff *spec.Backoff
+
+	BeforeEach(func() {
+		backoff = &spec.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond, Multiplier: 2}
+	})
+
+	It("should retry the initial connect until it succeeds", func() {
+		sys := &flakySystem{failures: 3}
+		sup := spec.NewSupervisor(sys, test.TestEnvironment(), spec.SupervisorConfig{Backoff: backoff})
+
+		Expect(sup.Connect(context.Background())).To(Succeed())
+		Expect(sys.attempts).To(Equal(4))
+		Expect(sup.ConnectionState()).To(Equal(spec.StateConnected))
+		Expect(sup.Client()).To(Equal("client"))
+	})
+
+	It("should give up after the maximum number of attempts", func() {
+		sys := &flakySystem{failures: 10}
+		sup := spec.NewSupervisor(sys, test.TestEnvironment(), spec.SupervisorConfig{Backoff: backoff, MaxAttempts: 2})
+
+		Expect(sup.Connect(context.Background())).To(MatchError(errFlaky))
+		Expect(sys.attempts).To(Equal(2))
+		Expect(sup.ConnectionState()).To(Equal(spec.StateDisconnected))
+	})
+
+	It("should not retry a system which is already connected", func() {
+		sys := &flakySystem{}
+		sup := spec.NewSupervisor(sys, test.TestEnvironment(), spec.SupervisorConfig{Backoff: backoff})
+
+		Expect(sup.Connect(context.Background())).To(Succeed())
+		Expect(sup.Connect(context.Background())).To(MatchError(spec.ErrAlreadyConnected))
+		Expect(sys.attempts).To(Equal(1))
+		Expect(sup.ConnectionState()).To(Equal(spec.StateConnected))
+	})
+
+	It("should give up once the context is done", func() {
+		sys := &flakySystem{failures: -1}
+		sup := spec.NewSupervisor(sys, test.TestEnvironment(), spec.SupervisorConfig{Backoff: backoff})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		Expect(sup.Connect(ctx)).To(MatchError(errFlaky))
+	})
+
+	It("should follow the connection state of systems which report it", func() {
+		sys := &observableSystem{states: spec.NewConnectionStates()}
+		metrics := &recordingMetrics{gauges: map[string]float64{}, counters: map[string]int64{}}
+		sup := spec.NewSupervisor(sys, test.TestEnvironment(), spec.SupervisorConfig{Name: "test", Metrics: metrics})
+
+		var events []spec.ConnectionState
+		sup.Observe(func(evt spec.ConnectionEvent) { events = append(events, evt.State) })
+
+		Expect(sup.Connect(context.Background())).To(Succeed())
+		sys.states.Set(spec.StateReconnecting, errors.New("connection reset"))
+		sys.states.Set(spec.StateConnected, nil)
+		Expect(sup.Close(context.Background())).To(Succeed())
+
+		Expect(events).To(Equal([]spec.ConnectionState{
+			spec.StateConnecting,
+			spec.StateConnected,
+			spec.StateReconnecting,
+			spec.StateConnected,
+			spec.StateClosed,
+		}))
+		Expect(metrics.counters["test_reconnects"]).To(Equal(int64(1)))
+	})
+
+	It("should stop following the connection state of the system once closed", func() {
+		sys := &observableSystem{states: spec.NewConnectionStates()}
+		sup := spec.NewSupervisor(sys, test.TestEnvironment(), spec.SupervisorConfig{Backoff: backoff})
+
+		Expect(sup.Connect(context.Background())).To(Succeed())
+		Expect(sup.Close(context.Background())).To(Succeed())
+
+		sys.states.Set(spec.StateReconnecting, errors.New("connection reset"))
+		Expect(sup.ConnectionState()).To(Equal(spec.StateClosed))
+
+		Expect(sup.Connect(context.Background())).To(Succeed())
+		sys.states.Set(spec.StateReconnecting, errors.New("connection reset"))
+		Expect(sup.ConnectionState()).To(Equal(spec.StateReconnecting))
+	})
+})
+
+var errFlaky = errors.New("connection refused")
+
+// flakySystem fails to connect the given number of times, or forever if failures is negative. Once connected, it
+// refuses to connect again.
+type flakySystem struct {
+	failures  int
+	attempts  int
+	connected bool
+}
+
+func (f *flakySystem) Connect(ctx context.Context) error {
+	if f.connected {
+		return spec.ErrAlreadyConnected
+	}
+
+	f.attempts++
+	if f.failures < 0 || f.attempts <= f.failures {
+		return errFlaky
+	}
+	f.connected = true
+	return nil
+}
+
+func (f *flakySystem) Close(ctx context.Context) error { return nil }
+
+func (f *flakySystem) Client() any { return "client" }
+
+type observableSystem struct {
+	states *spec.ConnectionStates
+}
+
+func (o *observableSystem) Connect(ctx context.Context) error {
+	o.states.Set(spec.StateConnecting, nil)
+	o.states.Set(spec.StateConnected, nil)
+	return nil
+}
+
+func (o *observableSystem) Close(ctx context.Context) error {
+	o.states.Set(spec.StateClosed, nil)
+	return nil
+}
+
+func (o *observableSystem) Client() any { return nil }
+
+func (o *observableSystem) ConnectionState() spec.ConnectionState { return o.states.ConnectionState() }
+
+func (o *observableSystem) Observe(fn func(spec.ConnectionEvent)) func() { return o.states.Observe(fn) }
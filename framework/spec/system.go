@@ -2,6 +2,9 @@ package spec
 
 import "context"
 
+// System is a connection to an external service, shared by the components which use it. Systems which maintain a
+// long-lived connection can implement ConnectionObserver to report its state, and can be wrapped in a Supervisor to
+// retry the initial connect.
 type System interface {
 	Connect(ctx context.Context) error
 	Close(ctx context.Context) error
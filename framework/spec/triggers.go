@@ -66,6 +66,7 @@ const (
 	TriggerSourceGenerate    = "generate"
 	TriggerSourceSQS         = "sqs"
 	TriggerSourceFile        = "file"
+	TriggerSourceObjectStore = "nats-object-store"
 )
 
 // Common Metadata Keys
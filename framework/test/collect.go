@@ -1,7 +1,6 @@
 package test
 
 import (
-	"context"
 	"sync"
 	"time"
 
@@ -90,12 +89,3 @@ func (l *ListCollector) Reset() {
 		l.locked = false
 	}
 }
-
-// Legacy methods
-func (l *ListCollector) WriteOld(ctx context.Context, message spec.Message) error {
-	return l.Collect(message)
-}
-
-func (l *ListCollector) DisconnectOld(ctx context.Context) error {
-	return nil
-}
@@ -0,0 +1,265 @@
+package test
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// Conformance describes the components of a bundle for the specs registered by DescribeConformance. The input and
+// output are expected to talk to the same broker, so whatever the output writes to a target arrives at an input
+// reading from that target.
+type Conformance struct {
+	// NewSystem creates the system used by the input and output, without connecting it. Optional, when set the system
+	// is checked as well and passed to NewInput and NewOutput once connected.
+	NewSystem func() (spec.System, error)
+
+	// NewInput creates an input reading from the given target, like a topic or a subject. Every spec uses a target
+	// of its own, so messages of one spec don't show up in another.
+	NewInput func(sys spec.System, target string) (spec.Input, error)
+
+	// NewOutput creates an output writing to the given target.
+	NewOutput func(sys spec.System, target string) (spec.Output, error)
+
+	// Settle is how long to wait after initializing an input before writing to its target, for inputs which
+	// subscribe asynchronously.
+	Settle time.Duration
+
+	// Timeout bounds the time to wait for a message to arrive. Defaults to 5s.
+	Timeout time.Duration
+
+	// Metadata reports whether the metadata of a message is carried from the output to the input.
+	Metadata bool
+
+	// Redelivery reports whether a message is delivered again after its callback was called with an error, without
+	// reconnecting the input.
+	Redelivery bool
+}
+
+// DescribeConformance registers specs checking the behavior every input, output and system is expected to share:
+//   - messages written by the output arrive at the input with their payload and, if supported, their metadata
+//   - initializing a component or connecting a system twice returns spec.ErrAlreadyConnected
+//   - reading from an input after closing it returns spec.ErrNotConnected
+//   - cancelling the context passed to Read unblocks it
+//   - a message is redelivered after its callback was called with an error, if supported
+//   - closing a component or system more than once is fine
+//
+// Call it at the top level of a test file of the bundle, with the broker the suite runs against:
+//
+//	var _ = test.DescribeConformance("MQTT", test.Conformance{...})
+func DescribeConformance(name string, c Conformance) bool {
+	timeout := cmp.Or(c.Timeout, 5*time.Second)
+
+	return Describe(name+" conformance", func() {
+		var (
+			ctx    spec.ComponentContext
+			sys    spec.System
+			target string
+		)
+
+		BeforeEach(func() {
+			ctx = NewMockComponentContext()
+			target = "conformance_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+			sys = nil
+			if c.NewSystem != nil {
+				var err error
+				sys, err = c.NewSystem()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sys.Connect(context.Background())).To(Succeed())
+
+				DeferCleanup(func() {
+					_ = sys.Close(context.Background())
+				})
+			}
+		})
+
+		newInput := func() spec.Input {
+			input, err := c.NewInput(sys, target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(input.Init(ctx)).To(Succeed())
+
+			DeferCleanup(func() {
+				_ = input.Close(ctx)
+			})
+
+			time.Sleep(c.Settle)
+			return input
+		}
+
+		newOutput := func() spec.Output {
+			output, err := c.NewOutput(sys, target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output.Init(ctx)).To(Succeed())
+
+			DeferCleanup(func() {
+				_ = output.Close(ctx)
+			})
+
+			return output
+		}
+
+		write := func(output spec.Output, payload string) {
+			msg := ctx.NewMessage()
+			msg.SetRaw([]byte(payload))
+			msg.SetMetadata("conformance_key", "conformance value")
+
+			Expect(output.Write(ctx, ctx.NewBatch(msg))).To(Succeed())
+		}
+
+		Describe("Input and Output", func() {
+			It("should round-trip the payload and metadata", func() {
+				input, output := newInput(), newOutput()
+				write(output, "hello, conformance")
+
+				batch, callback := readBatch(input, timeout)
+				msgs := batchMessages(batch)
+				Expect(msgs).To(HaveLen(1))
+				Expect(msgs[0].Raw()).To(Equal([]byte("hello, conformance")))
+
+				if c.Metadata {
+					metadata := make(map[string]string)
+					for key, value := range msgs[0].Metadata() {
+						metadata[key] = fmt.Sprint(value)
+					}
+					Expect(metadata).To(HaveKeyWithValue("conformance_key", "conformance value"))
+				}
+
+				Expect(callback(context.Background(), nil)).To(Succeed())
+			})
+
+			It("should refuse to initialize the input twice", func() {
+				input := newInput()
+				Expect(input.Init(ctx)).To(MatchError(spec.ErrAlreadyConnected))
+			})
+
+			It("should refuse to initialize the output twice", func() {
+				output := newOutput()
+				Expect(output.Init(ctx)).To(MatchError(spec.ErrAlreadyConnected))
+			})
+
+			It("should report the input as disconnected after closing it", func() {
+				input := newInput()
+				Expect(input.Close(ctx)).To(Succeed())
+
+				_, _, err := read(input, NewMockComponentContext(), timeout)
+				Expect(err).To(MatchError(spec.ErrNotConnected))
+			})
+
+			It("should unblock a read when its context is cancelled", func() {
+				input := newInput()
+
+				readCtx, cancel := context.WithCancel(context.Background())
+				done := make(chan error, 1)
+				go func() {
+					_, _, err := input.Read(NewMockComponentContextWithContext(readCtx))
+					done <- err
+				}()
+
+				time.Sleep(50 * time.Millisecond)
+				cancel()
+				Eventually(done, timeout).Should(Receive(HaveOccurred()))
+			})
+
+			if c.Redelivery {
+				It("should redeliver a message after its callback failed", func() {
+					input, output := newInput(), newOutput()
+					write(output, "redeliver me")
+
+					batch, callback := readBatch(input, timeout)
+					Expect(batchMessages(batch)).To(HaveLen(1))
+					Expect(callback(context.Background(), errors.New("processing failed"))).To(Succeed())
+
+					batch, callback = readBatch(input, timeout)
+					msgs := batchMessages(batch)
+					Expect(msgs).To(HaveLen(1))
+					Expect(msgs[0].Raw()).To(Equal([]byte("redeliver me")))
+					Expect(callback(context.Background(), nil)).To(Succeed())
+				})
+			}
+
+			It("should close the input and output more than once", func() {
+				input, output := newInput(), newOutput()
+
+				Expect(input.Close(ctx)).To(Succeed())
+				Expect(input.Close(ctx)).To(Succeed())
+				Expect(output.Close(ctx)).To(Succeed())
+				Expect(output.Close(ctx)).To(Succeed())
+			})
+		})
+
+		if c.NewSystem != nil {
+			Describe("System", func() {
+				It("should provide its client once connected", func() {
+					Expect(sys.Client()).ToNot(BeNil())
+				})
+
+				It("should refuse to connect twice", func() {
+					Expect(sys.Connect(context.Background())).To(MatchError(spec.ErrAlreadyConnected))
+				})
+
+				It("should close more than once", func() {
+					Expect(sys.Close(context.Background())).To(Succeed())
+					Expect(sys.Close(context.Background())).To(Succeed())
+				})
+			})
+		}
+	})
+}
+
+// read calls Read on the input, failing the spec if it doesn't return within the timeout.
+func read(input spec.Input, ctx spec.ComponentContext, timeout time.Duration) (spec.Batch, spec.ProcessedCallback, error) {
+	type result struct {
+		batch    spec.Batch
+		callback spec.ProcessedCallback
+		err      error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		batch, callback, err := input.Read(ctx)
+		done <- result{batch, callback, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.batch, res.callback, res.err
+	case <-time.After(timeout):
+		Fail(fmt.Sprintf("Read did not return within %s", timeout))
+		return nil, nil, nil
+	}
+}
+
+// readBatch reads the next batch from the input, retrying while the input reports there is no data yet.
+func readBatch(input spec.Input, timeout time.Duration) (spec.Batch, spec.ProcessedCallback) {
+	readCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for {
+		// -- leave some slack for inputs which only notice the cancelled context after it is done
+		batch, callback, err := read(input, NewMockComponentContextWithContext(readCtx), timeout+time.Second)
+		if errors.Is(err, spec.ErrNoData) && readCtx.Err() == nil {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+
+		Expect(err).ToNot(HaveOccurred())
+		return batch, callback
+	}
+}
+
+func batchMessages(batch spec.Batch) []spec.Message {
+	var msgs []spec.Message
+	for _, msg := range batch.Messages() {
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
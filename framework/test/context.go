@@ -16,6 +16,15 @@ func NewMockComponentContext() spec.ComponentContext {
 	}
 }
 
+// NewMockComponentContextWithContext creates a mock ComponentContext which carries the given context, allowing tests
+// to cancel the operations of the component.
+func NewMockComponentContextWithContext(ctx context.Context) spec.ComponentContext {
+	return &mockComponentContext{
+		env: TestEnvironment(),
+		ctx: ctx,
+	}
+}
+
 type mockComponentContext struct {
 	env spec.Environment
 	ctx context.Context
@@ -0,0 +1,432 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+// NewMemoryBroker creates a broker which connects in-memory inputs and outputs through named channels. It stands in
+// for a message broker in tests and local pipelines.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		channels: make(map[string]*MemoryChannel),
+	}
+}
+
+// MemoryBroker holds the named channels of in-memory inputs and outputs.
+type MemoryBroker struct {
+	lock     sync.Mutex
+	channels map[string]*MemoryChannel
+}
+
+// Channel returns the channel with the given name, creating it if it doesn't exist yet.
+func (b *MemoryBroker) Channel(name string) *MemoryChannel {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	ch, ok := b.channels[name]
+	if !ok {
+		ch = &MemoryChannel{name: name, changed: make(chan struct{})}
+		b.channels[name] = ch
+	}
+	return ch
+}
+
+// NewInput creates an input reading from the named channel. Inputs reading from the same channel compete for its
+// messages.
+func (b *MemoryBroker) NewInput(channel string, cfg MemoryInputConfig) *MemoryInput {
+	return &MemoryInput{
+		ch:  b.Channel(channel),
+		cfg: cfg,
+	}
+}
+
+// NewOutput creates an output writing to the named channel.
+func (b *MemoryBroker) NewOutput(channel string) *MemoryOutput {
+	return &MemoryOutput{
+		ch: b.Channel(channel),
+	}
+}
+
+// MemoryInputConfig configures how a MemoryInput acknowledges messages.
+type MemoryInputConfig struct {
+	// BatchSize is the maximum number of messages in a batch. Defaults to 1.
+	BatchSize int `mapstructure:"batch_size"`
+
+	// AutoAck acknowledges messages as soon as they are read, so they are never redelivered.
+	AutoAck bool `mapstructure:"auto_ack"`
+
+	// MaxDeliveries is the number of times a message is delivered before it is dropped. Zero keeps redelivering
+	// failed messages.
+	MaxDeliveries int `mapstructure:"max_deliveries"`
+
+	// Redelivery is the backoff schedule for failed messages. Delays requested through spec.RedeliverAfter are
+	// honored regardless. Without it failed messages are redelivered right away.
+	Redelivery *spec.Backoff `mapstructure:"redelivery"`
+}
+
+// MemoryChannel queues the messages written to it until an input reads them, and records what happened to them.
+type MemoryChannel struct {
+	name string
+
+	lock    sync.Mutex
+	pending []*memoryDelivery
+	written []memoryMessage
+	acked   []memoryMessage
+	nacked  []memoryMessage
+	dropped []memoryMessage
+
+	// changed is closed and replaced whenever the state of the channel changes
+	changed chan struct{}
+}
+
+type memoryMessage struct {
+	raw      []byte
+	metadata map[string]any
+}
+
+func (m memoryMessage) message() spec.Message {
+	msg := spec.NewBytesMessage(m.raw)
+	for key, value := range m.metadata {
+		msg.SetMetadata(key, value)
+	}
+	return msg
+}
+
+type memoryDelivery struct {
+	msg        memoryMessage
+	deliveries int
+}
+
+// Name returns the name of the channel.
+func (c *MemoryChannel) Name() string {
+	return c.name
+}
+
+// Len returns the number of messages waiting to be read.
+func (c *MemoryChannel) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.pending)
+}
+
+// Written returns all messages written to the channel, in order.
+func (c *MemoryChannel) Written() []spec.Message {
+	return c.snapshot(func() []memoryMessage { return c.written })
+}
+
+// Acked returns the messages which were acknowledged, in order.
+func (c *MemoryChannel) Acked() []spec.Message {
+	return c.snapshot(func() []memoryMessage { return c.acked })
+}
+
+// Nacked returns the messages which failed, once for every failed delivery.
+func (c *MemoryChannel) Nacked() []spec.Message {
+	return c.snapshot(func() []memoryMessage { return c.nacked })
+}
+
+// Dropped returns the messages which failed permanently or too often and won't be redelivered.
+func (c *MemoryChannel) Dropped() []spec.Message {
+	return c.snapshot(func() []memoryMessage { return c.dropped })
+}
+
+// WaitForWritten waits until at least n messages were written to the channel, returning false if that didn't happen
+// within the timeout.
+func (c *MemoryChannel) WaitForWritten(n int, timeout time.Duration) bool {
+	return c.waitFor(func() bool { return len(c.written) >= n }, timeout)
+}
+
+// WaitForAcked waits until at least n messages were acknowledged, returning false if that didn't happen within the
+// timeout.
+func (c *MemoryChannel) WaitForAcked(n int, timeout time.Duration) bool {
+	return c.waitFor(func() bool { return len(c.acked) >= n }, timeout)
+}
+
+// WaitForNacked waits until at least n deliveries failed, returning false if that didn't happen within the timeout.
+func (c *MemoryChannel) WaitForNacked(n int, timeout time.Duration) bool {
+	return c.waitFor(func() bool { return len(c.nacked) >= n }, timeout)
+}
+
+func (c *MemoryChannel) snapshot(list func() []memoryMessage) []spec.Message {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	msgs := make([]spec.Message, 0, len(list()))
+	for _, m := range list() {
+		msgs = append(msgs, m.message())
+	}
+	return msgs
+}
+
+func (c *MemoryChannel) waitFor(cond func() bool, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		c.lock.Lock()
+		ok, changed := cond(), c.changed
+		c.lock.Unlock()
+
+		if ok {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return false
+		}
+	}
+}
+
+// notify wakes up everyone waiting for the channel to change. The caller must hold the lock.
+func (c *MemoryChannel) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *MemoryChannel) write(msg memoryMessage) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.written = append(c.written, msg)
+	c.pending = append(c.pending, &memoryDelivery{msg: msg})
+	c.notify()
+}
+
+// take removes up to n pending messages from the channel, or returns the channel to wait on if there are none.
+func (c *MemoryChannel) take(n int) ([]*memoryDelivery, <-chan struct{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.pending) == 0 {
+		return nil, c.changed
+	}
+
+	n = min(n, len(c.pending))
+	taken := c.pending[:n:n]
+	c.pending = c.pending[n:]
+
+	for _, d := range taken {
+		d.deliveries++
+	}
+	return taken, nil
+}
+
+// requeue puts failed messages back in front of the channel, keeping their order.
+func (c *MemoryChannel) requeue(ds ...*memoryDelivery) {
+	if len(ds) == 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pending = append(append([]*memoryDelivery(nil), ds...), c.pending...)
+	c.notify()
+}
+
+func (c *MemoryChannel) record(list *[]memoryMessage, msg memoryMessage) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	*list = append(*list, msg)
+	c.notify()
+}
+
+// MemoryInput reads messages from a MemoryChannel. Failed messages are redelivered according to its configuration,
+// with spec.BatchError failing only some of the messages of a batch and spec.ErrPermanent dropping them right away.
+type MemoryInput struct {
+	ch  *MemoryChannel
+	cfg MemoryInputConfig
+
+	lock   sync.Mutex
+	state  int
+	closed chan struct{}
+}
+
+const (
+	memoryIdle = iota
+	memoryOpen
+	memoryClosed
+)
+
+// Channel returns the channel the input reads from.
+func (i *MemoryInput) Channel() *MemoryChannel {
+	return i.ch
+}
+
+func (i *MemoryInput) Init(ctx spec.ComponentContext) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.state == memoryOpen {
+		return spec.ErrAlreadyConnected
+	}
+
+	i.state = memoryOpen
+	i.closed = make(chan struct{})
+	return nil
+}
+
+func (i *MemoryInput) Close(ctx spec.ComponentContext) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.state == memoryOpen {
+		close(i.closed)
+	}
+	i.state = memoryClosed
+	return nil
+}
+
+func (i *MemoryInput) Read(ctx spec.ComponentContext) (spec.Batch, spec.ProcessedCallback, error) {
+	i.lock.Lock()
+	state, closed := i.state, i.closed
+	i.lock.Unlock()
+
+	if state != memoryOpen {
+		return nil, nil, spec.ErrNotConnected
+	}
+
+	for {
+		taken, changed := i.ch.take(max(i.cfg.BatchSize, 1))
+		if taken != nil {
+			return i.deliver(ctx, taken)
+		}
+
+		select {
+		case <-changed:
+		case <-closed:
+			return nil, nil, spec.ErrNotConnected
+		case <-ctx.Context().Done():
+			return nil, nil, ctx.Context().Err()
+		}
+	}
+}
+
+func (i *MemoryInput) deliver(ctx spec.ComponentContext, taken []*memoryDelivery) (spec.Batch, spec.ProcessedCallback, error) {
+	batch := ctx.NewBatch()
+	for _, d := range taken {
+		msg := ctx.NewMessage()
+		msg.SetRaw(d.msg.raw)
+		for key, value := range d.msg.metadata {
+			msg.SetMetadata(key, value)
+		}
+		batch.Append(msg)
+	}
+
+	if i.cfg.AutoAck {
+		for _, d := range taken {
+			i.ch.record(&i.ch.acked, d.msg)
+		}
+		return batch, spec.NoopCallback, nil
+	}
+
+	var once sync.Once
+	callback := func(_ context.Context, err error) error {
+		once.Do(func() {
+			var retry []*memoryDelivery
+			for idx, d := range taken {
+				if i.settle(d, spec.MessageError(err, idx)) {
+					retry = append(retry, d)
+				}
+			}
+			i.ch.requeue(retry...)
+		})
+		return nil
+	}
+
+	return batch, callback, nil
+}
+
+// settle records the outcome of a delivered message. It reports whether the message failed and should be redelivered
+// right away, messages which should be redelivered later are requeued once their delay has passed.
+func (i *MemoryInput) settle(d *memoryDelivery, err error) bool {
+	if err == nil {
+		i.ch.record(&i.ch.acked, d.msg)
+		return false
+	}
+
+	i.ch.record(&i.ch.nacked, d.msg)
+
+	if errors.Is(err, spec.ErrPermanent) || (i.cfg.MaxDeliveries > 0 && d.deliveries >= i.cfg.MaxDeliveries) {
+		i.ch.record(&i.ch.dropped, d.msg)
+		return false
+	}
+
+	if delay := spec.RedeliveryDelay(err, i.cfg.Redelivery, d.deliveries); delay > 0 {
+		time.AfterFunc(delay, func() { i.ch.requeue(d) })
+		return false
+	}
+	return true
+}
+
+// MemoryOutput writes messages to a MemoryChannel.
+type MemoryOutput struct {
+	ch *MemoryChannel
+
+	lock  sync.Mutex
+	state int
+}
+
+// Channel returns the channel the output writes to.
+func (o *MemoryOutput) Channel() *MemoryChannel {
+	return o.ch
+}
+
+func (o *MemoryOutput) Init(ctx spec.ComponentContext) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.state == memoryOpen {
+		return spec.ErrAlreadyConnected
+	}
+
+	o.state = memoryOpen
+	return nil
+}
+
+func (o *MemoryOutput) Close(ctx spec.ComponentContext) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.state = memoryClosed
+	return nil
+}
+
+func (o *MemoryOutput) Write(ctx spec.ComponentContext, batch spec.Batch) error {
+	o.lock.Lock()
+	state := o.state
+	o.lock.Unlock()
+
+	if state != memoryOpen {
+		return spec.ErrNotConnected
+	}
+
+	// -- copy the messages first, so a message which can't be read doesn't leave half a batch on the channel
+	var msgs []memoryMessage
+	for idx, msg := range batch.Messages() {
+		raw, err := msg.Raw()
+		if err != nil {
+			return fmt.Errorf("batch #%d: %w", idx, err)
+		}
+
+		msgs = append(msgs, memoryMessage{
+			raw:      append([]byte(nil), raw...),
+			metadata: maps.Collect(msg.Metadata()),
+		})
+	}
+
+	for _, msg := range msgs {
+		o.ch.write(msg)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package test_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/wombatwisdom/components/framework/spec"
+	"github.com/wombatwisdom/components/framework/test"
+)
+
+var conformanceBroker = test.NewMemoryBroker()
+
+var _ = test.DescribeConformance("Memory", test.Conformance{
+	NewInput: func(_ spec.System, target string) (spec.Input, error) {
+		return conformanceBroker.NewInput(target, test.MemoryInputConfig{}), nil
+	},
+	NewOutput: func(_ spec.System, target string) (spec.Output, error) {
+		return conformanceBroker.NewOutput(target), nil
+	},
+	Metadata:   true,
+	Redelivery: true,
+})
+
+var _ = Describe("MemoryBroker", func() {
+	var (
+		ctx    spec.ComponentContext
+		broker *test.MemoryBroker
+		output *test.MemoryOutput
+	)
+
+	BeforeEach(func() {
+		ctx = test.NewMockComponentContext()
+		broker = test.NewMemoryBroker()
+
+		output = broker.NewOutput("orders")
+		Expect(output.Init(ctx)).To(Succeed())
+	})
+
+	write := func(payloads ...string) {
+		batch := ctx.NewBatch()
+		for _, p := range payloads {
+			batch.Append(spec.NewBytesMessage([]byte(p)))
+		}
+		Expect(output.Write(ctx, batch)).To(Succeed())
+	}
+
+	newInput := func(cfg test.MemoryInputConfig) *test.MemoryInput {
+		input := broker.NewInput("orders", cfg)
+		Expect(input.Init(ctx)).To(Succeed())
+		DeferCleanup(func() { _ = input.Close(ctx) })
+		return input
+	}
+
+	read := func(input *test.MemoryInput) ([]string, spec.ProcessedCallback) {
+		batch, callback, err := input.Read(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		var payloads []string
+		for _, msg := range batch.Messages() {
+			raw, err := msg.Raw()
+			Expect(err).ToNot(HaveOccurred())
+			payloads = append(payloads, string(raw))
+		}
+		return payloads, callback
+	}
+
+	payloads := func(msgs []spec.Message) []string {
+		var result []string
+		for _, msg := range msgs {
+			raw, err := msg.Raw()
+			Expect(err).ToNot(HaveOccurred())
+			result = append(result, string(raw))
+		}
+		return result
+	}
+
+	It("should hold the written messages until they are read", func() {
+		write("one", "two")
+
+		channel := broker.Channel("orders")
+		Expect(channel.WaitForWritten(2, time.Second)).To(BeTrue())
+		Expect(channel.Len()).To(Equal(2))
+		Expect(payloads(channel.Written())).To(Equal([]string{"one", "two"}))
+	})
+
+	It("should read batches up to the batch size", func() {
+		write("one", "two", "three")
+		input := newInput(test.MemoryInputConfig{BatchSize: 2})
+
+		batch, callback := read(input)
+		Expect(batch).To(Equal([]string{"one", "two"}))
+		Expect(callback(context.Background(), nil)).To(Succeed())
+
+		batch, callback = read(input)
+		Expect(batch).To(Equal([]string{"three"}))
+		Expect(callback(context.Background(), nil)).To(Succeed())
+
+		Expect(payloads(input.Channel().Acked())).To(Equal([]string{"one", "two", "three"}))
+	})
+
+	It("should only redeliver the failed messages of a batch", func() {
+		write("one", "two", "three")
+		input := newInput(test.MemoryInputConfig{BatchSize: 3})
+
+		_, callback := read(input)
+		batchErr := spec.NewBatchError(nil).
+			Failed(0, fmt.Errorf("%w: invalid", spec.ErrPermanent)).
+			Failed(2, errors.New("unavailable"))
+		Expect(callback(context.Background(), batchErr)).To(Succeed())
+
+		channel := input.Channel()
+		Expect(payloads(channel.Acked())).To(Equal([]string{"two"}))
+		Expect(payloads(channel.Nacked())).To(Equal([]string{"one", "three"}))
+		Expect(payloads(channel.Dropped())).To(Equal([]string{"one"}))
+
+		batch, callback := read(input)
+		Expect(batch).To(Equal([]string{"three"}))
+		Expect(callback(context.Background(), nil)).To(Succeed())
+	})
+
+	It("should drop messages after the maximum number of deliveries", func() {
+		write("one")
+		input := newInput(test.MemoryInputConfig{MaxDeliveries: 2})
+
+		for range 2 {
+			_, callback := read(input)
+			Expect(callback(context.Background(), errors.New("failed"))).To(Succeed())
+		}
+
+		Expect(input.Channel().WaitForNacked(2, time.Second)).To(BeTrue())
+		Expect(payloads(input.Channel().Dropped())).To(Equal([]string{"one"}))
+		Expect(input.Channel().Len()).To(BeZero())
+	})
+
+	It("should delay the redelivery when requested", func() {
+		write("one")
+		input := newInput(test.MemoryInputConfig{})
+
+		_, callback := read(input)
+		Expect(callback(context.Background(), spec.RedeliverAfter(errors.New("busy"), 100*time.Millisecond))).To(Succeed())
+		Expect(input.Channel().Len()).To(BeZero())
+
+		Eventually(input.Channel().Len).Should(Equal(1))
+	})
+
+	It("should acknowledge messages on read with auto ack", func() {
+		write("one")
+		input := newInput(test.MemoryInputConfig{AutoAck: true})
+
+		_, callback := read(input)
+		Expect(payloads(input.Channel().Acked())).To(Equal([]string{"one"}))
+
+		Expect(callback(context.Background(), nil)).To(Succeed())
+		Expect(input.Channel().Len()).To(BeZero())
+	})
+
+	It("should wait for messages to be acknowledged", func() {
+		input := newInput(test.MemoryInputConfig{})
+
+		go func() {
+			defer GinkgoRecover()
+			batch, callback := read(input)
+			Expect(batch).To(Equal([]string{"one"}))
+			Expect(callback(context.Background(), nil)).To(Succeed())
+		}()
+
+		write("one")
+		Expect(input.Channel().WaitForAcked(1, 5*time.Second)).To(BeTrue())
+		Expect(input.Channel().WaitForAcked(2, 50*time.Millisecond)).To(BeFalse())
+	})
+})
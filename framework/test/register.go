@@ -0,0 +1,80 @@
+package test
+
+import (
+	"fmt"
+
+	"github.com/wombatwisdom/components/framework/registry"
+	"github.com/wombatwisdom/components/framework/spec"
+)
+
+const (
+	MemoryComponentName = "memory"
+)
+
+const memoryInputSchema = `{
+	"type": "object",
+	"properties": {
+		"channel": {"type": "string", "description": "The channel to read from."},
+		"batch_size": {"type": "integer", "minimum": 1, "default": 1, "description": "The maximum number of messages per batch."},
+		"auto_ack": {"type": "boolean", "default": false, "description": "Acknowledge messages as soon as they are read."},
+		"max_deliveries": {"type": "integer", "minimum": 0, "default": 0, "description": "The number of times a message is delivered before it is dropped. Zero keeps redelivering failed messages."},
+		"redelivery": {
+			"type": "object",
+			"properties": {
+				"initial": {"type": "string", "default": "500ms"},
+				"max": {"type": "string", "default": "30s"},
+				"multiplier": {"type": "number", "default": 2},
+				"jitter": {"type": "number", "minimum": 0, "maximum": 1}
+			},
+			"additionalProperties": false,
+			"description": "The backoff schedule for failed messages. Without it, they are redelivered right away."
+		}
+	},
+	"required": ["channel"],
+	"additionalProperties": false
+}`
+
+const memoryOutputSchema = `{
+	"type": "object",
+	"properties": {
+		"channel": {"type": "string", "description": "The channel to write to."}
+	},
+	"required": ["channel"],
+	"additionalProperties": false
+}`
+
+// Register adds an input and an output reading from and writing to the channels of the broker to the registry. They
+// connect the pipelines of a single process, like the ones run by a test.
+func (b *MemoryBroker) Register(r *registry.Registry) error {
+	err := r.RegisterInput(registry.Spec{
+		Name:    MemoryComponentName,
+		Summary: "Reads messages from an in-memory channel.",
+		Schema:  memoryInputSchema,
+	}, nil, func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Input, error) {
+		var c struct {
+			Channel           string `mapstructure:"channel"`
+			MemoryInputConfig `mapstructure:",squash"`
+		}
+		if err := cfg.Decode(&c); err != nil {
+			return nil, fmt.Errorf("failed to decode memory input config: %w", err)
+		}
+		return b.NewInput(c.Channel, c.MemoryInputConfig), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterOutput(registry.Spec{
+		Name:    MemoryComponentName,
+		Summary: "Writes messages to an in-memory channel.",
+		Schema:  memoryOutputSchema,
+	}, nil, func(_ spec.Environment, _ spec.System, cfg spec.Config) (spec.Output, error) {
+		var c struct {
+			Channel string `mapstructure:"channel"`
+		}
+		if err := cfg.Decode(&c); err != nil {
+			return nil, fmt.Errorf("failed to decode memory output config: %w", err)
+		}
+		return b.NewOutput(c.Channel), nil
+	})
+}
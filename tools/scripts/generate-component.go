@@ -1,173 +1,1203 @@
+// Command generate-component generates the skeleton of a bundle from the JSON schemas of the configurations of its
+// components. It writes the config structs with their defaults and validation, a spec.ComponentSpec, the
+// registration with a registry.Registry, the components with TODOs where the service has to be talked to, and the
+// tests: config tests and, for bundles with an input and an output, the conformance specs wired to a local stand-in.
+//
+// The bundle is described by flags or by a spec file, flags taking precedence:
+//
+//	go run tools/scripts/generate-component.go -name redis -summary "Reads and writes Redis streams." \
+//		-system system.schema.json -input input.schema.json -output output.schema.json
+//	go run tools/scripts/generate-component.go -spec redis.yaml
+//
+// A spec file holds the same settings, with the schemas inline or as paths relative to the spec file:
+//
+//	name: redis
+//	summary: Reads and writes Redis streams.
+//	client: "*redis.Client"
+//	client_import: github.com/redis/go-redis/v9
+//	system: system.schema.json
+//	input:
+//	  type: object
+//	  properties:
+//	    stream: {type: string, description: The stream to read from.}
+//	  required: [stream]
+//
+// Schemas are JSON or YAML. Strings with "format": "duration" become a time.Duration and strings with
+// "format": "expression" a spec.Expression. Run it from the root of the repository.
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"go/format"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
 )
 
-type ComponentConfig struct {
-	Package            string
-	Module             string
-	SystemName         string
-	ServiceName        string
-	Description        string
-	ClientType         string
-	ConfigExample      string
-	ValidConfigExample string
+const module = "github.com/wombatwisdom/components"
+
+// Definition describes the bundle to generate, as read from a spec file.
+type Definition struct {
+	Name         string `yaml:"name"`
+	Package      string `yaml:"package"`
+	Service      string `yaml:"service"`
+	Summary      string `yaml:"summary"`
+	Description  string `yaml:"description"`
+	Client       string `yaml:"client"`
+	ClientImport string `yaml:"client_import"`
+	Target       string `yaml:"target"`
+	Out          string `yaml:"out"`
+
+	System    yaml.Node `yaml:"system"`
+	Input     yaml.Node `yaml:"input"`
+	Output    yaml.Node `yaml:"output"`
+	Processor yaml.Node `yaml:"processor"`
+	Trigger   yaml.Node `yaml:"trigger"`
+	Retrieval yaml.Node `yaml:"retrieval"`
 }
 
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Println("Usage: go run scripts/generate-component.go <component-name>")
-		fmt.Println("Example: go run scripts/generate-component.go redis")
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+}
 
-	componentName := strings.ToLower(os.Args[1])
+func run(args []string) error {
+	fs := flag.NewFlagSet("generate-component", flag.ContinueOnError)
+	specFile := fs.String("spec", "", "a YAML file describing the bundle, overridden by the other flags")
+	name := fs.String("name", "", "the name of the bundle and its components, e.g. redis")
+	pkg := fs.String("package", "", "the Go package of the bundle, defaults to the name without dashes and underscores")
+	service := fs.String("service", "", "the name of the service in documentation, defaults to the capitalized name")
+	summary := fs.String("summary", "", "a single line describing the bundle")
+	description := fs.String("description", "", "the documentation of the bundle")
+	client := fs.String("client", "", "the Go type of the client held by the system, defaults to any")
+	clientImport := fs.String("client-import", "", "the import path of the package of the client type")
+	target := fs.String("target", "", "the input and output config field the conformance specs put the target in")
+	out := fs.String("out", "", "the directory to write the bundle to, defaults to bundles/<name>")
+	templates := fs.String("templates", filepath.Join("tools", "templates", "component"), "the directory holding the templates")
+	force := fs.Bool("force", false, "overwrite existing files")
 
-	// Interactive configuration
-	config := ComponentConfig{
-		Package:    componentName,
-		Module:     "github.com/wombatwisdom/components",
-		SystemName: cases.Title(language.English).String(componentName),
+	schemas := make(map[string]*string)
+	for _, kind := range []string{"system", "input", "output", "processor", "trigger", "retrieval"} {
+		schemas[kind] = fs.String(kind, "", "the JSON schema file of the "+kind+" config")
 	}
 
-	reader := bufio.NewReader(os.Stdin)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
 
-	fmt.Printf("Service name (default: %s): ", cases.Title(language.English).String(componentName))
-	if serviceName, _ := reader.ReadString('\n'); strings.TrimSpace(serviceName) != "" {
-		config.ServiceName = strings.TrimSpace(serviceName)
-	} else {
-		config.ServiceName = cases.Title(language.English).String(componentName)
+	var def Definition
+	dir := "."
+	if *specFile != "" {
+		data, err := os.ReadFile(*specFile)
+		if err != nil {
+			return err
+		}
+		if err := yaml.Unmarshal(data, &def); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", *specFile, err)
+		}
+		dir = filepath.Dir(*specFile)
 	}
 
-	fmt.Print("Description: ")
-	description, _ := reader.ReadString('\n')
-	config.Description = strings.TrimSpace(description)
+	override(&def.Name, *name)
+	override(&def.Package, *pkg)
+	override(&def.Service, *service)
+	override(&def.Summary, *summary)
+	override(&def.Description, *description)
+	override(&def.Client, *client)
+	override(&def.ClientImport, *clientImport)
+	override(&def.Target, *target)
+	override(&def.Out, *out)
 
-	fmt.Print("Client type (e.g., *redis.Client): ")
-	clientType, _ := reader.ReadString('\n')
-	config.ClientType = strings.TrimSpace(clientType)
+	nodes := map[string]*yaml.Node{
+		"system":    &def.System,
+		"input":     &def.Input,
+		"output":    &def.Output,
+		"processor": &def.Processor,
+		"trigger":   &def.Trigger,
+		"retrieval": &def.Retrieval,
+	}
+	for kind, path := range schemas {
+		if *path != "" {
+			// -- paths given as flags are relative to the working directory, not to the spec file
+			abs, err := filepath.Abs(*path)
+			if err != nil {
+				return err
+			}
+			*nodes[kind] = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: abs}
+		}
+	}
 
-	fmt.Println("Configuration example for invalid test:")
-	fmt.Print("> ")
-	configExample, _ := reader.ReadString('\n')
-	config.ConfigExample = strings.TrimSpace(configExample)
+	loaded := make(map[string]*object)
+	for kind, node := range nodes {
+		s, err := loadSchema(node, dir)
+		if err != nil {
+			return fmt.Errorf("%s schema: %w", kind, err)
+		}
+		loaded[kind] = s
+	}
 
-	fmt.Println("Valid configuration example:")
-	fmt.Print("> ")
-	validConfigExample, _ := reader.ReadString('\n')
-	config.ValidConfigExample = strings.TrimSpace(validConfigExample)
+	b, err := newBundle(def, loaded)
+	if err != nil {
+		return err
+	}
 
-	// Create component directory
-	componentDir := filepath.Join(".", componentName)
-	if err := os.MkdirAll(componentDir, 0755); err != nil {
-		fmt.Printf("Error creating directory: %v\n", err)
-		os.Exit(1)
+	outDir := def.Out
+	if outDir == "" {
+		outDir = filepath.Join("bundles", strings.ToLower(def.Name))
 	}
 
-	// Template files to generate
-	templates := map[string]string{
-		"system.go":      "tools/templates/component/system.go.tmpl",
-		"input.go":       "tools/templates/component/input.go.tmpl",
-		"output.go":      "tools/templates/component/output.go.tmpl",
-		"system_test.go": "tools/templates/component/system_test.go.tmpl",
-		"Taskfile.yml":   "tools/templates/component/Taskfile.yml.tmpl",
+	b.ImportPath, err = importPath(outDir)
+	if err != nil {
+		return err
 	}
 
-	// Generate files
-	for filename, templatePath := range templates {
-		if err := generateFile(templatePath, filepath.Join(componentDir, filename), config); err != nil {
-			fmt.Printf("Error generating %s: %v\n", filename, err)
-			os.Exit(1)
-		}
-		fmt.Printf("Generated: %s/%s\n", componentName, filename)
+	files, err := b.render(*templates)
+	if err != nil {
+		return err
 	}
 
-	// Generate schema files
-	schemas := []string{
-		"system_config.schema.yaml",
-		"input_config.schema.yaml",
-		"output_config.schema.yaml",
+	if !*force {
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(outDir, f.name)); err == nil {
+				return fmt.Errorf("%s already exists, use -force to overwrite it", filepath.Join(outDir, f.name))
+			}
+		}
 	}
 
-	for _, schema := range schemas {
-		schemaPath := filepath.Join(componentDir, schema)
-		if err := generateSchemaFile(schemaPath, config); err != nil {
-			fmt.Printf("Error generating %s: %v\n", schema, err)
-			os.Exit(1)
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(outDir, f.name), f.content, 0o644); err != nil {
+			return err
 		}
-		fmt.Printf("Generated: %s/%s\n", componentName, schema)
+		fmt.Printf("Generated: %s\n", filepath.Join(outDir, f.name))
 	}
 
-	// Generate test suite
-	suiteFile := filepath.Join(componentDir, fmt.Sprintf("%s_suite_test.go", componentName))
-	if err := generateSuiteFile(suiteFile, config); err != nil {
-		fmt.Printf("Error generating test suite: %v\n", err)
-		os.Exit(1)
+	fmt.Printf("\nNext steps:\n")
+	fmt.Printf("  1. Implement the TODOs in %s\n", outDir)
+	fmt.Printf("  2. Add %s.Register to cmd/ww/components.go and the bundle to the Taskfiles\n", b.Package)
+	if b.Input() != nil && b.Output() != nil {
+		fmt.Printf("  3. Start a local stand-in for %s in standIn to run the conformance specs\n", b.Service)
+	}
+	return nil
+}
+
+// importPath returns the import path of the package in dir, which has to be part of this module.
+func importPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
 	}
-	fmt.Printf("Generated: %s/%s_suite_test.go\n", componentName, componentName)
 
-	fmt.Printf("\n✅ Component '%s' generated successfully!\n", componentName)
-	fmt.Printf("📁 Directory: %s/\n", componentName)
-	fmt.Printf("🔧 Next steps:\n")
-	fmt.Printf("   1. cd %s\n", componentName)
-	fmt.Printf("   2. task models:generate\n")
-	fmt.Printf("   3. Implement TODO items in the generated files\n")
-	fmt.Printf("   4. task test\n")
+	for root := abs; ; root = filepath.Dir(root) {
+		if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return "", err
+			}
+			return module + "/" + filepath.ToSlash(rel), nil
+		}
+		if root == filepath.Dir(root) {
+			return "", fmt.Errorf("%s is not part of the %s module", dir, module)
+		}
+	}
+}
+
+func override(value *string, flagValue string) {
+	if flagValue != "" {
+		*value = flagValue
+	}
 }
 
-func generateFile(templatePath, outputPath string, config ComponentConfig) error {
-	tmpl, err := template.ParseFiles(templatePath)
+// loadSchema reads a schema given inline or as the path of a file relative to dir. It returns nil if there is none.
+func loadSchema(node *yaml.Node, dir string) (*object, error) {
+	if node.Kind == 0 || node.Tag == "!!null" {
+		return nil, nil
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		path := node.Value
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if len(doc.Content) == 0 {
+			return nil, fmt.Errorf("%s is empty", path)
+		}
+		node = doc.Content[0]
+	}
+
+	v, err := fromNode(node)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	s, ok := v.(*object)
+	if !ok {
+		return nil, fmt.Errorf("a schema is an object")
+	}
+	return s, nil
+}
+
+// object is a JSON object which keeps the order of its keys, so the generated fields and schemas follow the order
+// the schema was written in.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *object) get(key string) any {
+	if o == nil {
+		return nil
+	}
+	return o.values[key]
+}
+
+func (o *object) str(key string) string {
+	s, _ := o.get(key).(string)
+	return s
+}
+
+func (o *object) obj(key string) *object {
+	v, _ := o.get(key).(*object)
+	return v
+}
+
+func (o *object) without(keys ...string) *object {
+	result := &object{values: make(map[string]any)}
+	for _, key := range o.keys {
+		if !slices.Contains(keys, key) {
+			result.keys = append(result.keys, key)
+			result.values[key] = o.values[key]
+		}
+	}
+	return result
+}
+
+func fromNode(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return fromNode(node.Content[0])
+	case yaml.AliasNode:
+		return fromNode(node.Alias)
+	case yaml.MappingNode:
+		o := &object{values: make(map[string]any)}
+		for idx := 0; idx < len(node.Content); idx += 2 {
+			key := node.Content[idx].Value
+			value, err := fromNode(node.Content[idx+1])
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := o.values[key]; !ok {
+				o.keys = append(o.keys, key)
+			}
+			o.values[key] = value
+		}
+		return o, nil
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := fromNode(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	default:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// toJSON writes a value as JSON indented with tabs. Objects holding only scalars and lists of scalars, like most
+// property schemas, are kept on a single line.
+func toJSON(buf *bytes.Buffer, value any, indent string) {
+	switch v := value.(type) {
+	case *object:
+		if len(v.keys) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		if indent != "" && flat(v) {
+			buf.WriteString("{")
+			for idx, key := range v.keys {
+				if idx > 0 {
+					buf.WriteString(", ")
+				}
+				writeString(buf, key)
+				buf.WriteString(": ")
+				toJSON(buf, v.values[key], "")
+			}
+			buf.WriteString("}")
+			return
+		}
+		buf.WriteString("{\n")
+		for idx, key := range v.keys {
+			buf.WriteString(indent + "\t")
+			writeString(buf, key)
+			buf.WriteString(": ")
+			toJSON(buf, v.values[key], indent+"\t")
+			if idx < len(v.keys)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+	case []any:
+		buf.WriteString("[")
+		for idx, item := range v {
+			if idx > 0 {
+				buf.WriteString(", ")
+			}
+			toJSON(buf, item, indent)
+		}
+		buf.WriteString("]")
+	case string:
+		writeString(buf, v)
+	case nil:
+		buf.WriteString("null")
+	default:
+		fmt.Fprint(buf, v)
+	}
+}
+
+// flat reports whether an object holds no other objects.
+func flat(o *object) bool {
+	for _, value := range o.values {
+		switch v := value.(type) {
+		case *object:
+			return false
+		case []any:
+			for _, item := range v {
+				if _, ok := item.(*object); ok {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	buf.Truncate(buf.Len() - 1)
+}
+
+// goLiteral returns the Go expression of a value, with objects as map[string]any.
+func goLiteral(value any) string {
+	switch v := value.(type) {
+	case *object:
+		if len(v.keys) == 0 {
+			return "map[string]any{}"
+		}
+		var sb strings.Builder
+		sb.WriteString("map[string]any{\n")
+		for _, key := range v.keys {
+			sb.WriteString(strconv.Quote(key) + ": " + goLiteral(v.values[key]) + ",\n")
+		}
+		sb.WriteString("}")
+		return sb.String()
+	case []any:
+		items := make([]string, len(v))
+		for idx, item := range v {
+			items[idx] = goLiteral(item)
+		}
+		return "[]any{" + strings.Join(items, ", ") + "}"
+	case string:
+		return strconv.Quote(v)
+	case ident:
+		return string(v)
+	case nil:
+		return "nil"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ident is a Go identifier in a literal.
+type ident string
+
+// Bundle is what the templates are rendered with.
+type Bundle struct {
+	Module       string
+	Package      string
+	Name         string
+	Service      string
+	Summary      string
+	Description  string
+	Client       string
+	ClientImport string
+
+	// ImportPath is the import path of the package of the bundle.
+	ImportPath string
+
+	System     *Component
+	Components []*Component
+
+	// Target is the field of the input and output configs the conformance specs put their target in.
+	Target string
+}
+
+// Component is a generated component, or the system.
+type Component struct {
+	// RegistryKind is the name of the registry.Kind constant of the component.
+	RegistryKind string
+
+	Kind    string
+	Label   string
+	Name    string
+	NameVar string
+	Type    string
+	Config  string
+	Summary string
+
+	Schema      string
+	SchemaConst string
+	Structs     []*Struct
+	Defaults    string
+	DefaultsVar string
+
+	// Example is a config holding the required fields, Checked the required fields whose absence Validate reports.
+	Example *object
+	Checked []string
+
+	// WrongKey is a field the config tests set to WrongValue, a value of the wrong type.
+	WrongKey   string
+	WrongValue string
+
+	HasSystem bool
+}
+
+// Struct is a generated config struct.
+type Struct struct {
+	Name     string
+	Doc      []string
+	Validate string
+	Fields   []*Field
+	Checks   []string
+}
+
+// Field is a field of a generated config struct.
+type Field struct {
+	Name string
+	Key  string
+	Type string
+	Tag  string
+	Doc  []string
+}
+
+var kinds = []struct {
+	kind, label, suffix, typ, config, registryKind string
+}{
+	{"input", "input", "", "Input", "InputConfig", "KindInput"},
+	{"output", "output", "", "Output", "OutputConfig", "KindOutput"},
+	{"processor", "processor", "", "Processor", "ProcessorConfig", "KindProcessor"},
+	{"trigger", "trigger input", "_trigger", "TriggerInput", "TriggerInputConfig", "KindTriggerInput"},
+	{"retrieval", "retrieval processor", "_retrieval", "RetrievalProcessor", "RetrievalConfig", "KindRetrieval"},
+}
+
+func newBundle(def Definition, schemas map[string]*object) (*Bundle, error) {
+	name := strings.ReplaceAll(strings.ToLower(def.Name), "-", "_")
+	if name == "" {
+		return nil, errors.New("a name is required")
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return nil, fmt.Errorf("invalid name %q", def.Name)
+		}
+	}
+
+	b := &Bundle{
+		Module:       module,
+		Package:      def.Package,
+		Name:         name,
+		Service:      def.Service,
+		Summary:      def.Summary,
+		Description:  def.Description,
+		Client:       def.Client,
+		ClientImport: def.ClientImport,
+		Target:       def.Target,
+	}
+
+	if b.Package == "" {
+		b.Package = strings.ReplaceAll(name, "_", "")
+	}
+	if b.Service == "" {
+		b.Service = cases.Title(language.English).String(strings.ReplaceAll(name, "_", " "))
+	}
+	if b.Summary == "" {
+		b.Summary = "Talks to " + b.Service + "."
+	}
+	if b.Client == "" {
+		b.Client = "any"
 	}
 
-	file, err := os.Create(outputPath)
+	if s := schemas["system"]; s != nil {
+		c, err := newComponent(b, s, "system", "system", "", "System", "SystemConfig", "")
+		if err != nil {
+			return nil, fmt.Errorf("system: %w", err)
+		}
+		b.System = c
+	}
+
+	for _, k := range kinds {
+		s := schemas[k.kind]
+		if s == nil {
+			continue
+		}
+
+		c, err := newComponent(b, s, k.kind, k.label, k.suffix, k.typ, k.config, k.registryKind)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k.kind, err)
+		}
+		b.Components = append(b.Components, c)
+	}
+
+	if len(b.Components) == 0 {
+		return nil, errors.New("at least one component schema is required")
+	}
+
+	if b.Input() != nil && b.Output() != nil {
+		if err := b.resolveTarget(schemas["input"], schemas["output"]); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func newComponent(b *Bundle, s *object, kind, label, suffix, typ, config, registryKind string) (*Component, error) {
+	if t := s.str("type"); t != "" && t != "object" {
+		return nil, fmt.Errorf("a config schema is of type object, not %s", t)
+	}
+
+	c := &Component{
+		Kind:         kind,
+		Label:        label,
+		Name:         b.Name + suffix,
+		NameVar:      typ + "ComponentName",
+		Type:         typ,
+		Config:       config,
+		RegistryKind: registryKind,
+		Summary:      firstSentence(s.str("description")),
+		SchemaConst:  lowerFirst(typ) + "Schema",
+		DefaultsVar:  lowerFirst(typ) + "Defaults",
+		HasSystem:    b.System != nil && kind != "processor" && kind != "system",
+	}
+	if kind == "retrieval" {
+		c.NameVar = "RetrievalProcessorComponentName"
+	}
+	if c.Summary == "" {
+		c.Summary = b.Summary
+	}
+
+	var buf bytes.Buffer
+	toJSON(&buf, s.without("$schema", "$id"), "")
+	c.Schema = buf.String()
+
+	g := &structGen{label: b.Service + " " + label}
+	if _, err := g.object(config, s, true); err != nil {
+		return nil, err
+	}
+	c.Structs = g.structs
+
+	if defaults := defaultsOf(s); defaults != nil {
+		c.Defaults = goLiteral(defaults)
+	}
+
+	example, err := exampleOf(s)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() { _ = file.Close() }()
+	c.Example = example.(*object)
+	c.Checked = g.checked
 
-	return tmpl.Execute(file, config)
+	for _, f := range g.structs[0].Fields {
+		switch {
+		case f.Type == "any":
+			continue
+		case strings.HasPrefix(f.Type, "map["), f.Type[0] >= 'A' && f.Type[0] <= 'Z' && !strings.Contains(f.Type, "."):
+			c.WrongValue = `"unexpected"`
+		default:
+			c.WrongValue = `map[string]any{"unexpected": true}`
+		}
+		c.WrongKey = f.Key
+		break
+	}
+
+	return c, nil
 }
 
-func generateSchemaFile(outputPath string, config ComponentConfig) error {
-	schemaContent := fmt.Sprintf(`$schema: "https://json-schema.org/draft/2020-12/schema"
-$id: "https://wombatwisdom.com/schemas/%s/%s"
-title: "%s Configuration"
-description: "Configuration for %s %s"
-type: object
-properties:
-  # TODO: Define configuration properties
-additionalProperties: false
-`, config.Package, filepath.Base(outputPath), config.ServiceName, config.ServiceName, strings.TrimSuffix(filepath.Base(outputPath), ".schema.yaml"))
+// Input returns the input of the bundle, if it has one.
+func (b *Bundle) Input() *Component { return b.Lookup("input") }
+
+// Output returns the output of the bundle, if it has one.
+func (b *Bundle) Output() *Component { return b.Lookup("output") }
 
-	return os.WriteFile(outputPath, []byte(schemaContent), 0644)
+// Lookup returns the component of the given kind, if the bundle has one.
+func (b *Bundle) Lookup(kind string) *Component {
+	for _, c := range b.Components {
+		if c.Kind == kind {
+			return c
+		}
+	}
+	return nil
 }
 
-func generateSuiteFile(outputPath string, config ComponentConfig) error {
-	suiteContent := fmt.Sprintf(`package %s_test
+// Configs returns the system and the components, everything with a config.
+func (b *Bundle) Configs() []*Component {
+	if b.System == nil {
+		return b.Components
+	}
+	return append([]*Component{b.System}, b.Components...)
+}
 
-import (
-	"testing"
+// Uses reports whether the generated config structs use the given package.
+func (b *Bundle) Uses(pkg string) bool {
+	for _, c := range b.Configs() {
+		for _, s := range c.Structs {
+			for _, f := range s.Fields {
+				if strings.Contains(f.Type, pkg+".") {
+					return true
+				}
+			}
+			for _, check := range s.Checks {
+				if strings.Contains(check, pkg+".") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
 
-	. "github.com/onsi/ginkgo/v2"
-	. "github.com/onsi/gomega"
-)
+// resolveTarget picks the field of the input and output configs the conformance specs put their target in.
+func (b *Bundle) resolveTarget(input, output *object) error {
+	if b.Target == "" {
+		b.Target = firstString(input)
+	}
+	if b.Target == "" {
+		return errors.New("could not tell which input config field receives the topic, queue or subject to read from, set it with -target")
+	}
+
+	for _, s := range []*object{input, output} {
+		prop := s.obj("properties").obj(b.Target)
+		if prop == nil || prop.str("type") != "string" {
+			return fmt.Errorf("the target %s is not a string field of both the input and the output config", b.Target)
+		}
+	}
+	return nil
+}
+
+// firstString returns the first required string property of a schema, or else the first string property.
+func firstString(s *object) string {
+	props := s.obj("properties")
+	if props == nil {
+		return ""
+	}
+
+	required, _ := s.get("required").([]any)
+	for _, key := range props.keys {
+		if props.obj(key).str("type") == "string" && slices.Contains(required, any(key)) {
+			return key
+		}
+	}
+	for _, key := range props.keys {
+		if props.obj(key).str("type") == "string" {
+			return key
+		}
+	}
+	return ""
+}
+
+// structGen derives the config structs from a schema.
+type structGen struct {
+	label   string
+	structs []*Struct
+	checked []string
+}
+
+// object adds the struct of an object schema, returning whether the struct has a validate method.
+func (g *structGen) object(name string, s *object, root bool) (bool, error) {
+	st := &Struct{Name: name, Validate: "validate"}
+	if root {
+		st.Validate = "Validate"
+		st.Doc = wrap(fmt.Sprintf("%s defines the configuration of the %s.", name, g.label), 117)
+	} else if d := s.str("description"); d != "" {
+		st.Doc = wrap(name+" "+lowerFirst(strings.TrimSuffix(d, "."))+".", 117)
+	} else {
+		st.Doc = wrap(fmt.Sprintf("%s is part of the configuration of the %s.", name, g.label), 117)
+	}
+	g.structs = append(g.structs, st)
+
+	props := s.obj("properties")
+	if props == nil {
+		return root, nil
+	}
+
+	required, _ := s.get("required").([]any)
+	for _, key := range props.keys {
+		prop := props.obj(key)
+		if prop == nil {
+			return false, fmt.Errorf("property %s is not a schema", key)
+		}
+
+		fieldName := goName(key)
+		t, validated, err := g.typeOf(strings.TrimSuffix(name, "Config")+fieldName, prop, false)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", key, err)
+		}
+
+		isRequired := slices.Contains(required, any(key))
+		tag := key
+		if !isRequired {
+			tag += ",omitempty"
+		}
+
+		st.Fields = append(st.Fields, &Field{
+			Name: fieldName,
+			Key:  key,
+			Type: t,
+			Tag:  fmt.Sprintf("`json:%q yaml:%q mapstructure:%q`", tag, tag, tag),
+			Doc:  wrap(fieldDoc(prop), 116),
+		})
+
+		checks := checksOf("c."+fieldName, key, t, prop, isRequired, validated)
+		if root && isRequired && len(checks) > 0 && strings.Contains(checks[0], "is required") {
+			g.checked = append(g.checked, key)
+		}
+		st.Checks = append(st.Checks, checks...)
+	}
 
-func Test%s(t *testing.T) {
-	RegisterFailHandler(Fail)
-	RunSpecs(t, "%s Suite")
+	return root || len(st.Checks) > 0, nil
 }
-`, config.Package, cases.Title(language.English).String(config.Package), cases.Title(language.English).String(config.ServiceName))
 
-	return os.WriteFile(outputPath, []byte(suiteContent), 0644)
+// typeOf returns the Go type of a schema, and whether its values have a validate method.
+func (g *structGen) typeOf(name string, s *object, item bool) (string, bool, error) {
+	if s.get("$ref") != nil {
+		return "", false, errors.New("$ref is not supported, inline the schema")
+	}
+
+	switch s.str("type") {
+	case "string":
+		switch s.str("format") {
+		case "duration":
+			return "time.Duration", false, nil
+		case "expression":
+			return "spec.Expression", false, nil
+		}
+		return "string", false, nil
+	case "integer":
+		return "int", false, nil
+	case "number":
+		return "float64", false, nil
+	case "boolean":
+		return "bool", false, nil
+	case "array":
+		items := s.obj("items")
+		if items == nil {
+			return "[]any", false, nil
+		}
+		t, validated, err := g.typeOf(name+"Item", items, true)
+		return "[]" + t, validated, err
+	case "object":
+		if s.obj("properties") != nil {
+			validated, err := g.object(name, s, false)
+			return name, validated, err
+		}
+		if additional := s.obj("additionalProperties"); additional != nil {
+			t, validated, err := g.typeOf(name+"Value", additional, true)
+			return "map[string]" + t, validated, err
+		}
+		return "map[string]any", false, nil
+	}
+
+	return "any", false, nil
+}
+
+// checksOf returns the statements validating a field.
+func checksOf(field, key, t string, s *object, required, validated bool) []string {
+	var checks []string
+
+	if required {
+		var cond string
+		switch {
+		case t == "string":
+			cond = field + ` == ""`
+		case t == "time.Duration":
+			cond = field + " == 0"
+		case t == "spec.Expression", t == "any":
+			cond = field + " == nil"
+		case strings.HasPrefix(t, "[]"), strings.HasPrefix(t, "map["):
+			cond = "len(" + field + ") == 0"
+		}
+		if cond != "" {
+			checks = append(checks, fmt.Sprintf("if %s {\n\treturn fmt.Errorf(%q)\n}", cond, key+" is required"))
+		}
+	}
+
+	if enum, ok := s.get("enum").([]any); ok && t == "string" {
+		values := make([]string, len(enum))
+		quoted := make([]string, len(enum))
+		for idx, v := range enum {
+			values[idx] = fmt.Sprint(v)
+			quoted[idx] = strconv.Quote(values[idx])
+		}
+		cond := fmt.Sprintf("!slices.Contains([]string{%s}, %s)", strings.Join(quoted, ", "), field)
+		if !required {
+			cond = field + ` != "" && ` + cond
+		}
+		checks = append(checks, fmt.Sprintf("if %s {\n\treturn fmt.Errorf(\"%s must be one of %s, got %%q\", %s)\n}",
+			cond, key, strings.Join(values, ", "), field))
+	}
+
+	if t == "int" || t == "float64" {
+		if minimum, ok := number(s.get("minimum")); ok {
+			cond := fmt.Sprintf("%s < %v", field, minimum)
+			if !required && minimum > 0 {
+				cond = field + " != 0 && " + cond
+			}
+			checks = append(checks, fmt.Sprintf("if %s {\n\treturn fmt.Errorf(\"%s must be at least %v\")\n}", cond, key, minimum))
+		}
+		if maximum, ok := number(s.get("maximum")); ok {
+			checks = append(checks, fmt.Sprintf("if %s > %v {\n\treturn fmt.Errorf(\"%s must be at most %v\")\n}", field, maximum, key, maximum))
+		}
+	}
+
+	if validated {
+		switch {
+		case strings.HasPrefix(t, "[]"):
+			checks = append(checks, fmt.Sprintf("for idx, item := range %s {\n\tif err := item.validate(); err != nil {\n\t\treturn fmt.Errorf(\"%s.%%d: %%w\", idx, err)\n\t}\n}", field, key))
+		case strings.HasPrefix(t, "map["):
+			checks = append(checks, fmt.Sprintf("for key, item := range %s {\n\tif err := item.validate(); err != nil {\n\t\treturn fmt.Errorf(\"%s.%%s: %%w\", key, err)\n\t}\n}", field, key))
+		default:
+			checks = append(checks, fmt.Sprintf("if err := %s.validate(); err != nil {\n\treturn fmt.Errorf(\"%s: %%w\", err)\n}", field, key))
+		}
+	}
+
+	return checks
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// fieldDoc returns the documentation of a field: its description and default.
+func fieldDoc(s *object) string {
+	doc := strings.TrimSpace(s.str("description"))
+	if doc != "" {
+		doc = strings.ToUpper(doc[:1]) + doc[1:]
+		if !strings.HasSuffix(doc, ".") {
+			doc += "."
+		}
+	}
+
+	// -- zero defaults say nothing the type doesn't
+	switch d := s.get("default").(type) {
+	case nil, *object, []any:
+	case string:
+		if d == "" {
+			break
+		}
+		if s.str("format") == "duration" || s.str("format") == "expression" {
+			doc += " Defaults to " + d + "."
+		} else {
+			doc += " Defaults to " + strconv.Quote(d) + "."
+		}
+	case bool:
+		if d {
+			doc += " Defaults to true."
+		}
+	default:
+		if n, _ := number(d); n != 0 {
+			doc += fmt.Sprintf(" Defaults to %v.", d)
+		}
+	}
+
+	return strings.TrimSpace(doc)
+}
+
+// defaultsOf returns the defaults of the properties of an object schema, nested objects included, or nil if there
+// are none.
+func defaultsOf(s *object) *object {
+	props := s.obj("properties")
+	if props == nil {
+		return nil
+	}
+
+	defaults := &object{values: make(map[string]any)}
+	for _, key := range props.keys {
+		prop := props.obj(key)
+
+		value := prop.get("default")
+		if value == nil && prop.str("type") == "object" {
+			if nested := defaultsOf(prop); nested != nil {
+				value = nested
+			}
+		}
+
+		if value != nil {
+			defaults.keys = append(defaults.keys, key)
+			defaults.values[key] = value
+		}
+	}
+
+	if len(defaults.keys) == 0 {
+		return nil
+	}
+	return defaults
+}
+
+// exampleOf returns a value valid for a schema, with only the required properties of objects.
+func exampleOf(s *object) (any, error) {
+	if examples, ok := s.get("examples").([]any); ok && len(examples) > 0 {
+		return examples[0], nil
+	}
+	if d := s.get("default"); d != nil {
+		return d, nil
+	}
+	if enum, ok := s.get("enum").([]any); ok && len(enum) > 0 {
+		return enum[0], nil
+	}
+
+	switch s.str("type") {
+	case "string":
+		switch s.str("format") {
+		case "duration":
+			return "1s", nil
+		}
+		return "example", nil
+	case "integer", "number":
+		value := 1.0
+		if minimum, ok := number(s.get("minimum")); ok {
+			value = minimum
+		}
+		if maximum, ok := number(s.get("maximum")); ok && value > maximum {
+			value = maximum
+		}
+		if s.str("type") == "integer" {
+			return int(value), nil
+		}
+		return value, nil
+	case "boolean":
+		return true, nil
+	case "array":
+		if items := s.obj("items"); items != nil {
+			item, err := exampleOf(items)
+			if err != nil {
+				return nil, err
+			}
+			return []any{item}, nil
+		}
+		return []any{"example"}, nil
+	case "object":
+		example := &object{values: make(map[string]any)}
+		props := s.obj("properties")
+		if props == nil {
+			if additional := s.obj("additionalProperties"); additional != nil {
+				value, err := exampleOf(additional)
+				if err != nil {
+					return nil, err
+				}
+				example.keys = []string{"example"}
+				example.values["example"] = value
+			}
+			return example, nil
+		}
+
+		required, _ := s.get("required").([]any)
+		for _, key := range props.keys {
+			if !slices.Contains(required, any(key)) {
+				continue
+			}
+			value, err := exampleOf(props.obj(key))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			example.keys = append(example.keys, key)
+			example.values[key] = value
+		}
+		return example, nil
+	}
+
+	return "example", nil
+}
+
+var initialisms = map[string]string{
+	"acl": "ACL", "api": "API", "arn": "ARN", "cpu": "CPU", "dns": "DNS", "http": "HTTP", "https": "HTTPS",
+	"id": "ID", "ip": "IP", "json": "JSON", "sql": "SQL", "ssl": "SSL", "tcp": "TCP", "tls": "TLS", "ttl": "TTL",
+	"udp": "UDP", "uri": "URI", "url": "URL", "uuid": "UUID", "xml": "XML",
+}
+
+// goName turns a config key like max_batch_size into a Go name like MaxBatchSize.
+func goName(key string) string {
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if upper, ok := initialisms[strings.ToLower(word)]; ok {
+			sb.WriteString(upper)
+			continue
+		}
+		sb.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+
+	name := sb.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "Field" + name
+	}
+	return name
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func firstSentence(s string) string {
+	s = strings.TrimSpace(s)
+	if idx := strings.Index(s, ". "); idx >= 0 {
+		s = s[:idx+1]
+	}
+	if s != "" && !strings.HasSuffix(s, ".") {
+		s += "."
+	}
+	return s
+}
+
+// wrap breaks text into lines of at most width characters.
+func wrap(text string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+type file struct {
+	name    string
+	content []byte
+}
+
+// render renders the templates of the bundle, formatting the Go files.
+func (b *Bundle) render(dir string) ([]file, error) {
+	tmpl, err := template.New("component").Funcs(template.FuncMap{
+		"quote":   strconv.Quote,
+		"literal": goLiteral,
+		"schema": func(s string) string {
+			return "`" + strings.ReplaceAll(s, "`", "` + \"`\" + `") + "`"
+		},
+		"title": func(s string) string {
+			return cases.Title(language.English).String(s)
+		},
+		"comment": func(text string) string {
+			return "// " + strings.Join(wrap(text, 117), "\n// ")
+		},
+		"targeted": func(o *object, key, value string) string {
+			o = o.without(key)
+			o.keys = append(o.keys, key)
+			o.values[key] = ident(value)
+			return goLiteral(o)
+		},
+	}).ParseGlob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names = []struct {
+		file, template string
+		when           bool
+	}{
+		{"config.go", "config.go.tmpl", true},
+		{"spec.go", "spec.go.tmpl", true},
+		{"register.go", "register.go.tmpl", true},
+		{"system.go", "system.go.tmpl", b.System != nil},
+		{"input.go", "input.go.tmpl", b.Lookup("input") != nil},
+		{"output.go", "output.go.tmpl", b.Lookup("output") != nil},
+		{"processor.go", "processor.go.tmpl", b.Lookup("processor") != nil},
+		{"trigger_input.go", "trigger_input.go.tmpl", b.Lookup("trigger") != nil},
+		{"retrieval.go", "retrieval.go.tmpl", b.Lookup("retrieval") != nil},
+		{b.Package + "_suite_test.go", "suite_test.go.tmpl", true},
+		{"config_test.go", "config_test.go.tmpl", true},
+		{"register_test.go", "register_test.go.tmpl", true},
+		{"conformance_test.go", "conformance_test.go.tmpl", b.Input() != nil && b.Output() != nil},
+		{"Taskfile.yml", "Taskfile.yml.tmpl", true},
+	}
+
+	var files []file
+	for _, n := range names {
+		if !n.when {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, n.template, b); err != nil {
+			return nil, err
+		}
+
+		content := buf.Bytes()
+		if strings.HasSuffix(n.file, ".go") {
+			formatted, err := format.Source(content)
+			if err != nil {
+				return nil, fmt.Errorf("generated invalid code for %s: %w\n%s", n.file, err, content)
+			}
+			content = formatted
+		}
+
+		files = append(files, file{name: n.file, content: content})
+	}
+
+	return files, nil
 }
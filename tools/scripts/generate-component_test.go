@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const bundleSpec = `
+name: cache
+service: Cache
+summary: Reads and writes a cache.
+system:
+  type: object
+  properties:
+    url: {type: string, default: "cache://localhost", description: the url of the cache server}
+    timeout: {type: string, format: duration, default: 5s}
+input: input.schema.json
+output:
+  type: object
+  properties:
+    key: {type: string, format: expression, description: "The key to write to, like ` + "`orders`" + `."}
+    ttl: {type: integer, minimum: 1}
+  required: [key]
+processor:
+  type: object
+  properties:
+    mode: {type: string, enum: [get, delete]}
+    fields:
+      type: array
+      items:
+        type: object
+        properties:
+          name: {type: string}
+        required: [name]
+  required: [mode]
+trigger:
+  type: object
+  properties:
+    pattern: {type: string}
+retrieval:
+  type: object
+  properties:
+    delete: {type: boolean}
+`
+
+const inputSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"properties": {
+		"key": {"type": "string", "examples": ["orders"]},
+		"batch_size": {"type": "integer", "minimum": 1, "maximum": 100, "default": 10}
+	},
+	"required": ["key"],
+	"additionalProperties": false
+}`
+
+var _ = Describe("generate-component", func() {
+	var dir, out string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "cache.yaml"), []byte(bundleSpec), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "input.schema.json"), []byte(inputSchema), 0o644)).To(Succeed())
+
+		// -- the bundle has to be part of the module for its imports to resolve, the underscore keeps it out of ./...
+		var err error
+		out, err = os.MkdirTemp(".", "_generated")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(os.RemoveAll, out)
+	})
+
+	generate := func(args ...string) error {
+		return run(append([]string{"-templates", filepath.Join("..", "templates", "component"), "-out", out}, args...))
+	}
+
+	files := func() []string {
+		entries, err := os.ReadDir(out)
+		Expect(err).ToNot(HaveOccurred())
+
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	goTool := func(args ...string) {
+		cmd := exec.Command("go", append(args, "./"+out)...)
+		output, err := cmd.CombinedOutput()
+		Expect(err).ToNot(HaveOccurred(), string(output))
+	}
+
+	It("should generate a bundle which builds and passes its tests", func() {
+		Expect(generate("-spec", filepath.Join(dir, "cache.yaml"))).To(Succeed())
+
+		Expect(files()).To(ConsistOf(
+			"Taskfile.yml", "cache_suite_test.go", "config.go", "config_test.go", "conformance_test.go",
+			"input.go", "output.go", "processor.go", "register.go", "register_test.go", "retrieval.go", "spec.go",
+			"system.go", "trigger_input.go",
+		))
+
+		config, err := os.ReadFile(filepath.Join(out, "config.go"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(config)).To(ContainSubstring("Timeout time.Duration"))
+		Expect(string(config)).To(ContainSubstring("Key spec.Expression"))
+		Expect(string(config)).To(ContainSubstring("Fields []ProcessorFieldsItem"))
+		Expect(string(config)).To(ContainSubstring(`return fmt.Errorf("batch_size must be at most 100")`))
+		Expect(string(config)).To(ContainSubstring(`// The url of the cache server. Defaults to "cache://localhost".`))
+
+		goTool("vet")
+		goTool("test")
+	})
+
+	It("should let flags override the spec file", func() {
+		processor := filepath.Join(dir, "processor.json")
+		Expect(os.WriteFile(processor, []byte(`{"type": "object", "properties": {"level": {"type": "integer"}}}`), 0o644)).To(Succeed())
+
+		Expect(generate("-spec", filepath.Join(dir, "cache.yaml"), "-name", "squeeze", "-processor", processor)).To(Succeed())
+		Expect(files()).To(ContainElement("squeeze_suite_test.go"))
+
+		config, err := os.ReadFile(filepath.Join(out, "config.go"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(config)).To(ContainSubstring("Level int"))
+		Expect(string(config)).ToNot(ContainSubstring("Mode string"))
+	})
+
+	It("should generate a bundle from flags alone", func() {
+		processor := filepath.Join(dir, "processor.json")
+		Expect(os.WriteFile(processor, []byte(`{"type": "object", "properties": {"level": {"type": "integer"}}}`), 0o644)).To(Succeed())
+
+		Expect(generate("-name", "squeeze", "-processor", processor)).To(Succeed())
+		Expect(files()).To(ConsistOf(
+			"Taskfile.yml", "squeeze_suite_test.go", "config.go", "config_test.go", "processor.go", "register.go",
+			"register_test.go", "spec.go",
+		))
+
+		goTool("vet")
+	})
+
+	It("should not overwrite existing files unless forced", func() {
+		Expect(generate("-spec", filepath.Join(dir, "cache.yaml"))).To(Succeed())
+		Expect(generate("-spec", filepath.Join(dir, "cache.yaml"))).To(MatchError(ContainSubstring("use -force to overwrite it")))
+		Expect(generate("-spec", filepath.Join(dir, "cache.yaml"), "-force")).To(Succeed())
+	})
+
+	It("should require a name and a component", func() {
+		Expect(generate("-processor", filepath.Join(dir, "input.schema.json"))).To(MatchError("a name is required"))
+		Expect(generate("-name", "empty")).To(MatchError("at least one component schema is required"))
+	})
+
+	It("should require a target shared by the input and output", func() {
+		Expect(generate("-spec", filepath.Join(dir, "cache.yaml"), "-target", "ttl")).
+			To(MatchError(ContainSubstring("the target ttl is not a string field of both the input and the output config")))
+	})
+})